@@ -0,0 +1,762 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/audit"
+	"anthropic-chat/cassette"
+	"anthropic-chat/compaction"
+	"anthropic-chat/config"
+	"anthropic-chat/embeddings"
+	"anthropic-chat/ignore"
+	"anthropic-chat/lsp"
+	"anthropic-chat/memory"
+	"anthropic-chat/plugin"
+	"anthropic-chat/project"
+	"anthropic-chat/provider"
+	"anthropic-chat/redact"
+	"anthropic-chat/session"
+	"anthropic-chat/telemetry"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/artifact"
+	codeindextools "anthropic-chat/tools/codeindex"
+	"anthropic-chat/tools/file"
+	kbtools "anthropic-chat/tools/kb"
+	lsptools "anthropic-chat/tools/lsp"
+	memorytools "anthropic-chat/tools/memory"
+	"anthropic-chat/tools/outline"
+	"anthropic-chat/tools/shell"
+	"anthropic-chat/tools/snippet"
+	"anthropic-chat/tools/testrunner"
+	"anthropic-chat/tools/todo"
+	"anthropic-chat/ui"
+	"anthropic-chat/watch"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Agent runs a conversation with a model, executing its tool calls inside a working directory
+type Agent struct {
+	provider       provider.Provider
+	getUserMessage func() (string, bool)
+	workingDir     string
+	promptTemplate string // system_prompt.txt or the embedder's prompt, before rendering
+	systemPrompt   string
+	toolRegistry   *tools.Registry
+	config         *config.Config
+	uiManager      *ui.Manager
+	events         EventHandler
+	lspManager     *lsp.Manager
+	sessionStore   *session.Store
+	session        *session.Session
+	compaction     compaction.Strategy
+	compacting     *backgroundCompaction // Compaction running alongside the conversation, if any
+	redactor       *redact.Redactor      // nil when secret redaction is disabled
+	redactionLog   *redact.AuditLog
+	auditLog       *audit.Log // nil unless tool call auditing is enabled
+	callDecisions  []string   // How the running tool call's gated actions were decided, for the audit log
+	pins           []*pinnedFile
+	reads          map[string]readCacheEntry // Files the model has seen since the last compaction, by full path
+	readBytes      int
+	workspace      map[string]*workspaceFile // Files read or written this session, by full path
+	workspaceSeq   int                       // Orders workspace entries by last use
+	pinnedMessages []*pinnedMessage
+	nextMessagePin int
+	turn           int // Completed user turns, used to measure how long pins sit unused
+	toolStats      map[string]*ToolStat
+	onExit         func()         // Called before exiting on Ctrl-C, such as to flush telemetry
+	statsMu        sync.Mutex     // Guards toolStats, which Ctrl-C reads to print the report on the way out
+	watcher        *watch.Watcher // nil unless watch mode is enabled
+	removed        []string       // Paths tool calls deleted this turn, which watch mode doesn't report
+	approvalPolicy *approval.Policy
+	approvals      *approval.Webhook  // nil unless headless with a webhook configured
+	project        *project.Project   // nil unless the working tree has a .goocode directory
+	shell          *shell.Session     // Persistent shell behind the shell tool
+	plugins        []*plugin.Plugin   // Running tool plugins, stopped on Close
+	postProcessors []PostProcessor    // Run over every completed assistant message
+	lastResponse   string             // Text of the latest assistant message that had any, for save_output
+	pendingPaste   string             // Clipboard content from /paste, added to the next message
+	callApproved   bool               // The running tool call was approved up front, so the tool needn't ask again
+	interruptState atomic.Int32       // interruptIdle, interruptRunning or interruptPausing
+	toolMu         sync.Mutex         // Guards stopTool, which Ctrl-C calls from the signal handler
+	stopTool       context.CancelFunc // Cancels the running tool call, if any
+	dryRun         bool               // Gated actions report what they would do instead of doing it
+	primaryModel   *config.ModelInfo  // The user's model while a turn runs on a fallback
+	spend          *turnSpend         // Usage of the running turn, added as each stream finishes
+	nextToolChoice string             // Set by /force-tool for the next turn
+	toolChoice     string             // Tool choice of the next request in the running turn ("" = auto)
+	snapshot       *sessionSnapshot   // Working tree before an interactive session changed it, for /summary
+	commands       []string           // Shell commands run this session, for /summary
+	profile        string             // Active profile ("" = none)
+	basePrompt     string             // promptTemplate without a profile, restored by /profile off
+	baseModel      string             // Model in use before the profile, restored by /profile off
+	todosChanged   bool               // The task list changed since it was last shown
+	ignores        *ignore.Matcher    // .goocodeignore of the working directory
+	recalled       []memory.Fact      // Saved facts brought back by the last compaction
+	gitContext     string             // Recent commits and uncommitted changes, read at session start
+	answerTool     string             // final_answer when a structured answer is expected ("" = none)
+	answer         json.RawMessage    // Input of the last valid final_answer call
+
+	// Recording or replay of tool results, if any
+	cassette *cassette.Cassette
+}
+
+// New creates an agent for modelProvider configured by opts; it fails when the approval policy can't be read
+func New(modelProvider provider.Provider, opts ...Option) (*Agent, error) {
+	o := options{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	cfg := o.config
+	if cfg == nil {
+		cfg = config.NewConfig()
+	}
+	if o.model != "" {
+		model, _ := config.LookupModel(o.model)
+		cfg.SetModel(model)
+	}
+	workingDir := o.workingDir
+	if workingDir == "" {
+		workingDir, _ = os.Getwd()
+	}
+	systemPrompt := o.systemPrompt
+	if systemPrompt == "" {
+		systemPrompt = loadSystemPrompt(cfg.Agent.SystemPromptFile)
+	}
+	if cfg.Security.ReadOnly {
+		systemPrompt += readOnlyPrompt
+	}
+	getUserMessage := o.input
+	if getUserMessage == nil {
+		scanner := bufio.NewScanner(os.Stdin)
+		getUserMessage = func() (string, bool) {
+			if !scanner.Scan() {
+				return "", false
+			}
+			return scanner.Text(), true
+		}
+	}
+
+	strategy, err := compaction.New(cfg.CompactionStrategy())
+	if err != nil {
+		log.Printf("Warning: %v. Using %s.", err, compaction.DefaultStrategy)
+		strategy, _ = compaction.New(compaction.DefaultStrategy)
+	}
+	var redactor *redact.Redactor
+	if cfg.Security.RedactSecrets {
+		redactor = redact.New()
+	}
+
+	uiManager := ui.NewManager(cfg.UI)
+	events := o.events
+	if events == nil {
+		events = NewConsoleEvents(uiManager, getUserMessage)
+	}
+
+	a := &Agent{
+		provider:       modelProvider,
+		getUserMessage: getUserMessage,
+		workingDir:     workingDir,
+		promptTemplate: systemPrompt,
+		toolRegistry:   tools.NewRegistry(),
+		config:         cfg,
+		uiManager:      uiManager,
+		events:         events,
+		lspManager:     lsp.NewManager(),
+		sessionStore:   session.NewStore(cfg.Session.Dir),
+		session:        session.New(workingDir),
+		compaction:     strategy,
+		redactor:       redactor,
+		redactionLog:   redact.NewAuditLog(cfg.Security.RedactionLog),
+		auditLog:       newAuditLog(cfg.Security.AuditLog),
+		approvals:      startApprovalWebhook(cfg.Security),
+		cassette:       o.cassette,
+		onExit:         o.onExit,
+		basePrompt:     systemPrompt,
+		baseModel:      cfg.Model().ID,
+	}
+	profile := o.profile
+	if profile == "" {
+		profile = cfg.Agent.Profile
+	}
+	if profile != "" {
+		// An explicit model wins over the profile's
+		if model, err := a.setProfile(profile); err != nil {
+			log.Printf("Warning: %v. Starting without a profile.", err)
+		} else if o.model == "" {
+			info, _ := config.LookupModel(model)
+			cfg.SetModel(info)
+		}
+	}
+	a.renderSystemPrompt()
+	// Running without the configured matrix would leave a CI run unbounded, so refuse to start
+	if err := a.loadProject(); err != nil {
+		return nil, err
+	}
+	a.startWatcher()
+	return a, nil
+}
+
+// RegisterTools registers the built-in tools with the agent
+func (a *Agent) RegisterTools() {
+	// Register file operation tools
+	a.toolRegistry.Register(file.NewReadFileTool())
+	a.toolRegistry.Register(file.NewReadManyFilesTool())
+	a.toolRegistry.Register(file.NewListFilesTool())
+	a.toolRegistry.Register(outline.NewOutlineFileTool())
+	a.toolRegistry.Register(file.NewStatFileTool())
+	a.toolRegistry.Register(todo.NewManageTodosTool())
+	a.toolRegistry.Register(memorytools.NewMemoryTool())
+	if !a.config.Security.ReadOnly {
+		a.toolRegistry.Register(file.NewMultiEditTool())
+		a.toolRegistry.Register(file.NewDuplicateFileTool())
+		a.toolRegistry.Register(file.NewCreateDirectoryTool())
+		a.toolRegistry.Register(file.NewRemoveDirectoryTool())
+		a.toolRegistry.Register(file.NewSaveOutputTool())
+		a.toolRegistry.Register(artifact.NewEmitArtifactTool())
+
+		// Register the persistent shell
+		a.shell = shell.NewSession(a.workingDir, a.config.Agent.ShellOutputLimit)
+		a.toolRegistry.Register(shell.NewShellTool(a.shell, time.Duration(a.config.Agent.ShellTimeout)*time.Second, time.Duration(a.config.Agent.ShellMaxTimeout)*time.Second))
+	}
+	// Note: Would register other tools here:
+	// a.toolRegistry.Register(file.NewEditFileTool())
+	// a.toolRegistry.Register(command.NewExecuteCommandTool())
+
+	// Register language server backed code intelligence tools
+	a.toolRegistry.Register(lsptools.NewGoToDefinitionTool(a.lspManager))
+	a.toolRegistry.Register(lsptools.NewFindReferencesTool(a.lspManager))
+	a.toolRegistry.Register(lsptools.NewDocumentSymbolsTool(a.lspManager))
+	if !a.config.Security.ReadOnly {
+		a.toolRegistry.Register(lsptools.NewRenameSymbolTool(a.lspManager))
+	}
+
+	// Register test runner and snippet sandbox; both run arbitrary code, so not in read-only mode
+	if !a.config.Security.ReadOnly {
+		a.toolRegistry.Register(testrunner.NewRunTestsTool())
+		a.toolRegistry.Register(snippet.NewRunSnippetTool(a.config.Agent.SnippetBackend, time.Duration(a.config.Agent.SnippetTimeout)*time.Second, config.SnippetOutputLimit))
+	}
+
+	// Register project documentation and code search
+	if embedder, err := embeddings.New(a.config.Embeddings); err == nil {
+		a.toolRegistry.Register(kbtools.NewSearchTool(a.knowledgeDir(), embedder))
+		a.toolRegistry.Register(codeindextools.NewSemanticSearchTool(a.knowledgeDir(), embedder))
+	} else {
+		log.Printf("Knowledge base and code search disabled: %v", err)
+	}
+
+	// Register tools provided by plugin executables
+	a.loadPlugins(context.Background())
+}
+
+// RegisterTool adds a custom tool alongside the built-in ones, replacing any tool with the same name
+func (a *Agent) RegisterTool(tool tools.Tool) {
+	a.toolRegistry.Register(tool)
+}
+
+// UnregisterTool removes a tool, built-in or custom, from the next request on; it reports whether the tool existed
+func (a *Agent) UnregisterTool(name string) bool {
+	return a.toolRegistry.Unregister(name)
+}
+
+// Config returns the agent's configuration
+func (a *Agent) Config() *config.Config {
+	return a.config
+}
+
+// Close releases resources such as language servers and the file watcher started by the agent
+func (a *Agent) Close() {
+	a.lspManager.Close()
+	a.stopWatcher()
+	if a.shell != nil {
+		a.shell.Close()
+	}
+	a.closePlugins()
+	if a.approvals != nil {
+		a.approvals.Close()
+	}
+}
+
+// WorkingDir implements the ToolContext interface
+func (a *Agent) WorkingDir() string {
+	return a.workingDir
+}
+
+// ReportProgress implements the tools.ProgressReporter interface
+func (a *Agent) ReportProgress(toolName string, message string) {
+	a.events.OnToolProgress(toolName, message)
+}
+
+// CommandStarted implements the tools.CommandMonitor interface
+func (a *Agent) CommandStarted(toolName, command string) {
+	a.commands = append(a.commands, command)
+	a.events.OnCommandStart(toolName, command)
+}
+
+// CommandOutput implements the tools.CommandMonitor interface
+func (a *Agent) CommandOutput(toolName, line string) {
+	a.events.OnCommandOutput(toolName, line)
+}
+
+// CommandFinished implements the tools.CommandMonitor interface
+func (a *Agent) CommandFinished(toolName string) {
+	a.events.OnCommandEnd(toolName)
+}
+
+// LastResponse implements the tools.ResponseSource interface
+func (a *Agent) LastResponse() string {
+	return a.lastResponse
+}
+
+// ResolveFilePath implements the ToolContext interface with security validation
+func (a *Agent) ResolveFilePath(relativePath string) (string, error) {
+	// Clean the path to prevent directory traversal
+	cleanPath := filepath.Clean(relativePath)
+
+	// Prevent paths from escaping the working directory
+	if strings.Contains(cleanPath, "..") {
+		return "", fmt.Errorf("path cannot contain '..' for security reasons: %w", tools.ErrPathNotAllowed)
+	}
+
+	// Join with working directory
+	fullPath := filepath.Join(a.workingDir, cleanPath)
+
+	// Ensure the path, with symlinks resolved, is still within the working directory
+	absFullPath, err := filepath.Abs(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get absolute path: %w", err)
+	}
+	if !tools.Within(absFullPath, a.workingDir) {
+		return "", fmt.Errorf("path %s escapes or links outside the working directory: %w", relativePath, tools.ErrPathNotAllowed)
+	}
+
+	// Enforce the project's deny-list for every tool at once
+	info, statErr := os.Stat(absFullPath)
+	if a.Ignored(absFullPath, statErr == nil && info.IsDir()) {
+		return "", fmt.Errorf("%s: %w", relativePath, tools.ErrIgnored)
+	}
+
+	return fullPath, nil
+}
+
+// Run executes the main agent loop
+func (a *Agent) Run(ctx context.Context) error {
+	conversation := []anthropic.MessageParam{}
+	defer a.Close()
+	defer a.handleInterrupts(func() {
+		a.showSessionReport()
+		a.dropSessionSnapshot()
+	})()
+
+	// Display welcome message
+	a.uiManager.ShowWelcome()
+	a.uiManager.ShowCommands()
+	if commands := a.customCommandList(); commands != "" {
+		fmt.Printf("%s\n%s\n", a.uiManager.Paint(ui.StyleInfo, "Custom commands:"), commands)
+	}
+	if a.config.Security.ReadOnly {
+		fmt.Printf("%s: tools that change files or run commands are disabled\n\n", a.uiManager.Paint(ui.StyleNotice, "Read-only mode"))
+	}
+	a.startSessionSnapshot()
+	defer a.dropSessionSnapshot()
+	// Deferred so a failed turn still reports; quitting with Ctrl-C prints it before exiting
+	defer a.showSessionReport()
+	a.loadGitContext()
+
+	for {
+		fmt.Print(a.tokenMeter(conversation) + a.uiManager.Paint(ui.StyleUser, "You") + ": ")
+		userInput, ok := a.nextUserInput()
+		if !ok {
+			break
+		}
+
+		// Handle slash commands, then the project's custom ones
+		if handled := a.handleSlashCommand(ctx, userInput, &conversation); handled {
+			continue
+		}
+		if prompt, ok := a.customCommand(userInput); ok {
+			userInput = prompt
+		}
+		userInput = a.takePaste(userInput)
+
+		var err error
+		started := time.Now()
+		conversation, err = a.RunTurn(ctx, conversation, userInput)
+		if errors.Is(err, ErrRequestDeclined) {
+			fmt.Printf("%s: Request not sent\n\n", a.uiManager.Paint(ui.StyleInfo, "Cost"))
+			continue
+		}
+		if err != nil {
+			a.uiManager.Notify("GooCode failed", err.Error())
+			return err
+		}
+		if a.todosChanged {
+			a.showTodos()
+		}
+		a.uiManager.NotifyTurn(started, "Done; waiting for your next message")
+
+		a.saveSession(ctx, conversation)
+	}
+
+	return nil
+}
+
+// nextUserInput reads the next message or command at the prompt. A replay takes it from the recording
+// instead, since answers to approval prompts, which a replay doesn't ask, would otherwise be read as messages.
+func (a *Agent) nextUserInput() (string, bool) {
+	if a.cassette.Replaying() {
+		line, ok := a.cassette.ReplayInput()
+		if ok {
+			fmt.Println(line)
+		}
+		return line, ok
+	}
+	line, ok := a.getUserMessage()
+	if ok {
+		if err := a.cassette.RecordInput(line); err != nil {
+			log.Printf("Warning: %v", err)
+		}
+	}
+	return line, ok
+}
+
+// RunOnce answers a single prompt, running tools as needed, and returns without starting the interactive
+// loop. Approvals and cost confirmations are still read from the input, if any.
+func (a *Agent) RunOnce(ctx context.Context, prompt string) error {
+	defer a.Close()
+	defer a.handleInterrupts(nil)()
+
+	a.loadGitContext()
+	started := time.Now()
+	conversation, err := a.RunTurn(ctx, nil, prompt)
+	if err == nil {
+		conversation, err = a.finishAnswer(ctx, conversation)
+	}
+	if len(conversation) > 0 {
+		a.saveSession(ctx, conversation)
+	}
+	if err != nil {
+		a.uiManager.Notify("GooCode failed", err.Error())
+	} else {
+		a.uiManager.NotifyTurn(started, "Done")
+	}
+	return err
+}
+
+// RunTurn adds the user's message and runs inference and tool calls until the model stops using tools
+func (a *Agent) RunTurn(ctx context.Context, conversation []anthropic.MessageParam, userInput string) (_ []anthropic.MessageParam, err error) {
+	ctx, span := telemetry.StartTurn(ctx, a.turn+1)
+	defer func() {
+		span.End(err)
+		if err != nil && !errors.Is(err, ErrRequestDeclined) {
+			a.events.OnError(err)
+		}
+	}()
+	a.applyPendingUnpins()
+	defer a.restorePrimaryModel()
+	a.startToolChoice()
+	defer func() { a.toolChoice = "" }()
+	a.interruptState.Store(interruptRunning)
+	defer a.interruptState.Store(interruptIdle)
+
+	// Add user message to conversation, noting files the user changed since the last turn
+	userMessage := anthropic.NewUserMessage(anthropic.NewTextBlock(a.externalChangesNote() + userInput))
+	defer a.discardOwnChanges(a.workspaceSeq)
+	conversation = append(conversation, userMessage)
+
+	// Manage conversation length
+	managedConversation, err := a.manageConversationLength(ctx, conversation)
+	if err != nil {
+		log.Printf("Warning: failed to manage conversation length: %v", err)
+	} else {
+		conversation = managedConversation
+	}
+
+	turnStart := len(conversation)
+	guard := &loopGuard{}
+	a.spend = &guard.spend
+	defer func() { a.spend = nil }()
+
+	// Process conversation with tool execution loop
+	for {
+		if err := a.checkRequestCost(conversation, len(conversation) == turnStart); err != nil {
+			if len(conversation) == turnStart {
+				// Nothing was sent, so forget the message instead of leaving an unanswered turn
+				return conversation[:turnStart-1], err
+			}
+			return conversation, err
+		}
+
+		// Between requests is a safe point to swap in a finished background compaction
+		if compacted := a.applyBackgroundCompaction(conversation, false); len(compacted) != len(conversation) {
+			turnStart += len(compacted) - len(conversation)
+			conversation = compacted
+		}
+
+		message, err := a.inferWithFallback(ctx, conversation)
+		if provider.IsContextLengthError(err) {
+			// Retry once with an aggressively compacted conversation instead of dropping the turn
+			turnMessages := len(conversation) - turnStart + 1
+			conversation = a.emergencyCompact(ctx, conversation, turnMessages)
+			turnStart = max(len(conversation)-turnMessages+1, 1)
+			message, err = a.inferWithFallback(ctx, conversation)
+		}
+		if err != nil {
+			return conversation, err
+		}
+		a.relaxToolChoice()
+		conversation = append(conversation, message.ToParam())
+		if text := latestResponseText(conversation[len(conversation)-1:]); text != "" {
+			a.lastResponse = text
+		}
+		a.postProcess(ctx, message)
+
+		// Process tool use blocks
+		toolResults := []anthropic.ContentBlockParamUnion{}
+		hasToolUse := false
+		paused := false
+
+		for _, content := range message.Content {
+			if block, ok := content.AsAny().(anthropic.ToolUseBlock); ok {
+				hasToolUse = true
+
+				if paused {
+					result := pausedToolResult()
+					a.events.OnToolResult(block.Name, result)
+					toolResults = append(toolResults, anthropic.NewToolResultBlock(block.ID, result, true))
+					continue
+				}
+				if !a.profileAllows(block.Name) {
+					result := fmt.Sprintf("Error: %s is not available in the %s profile", block.Name, a.profile)
+					a.events.OnToolResult(block.Name, result)
+					toolResults = append(toolResults, anthropic.NewToolResultBlock(block.ID, result, true))
+					continue
+				}
+				if !a.allowToolCall(guard, block) {
+					result := stoppedToolResult(guard)
+					a.events.OnToolResult(block.Name, result)
+					toolResults = append(toolResults, anthropic.NewToolResultBlock(block.ID, result, true))
+					continue
+				}
+
+				// A replay gets the recorded result instead of running the tool again
+				if a.cassette.Replaying() {
+					result, isError, err := a.cassette.ReplayTool(block.Name, block.Input)
+					if err != nil {
+						return conversation, err
+					}
+					a.events.OnToolResult(block.Name, result)
+					toolResults = append(toolResults, anthropic.NewToolResultBlock(block.ID, result, isError))
+					continue
+				}
+
+				// Execute tool using the new registry system
+				if phases, ok := a.events.(PhaseObserver); ok {
+					phases.OnToolRun(block.Name)
+				}
+				started := time.Now()
+				toolCtx, toolSpan := telemetry.StartTool(ctx, block.Name)
+				toolCtx = a.startToolCall(toolCtx)
+				a.callDecisions = nil
+				result, err := a.toolRegistry.Execute(toolCtx, a, block.Name, block.Input)
+				a.endToolCall()
+				a.callApproved = false
+				toolSpan.End(err)
+				var dryRun *approval.DryRunError
+				switch {
+				case errors.As(err, &dryRun):
+					result, err = dryRun.Error(), nil
+				case err != nil:
+					result = tools.FormatError(err) + a.pathSuggestions(err)
+				}
+				a.recordToolCall(block.Name, time.Since(started), result, err != nil)
+				result = a.redactToolResult(block.Name, result)
+				a.auditToolCall(block.Name, block.Input, result, err != nil)
+				if recordErr := a.cassette.RecordTool(block.Name, block.Input, result, err != nil); recordErr != nil {
+					log.Printf("Warning: %v", recordErr)
+				}
+
+				a.events.OnToolResult(block.Name, result)
+				toolResults = append(toolResults, anthropic.NewToolResultBlock(block.ID, result, err != nil))
+				paused = a.pauseRequested()
+			}
+		}
+
+		if !hasToolUse {
+			break
+		}
+		if paused {
+			steering, stop := a.pauseTurn(guard)
+			if steering != "" {
+				toolResults = append(toolResults, anthropic.NewTextBlock(steering))
+			}
+			guard.stopped = guard.stopped || stop
+		}
+
+		// Add tool results to conversation and continue
+		if len(toolResults) > 0 {
+			conversation = append(conversation, anthropic.NewUserMessage(toolResults...))
+		}
+		if guard.stopped {
+			if guard.reported {
+				break
+			}
+			// One more request, without tools, so the model reports what it did and what remains
+			guard.reported = true
+			a.toolChoice = config.ToolChoiceNone
+		}
+	}
+
+	a.turn++
+	a.trackPinUsage(conversation[turnStart:])
+	return conversation, nil
+}
+
+// redactToolResult strips secrets from tool output before it is shown or added to the conversation
+func (a *Agent) redactToolResult(toolName, result string) string {
+	if a.redactor == nil {
+		return result
+	}
+	redacted, redactions := a.redactor.Redact(result)
+	if len(redactions) == 0 {
+		return result
+	}
+
+	a.events.OnNotice("Redacted", fmt.Sprintf("%d secret(s) hidden from %s output", redact.Total(redactions), toolName))
+	err := a.redactionLog.Record(redact.AuditEntry{Tool: toolName, WorkingDir: a.workingDir, Redactions: redactions})
+	if err != nil {
+		log.Printf("Warning: failed to write redaction audit log: %v", err)
+	}
+	return redacted
+}
+
+// RedactSecrets implements the tools.SecretRedactor interface
+func (a *Agent) RedactSecrets(text string) string {
+	if a.redactor == nil {
+		return text
+	}
+	redacted, _ := a.redactor.Redact(text)
+	return redacted
+}
+
+// saveSession persists the conversation so it can be managed with `goocode sessions`
+func (a *Agent) saveSession(ctx context.Context, conversation []anthropic.MessageParam) {
+	a.session.Messages = conversation
+	a.session.TokenCount = a.estimateConversationTokens(conversation)
+	if a.session.Title == "" {
+		a.session.Title = a.generateSessionTitle(ctx, conversation)
+	}
+	if err := a.sessionStore.Save(a.session); err != nil {
+		log.Printf("Warning: failed to save session: %v", err)
+	}
+}
+
+// generateSessionTitle asks the model for a short title, falling back to the first user message
+func (a *Agent) generateSessionTitle(ctx context.Context, conversation []anthropic.MessageParam) string {
+	firstMessage := ""
+	for _, msg := range conversation {
+		if msg.Role != anthropic.MessageParamRoleUser {
+			continue
+		}
+		for _, block := range msg.Content {
+			if block.OfText != nil {
+				firstMessage = block.OfText.Text
+				break
+			}
+		}
+		if firstMessage != "" {
+			break
+		}
+	}
+	if firstMessage == "" {
+		return ""
+	}
+	if len(firstMessage) > config.SessionTitleContextChars {
+		firstMessage = firstMessage[:config.SessionTitleContextChars]
+	}
+
+	fallback := strings.Join(strings.Fields(firstMessage), " ")
+	if len(fallback) > 60 {
+		fallback = fallback[:57] + "..."
+	}
+
+	message, err := a.provider.NewMessage(ctx, anthropic.MessageNewParams{
+		Model:     anthropic.Model(a.config.Model().ID),
+		MaxTokens: 30,
+		Messages: []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(
+			"Write a short title (at most 6 words) for a coding session that starts with this request. Reply with the title only.\n\n" + firstMessage,
+		))},
+	})
+	if err != nil {
+		return fallback
+	}
+	for _, content := range message.Content {
+		if textBlock, ok := content.AsAny().(anthropic.TextBlock); ok {
+			if title := strings.Trim(strings.TrimSpace(textBlock.Text), "\"'#*"); title != "" {
+				return title
+			}
+		}
+	}
+	return fallback
+}
+
+// ValidateDirectory checks that dir exists and is a directory
+func ValidateDirectory(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("directory does not exist: %s", dir)
+		}
+		return fmt.Errorf("cannot access directory: %w", err)
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("path is not a directory: %s", dir)
+	}
+
+	return nil
+}
+
+// BroadDirectory describes dir when it is too broad to hand the agent (the filesystem root, the home
+// directory or one of its parents) and returns "" otherwise
+func BroadDirectory(dir string) string {
+	dir = filepath.Clean(dir)
+	if filepath.Dir(dir) == dir {
+		return "the filesystem root"
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	home = filepath.Clean(home)
+	switch {
+	case dir == home:
+		return "your home directory"
+	case strings.HasPrefix(home, dir+string(filepath.Separator)):
+		return "a parent of your home directory"
+	}
+	return ""
+}
+
+// loadSystemPrompt reads the system prompt template from path
+func loadSystemPrompt(path string) string {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		log.Printf("Warning: Could not load %s: %v. Using default prompt.", path, err)
+		return "You are GooCode, a helpful AI coding assistant with access to file operations within the working directory."
+	}
+	return string(content)
+}
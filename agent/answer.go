@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"anthropic-chat/config"
+	"anthropic-chat/tools/answer"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// answerPrompt tells the model how a run with an answer schema ends
+const answerPrompt = `
+
+# Structured answer
+A program consumes this run's answer. When you have gathered what you need, call final_answer once with an answer
+that matches its schema. Text you write outside of it is not part of the answer.`
+
+// SetAnswerSchema makes one-shot runs end with an answer matching the JSON Schema document, submitted
+// through the final_answer tool; Answer returns it once RunOnce succeeds
+func (a *Agent) SetAnswerSchema(schemaJSON []byte) error {
+	tool, err := answer.NewFinalAnswerTool(schemaJSON)
+	if err != nil {
+		return err
+	}
+	a.toolRegistry.Register(tool)
+	a.answerTool = tool.Name()
+	return nil
+}
+
+// SetAnswer implements the tools.AnswerSink interface with the input of a valid final_answer call
+func (a *Agent) SetAnswer(answer json.RawMessage) {
+	a.answer = answer
+}
+
+// Answer returns the structured answer of the last run, if it produced one
+func (a *Agent) Answer() (json.RawMessage, bool) {
+	return a.answer, a.answer != nil
+}
+
+// answerContext reminds the model to finish with final_answer when an answer schema is set
+func (a *Agent) answerContext() string {
+	if a.answerTool == "" {
+		return ""
+	}
+	return answerPrompt
+}
+
+// finishAnswer asks again, forcing the final_answer tool, when a turn ended without a valid answer
+func (a *Agent) finishAnswer(ctx context.Context, conversation []anthropic.MessageParam) ([]anthropic.MessageParam, error) {
+	var err error
+	for retry := 0; a.answerTool != "" && a.answer == nil; retry++ {
+		if retry == config.AnswerRetries {
+			return conversation, fmt.Errorf("no answer matching the schema after %d retries", retry)
+		}
+		a.events.OnNotice("Answer", "The run ended without a valid answer; asking for one")
+		if err := a.ForceTool(a.answerTool); err != nil {
+			return conversation, err
+		}
+		conversation, err = a.RunTurn(ctx, conversation, "Call final_answer now with your answer. It must match the tool's schema exactly.")
+		if err != nil {
+			return conversation, err
+		}
+	}
+	return conversation, nil
+}
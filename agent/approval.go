@@ -0,0 +1,332 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/config"
+	"anthropic-chat/project"
+	"anthropic-chat/ui"
+)
+
+// readOnlyPrompt tells the model which tools are missing in read-only mode and why
+const readOnlyPrompt = `
+
+# Read-only mode
+This session is read-only: tools that change files or run commands are not available. Explore and explain the code,
+and describe any changes you would make instead of making them.`
+
+// loadApprovalPolicy reads the configured approval matrix, if any
+func loadApprovalPolicy(file string) (*approval.Policy, error) {
+	if file == "" {
+		return nil, nil
+	}
+	return approval.Load(file)
+}
+
+// trustProjectPolicy reports whether the approval policy a repository provides may be used. A cloned
+// repository could allow anything, so the user is shown the policy and asked the first time and after
+// every change to it; headless runs can't ask and leave an unaccepted policy unused.
+func (a *Agent) trustProjectPolicy(file string) bool {
+	trusted, err := approval.LoadTrustedPolicies(a.config.Security.TrustedPolicies)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return false
+	}
+	if trusted.Trusted(file) {
+		return true
+	}
+	if a.config.Security.Headless {
+		a.events.OnNotice("Approval", fmt.Sprintf("Ignoring the approval policy in %s, which hasn't been accepted; accept it in an interactive session or set GOOCODE_APPROVAL_POLICY", file))
+		return false
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		log.Printf("Warning: failed to read approval policy: %v", err)
+		return false
+	}
+	fmt.Printf("%s: this repository provides an approval policy (%s):\n%s\n", a.uiManager.Paint(ui.StyleWarning, "[Approval]"), file, ui.EscapeControl(strings.TrimRight(string(data), "\n")))
+	if !a.confirm("Use it to decide what tools may do without asking? [y/N] ", false) {
+		a.events.OnNotice("Approval", "Not using "+file+"; gated actions will ask")
+		return false
+	}
+	if err := trusted.Trust(file); err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	return true
+}
+
+// startApprovalWebhook sets up remote approval for headless runs when a webhook is configured
+func startApprovalWebhook(cfg config.SecurityConfig) *approval.Webhook {
+	if !cfg.Headless || cfg.ApprovalWebhook == "" {
+		return nil
+	}
+	timeout := time.Duration(cfg.ApprovalTimeout) * time.Second
+	webhook, err := approval.NewWebhook(cfg.ApprovalWebhook, cfg.ApprovalListen, cfg.ApprovalCallbackURL, timeout)
+	if err != nil {
+		// Undecided actions are then denied, as in any headless run
+		log.Printf("Warning: remote approval disabled: %v", err)
+		return nil
+	}
+	return webhook
+}
+
+// Approve implements the tools.Approver interface: the approval policy decides first, then the user is
+// asked where the policy says so. Headless runs never prompt, so undecided actions are denied.
+func (a *Agent) Approve(req approval.Request) error {
+	if a.config.Security.ReadOnly {
+		// Catches gated actions from tools registered outside RegisterTools
+		a.noteDecision(req, "blocked by read-only mode")
+		return fmt.Errorf("%w: %s", approval.ErrReadOnly, req.Summary)
+	}
+	if path := a.protectedPath(req); path != "" {
+		a.noteDecision(req, "denied (protected file)")
+		return fmt.Errorf("%w: %s would change %s, which decides what tools may do; edit it yourself", approval.ErrDenied, req.Summary, path)
+	}
+	if a.dryRun {
+		a.noteDecision(req, "dry run")
+		return &approval.DryRunError{Request: req}
+	}
+	// The working tree is about to change, so the session summary needs its starting point now
+	a.takeSessionSnapshot()
+	// Without a policy, every gated action (a write or a command) asks while approval is required, and
+	// always in headless runs, where asking means denying unless a remote approver decides
+	action, rule := approval.Allow, 0
+	if a.approvalPolicy != nil {
+		action, rule = a.approvalPolicy.Evaluate(req)
+	} else if a.config.Security.RequireApproval || a.config.Security.Headless {
+		action = approval.Ask
+	}
+	escalated := false
+	if rule == 0 && action == approval.Allow && req.Dangerous && !a.config.Security.AllowDangerousCommands {
+		// Destructive commands are never waved through by a default, only by an explicit rule
+		action, escalated = approval.Ask, true
+	}
+	source := "default"
+	if rule > 0 {
+		source = fmt.Sprintf("rule %d", rule)
+	}
+
+	switch action {
+	case approval.Allow:
+		a.noteDecision(req, "allowed (%s)", source)
+		if a.config.Security.Headless {
+			log.Printf("Approved %s: %s (%s)", req.Tool, req.Summary, source)
+		}
+		return nil
+	case approval.Deny:
+		a.noteDecision(req, "denied (%s)", source)
+		log.Printf("Denied %s: %s (%s)", req.Tool, req.Summary, source)
+		return fmt.Errorf("%w: %s was denied by the approval policy (%s)", approval.ErrDenied, req.Summary, source)
+	}
+	if a.callApproved && !escalated {
+		// The user already approved this tool call when a rule for the tool asked; a destructive
+		// command inside it still gets its own question
+		a.noteDecision(req, "allowed (tool call approved)")
+		return nil
+	}
+
+	return a.ask(req)
+}
+
+// protectedPath returns the first of req's paths that tools may never write, or ""
+func (a *Agent) protectedPath(req approval.Request) string {
+	for _, path := range req.Paths {
+		fullPath := filepath.Join(a.workingDir, filepath.FromSlash(strings.TrimSuffix(path, "/")))
+		if project.Protected(a.workingDir, fullPath, a.config.Security.ApprovalPolicy) {
+			return path
+		}
+	}
+	return ""
+}
+
+// CheckToolCall implements the tools.Gate interface: rules that name the tool decide every call to it,
+// including tools that never ask for approval themselves
+func (a *Agent) CheckToolCall(toolName string, input json.RawMessage) error {
+	a.callApproved = false
+	if a.approvalPolicy == nil {
+		return nil
+	}
+	req := approval.CallRequest(toolName, input)
+	action, rule := a.approvalPolicy.EvaluateCall(req)
+	switch {
+	case rule == 0:
+		return nil
+	case action == approval.Allow:
+		a.noteDecision(req, "allowed (rule %d)", rule)
+		return nil
+	case action == approval.Deny:
+		a.noteDecision(req, "denied (rule %d)", rule)
+		log.Printf("Denied %s: %s (rule %d)", req.Tool, req.Summary, rule)
+		return fmt.Errorf("%w: %s was denied by the approval policy (rule %d)", approval.ErrDenied, req.Summary, rule)
+	case a.dryRun:
+		// Nothing will happen, so there is nothing to ask about; gated tools report what they would do
+		return nil
+	}
+	if err := a.ask(req); err != nil {
+		return err
+	}
+	a.callApproved = true
+	return nil
+}
+
+// ask gets a decision on req from a remote approver in headless runs, or from the user. Commands the
+// user chose to always allow in this project skip the question.
+func (a *Agent) ask(req approval.Request) error {
+	allowlist := a.commandAllowlist()
+	if req.Command != "" && !req.Dangerous && allowlist != nil && allowlist.Allows(req.Command) {
+		a.noteDecision(req, "allowed (command allowlist)")
+		return nil
+	}
+	if a.config.Security.Headless && a.approvals != nil {
+		return a.remoteApprove(req)
+	}
+	if a.config.Security.Headless {
+		a.noteDecision(req, "denied (headless, no approver)")
+		log.Printf("Denied %s: %s (needs approval in a headless run)", req.Tool, req.Summary)
+		return fmt.Errorf("%w: %s needs approval, which is unavailable in a headless run", approval.ErrDenied, req.Summary)
+	}
+	a.uiManager.HideSpinner()
+	a.uiManager.Notify("Approval needed", req.Summary)
+	fmt.Printf("%s: %s\n", a.uiManager.Paint(ui.StyleWarning, "[Approval]"), ui.EscapeControl(req.Summary))
+	if req.Diff != "" {
+		added, removed := ui.DiffStats(req.Diff)
+		// File contents could otherwise move the cursor or rewrite lines of the prompt being answered
+		fmt.Printf("%s\n%s", a.uiManager.Paint(ui.StyleInfo, fmt.Sprintf("Proposed change (+%d -%d lines):", added, removed)), a.uiManager.FormatDiff(ui.EscapeControl(req.Diff), config.ApprovalDiffLines))
+	}
+	if prefix := approval.SuggestPrefix(req.Command); prefix != "" && !req.Dangerous && allowlist != nil {
+		fmt.Printf("Allow? [y/N, a = always allow commands starting with '%s' in this project] ", prefix)
+		answer, _ := a.getUserMessage()
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			a.noteDecision(req, "approved by the user")
+			return nil
+		case "a", "always":
+			if err := allowlist.Add(prefix); err != nil {
+				fmt.Printf("%s: %v\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+			} else {
+				fmt.Printf("%s: Commands starting with '%s' will run without asking (see /allowed)\n", a.uiManager.Paint(ui.StyleSuccess, "Approval"), prefix)
+			}
+			a.noteDecision(req, "approved by the user, who allowed %q from now on", prefix)
+			return nil
+		}
+	} else if req.Proposed != nil {
+		if a.confirmEditable(req) {
+			return nil
+		}
+	} else if a.confirm("Allow? [y/N] ", false) {
+		a.noteDecision(req, "approved by the user")
+		return nil
+	}
+	a.noteDecision(req, "declined by the user")
+	return fmt.Errorf("%w: the user declined to %s", approval.ErrDenied, req.Summary)
+}
+
+// confirmEditable asks about an edit whose new content the user may rewrite in their editor before it
+// is applied, reporting whether it was approved
+func (a *Agent) confirmEditable(req approval.Request) bool {
+	path := req.Tool
+	if len(req.Paths) > 0 {
+		path = req.Paths[0]
+	}
+	for {
+		fmt.Print("Allow? [y/N, e = edit the new content in your editor, then apply it] ")
+		answer, _ := a.getUserMessage()
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			a.noteDecision(req, "approved by the user")
+			return true
+		case "e", "edit":
+			edited, err := editInEditor(*req.Proposed, path)
+			if err != nil {
+				fmt.Printf("%s: %v\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+				continue
+			}
+			if edited == *req.Proposed {
+				fmt.Printf("%s: No changes made in the editor\n", a.uiManager.Paint(ui.StyleInfo, "Approval"))
+				continue
+			}
+			*req.Proposed = edited
+			a.noteDecision(req, "approved by the user with their own edits")
+			fmt.Printf("%s: Applying your version of %s\n", a.uiManager.Paint(ui.StyleSuccess, "Approval"), path)
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+// remoteApprove sends req to the approval webhook and waits for the decision
+func (a *Agent) remoteApprove(req approval.Request) error {
+	log.Printf("Waiting up to %ds for remote approval of %s: %s", a.config.Security.ApprovalTimeout, req.Tool, req.Summary)
+	decision, err := a.approvals.Request(context.Background(), req)
+	if err != nil {
+		a.noteDecision(req, "denied (no remote decision: %v)", err)
+		log.Printf("Denied %s: %s (%v)", req.Tool, req.Summary, err)
+		return fmt.Errorf("%w: %s was not approved remotely: %v", approval.ErrDenied, req.Summary, err)
+	}
+
+	by := decision.By
+	if by == "" {
+		by = "remote approver"
+	}
+	if !decision.Approved {
+		a.noteDecision(req, "denied by %s", by)
+		log.Printf("Denied %s: %s (by %s)", req.Tool, req.Summary, by)
+		message := fmt.Sprintf("%s was denied by %s", req.Summary, by)
+		if decision.Reason != "" {
+			message += ": " + decision.Reason
+		}
+		return fmt.Errorf("%w: %s", approval.ErrDenied, message)
+	}
+	a.noteDecision(req, "approved by %s", by)
+	log.Printf("Approved %s: %s (by %s)", req.Tool, req.Summary, by)
+	return nil
+}
+
+// commandAllowlist loads the prefixes the user always allows in this project, or nil if they can't be read
+func (a *Agent) commandAllowlist() *approval.Allowlist {
+	allowlist, err := approval.ProjectAllowlist(a.config.Security.AllowlistDir, a.projectDir().Root)
+	if err != nil {
+		log.Printf("Warning: %v", err)
+		return nil
+	}
+	return allowlist
+}
+
+// allowedCommand handles /allowed: it lists the always-allowed command prefixes, or removes one
+func (a *Agent) allowedCommand(arg string) {
+	allowlist := a.commandAllowlist()
+	if allowlist == nil {
+		return
+	}
+	if prefix, ok := strings.CutPrefix(arg, "remove "); ok {
+		prefix = strings.TrimSpace(prefix)
+		removed, err := allowlist.Remove(prefix)
+		switch {
+		case err != nil:
+			fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+		case !removed:
+			fmt.Printf("%s: '%s' is not in the allowlist\n\n", a.uiManager.Paint(ui.StyleError, "Error"), prefix)
+		default:
+			fmt.Printf("%s: Commands starting with '%s' will ask again\n\n", a.uiManager.Paint(ui.StyleSuccess, "Approval"), prefix)
+		}
+		return
+	}
+	if len(allowlist.Prefixes) == 0 {
+		fmt.Printf("%s: No commands are always allowed; answer 'a' at a command's approval prompt to add one\n\n", a.uiManager.Paint(ui.StyleInfo, "Approval"))
+		return
+	}
+	fmt.Printf("%s\n", a.uiManager.Paint(ui.StyleInfo, fmt.Sprintf("Always allowed commands (%s):", allowlist.Path())))
+	for _, prefix := range allowlist.Prefixes {
+		fmt.Printf("  %s ...\n", prefix)
+	}
+	fmt.Println()
+}
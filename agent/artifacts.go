@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/project"
+	"anthropic-chat/session"
+	"anthropic-chat/ui"
+)
+
+// artifactsDir is where this session's artifacts go
+func (a *Agent) artifactsDir() string {
+	base := a.config.Session.ArtifactsDir
+	if base == "" {
+		base = a.projectDir().Path(project.ArtifactsDir)
+	}
+	return filepath.Join(base, a.session.ID)
+}
+
+// WriteArtifact implements the tools.ArtifactWriter interface, recording the artifact in the session manifest
+func (a *Agent) WriteArtifact(name, kind, description string, content []byte) (string, error) {
+	clean := filepath.Clean(filepath.FromSlash(name))
+	if filepath.IsAbs(clean) || clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("artifact name %q must be a relative path inside the artifact directory", name)
+	}
+
+	dir := a.artifactsDir()
+	path := filepath.Join(dir, clean)
+	// Writes an artifact like any other file, so deny rules, dry runs and read-only mode apply
+	lines := strings.Count(string(content), "\n")
+	err := a.Approve(approval.Request{
+		Tool:    "emit_artifact",
+		Summary: fmt.Sprintf("write artifact %s with %d lines", a.displayPath(path), lines),
+		Paths:   []string{filepath.ToSlash(a.displayPath(path))},
+		Lines:   lines,
+	})
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create artifact directory: %w", err)
+	}
+	if a.config.Session.ArtifactsDir == "" {
+		// The default location is inside the working tree, so keep it out of git
+		if err := a.projectDir().EnsureGitignore(); err != nil {
+			return "", err
+		}
+	}
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write artifact: %w", err)
+	}
+
+	artifact := session.Artifact{Path: path, Kind: kind, Description: description, Bytes: len(content), CreatedAt: time.Now()}
+	replaced := false
+	for i := range a.session.Artifacts {
+		if a.session.Artifacts[i].Path == path {
+			a.session.Artifacts[i] = artifact
+			replaced = true
+		}
+	}
+	if !replaced {
+		a.session.Artifacts = append(a.session.Artifacts, artifact)
+	}
+	return a.displayPath(path), nil
+}
+
+// displayPath shows artifacts inside the working tree relative to it
+func (a *Agent) displayPath(path string) string {
+	if rel, err := filepath.Rel(a.workingDir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return rel
+	}
+	return path
+}
+
+// formatArtifacts lists the session's artifacts for /artifacts
+func (a *Agent) formatArtifacts() string {
+	var b strings.Builder
+	for _, artifact := range a.session.Artifacts {
+		fmt.Fprintf(&b, "  %s (%s", a.displayPath(artifact.Path), ui.FormatBytes(int64(artifact.Bytes)))
+		if artifact.Kind != "" {
+			fmt.Fprintf(&b, ", %s", artifact.Kind)
+		}
+		b.WriteString(")")
+		if artifact.Description != "" {
+			fmt.Fprintf(&b, " - %s", artifact.Description)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
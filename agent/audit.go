@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/audit"
+)
+
+// newAuditLog opens the tool call audit log when one is configured
+func newAuditLog(path string) *audit.Log {
+	if path == "" {
+		return nil
+	}
+	return audit.New(path)
+}
+
+// noteDecision remembers how a gated action in the running tool call was decided
+func (a *Agent) noteDecision(req approval.Request, format string, args ...interface{}) {
+	a.callDecisions = append(a.callDecisions, fmt.Sprintf(format, args...)+": "+req.Summary)
+}
+
+// auditToolCall appends an executed tool call to the audit log; inputs and approval summaries are redacted like tool output
+func (a *Agent) auditToolCall(toolName string, input json.RawMessage, result string, failed bool) {
+	if a.auditLog == nil {
+		return
+	}
+	decision := "not required"
+	if len(a.callDecisions) > 0 {
+		decision = strings.Join(a.callDecisions, "; ")
+	}
+	if a.redactor != nil {
+		redacted, _ := a.redactor.Redact(string(input))
+		input = json.RawMessage(redacted)
+		decision, _ = a.redactor.Redact(decision)
+	}
+	err := a.auditLog.Record(audit.Entry{
+		Session:    a.session.ID,
+		WorkingDir: a.workingDir,
+		Tool:       toolName,
+		Input:      input,
+		Failed:     failed,
+		Approval:   decision,
+	}, result)
+	if err != nil {
+		log.Printf("Warning: failed to write tool call audit log: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"log"
+	"slices"
+
+	"anthropic-chat/compaction"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// backgroundCompaction is a compaction of a snapshot of the conversation, running while the conversation continues
+type backgroundCompaction struct {
+	base   int    // Messages in the snapshot; anything after them arrived while compacting
+	first  string // Fingerprints of the snapshot's first and last messages, to tell whether the
+	last   string // conversation was replaced meanwhile (say, by emergency compaction)
+	before int    // Estimated tokens of the snapshot
+	cancel context.CancelFunc
+	done   chan struct{}
+	result []anthropic.MessageParam
+	err    error
+}
+
+// maybeCompactInBackground starts compacting once the conversation passes the background threshold
+func (a *Agent) maybeCompactInBackground(ctx context.Context, conversation []anthropic.MessageParam, tokenCount int) {
+	percent := a.config.Agent.BackgroundCompaction
+	if a.compacting != nil || percent <= 0 || percent >= 100 {
+		return
+	}
+	if tokenCount < a.config.MaxInputTokens()*percent/100 || compaction.SplitPoint(conversation, a.config.RecentMessagesKeep()) == 0 {
+		return
+	}
+
+	snapshot := slices.Clone(conversation)
+	job := &backgroundCompaction{
+		base:   len(snapshot),
+		first:  messageFingerprint(snapshot[0]),
+		last:   messageFingerprint(snapshot[len(snapshot)-1]),
+		before: a.estimateConversationTokens(snapshot),
+		done:   make(chan struct{}),
+	}
+	a.events.OnNotice("Token Management", fmt.Sprintf("Conversation has %s tokens, compacting in the background with %s...", ui.FormatCount(tokenCount), a.compaction.Name()))
+
+	// Everything the job needs is captured now, so it never touches state the main loop is changing
+	overhead := a.contextOverheadTokens()
+	opts := compaction.Options{
+		KeepRecent:   a.config.RecentMessagesKeep(),
+		TargetTokens: a.config.MaxInputTokens() * 3 / 4,
+		Summarize:    a.summarizeWith(a.config.Model().ID),
+		CountTokens: func(_ context.Context, conversation []anthropic.MessageParam) (int, error) {
+			return estimateTokens(overhead, conversation), nil
+		},
+	}
+	ctx, job.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	a.compacting = job
+	go func() {
+		defer close(job.done)
+		defer job.cancel()
+		job.result, job.err = a.compaction.Compact(ctx, snapshot, opts)
+	}()
+}
+
+// applyBackgroundCompaction swaps a finished compaction into conversation, keeping the messages added since it
+// started. With wait it blocks until the running compaction is done; otherwise an unfinished one is left running.
+func (a *Agent) applyBackgroundCompaction(conversation []anthropic.MessageParam, wait bool) []anthropic.MessageParam {
+	job := a.compacting
+	if job == nil {
+		return conversation
+	}
+	if wait {
+		<-job.done
+	} else {
+		select {
+		case <-job.done:
+		default:
+			return conversation
+		}
+	}
+	a.compacting = nil
+
+	if job.err != nil {
+		log.Printf("Warning: %v", job.err)
+	}
+	if len(job.result) == 0 || len(conversation) < job.base ||
+		messageFingerprint(conversation[0]) != job.first || messageFingerprint(conversation[job.base-1]) != job.last {
+		return conversation
+	}
+
+	compacted := slices.Concat(job.result, conversation[job.base:])
+	a.forgetReads()
+	a.recallMemories(compacted)
+	a.events.OnNotice("Token Management", fmt.Sprintf("Background compaction finished: reduced from ~%s to ~%s tokens.", ui.FormatCount(job.before), ui.FormatCount(a.estimateConversationTokens(compacted))))
+	return compacted
+}
+
+// stopBackgroundCompaction cancels the running compaction, if any, and waits for it to let go of the strategy
+func (a *Agent) stopBackgroundCompaction() {
+	if job := a.compacting; job != nil {
+		job.cancel()
+		<-job.done
+		a.compacting = nil
+	}
+}
+
+// messageFingerprint identifies a message's content
+func messageFingerprint(msg anthropic.MessageParam) string {
+	data, _ := json.Marshal(msg)
+	return fmt.Sprintf("%x", sha256.Sum256(data))
+}
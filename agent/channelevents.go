@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"encoding/json"
+	"sync"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// EventKind names the agent activity an Event reports
+type EventKind string
+
+const (
+	EventInferenceStart EventKind = "inference_start"
+	EventText           EventKind = "text"
+	EventToolStart      EventKind = "tool_start"
+	EventToolInputDelta EventKind = "tool_input_delta"
+	EventToolCall       EventKind = "tool_call"
+	EventMessage        EventKind = "message"
+	EventInferenceEnd   EventKind = "inference_end"
+	EventToolResult     EventKind = "tool_result"
+	EventToolProgress   EventKind = "tool_progress"
+	EventCommandStart   EventKind = "command_start"
+	EventCommandOutput  EventKind = "command_output"
+	EventCommandEnd     EventKind = "command_end"
+	EventUsage          EventKind = "usage"
+	EventNotice         EventKind = "notice"
+	EventError          EventKind = "error"
+)
+
+// Event is one callback of the EventHandler and DetailObserver interfaces as a value; fields the kind
+// doesn't use are left empty
+type Event struct {
+	Kind    EventKind
+	Name    string             // Tool name for tool and command events, the label for notices
+	ID      string             // Tool call ID for tool_start and tool_input_delta
+	Text    string             // Text or input delta, tool result, progress or notice message, command or output line
+	Input   json.RawMessage    // Complete tool input for tool_call
+	Message *anthropic.Message // The finished response for message
+	Usage   anthropic.Usage    // Token usage for usage
+	Err     error              // The turn's error for error
+}
+
+// ChannelEvents is an EventHandler that sends every event, including the DetailObserver ones, on a
+// channel, for embedders that consume the agent's activity from another goroutine and for tests that
+// check event sequences. The agent waits while the channel is full, so keep reading until Close.
+type ChannelEvents struct {
+	events chan Event
+	once   sync.Once
+}
+
+// NewChannelEvents creates a channel event handler whose channel holds up to buffer unread events
+func NewChannelEvents(buffer int) *ChannelEvents {
+	return &ChannelEvents{events: make(chan Event, buffer)}
+}
+
+// Events returns the channel events are delivered on; it is closed by Close
+func (c *ChannelEvents) Events() <-chan Event {
+	return c.events
+}
+
+// Close closes the events channel. Call it once the agent is done, such as after RunTurn returns.
+func (c *ChannelEvents) Close() {
+	c.once.Do(func() { close(c.events) })
+}
+
+func (c *ChannelEvents) OnInferenceStart() {
+	c.events <- Event{Kind: EventInferenceStart}
+}
+
+func (c *ChannelEvents) OnText(delta string) {
+	c.events <- Event{Kind: EventText, Text: delta}
+}
+
+func (c *ChannelEvents) OnToolStart(id string, name string) {
+	c.events <- Event{Kind: EventToolStart, ID: id, Name: name}
+}
+
+func (c *ChannelEvents) OnToolInputDelta(id string, partial string) {
+	c.events <- Event{Kind: EventToolInputDelta, ID: id, Text: partial}
+}
+
+func (c *ChannelEvents) OnToolCall(name string, input json.RawMessage) {
+	c.events <- Event{Kind: EventToolCall, Name: name, Input: input}
+}
+
+func (c *ChannelEvents) OnMessage(message *anthropic.Message) {
+	c.events <- Event{Kind: EventMessage, Message: message}
+}
+
+func (c *ChannelEvents) OnInferenceEnd() {
+	c.events <- Event{Kind: EventInferenceEnd}
+}
+
+func (c *ChannelEvents) OnToolResult(name string, result string) {
+	c.events <- Event{Kind: EventToolResult, Name: name, Text: result}
+}
+
+func (c *ChannelEvents) OnToolProgress(name string, message string) {
+	c.events <- Event{Kind: EventToolProgress, Name: name, Text: message}
+}
+
+func (c *ChannelEvents) OnCommandStart(name string, command string) {
+	c.events <- Event{Kind: EventCommandStart, Name: name, Text: command}
+}
+
+func (c *ChannelEvents) OnCommandOutput(name string, line string) {
+	c.events <- Event{Kind: EventCommandOutput, Name: name, Text: line}
+}
+
+func (c *ChannelEvents) OnCommandEnd(name string) {
+	c.events <- Event{Kind: EventCommandEnd, Name: name}
+}
+
+func (c *ChannelEvents) OnUsage(usage anthropic.Usage) {
+	c.events <- Event{Kind: EventUsage, Usage: usage}
+}
+
+func (c *ChannelEvents) OnNotice(label string, message string) {
+	c.events <- Event{Kind: EventNotice, Name: label, Text: message}
+}
+
+func (c *ChannelEvents) OnError(err error) {
+	c.events <- Event{Kind: EventError, Err: err}
+}
@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"anthropic-chat/tools/file"
+	"anthropic-chat/ui"
+
+	"github.com/atotto/clipboard"
+)
+
+// copyCommand handles /copy [n|all], copying a code block of the latest response (the last one by default)
+func (a *Agent) copyCommand(arg string) {
+	block := -1
+	switch {
+	case arg == "":
+	case arg == "all":
+		block = 0
+	default:
+		n, err := strconv.Atoi(arg)
+		if err != nil || n < 1 {
+			fmt.Printf("Usage: /copy [block number|all]\n\n")
+			return
+		}
+		block = n
+	}
+	if strings.TrimSpace(a.lastResponse) == "" {
+		fmt.Printf("%s: there is no assistant response to copy yet\n\n", a.uiManager.Paint(ui.StyleError, "Error"))
+		return
+	}
+
+	content, err := file.SelectOutput(a.lastResponse, block)
+	if err == nil {
+		err = clipboard.WriteAll(content)
+	}
+	if err != nil {
+		fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+		return
+	}
+	fmt.Printf("%s %d lines\n\n", a.uiManager.Paint(ui.StyleSuccess, "Copied:"), strings.Count(strings.TrimSuffix(content, "\n"), "\n")+1)
+}
+
+// pasteCommand handles /paste [language], holding the clipboard as a fenced block for the next message
+func (a *Agent) pasteCommand(language string) {
+	content, err := clipboard.ReadAll()
+	if err != nil {
+		fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+		return
+	}
+	if strings.TrimSpace(content) == "" {
+		fmt.Printf("%s: the clipboard is empty\n\n", a.uiManager.Paint(ui.StyleInfo, "Paste"))
+		return
+	}
+
+	// A fence longer than any backtick run in the content can't be closed early
+	fence := "```"
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	a.pendingPaste += fmt.Sprintf("%s%s\n%s\n%s\n", fence, language, strings.TrimRight(content, "\n"), fence)
+	fmt.Printf("%s %d lines will be added to your next message\n\n", a.uiManager.Paint(ui.StyleSuccess, "Pasted:"), strings.Count(strings.TrimRight(content, "\n"), "\n")+1)
+}
+
+// takePaste appends anything pasted with /paste to input
+func (a *Agent) takePaste(input string) string {
+	if a.pendingPaste == "" {
+		return input
+	}
+	input = strings.TrimRight(input, "\n") + "\n\n" + a.pendingPaste
+	a.pendingPaste = ""
+	return input
+}
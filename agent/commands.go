@@ -0,0 +1,526 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/compaction"
+	"anthropic-chat/config"
+	"anthropic-chat/project"
+	"anthropic-chat/session"
+	"anthropic-chat/tools/file"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// handleSlashCommand processes slash commands and returns true if handled
+func (a *Agent) handleSlashCommand(ctx context.Context, input string, conversationPtr *[]anthropic.MessageParam) bool {
+	conversation := *conversationPtr
+
+	if input == "/expand" {
+		if !a.uiManager.Expand(a.getUserMessage) {
+			fmt.Printf("%s: Nothing to expand\n\n", a.uiManager.Paint(ui.StyleInfo, "Expand"))
+		}
+		return true
+	}
+
+	if strings.HasPrefix(input, "/dryrun") {
+		switch strings.TrimSpace(strings.TrimPrefix(input, "/dryrun")) {
+		case "":
+			a.dryRun = !a.dryRun
+		case "on":
+			a.dryRun = true
+		case "off":
+			a.dryRun = false
+		default:
+			fmt.Printf("Usage: /dryrun [on|off]\n\n")
+			return true
+		}
+		if a.dryRun {
+			fmt.Printf("%s on; file changes and commands are reported instead of performed\n\n", a.uiManager.Paint(ui.StyleSuccess, "Dry run:"))
+		} else {
+			fmt.Printf("%s off\n\n", a.uiManager.Paint(ui.StyleSuccess, "Dry run:"))
+		}
+		return true
+	}
+
+	if input == "/brief" {
+		level := config.VerbosityTerse
+		if a.Verbosity() == config.VerbosityTerse {
+			level = config.VerbosityNormal
+		}
+		_ = a.SetVerbosity(level)
+		fmt.Printf("%s %s (up to %s output tokens)\n\n", a.uiManager.Paint(ui.StyleSuccess, "Verbosity:"), level, ui.FormatCount(a.maxOutputTokens()))
+		return true
+	}
+
+	if strings.HasPrefix(input, "/verbosity") {
+		level := strings.TrimSpace(strings.TrimPrefix(input, "/verbosity"))
+		if level != "" {
+			if err := a.SetVerbosity(level); err != nil {
+				fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+				return true
+			}
+		}
+		fmt.Printf("%s %s (up to %s output tokens)\n\n", a.uiManager.Paint(ui.StyleSuccess, "Verbosity:"), a.Verbosity(), ui.FormatCount(a.maxOutputTokens()))
+		return true
+	}
+
+	if input == "/force-tool" || strings.HasPrefix(input, "/force-tool ") {
+		choice := strings.TrimSpace(strings.TrimPrefix(input, "/force-tool"))
+		if choice == "" {
+			choice, forced := a.pendingToolChoice()
+			source := "the GOOCODE_TOOL_CHOICE default"
+			if forced {
+				source = "set with /force-tool"
+			}
+			fmt.Printf("%s %s (%s)\n\n", a.uiManager.Paint(ui.StyleInfo, "Tool choice:"), toolChoiceDescription(choice), source)
+			return true
+		}
+		if choice == "off" {
+			choice = config.ToolChoiceAuto
+		}
+		if err := a.ForceTool(choice); err != nil {
+			fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+			return true
+		}
+		fmt.Printf("%s %s\n\n", a.uiManager.Paint(ui.StyleSuccess, "Tool choice:"), toolChoiceDescription(choice))
+		return true
+	}
+
+	if input == "/copy" || strings.HasPrefix(input, "/copy ") {
+		a.copyCommand(strings.TrimSpace(strings.TrimPrefix(input, "/copy")))
+		return true
+	}
+
+	if input == "/paste" || strings.HasPrefix(input, "/paste ") {
+		a.pasteCommand(strings.TrimSpace(strings.TrimPrefix(input, "/paste")))
+		return true
+	}
+
+	if strings.HasPrefix(input, "/save-output") {
+		a.saveOutputCommand(strings.Fields(strings.TrimPrefix(input, "/save-output")), conversation)
+		return true
+	}
+
+	if strings.HasPrefix(input, "/shell") {
+		if strings.TrimSpace(strings.TrimPrefix(input, "/shell")) != "reset" {
+			fmt.Printf("Usage: /shell reset\n\n")
+			return true
+		}
+		if a.shell != nil {
+			a.shell.Reset(a.workingDir)
+		}
+		fmt.Printf("%s Restarted in %s; its directory and environment were reset\n\n", a.uiManager.Paint(ui.StyleSuccess, "Shell:"), a.workingDir)
+		return true
+	}
+
+	if input == "/init" {
+		if a.config.Security.ReadOnly {
+			fmt.Printf("%s: /init writes %s, which is %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), project.BriefFile, approval.ErrReadOnly)
+			return true
+		}
+		a.runInit(ctx)
+		return true
+	}
+
+	if strings.HasPrefix(input, "/remember") {
+		note := strings.TrimSpace(strings.TrimPrefix(input, "/remember"))
+		if note == "" {
+			fmt.Printf("Usage: /remember <note>\n\n")
+			return true
+		}
+		dir := a.projectDir()
+		if err := dir.Remember(note); err != nil {
+			fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+			return true
+		}
+		a.project = dir
+		fmt.Printf("%s Added to %s\n\n", a.uiManager.Paint(ui.StyleSuccess, "Remembered:"), a.displayPath(dir.Path(project.MemoryFile)))
+		return true
+	}
+
+	if input == "/artifacts" {
+		list := a.formatArtifacts()
+		if list == "" {
+			fmt.Printf("%s: No artifacts in this session (they go to %s)\n\n", a.uiManager.Paint(ui.StyleInfo, "Artifacts"), a.displayPath(a.artifactsDir()))
+			return true
+		}
+		fmt.Printf("%s\n%s\n", a.uiManager.Paint(ui.StyleInfo, "Artifacts:"), list)
+		return true
+	}
+
+	if input == "/env" {
+		fmt.Printf("%s\n%s\n", a.uiManager.Paint(ui.StyleInfo, "Environment:"), a.formatEnv())
+		return true
+	}
+
+	if input == "/stats" {
+		report := formatToolStats(a.ToolStats())
+		if report == "" {
+			fmt.Printf("%s: No tools have been used yet\n\n", a.uiManager.Paint(ui.StyleInfo, "Stats"))
+			return true
+		}
+		fmt.Printf("%s\n%s\n", a.uiManager.Paint(ui.StyleInfo, "Tool usage this session:"), report)
+		return true
+	}
+
+	if strings.HasPrefix(input, "/pins") {
+		if len(a.pins) == 0 && len(a.pinnedMessages) == 0 {
+			fmt.Printf("%s: Nothing pinned\n\n", a.uiManager.Paint(ui.StyleInfo, "Pins"))
+			return true
+		}
+		for _, pin := range a.pins {
+			status := ""
+			if pin.pendingUnpin {
+				status = ", unpinning before the next message"
+			}
+			fmt.Printf("  %s (~%s tokens, last used %d turn(s) ago%s)\n", pin.path, ui.FormatCount(a.pinTokens(pin)), a.turn-pin.lastUsed, status)
+		}
+		for _, pin := range a.pinnedMessages {
+			fmt.Printf("  %s: %s (~%s tokens) %s\n", pin.id, pin.source, ui.FormatCount(pinnedMessageTokens(pin)), pinPreview(pin.text))
+		}
+		fmt.Println()
+		return true
+	}
+
+	if input == "/pin last" {
+		if a.lastResponse == "" {
+			fmt.Printf("%s: There is no response to pin yet\n\n", a.uiManager.Paint(ui.StyleError, "Error"))
+			return true
+		}
+		id := a.PinMessage("your earlier reply", a.lastResponse)
+		fmt.Printf("%s the last response as %s\n\n", a.uiManager.Paint(ui.StyleSuccess, "Pinned:"), id)
+		return true
+	}
+
+	if strings.HasPrefix(input, "/pin note ") {
+		note := strings.TrimSpace(strings.TrimPrefix(input, "/pin note"))
+		id := a.PinMessage("user note", note)
+		fmt.Printf("%s note as %s\n\n", a.uiManager.Paint(ui.StyleSuccess, "Pinned:"), id)
+		return true
+	}
+
+	if strings.HasPrefix(input, "/pin ") {
+		path := strings.TrimSpace(strings.TrimPrefix(input, "/pin"))
+		if err := a.Pin(path); err != nil {
+			fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+			return true
+		}
+		fmt.Printf("%s %s\n\n", a.uiManager.Paint(ui.StyleSuccess, "Pinned:"), path)
+		return true
+	}
+
+	if strings.HasPrefix(input, "/unpin ") {
+		target := strings.TrimSpace(strings.TrimPrefix(input, "/unpin"))
+		if !a.UnpinMessage(target) && !a.Unpin(target) {
+			fmt.Printf("%s: %s is not pinned\n\n", a.uiManager.Paint(ui.StyleError, "Error"), target)
+			return true
+		}
+		fmt.Printf("%s %s\n\n", a.uiManager.Paint(ui.StyleSuccess, "Unpinned:"), target)
+		return true
+	}
+
+	if strings.HasPrefix(input, "/keep ") {
+		path := strings.TrimSpace(strings.TrimPrefix(input, "/keep"))
+		if !a.keepPin(path) {
+			fmt.Printf("%s: %s is not pinned\n\n", a.uiManager.Paint(ui.StyleError, "Error"), path)
+			return true
+		}
+		fmt.Printf("%s %s stays pinned\n\n", a.uiManager.Paint(ui.StyleSuccess, "Kept:"), path)
+		return true
+	}
+
+	if input == "/profile" || strings.HasPrefix(input, "/profile ") {
+		*conversationPtr = a.profileCommand(ctx, strings.TrimSpace(strings.TrimPrefix(input, "/profile")), conversation)
+		return true
+	}
+
+	if input == "/memories" || strings.HasPrefix(input, "/memories ") {
+		a.memoriesCommand(strings.TrimSpace(strings.TrimPrefix(input, "/memories")))
+		return true
+	}
+
+	if input == "/allowed" || strings.HasPrefix(input, "/allowed ") {
+		a.allowedCommand(strings.TrimSpace(strings.TrimPrefix(input, "/allowed")))
+		return true
+	}
+
+	if input == "/todos" || strings.HasPrefix(input, "/todos ") {
+		a.todosCommand(ctx, strings.TrimSpace(strings.TrimPrefix(input, "/todos")), conversation)
+		return true
+	}
+
+	if input == "/system" || strings.HasPrefix(input, "/system ") {
+		a.systemCommand(ctx, strings.TrimSpace(strings.TrimPrefix(input, "/system")), conversation)
+		return true
+	}
+
+	if input == "/summary" {
+		a.showSessionSummary()
+		return true
+	}
+
+	if input == "/clear" {
+		a.clearConversation(conversation)
+		*conversationPtr = []anthropic.MessageParam{}
+		return true
+	}
+
+	if input == "/new" {
+		a.newConversation(ctx, conversation)
+		*conversationPtr = []anthropic.MessageParam{}
+		return true
+	}
+
+	if strings.HasPrefix(input, "/sessions") {
+		query := strings.TrimSpace(strings.TrimPrefix(input, "/sessions"))
+		matches, err := a.sessionStore.Search(query, session.StateActive, session.StateArchived)
+		if err != nil {
+			fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+			return true
+		}
+		if len(matches) == 0 {
+			fmt.Printf("%s: No sessions match %q\n\n", a.uiManager.Paint(ui.StyleInfo, "Sessions"), query)
+			return true
+		}
+		for i, entry := range matches {
+			if i == config.SessionSearchResults {
+				fmt.Printf("  ... %d more\n", len(matches)-i)
+				break
+			}
+			fmt.Println(entry)
+		}
+		fmt.Println()
+		return true
+	}
+
+	if strings.HasPrefix(input, "/refactor") {
+		goal := strings.TrimSpace(strings.TrimPrefix(input, "/refactor"))
+		if a.config.Security.ReadOnly {
+			fmt.Printf("%s: /refactor changes files, which is %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), approval.ErrReadOnly)
+			return true
+		}
+		if goal == "" {
+			fmt.Printf("%s: usage: /refactor <goal>\n\n", a.uiManager.Paint(ui.StyleError, "Error"))
+			return true
+		}
+		updated, err := a.runRefactor(ctx, goal, conversation)
+		if err != nil {
+			fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+		}
+		*conversationPtr = updated
+		a.saveSession(ctx, updated)
+		return true
+	}
+
+	if strings.HasPrefix(input, "/model") {
+		name := strings.TrimSpace(strings.TrimPrefix(input, "/model"))
+		if name == "" {
+			current := a.config.Model()
+			fmt.Printf("%s: %s (%d token context, tools %s)\n", a.uiManager.Paint(ui.StyleInfo, "Model"), current.ID, current.ContextWindow, map[bool]string{true: "supported", false: "unsupported"}[current.SupportsTools])
+			fmt.Println("Known models:")
+			for _, model := range config.Models {
+				fmt.Printf("  %s\n", model.ID)
+			}
+			fmt.Println()
+			return true
+		}
+
+		handedOff, err := a.switchModel(ctx, name, conversation)
+		if err != nil {
+			fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+			return true
+		}
+		*conversationPtr = handedOff
+		fmt.Printf("%s %s\n\n", a.uiManager.Paint(ui.StyleSuccess, "Model switched to:"), a.config.Model().ID)
+		return true
+	}
+	if strings.HasPrefix(input, "/cd") {
+		fmt.Print("Enter new directory path: ")
+		if input, ok := a.getUserMessage(); ok {
+			newDir := strings.TrimSpace(input)
+			if newDir != "" {
+				// Expand ~ to home directory
+				if strings.HasPrefix(newDir, "~/") {
+					home, err := os.UserHomeDir()
+					if err != nil {
+						fmt.Printf("%s: Failed to get home directory: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+						return true
+					}
+					newDir = filepath.Join(home, newDir[2:])
+				}
+
+				// Clean and validate the path
+				newDir = filepath.Clean(newDir)
+				if err := ValidateDirectory(newDir); err != nil {
+					fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+				} else {
+					a.workingDir = newDir
+					a.renderSystemPrompt()
+					if err := a.loadProject(); err != nil {
+						fmt.Printf("%s: %v; gated actions will ask until it is fixed\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+					}
+					if a.shell != nil {
+						a.shell.Reset(newDir)
+					}
+					a.startWatcher()
+					a.loadGitContext()
+					a.restartSessionSnapshot()
+					fmt.Printf("%s %s\n\n", a.uiManager.Paint(ui.StyleSuccess, "Working directory changed to:"), newDir)
+					if reason := BroadDirectory(newDir); reason != "" {
+						fmt.Printf("%s: %s is %s, so every file under it is within reach of the tools\n\n", a.uiManager.Paint(ui.StyleWarning, "⚠️  Warning"), newDir, reason)
+					}
+					if abs, err := filepath.Abs(newDir); err == nil {
+						if err := session.AddRecentDir(a.config.Session.RecentDirs, abs); err != nil {
+							log.Printf("Warning: failed to record recent directory: %v", err)
+						}
+					}
+				}
+			}
+		}
+		return true
+	}
+
+	if strings.HasPrefix(input, "/tokens") {
+		if len(conversation) == 0 {
+			fmt.Printf("%s: No conversation yet (0 tokens)\n\n", a.uiManager.Paint(ui.StyleInfo, "Token Info"))
+		} else {
+			// The local estimate is used unless the limit is close or "/tokens exact" asks the API
+			tokenCount, source := a.estimateConversationTokens(conversation), "estimated locally"
+			var err error
+			if strings.TrimSpace(strings.TrimPrefix(input, "/tokens")) == "exact" || !a.estimateSuffices(tokenCount) {
+				source = "counted by the API"
+				tokenCount, err = a.countConversationTokensAccurate(ctx, conversation)
+			}
+			if err != nil {
+				fmt.Printf("%s: Failed to count tokens: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+			} else {
+				percentage := float64(tokenCount) / float64(a.config.MaxInputTokens()) * 100
+				fmt.Printf("%s: Current conversation has %s tokens, %s (%s of %s input limit)\n", a.uiManager.Paint(ui.StyleInfo, "Token Info"), ui.FormatCount(tokenCount), source, ui.FormatPercent(percentage), ui.FormatCount(a.config.MaxInputTokens()))
+				fmt.Printf("%s: Max output tokens per response: %s\n", a.uiManager.Paint(ui.StyleInfo, "Token Info"), ui.FormatCount(a.config.MaxTokens()))
+				fmt.Printf("%s: %d messages in conversation\n\n", a.uiManager.Paint(ui.StyleInfo, "Token Info"), len(conversation))
+
+				// Show warning if approaching threshold
+				if tokenCount >= a.config.WarningThreshold() {
+					fmt.Printf("%s: Approaching input token limit (%s/%s tokens)\n", a.uiManager.Paint(ui.StyleWarning, "⚠️  Warning"), ui.FormatCount(tokenCount), ui.FormatCount(a.config.MaxInputTokens()))
+					fmt.Printf("%s: Conversation will be summarized soon to manage length\n\n", a.uiManager.Paint(ui.StyleWarning, "⚠️  Warning"))
+				}
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// switchModel changes the active model and rebuilds the conversation so its first request succeeds:
+// tool blocks are translated for models without tool support and history is compacted to fit
+func (a *Agent) switchModel(ctx context.Context, name string, conversation []anthropic.MessageParam) ([]anthropic.MessageParam, error) {
+	model, known := config.LookupModel(name)
+	if !known {
+		fmt.Printf("%s: %s is not in the model catalog; assuming a %s token context window\n", a.uiManager.Paint(ui.StyleWarning, "Warning"), name, ui.FormatCount(model.ContextWindow))
+	}
+
+	previous := a.config.Model()
+	a.config.SetModel(model)
+
+	if !model.SupportsTools && compaction.HasToolBlocks(conversation) {
+		conversation = compaction.FlattenToolHistory(conversation)
+		a.events.OnNotice("Model Handoff", fmt.Sprintf("Converted tool calls in history to text for %s", model.ID))
+	}
+
+	// Compact until the history fits the new context window, falling back to dropping messages
+	for attempt := 0; attempt < 3 && len(conversation) > 0; attempt++ {
+		tokens, err := a.countConversationTokens(ctx, conversation)
+		if err != nil || tokens < a.config.MaxInputTokens() {
+			break
+		}
+		a.events.OnNotice("Model Handoff", fmt.Sprintf("%s tokens exceeds the %s token limit of %s, compacting...", ui.FormatCount(tokens), ui.FormatCount(a.config.MaxInputTokens()), model.ID))
+
+		var strategy compaction.Strategy = &compaction.SlidingWindow{}
+		if attempt == 0 {
+			strategy = a.compaction
+		}
+		compacted, err := strategy.Compact(ctx, conversation, compaction.Options{
+			KeepRecent:   a.config.RecentMessagesKeep(),
+			TargetTokens: a.config.MaxInputTokens() * 3 / 4,
+			Summarize:    a.summarizeConversation,
+			CountTokens:  a.countConversationTokens,
+		})
+		if err != nil {
+			log.Printf("Warning: %v", err)
+		}
+		if len(compacted) == len(conversation) {
+			a.config.SetModel(previous)
+			return nil, fmt.Errorf("conversation cannot be reduced to fit %s; staying on %s", model.ID, previous.ID)
+		}
+		conversation = compacted
+		a.forgetReads()
+	}
+
+	return conversation, nil
+}
+
+// saveOutputCommand handles /save-output <path> [block|last]
+func (a *Agent) saveOutputCommand(args []string, conversation []anthropic.MessageParam) {
+	if len(args) == 0 || len(args) > 2 {
+		fmt.Printf("Usage: /save-output <path> [block number|last]\n\n")
+		return
+	}
+	block := 0
+	if len(args) == 2 {
+		if args[1] == "last" {
+			block = -1
+		} else if n, err := strconv.Atoi(args[1]); err == nil && n > 0 {
+			block = n
+		} else {
+			fmt.Printf("%s: block must be a positive number or 'last'\n\n", a.uiManager.Paint(ui.StyleError, "Error"))
+			return
+		}
+	}
+
+	overwrite := false
+	if target, err := a.ResolveFilePath(args[0]); err == nil {
+		if info, err := os.Stat(target); err == nil && !info.IsDir() {
+			if !a.confirm(fmt.Sprintf("%s exists. Overwrite? [y/N] ", args[0]), false) {
+				fmt.Println()
+				return
+			}
+			overwrite = true
+		}
+	}
+
+	result, err := file.SaveOutput(a, "save_output", latestResponseText(conversation), args[0], block, overwrite)
+	if err != nil {
+		fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+		return
+	}
+	fmt.Printf("%s\n\n", a.uiManager.Paint(ui.StyleSuccess, result))
+}
+
+// latestResponseText returns the text of the most recent assistant message that has any
+func latestResponseText(conversation []anthropic.MessageParam) string {
+	for i := len(conversation) - 1; i >= 0; i-- {
+		if conversation[i].Role != anthropic.MessageParamRoleAssistant {
+			continue
+		}
+		var text strings.Builder
+		for _, block := range conversation[i].Content {
+			if block.OfText != nil {
+				text.WriteString(block.OfText.Text)
+			}
+		}
+		if strings.TrimSpace(text.String()) != "" {
+			return text.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,248 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"anthropic-chat/compaction"
+	"anthropic-chat/config"
+	"anthropic-chat/tokenizer"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// estimateConversationTokens provides a client-side approximation of token count
+func (a *Agent) estimateConversationTokens(conversation []anthropic.MessageParam) int {
+	if len(conversation) == 0 {
+		return 0
+	}
+
+	return estimateTokens(a.contextOverheadTokens(), conversation)
+}
+
+// contextOverheadTokens is the size of everything sent with each request besides the messages
+func (a *Agent) contextOverheadTokens() int {
+	system := a.systemText()
+	if !a.toolsSupported() {
+		return tokenizer.Count(system)
+	}
+	var toolParams []anthropic.ToolParam
+	for _, tool := range a.toolRegistry.All() {
+		if a.profileAllows(tool.Name) {
+			toolParams = append(toolParams, anthropic.ToolParam{Name: tool.Name, Description: anthropic.String(tool.Description), InputSchema: tool.InputSchema})
+		}
+	}
+	return tokenizer.Count(system) + tokenizer.Tools(toolParams)
+}
+
+// estimateTokens approximates the tokens of a request with overheadTokens of context around conversation,
+// using the local tokenizer so no request is needed
+func estimateTokens(overheadTokens int, conversation []anthropic.MessageParam) int {
+	return overheadTokens + tokenizer.Messages(conversation)
+}
+
+// countConversationTokensAccurate gets precise token count via API (used sparingly)
+func (a *Agent) countConversationTokensAccurate(ctx context.Context, conversation []anthropic.MessageParam) (int, error) {
+	if len(conversation) == 0 {
+		return 0, nil
+	}
+
+	// Convert tools to the format needed for token counting
+	var toolParams []anthropic.MessageCountTokensToolUnionParam
+	for _, tool := range a.toolRegistry.All() {
+		if !a.profileAllows(tool.Name) {
+			continue
+		}
+		toolParams = append(toolParams, anthropic.MessageCountTokensToolUnionParam{
+			OfTool: &anthropic.ToolParam{
+				Name:        tool.Name,
+				Description: anthropic.String(tool.Description),
+				InputSchema: tool.InputSchema,
+			},
+		})
+	}
+
+	if !a.toolsSupported() {
+		toolParams = nil
+	}
+
+	// Count tokens for the conversation
+	tokenCount, err := a.provider.CountTokens(ctx, anthropic.MessageCountTokensParams{
+		Model:    anthropic.Model(a.config.Model().ID),
+		System:   anthropic.MessageCountTokensParamsSystemUnion{OfTextBlockArray: []anthropic.TextBlockParam{{Text: a.systemText()}}},
+		Messages: conversation,
+		Tools:    toolParams,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count tokens: %w", err)
+	}
+
+	return tokenCount, nil
+}
+
+// countConversationTokens provides intelligent token counting - uses the local estimate unless the
+// conversation is within the tokenizer's margin of error of the limit, then asks the API
+func (a *Agent) countConversationTokens(ctx context.Context, conversation []anthropic.MessageParam) (int, error) {
+	// Use fast estimation first
+	estimated := a.estimateConversationTokens(conversation)
+
+	if a.estimateSuffices(estimated) {
+		return estimated, nil
+	}
+
+	// On the verge of the limit, confirm with the API
+	return a.countConversationTokensAccurate(ctx, conversation)
+}
+
+// estimateSuffices reports whether a local estimate is close enough: it is unless the conversation is
+// about to hit the limit, where the tokenizer's margin of error matters
+func (a *Agent) estimateSuffices(estimated int) bool {
+	return estimated < a.config.MaxInputTokens()*config.ExactTokenCountPercent/100
+}
+
+// summarizeConversation creates a summary of older messages in the conversation
+func (a *Agent) summarizeConversation(ctx context.Context, messagesToSummarize []anthropic.MessageParam) (*anthropic.MessageParam, error) {
+	return a.summarizeWith(a.config.Model().ID)(ctx, messagesToSummarize)
+}
+
+// summarizeWith returns a summarizer bound to model, safe to use off the main goroutine
+func (a *Agent) summarizeWith(model string) func(context.Context, []anthropic.MessageParam) (*anthropic.MessageParam, error) {
+	return func(ctx context.Context, messagesToSummarize []anthropic.MessageParam) (*anthropic.MessageParam, error) {
+		return a.summarizeMessages(ctx, model, messagesToSummarize)
+	}
+}
+
+func (a *Agent) summarizeMessages(ctx context.Context, model string, messagesToSummarize []anthropic.MessageParam) (*anthropic.MessageParam, error) {
+	if len(messagesToSummarize) == 0 {
+		return nil, fmt.Errorf("no messages to summarize")
+	}
+
+	// Create a prompt to summarize the conversation
+	summaryPrompt := "Please provide a concise summary of this conversation, preserving key context, decisions made, and important information that might be relevant for future interactions. Focus on factual content and avoid redundant details."
+
+	// Add the messages to summarize as context
+	summaryMessages := []anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock(summaryPrompt)),
+	}
+	summaryMessages = append(summaryMessages, messagesToSummarize...)
+	summaryMessages = append(summaryMessages, anthropic.NewUserMessage(anthropic.NewTextBlock("Now provide the summary:")))
+
+	// Get the summary from Claude
+	message, err := a.provider.NewMessage(ctx, anthropic.MessageNewParams{
+		Model:     anthropic.Model(model),
+		MaxTokens: int64(config.SummaryTokenTarget),
+		Messages:  summaryMessages,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate summary: %w", err)
+	}
+
+	// Extract the text content from the response
+	var summaryText strings.Builder
+	for _, content := range message.Content {
+		if textBlock, ok := content.AsAny().(anthropic.TextBlock); ok {
+			summaryText.WriteString(textBlock.Text)
+		}
+	}
+
+	// Create a system-like message with the summary
+	summaryMessage := anthropic.NewUserMessage(
+		anthropic.NewTextBlock(fmt.Sprintf("[CONVERSATION SUMMARY] %s", summaryText.String())),
+	)
+
+	return &summaryMessage, nil
+}
+
+// manageConversationLength ensures the conversation stays within token limits
+func (a *Agent) manageConversationLength(ctx context.Context, conversation []anthropic.MessageParam) ([]anthropic.MessageParam, error) {
+	conversation = a.applyBackgroundCompaction(conversation, false)
+	if a.config.Agent.DedupeToolResults {
+		if deduped, saved := compaction.DedupeToolResults(conversation, config.DedupeMinChars); saved > 0 {
+			conversation = deduped
+			a.events.OnNotice("Token Management", fmt.Sprintf("Replaced repeated tool results with references to the earlier ones, saving ~%s tokens", ui.FormatCount(saved)))
+		}
+	}
+
+	tokenCount, err := a.countConversationTokens(ctx, conversation)
+	if err != nil {
+		// If we can't count tokens, fall back to message count limit
+		log.Printf("Warning: couldn't count tokens, falling back to message limit: %v", err)
+		if len(conversation) > a.config.RecentMessagesKeep()*2 { // *2 because we might have tool use messages
+			return conversation[compaction.SplitPoint(conversation, a.config.RecentMessagesKeep()):], nil
+		}
+		return conversation, nil
+	}
+
+	// If we're under the limit, no need to manage, though compaction may start early so nobody waits on it later
+	if tokenCount < a.config.MaxInputTokens() {
+		a.maybeCompactInBackground(ctx, conversation, tokenCount)
+		return conversation, nil
+	}
+
+	// A compaction already under way is most of the way there; waiting beats starting over
+	if a.compacting != nil {
+		a.events.OnNotice("Token Management", "Waiting for the background compaction to finish...")
+		conversation = a.applyBackgroundCompaction(conversation, true)
+		if tokenCount, err = a.countConversationTokens(ctx, conversation); err == nil && tokenCount < a.config.MaxInputTokens() {
+			return conversation, nil
+		}
+	}
+
+	a.events.OnNotice("Token Management", fmt.Sprintf("Conversation has %s tokens, compacting with %s...", ui.FormatCount(tokenCount), a.compaction.Name()))
+
+	// Keep the most recent messages
+	if len(conversation) <= a.config.RecentMessagesKeep() {
+		// If we have very few messages but still over limit, something's wrong
+		return conversation, nil
+	}
+
+	managedConversation, err := a.compaction.Compact(ctx, conversation, compaction.Options{
+		KeepRecent:   a.config.RecentMessagesKeep(),
+		TargetTokens: a.config.MaxInputTokens() * 3 / 4,
+		Summarize:    a.summarizeConversation,
+		CountTokens:  a.countConversationTokens,
+	})
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	a.forgetReads()
+	a.recallMemories(managedConversation)
+
+	// Verify we're now under the limit
+	newTokenCount, err := a.countConversationTokens(ctx, managedConversation)
+	if err == nil {
+		a.events.OnNotice("Token Management", fmt.Sprintf("Reduced from %s to %s tokens.", ui.FormatCount(tokenCount), ui.FormatCount(newTokenCount)))
+	}
+
+	return managedConversation, nil
+}
+
+// emergencyCompact shrinks a conversation the API rejected as too long, keeping the current turn's
+// turnMessages messages. It aims well below the limit since the estimate already proved too low.
+func (a *Agent) emergencyCompact(ctx context.Context, conversation []anthropic.MessageParam, turnMessages int) []anthropic.MessageParam {
+	before := a.estimateConversationTokens(conversation)
+	a.events.OnNotice("Token Management", "The request exceeded the model's context window, compacting aggressively and retrying...")
+
+	compacted, err := compaction.Emergency(ctx, conversation, compaction.Options{
+		KeepRecent:   turnMessages,
+		TargetTokens: a.config.MaxInputTokens() / 2,
+		Summarize:    a.summarizeConversation,
+		CountTokens: func(ctx context.Context, conversation []anthropic.MessageParam) (int, error) {
+			if tokens, err := a.countConversationTokensAccurate(ctx, conversation); err == nil {
+				return tokens, nil
+			}
+			return a.estimateConversationTokens(conversation), nil
+		},
+	})
+	if err != nil {
+		log.Printf("Warning: %v", err)
+	}
+	a.forgetReads()
+	a.recallMemories(compacted)
+
+	a.events.OnNotice("Token Management", fmt.Sprintf("Reduced from ~%s to ~%s tokens.", ui.FormatCount(before), ui.FormatCount(a.estimateConversationTokens(compacted))))
+	return compacted
+}
@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+
+	"anthropic-chat/config"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// ErrRequestDeclined is returned by RunTurn when the user declines a request above the cost threshold
+var ErrRequestDeclined = errors.New("request declined: estimated cost is above the confirmation threshold")
+
+// checkRequestCost estimates the next request's size and price, showing a preview when announce is set
+// and asking for confirmation when the input alone costs more than the configured threshold
+func (a *Agent) checkRequestCost(conversation []anthropic.MessageParam, announce bool) error {
+	model := a.config.Model()
+	inputTokens := a.estimateConversationTokens(conversation)
+	inputCost := float64(inputTokens) * model.InputPrice / 1e6
+	outputCost := float64(a.maxOutputTokens()) * model.OutputPrice / 1e6
+
+	threshold := a.config.Agent.CostConfirmThreshold
+	expensive := threshold > 0 && inputCost >= threshold
+	if !announce && !expensive {
+		return nil
+	}
+
+	preview := fmt.Sprintf("~%s input tokens", ui.FormatCount(inputTokens))
+	if model.InputPrice > 0 {
+		preview += fmt.Sprintf(" ≈ $%s, plus up to $%s if all %s output tokens are used", ui.FormatDecimal(inputCost, 3), ui.FormatDecimal(outputCost, 3), ui.FormatCount(a.maxOutputTokens()))
+	}
+	if !expensive {
+		if a.config.Agent.ShowCostPreview {
+			a.events.OnNotice("Cost", preview)
+		}
+		return nil
+	}
+
+	fmt.Printf("%s: %s\n", a.uiManager.Paint(ui.StyleWarning, "[Cost]"), preview)
+	if !a.confirm(fmt.Sprintf("This request exceeds the $%s confirmation threshold. Send it? [y/N] ", ui.FormatDecimal(threshold, 2)), false) {
+		return ErrRequestDeclined
+	}
+	return nil
+}
+
+// turnSpend adds up the tokens and estimated cost of the responses in one turn
+type turnSpend struct {
+	tokens     int
+	cost       float64
+	nextTokens int // Totals at which the user is next asked to continue
+	nextCost   float64
+	started    bool // The thresholds have been set from the budgets
+}
+
+// add counts a response's usage, priced for model
+func (s *turnSpend) add(model config.ModelInfo, usage anthropic.Usage) {
+	s.tokens += int(usage.InputTokens + usage.CacheCreationInputTokens + usage.CacheReadInputTokens + usage.OutputTokens)
+	s.cost += (float64(usage.InputTokens) + float64(usage.CacheCreationInputTokens)*config.CacheWritePriceFactor +
+		float64(usage.CacheReadInputTokens)*config.CacheReadPriceFactor) * model.InputPrice / 1e6
+	s.cost += float64(usage.OutputTokens) * model.OutputPrice / 1e6
+}
+
+// overBudget explains how the turn went past its token or cost budget, or returns "" while it is within
+// them. Each time the user lets it continue, the budget it passed is allowed once more.
+func (a *Agent) overBudget(spend *turnSpend) string {
+	tokenBudget, costBudget := a.config.Agent.TurnTokenBudget, a.config.Agent.TurnCostBudget
+	if !spend.started {
+		spend.nextTokens, spend.nextCost, spend.started = tokenBudget, costBudget, true
+	}
+	used := fmt.Sprintf("%s tokens", ui.FormatCount(spend.tokens))
+	if a.config.Model().InputPrice > 0 {
+		used += fmt.Sprintf(" (≈ $%s)", ui.FormatDecimal(spend.cost, 2))
+	}
+	switch {
+	case tokenBudget > 0 && spend.tokens > spend.nextTokens:
+		for spend.tokens > spend.nextTokens {
+			spend.nextTokens += tokenBudget
+		}
+		return fmt.Sprintf("This turn has used %s, over its budget of %s tokens.", used, ui.FormatCount(spend.nextTokens-tokenBudget))
+	case costBudget > 0 && spend.cost > spend.nextCost:
+		for spend.cost > spend.nextCost {
+			spend.nextCost += costBudget
+		}
+		return fmt.Sprintf("This turn has used %s, over its budget of $%s.", used, ui.FormatDecimal(spend.nextCost-costBudget, 2))
+	}
+	return ""
+}
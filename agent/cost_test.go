@@ -0,0 +1,35 @@
+package agent
+
+import (
+	"testing"
+
+	"anthropic-chat/config"
+)
+
+// TestOverBudgetCostOnly checks that with only a cost budget, a turn the user let continue isn't stopped
+// again until it passes the next multiple of the budget
+func TestOverBudgetCostOnly(t *testing.T) {
+	cfg := config.NewConfig()
+	cfg.Agent.TurnTokenBudget = 0
+	cfg.Agent.TurnCostBudget = 1
+	a := &Agent{config: cfg}
+
+	spend := &turnSpend{}
+	steps := []struct {
+		cost float64
+		over bool
+	}{
+		{0.5, false},
+		{1.5, true},
+		{1.6, false},
+		{1.9, false},
+		{2.1, true},
+		{2.5, false},
+	}
+	for _, step := range steps {
+		spend.cost = step.cost
+		if got := a.overBudget(spend) != ""; got != step.over {
+			t.Errorf("at $%.2f, over budget is %v, want %v", step.cost, got, step.over)
+		}
+	}
+}
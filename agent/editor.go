@@ -0,0 +1,51 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// editInEditor opens content in $VISUAL or $EDITOR as a temporary file named like path, so the editor
+// picks the right mode, and returns the text saved when it exits
+func editInEditor(content, path string) (string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = "vi"
+		if runtime.GOOS == "windows" {
+			editor = "notepad"
+		}
+	}
+
+	tmp, err := os.CreateTemp("", "goocode-*-"+filepath.Base(path))
+	if err != nil {
+		return "", fmt.Errorf("failed to create a file to edit: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+
+	// The variable may carry arguments, as in EDITOR="code --wait"
+	args := strings.Fields(editor)
+	cmd := exec.Command(args[0], append(args[1:], tmp.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s failed: %w", editor, err)
+	}
+	edited, err := os.ReadFile(tmp.Name())
+	if err != nil {
+		return "", fmt.Errorf("failed to read back %s: %w", tmp.Name(), err)
+	}
+	return string(edited), nil
+}
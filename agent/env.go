@@ -0,0 +1,77 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"anthropic-chat/config"
+	"anthropic-chat/redact"
+)
+
+// envPrefixes selects the variables /env shows
+var envPrefixes = []string{"GOOCODE_", "ANTHROPIC_", "OTEL_", "VOYAGE_", "OPENAI_", "OLLAMA_"}
+
+// formatEnv lists the .env files that apply to the working directory and the effective settings with their sources
+func (a *Agent) formatEnv() string {
+	var b strings.Builder
+	b.WriteString("Settings file, overridden by the .env files:\n")
+	b.WriteString(a.envFileLine(config.SettingsFile()))
+	b.WriteString(".env files, later ones override earlier ones:\n")
+	for _, file := range config.EnvFiles(a.workingDir) {
+		b.WriteString(a.envFileLine(file))
+	}
+
+	var names []string
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		for _, prefix := range envPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	sort.Strings(names)
+
+	b.WriteString("\nSettings:\n")
+	if len(names) == 0 {
+		b.WriteString("  (none set)\n")
+	}
+	for _, name := range names {
+		value := os.Getenv(name)
+		if redact.IsSensitiveName(name) {
+			value = maskSecret(value)
+		}
+		source := "environment"
+		if file := config.EnvSource(name); file != "" {
+			source = a.displayPath(file)
+		}
+		fmt.Fprintf(&b, "  %s=%s  [%s]\n", name, value, source)
+	}
+	return b.String()
+}
+
+// envFileLine shows a configuration file and whether it was loaded
+func (a *Agent) envFileLine(file string) string {
+	status := "not found"
+	if _, err := os.Stat(file); err == nil {
+		status = "found, not loaded; .env files are read at startup"
+		if loaded, ignored := config.EnvFileStatus(file); loaded || file == config.SettingsFile() {
+			status = "loaded"
+			if len(ignored) > 0 {
+				status += "; ignored " + strings.Join(ignored, ", ")
+			}
+		}
+	}
+	return fmt.Sprintf("  %s (%s)\n", a.displayPath(file), status)
+}
+
+// maskSecret keeps just enough of a secret to tell which one is set
+func maskSecret(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + "…" + value[len(value)-2:]
+}
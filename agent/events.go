@@ -0,0 +1,316 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"anthropic-chat/config"
+	"anthropic-chat/tokenizer"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// EventHandler receives what the agent is doing so embedding programs can render or record it.
+// Callbacks run synchronously on the goroutine that called RunTurn.
+type EventHandler interface {
+	OnInferenceStart()                             // A model request is about to be sent
+	OnText(delta string)                           // Streamed assistant text
+	OnToolCall(name string, input json.RawMessage) // The model requested a tool
+	OnInferenceEnd()                               // The model response has finished (or failed)
+	OnToolResult(name string, result string)       // A tool finished; result is what the model will see
+	OnToolProgress(name string, message string)    // A long-running tool reported progress
+	OnCommandStart(name string, command string)    // A tool started running a shell command
+	OnCommandOutput(name string, line string)      // The running command printed a line
+	OnCommandEnd(name string)                      // The command finished, before its result is reported
+	OnUsage(usage anthropic.Usage)                 // Token usage of the model response that just finished
+	OnNotice(label string, message string)         // Housekeeping such as compaction or redaction
+	OnError(err error)                             // A turn failed; RunTurn returns the same error
+}
+
+// StreamObserver is an optional EventHandler extension that also receives every raw API stream event
+type StreamObserver interface {
+	OnStreamEvent(event anthropic.MessageStreamEventUnion)
+}
+
+// DetailObserver is an optional EventHandler extension for the finer-grained stream events
+type DetailObserver interface {
+	OnToolStart(id string, name string)         // The model began a tool call; its input follows as deltas
+	OnToolInputDelta(id string, partial string) // A fragment of the tool call's JSON input
+	OnMessage(message *anthropic.Message)       // A model response streamed in full, before its tools run
+}
+
+// PhaseObserver is an optional EventHandler extension told what the agent waits on between the other
+// events, for live status displays
+type PhaseObserver interface {
+	OnToolRun(name string) // A tool is about to run; OnToolResult follows when it is done
+	OnRetry()              // A failed request is about to be retried, after a pause
+}
+
+// NopEvents ignores every event; embed it to implement only the callbacks you need
+type NopEvents struct{}
+
+func (NopEvents) OnInferenceStart()                  {}
+func (NopEvents) OnText(string)                      {}
+func (NopEvents) OnToolCall(string, json.RawMessage) {}
+func (NopEvents) OnInferenceEnd()                    {}
+func (NopEvents) OnToolResult(string, string)        {}
+func (NopEvents) OnToolProgress(string, string)      {}
+func (NopEvents) OnCommandStart(string, string)      {}
+func (NopEvents) OnCommandOutput(string, string)     {}
+func (NopEvents) OnCommandEnd(string)                {}
+func (NopEvents) OnUsage(anthropic.Usage)            {}
+func (NopEvents) OnNotice(string, string)            {}
+func (NopEvents) OnError(error)                      {}
+
+// consoleEvents renders agent activity to the terminal
+type consoleEvents struct {
+	ui          *ui.Manager
+	readLine    func() (string, bool)
+	spinner     *ui.Spinner
+	output      *ui.ResponseWriter
+	command     *ui.CommandStatus
+	streamed    bool // The next tool result's output was already shown live
+	textStarted bool
+	midLine     bool // Response text was printed since the last line break
+	deltas      int  // Stream deltas since the last event shown at the verbose level
+}
+
+// NewConsoleEvents creates the terminal event handler used by the interactive CLI.
+// readLine supplies keystrokes for paging long responses.
+func NewConsoleEvents(manager *ui.Manager, readLine func() (string, bool)) EventHandler {
+	return &consoleEvents{ui: manager, readLine: readLine}
+}
+
+func (c *consoleEvents) OnInferenceStart() {
+	c.spinner = c.ui.NewSpinner()
+	c.spinner.Start(ui.PhaseThinking)
+	c.output = c.ui.NewResponseWriter()
+	c.textStarted = false
+}
+
+func (c *consoleEvents) OnText(delta string) {
+	c.stopSpinner()
+	if !c.textStarted {
+		fmt.Print(c.ui.Paint(ui.StyleAssistant, "Claude") + ": ")
+		c.textStarted = true
+	}
+	c.output.Write(delta)
+	c.midLine = true
+}
+
+// OnToolStart brings the spinner back while a tool call's input streams in after response text
+func (c *consoleEvents) OnToolStart(id string, name string) {
+	c.breakLine()
+	c.textStarted = false
+	if c.spinner != nil {
+		c.spinner.Start("preparing " + name)
+	}
+}
+
+func (c *consoleEvents) OnToolInputDelta(string, string) {}
+
+func (c *consoleEvents) OnMessage(*anthropic.Message) {}
+
+func (c *consoleEvents) OnToolCall(name string, input json.RawMessage) {
+	c.stopSpinner()
+	c.breakLine()
+	c.textStarted = false
+	if c.ui.Verbosity() == config.UIQuiet {
+		return
+	}
+	fmt.Printf("%s: %s\n", c.ui.Paint(ui.StyleTool, "[Tool: "+name+"]"), string(input))
+}
+
+func (c *consoleEvents) OnInferenceEnd() {
+	c.stopSpinner()
+	c.breakLine()
+	c.textStarted = false
+	if c.output != nil {
+		c.output.Finish(c.readLine)
+		c.output = nil
+	}
+}
+
+// OnToolRun shows the spinner while the tool runs; commands replace it with their own status line
+func (c *consoleEvents) OnToolRun(name string) {
+	c.spinner = c.ui.NewSpinner()
+	c.spinner.Start("running " + name)
+}
+
+// OnRetry shows the spinner during the pause before a request is sent again
+func (c *consoleEvents) OnRetry() {
+	if c.spinner != nil {
+		c.spinner.Start(ui.PhaseRetrying)
+	}
+}
+
+func (c *consoleEvents) OnToolResult(name string, result string) {
+	c.stopSpinner()
+	if c.streamed {
+		// Only the status line is new, or the error line for a failure; the output scrolled past while the command ran
+		c.streamed = false
+		if first, _, _ := strings.Cut(result, "\n"); strings.HasPrefix(result, "Error [") {
+			result = first
+		} else {
+			result = result[strings.LastIndex(result, "\n")+1:]
+		}
+	}
+	switch c.ui.Verbosity() {
+	case config.UIQuiet:
+		// A failed call still shows, as its first line, so the user isn't left wondering what went wrong
+		if !strings.HasPrefix(result, "Error") {
+			return
+		}
+		result, _, _ = strings.Cut(result, "\n")
+	case config.UINormal:
+		result = previewLines(result, config.ToolResultPreviewLines)
+	}
+	fmt.Printf("%s: %s\n", c.ui.Paint(ui.StyleInfo, "[Tool Result]"), result)
+}
+
+func (c *consoleEvents) OnToolProgress(name string, message string) {
+	if c.ui.Verbosity() == config.UIQuiet {
+		return
+	}
+	running := c.stopSpinner()
+	fmt.Printf("%s: %s\n", c.ui.Paint(ui.StyleInfo, "["+name+"]"), message)
+	if running {
+		c.spinner.Start("running " + name)
+	}
+}
+
+func (c *consoleEvents) OnCommandStart(name string, command string) {
+	if c.ui.Verbosity() == config.UIQuiet {
+		return
+	}
+	c.stopSpinner()
+	c.command = c.ui.StartCommandStatus(command)
+}
+
+func (c *consoleEvents) OnCommandOutput(name string, line string) {
+	if c.command != nil {
+		c.command.Line(line)
+	}
+}
+
+func (c *consoleEvents) OnCommandEnd(name string) {
+	if c.command != nil {
+		c.command.Stop()
+		c.command = nil
+		c.streamed = true
+	}
+}
+
+// OnUsage shows the response's token counts at the verbose level; otherwise the cost estimate printed
+// before each request is enough
+func (c *consoleEvents) OnUsage(usage anthropic.Usage) {
+	if c.ui.Verbosity() != config.UIVerbose {
+		return
+	}
+	c.stopSpinner()
+	c.breakLine()
+	fmt.Printf("%s: %s input, %s output, %s cache read, %s cache write tokens\n", c.ui.Paint(ui.StyleOutput, "[Usage]"),
+		ui.FormatCount(int(usage.InputTokens)), ui.FormatCount(int(usage.OutputTokens)), ui.FormatCount(int(usage.CacheReadInputTokens)), ui.FormatCount(int(usage.CacheCreationInputTokens)))
+}
+
+// OnStreamEvent counts streamed output tokens for the spinner and shows raw API event types at the
+// verbose level; runs of deltas are counted, not listed
+func (c *consoleEvents) OnStreamEvent(event anthropic.MessageStreamEventUnion) {
+	if c.spinner != nil {
+		switch variant := event.AsAny().(type) {
+		case anthropic.MessageStartEvent:
+			c.spinner.Start(ui.PhaseThinking) // Also back from retrying, as the new attempt answers
+		case anthropic.ContentBlockDeltaEvent:
+			switch delta := variant.Delta.AsAny().(type) {
+			case anthropic.TextDelta:
+				c.spinner.AddTokens(tokenizer.Count(delta.Text))
+			case anthropic.InputJSONDelta:
+				c.spinner.AddTokens(tokenizer.Count(delta.PartialJSON))
+			case anthropic.ThinkingDelta:
+				c.spinner.AddTokens(tokenizer.Count(delta.Thinking))
+			}
+		}
+	}
+	if c.ui.Verbosity() != config.UIVerbose {
+		return
+	}
+	detail := ""
+	switch variant := event.AsAny().(type) {
+	case anthropic.ContentBlockDeltaEvent:
+		c.deltas++
+		return
+	case anthropic.MessageStartEvent:
+		detail = string(variant.Message.Model)
+	case anthropic.ContentBlockStartEvent:
+		detail = fmt.Sprintf("index %d, %s", variant.Index, variant.ContentBlock.Type)
+	case anthropic.ContentBlockStopEvent:
+		detail = fmt.Sprintf("index %d, after %d deltas", variant.Index, c.deltas)
+	case anthropic.MessageDeltaEvent:
+		detail = string(variant.Delta.StopReason)
+	}
+	c.deltas = 0
+	c.stopSpinner()
+	c.breakLine()
+	line := event.Type
+	if detail != "" {
+		line += " (" + detail + ")"
+	}
+	fmt.Printf("%s: %s\n", c.ui.Paint(ui.StyleOutput, "[API]"), line)
+}
+
+func (c *consoleEvents) OnNotice(label string, message string) {
+	if c.ui.Verbosity() == config.UIQuiet && !quietNotices[label] {
+		return
+	}
+	// A notice in the middle of a response, such as a resumed stream, goes on its own line
+	c.stopSpinner()
+	c.breakLine()
+	fmt.Printf("%s: %s\n", c.ui.Paint(ui.StyleNotice, "["+label+"]"), message)
+}
+
+// quietNotices are the notice labels quiet mode still shows: warnings and errors the user has to know
+// about or answer, rather than housekeeping
+var quietNotices = map[string]bool{
+	"Answer":         true,
+	"Approval":       true,
+	"Model Fallback": true,
+	"Output Limit":   true,
+	"Pins":           true,
+	"Post-processor": true,
+	"Tool Choice":    true,
+}
+
+// OnError shows nothing; the caller of Run reports the error it returns
+func (c *consoleEvents) OnError(error) {}
+
+// breakLine ends a partly printed response line so a status line can follow
+func (c *consoleEvents) breakLine() {
+	if c.output != nil {
+		c.output.Flush()
+	}
+	if c.midLine {
+		fmt.Println()
+		c.midLine = false
+	}
+}
+
+// previewLines shortens text to its first n lines, noting how many were left out
+func previewLines(text string, n int) string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines) <= n || (len(lines) == n+1 && lines[n] == "") {
+		return text
+	}
+	return strings.Join(lines[:n], "") + fmt.Sprintf("... (%d more lines; GOOCODE_UI_VERBOSITY=verbose shows tool results in full)", len(lines)-n)
+}
+
+// stopSpinner clears the spinner line, reporting whether it was shown
+func (c *consoleEvents) stopSpinner() bool {
+	if c.spinner == nil {
+		return false
+	}
+	running := c.spinner.Running()
+	c.spinner.Stop()
+	return running
+}
@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"anthropic-chat/compaction"
+	"anthropic-chat/config"
+	"anthropic-chat/provider"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// inferWithFallback runs inference, moving down GOOCODE_FALLBACK_MODELS for the rest of the turn while
+// the active model is overloaded or rate limited
+func (a *Agent) inferWithFallback(ctx context.Context, conversation []anthropic.MessageParam) (*anthropic.Message, error) {
+	message, err := a.runInference(ctx, conversation)
+	for err != nil && provider.IsOverloadedError(err) && ctx.Err() == nil {
+		next, ok := a.nextFallback(conversation)
+		if !ok {
+			return nil, err
+		}
+		if a.primaryModel == nil {
+			primary := a.config.Model()
+			a.primaryModel = &primary
+		}
+		a.events.OnNotice("Model Fallback", fmt.Sprintf("%s is unavailable (%v); using %s for this turn", a.config.Model().ID, err, next.ID))
+		a.config.SetModel(next)
+		message, err = a.runInference(ctx, conversation)
+	}
+	return message, err
+}
+
+// nextFallback returns the model after the active one in the fallback chain, skipping models without
+// tool support once the conversation has tool calls in it
+func (a *Agent) nextFallback(conversation []anthropic.MessageParam) (config.ModelInfo, bool) {
+	chain := a.config.Agent.FallbackModels
+	active := a.config.Model().ID
+	start := 0
+	for i, id := range chain {
+		if id == active {
+			start = i + 1
+		}
+	}
+	for _, id := range chain[start:] {
+		model, _ := config.LookupModel(id)
+		if model.ID == active || (a.primaryModel != nil && model.ID == a.primaryModel.ID) {
+			continue
+		}
+		if !model.SupportsTools && compaction.HasToolBlocks(conversation) {
+			continue
+		}
+		return model, true
+	}
+	return config.ModelInfo{}, false
+}
+
+// restorePrimaryModel switches back to the user's model after a turn that fell back
+func (a *Agent) restorePrimaryModel() {
+	if a.primaryModel == nil {
+		return
+	}
+	a.config.SetModel(*a.primaryModel)
+	a.primaryModel = nil
+}
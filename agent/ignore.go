@@ -0,0 +1,22 @@
+package agent
+
+import (
+	"path/filepath"
+
+	"anthropic-chat/ignore"
+)
+
+// Ignored implements the tools.IgnoreChecker interface with the working directory's .goocodeignore
+func (a *Agent) Ignored(fullPath string, isDir bool) bool {
+	root, err := filepath.Abs(a.workingDir)
+	if err != nil {
+		return false
+	}
+	if a.ignores == nil || a.ignores.Root() != root {
+		a.ignores = ignore.New(root) // First use, or the working directory changed with /cd
+	}
+	if abs, err := filepath.Abs(fullPath); err == nil {
+		fullPath = abs
+	}
+	return a.ignores.Ignored(fullPath, isDir)
+}
@@ -0,0 +1,176 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// toolsSupported reports whether tool definitions should be sent to the active model
+func (a *Agent) toolsSupported() bool {
+	return a.config.Model().SupportsTools
+}
+
+// systemText is the system prompt of the next request: the session's prompt with the project context,
+// git warm start, verbosity, pins, task list, recalled facts and workspace state added
+func (a *Agent) systemText() string {
+	return a.sessionPrompt() + a.projectContext() + a.gitContext + verbosityPrompts[a.Verbosity()] + a.pinnedContext() + a.todoContext() + a.memoryContext() + a.answerContext() + a.workspaceState()
+}
+
+// runInference handles the Anthropic API call with streaming
+func (a *Agent) runInference(ctx context.Context, conversation []anthropic.MessageParam) (*anthropic.Message, error) {
+	// Convert tools to Anthropic format
+	var toolParams []anthropic.ToolParam
+	for _, tool := range a.toolRegistry.All() {
+		if !a.profileAllows(tool.Name) {
+			continue
+		}
+		toolParams = append(toolParams, anthropic.ToolParam{
+			Name:        tool.Name,
+			Description: anthropic.String(tool.Description),
+			InputSchema: tool.InputSchema,
+		})
+	}
+
+	tools := make([]anthropic.ToolUnionParam, len(toolParams))
+	for i, toolParam := range toolParams {
+		tools[i] = anthropic.ToolUnionParam{OfTool: &toolParam}
+	}
+	if !a.toolsSupported() {
+		tools = nil
+	}
+
+	a.events.OnInferenceStart()
+	defer a.events.OnInferenceEnd()
+
+	params := anthropic.MessageNewParams{
+		Model:     anthropic.Model(a.config.Model().ID),
+		MaxTokens: int64(a.maxOutputTokens()),
+		System: []anthropic.TextBlockParam{
+			{Text: a.systemText()},
+		},
+		Messages: conversation,
+		Tools:    tools,
+	}
+	if tools != nil {
+		params.ToolChoice = a.toolChoiceParam()
+	}
+	if temperature, ok := a.temperature(); ok {
+		params.Temperature = anthropic.Float(temperature)
+	}
+
+	// A stream cut off by the network is resumed: the text received so far is sent back as the start of
+	// the assistant's reply, so the model carries on from there instead of starting over. A text reply
+	// that ran into the output token limit, say in the middle of a file, is continued the same way.
+	var received string
+	continuations := 0
+	for attempt := 1; ; attempt++ {
+		message, err := a.streamMessage(ctx, params)
+		if err == nil {
+			if received != "" {
+				if message, err = resumedMessage(received, message); err != nil {
+					return nil, err
+				}
+			}
+			if message.StopReason == anthropic.StopReasonMaxTokens {
+				text := strings.TrimRightFunc(partialText(message), unicode.IsSpace)
+				if continuations < a.config.Agent.OutputContinuations && allText(message) && text != "" {
+					continuations++
+					received = text
+					params.Messages = append(slices.Clip(conversation), anthropic.NewAssistantMessage(anthropic.NewTextBlock(received)))
+					a.events.OnNotice("Output Limit", fmt.Sprintf("Response reached the %s token limit; continuing where it left off (%d of %d)...", ui.FormatCount(a.maxOutputTokens()), continuations, a.config.Agent.OutputContinuations))
+					attempt = 0
+					continue
+				}
+				a.events.OnNotice("Output Limit", fmt.Sprintf("Response was cut off at the %s token limit and may be incomplete", ui.FormatCount(a.maxOutputTokens())))
+			}
+			if details, ok := a.events.(DetailObserver); ok {
+				details.OnMessage(message)
+			}
+			return message, nil
+		}
+		if attempt > a.config.Agent.StreamRetries || !resumable(ctx, err) {
+			return nil, err
+		}
+
+		received += partialText(message)
+		received = strings.TrimRightFunc(received, unicode.IsSpace) // The API rejects a prefill ending in whitespace
+		params.Messages = conversation
+		if received != "" {
+			params.Messages = append(slices.Clip(conversation), anthropic.NewAssistantMessage(anthropic.NewTextBlock(received)))
+		}
+		a.events.OnNotice("Connection", fmt.Sprintf("Stream interrupted (%v); resuming, attempt %d of %d...", err, attempt, a.config.Agent.StreamRetries))
+		if phases, ok := a.events.(PhaseObserver); ok {
+			phases.OnRetry()
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Duration(attempt) * time.Second):
+		}
+	}
+}
+
+// streamMessage makes one streaming request; when the stream fails, the message received so far is
+// returned along with the error
+func (a *Agent) streamMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	stream := a.provider.StreamMessage(ctx, params)
+	defer stream.Close()
+
+	observer, _ := a.events.(StreamObserver)
+	details, _ := a.events.(DetailObserver)
+	toolIDs := make(map[int64]string) // Tool call IDs by content block index, for input deltas
+	message := anthropic.Message{}
+	for stream.Next() {
+		event := stream.Current()
+		if observer != nil {
+			observer.OnStreamEvent(event)
+		}
+		err := message.Accumulate(event)
+		if err != nil {
+			return nil, fmt.Errorf("failed to accumulate stream event: %w", err)
+		}
+
+		// Process streaming events
+		switch eventVariant := event.AsAny().(type) {
+		case anthropic.ContentBlockStartEvent:
+			if block, ok := eventVariant.ContentBlock.AsAny().(anthropic.ToolUseBlock); ok && details != nil {
+				toolIDs[eventVariant.Index] = block.ID
+				details.OnToolStart(block.ID, block.Name)
+			}
+		case anthropic.ContentBlockDeltaEvent:
+			switch deltaVariant := eventVariant.Delta.AsAny().(type) {
+			case anthropic.TextDelta:
+				a.events.OnText(deltaVariant.Text)
+			case anthropic.InputJSONDelta:
+				if details != nil {
+					details.OnToolInputDelta(toolIDs[eventVariant.Index], deltaVariant.PartialJSON)
+				}
+			}
+		case anthropic.ContentBlockStopEvent:
+			// The input has streamed in full by the time the block stops
+			if int(eventVariant.Index) < len(message.Content) {
+				if block, ok := message.Content[eventVariant.Index].AsAny().(anthropic.ToolUseBlock); ok {
+					a.events.OnToolCall(block.Name, block.Input)
+				}
+			}
+		}
+	}
+
+	// Interrupted, continued and fallback requests are billed too, so each one counts toward the budget
+	a.events.OnUsage(message.Usage)
+	if a.spend != nil {
+		a.spend.add(a.config.Model(), message.Usage)
+	}
+	if stream.Err() != nil {
+		return &message, fmt.Errorf("streaming error: %w", stream.Err())
+	}
+	return &message, nil
+}
@@ -0,0 +1,131 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Interrupt states, kept in Agent.interruptState
+const (
+	interruptIdle    int32 = iota // No turn is running; Ctrl-C quits
+	interruptRunning              // A turn is running; Ctrl-C asks it to pause
+	interruptPausing              // A pause was requested or is under way; Ctrl-C quits
+)
+
+// recentCallsShown is how many of the turn's latest tool calls a pause lists
+const recentCallsShown = 5
+
+// Interrupt asks the running turn to pause so the user can steer it, stopping the current tool call.
+// It reports false when there is no turn to pause or a pause is already pending.
+func (a *Agent) Interrupt() bool {
+	if !a.interruptState.CompareAndSwap(interruptRunning, interruptPausing) {
+		return false
+	}
+	a.toolMu.Lock()
+	defer a.toolMu.Unlock()
+	if a.stopTool != nil {
+		a.stopTool()
+	}
+	return true
+}
+
+// startToolCall returns the context a tool call runs in, which Interrupt cancels
+func (a *Agent) startToolCall(ctx context.Context) context.Context {
+	a.toolMu.Lock()
+	defer a.toolMu.Unlock()
+	ctx, a.stopTool = context.WithCancel(ctx)
+	return ctx
+}
+
+// endToolCall releases the context of the tool call that finished
+func (a *Agent) endToolCall() {
+	a.toolMu.Lock()
+	defer a.toolMu.Unlock()
+	if a.stopTool != nil {
+		a.stopTool()
+		a.stopTool = nil
+	}
+}
+
+// handleInterrupts turns the first Ctrl-C during a turn into a pause and any other Ctrl-C into quitting,
+// after calling beforeExit if it is set, since deferred calls don't run. The returned function stops
+// handling them.
+func (a *Agent) handleInterrupts(beforeExit func()) func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-signals:
+				if a.Interrupt() {
+					fmt.Printf("\n%s: Pausing; a running command is stopped. Press Ctrl-C again to quit\n", a.uiManager.Paint(ui.StyleNotice, "[Interrupt]"))
+					continue
+				}
+				fmt.Println()
+				if beforeExit != nil {
+					beforeExit()
+				}
+				a.Close()
+				if a.onExit != nil {
+					a.onExit()
+				}
+				os.Exit(130)
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+// pauseRequested reports whether the user asked the turn to pause
+func (a *Agent) pauseRequested() bool {
+	return a.interruptState.Load() == interruptPausing
+}
+
+// pauseTurn shows what the agent has been doing and asks the user how to continue. It returns
+// text to send the model along with the tool results, and whether the turn should stop after the model
+// reports on it.
+func (a *Agent) pauseTurn(guard *loopGuard) (steering string, stop bool) {
+	defer a.interruptState.Store(interruptRunning)
+
+	fmt.Printf("%s: The agent has made %d tool call(s) this turn. Latest:\n", a.uiManager.Paint(ui.StyleNotice, "[Paused]"), guard.calls)
+	for _, call := range guard.recent {
+		fmt.Printf("  %s\n", call)
+	}
+	fmt.Print("Steer the agent (Enter to continue, 'stop' to end the turn): ")
+	answer, ok := a.getUserMessage()
+	answer = strings.TrimSpace(answer)
+	switch {
+	case !ok || strings.EqualFold(answer, "stop"):
+		return "The user stopped you here. Summarize what you have done so far and what remains.", true
+	case answer == "":
+		return "", false
+	}
+	return "The user paused you to add this instruction; follow it from here on:\n" + answer, false
+}
+
+// pausedToolResult tells the model why a tool call after the pause was not executed
+func pausedToolResult() string {
+	return "Not executed: the user paused the agent before this call. Reconsider it in light of the user's instruction."
+}
+
+// describeCall summarizes a tool call for the pause listing
+func describeCall(block anthropic.ToolUseBlock) string {
+	input := string(block.Input)
+	if runes := []rune(input); len(runes) > 100 {
+		input = string(runes[:100]) + "..."
+	}
+	return block.Name + " " + input
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// jsonEvents writes every agent event as one JSON object per line, for programs that render their own UI
+type jsonEvents struct {
+	encoder *json.Encoder
+}
+
+// NewJSONEvents creates an event handler that streams newline-delimited JSON events to w.
+// Each object has a "type" field naming the event, such as "text", "tool_call" or "usage".
+func NewJSONEvents(w io.Writer) EventHandler {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return &jsonEvents{encoder: encoder}
+}
+
+// jsonEvent is the union of the fields events carry; unused ones are omitted
+type jsonEvent struct {
+	Type    string          `json:"type"`
+	Name    string          `json:"name,omitempty"`
+	Text    string          `json:"text,omitempty"`
+	Input   json.RawMessage `json:"input,omitempty"`
+	Result  *string         `json:"result,omitempty"`
+	Message string          `json:"message,omitempty"`
+	Label   string          `json:"label,omitempty"`
+	Command string          `json:"command,omitempty"`
+	Line    *string         `json:"line,omitempty"`
+	Usage   *jsonUsage      `json:"usage,omitempty"`
+}
+
+type jsonUsage struct {
+	InputTokens              int64 `json:"input_tokens"`
+	OutputTokens             int64 `json:"output_tokens"`
+	CacheReadInputTokens     int64 `json:"cache_read_input_tokens"`
+	CacheCreationInputTokens int64 `json:"cache_creation_input_tokens"`
+}
+
+func (j *jsonEvents) emit(event jsonEvent) {
+	// A failed write means the consumer has gone away; there is nobody left to tell
+	_ = j.encoder.Encode(event)
+}
+
+func (j *jsonEvents) OnInferenceStart() {
+	j.emit(jsonEvent{Type: "inference_start"})
+}
+
+func (j *jsonEvents) OnText(delta string) {
+	j.emit(jsonEvent{Type: "text", Text: delta})
+}
+
+func (j *jsonEvents) OnToolCall(name string, input json.RawMessage) {
+	if !json.Valid(input) {
+		input = json.RawMessage("{}")
+	}
+	j.emit(jsonEvent{Type: "tool_call", Name: name, Input: input})
+}
+
+func (j *jsonEvents) OnInferenceEnd() {
+	j.emit(jsonEvent{Type: "inference_end"})
+}
+
+func (j *jsonEvents) OnToolResult(name string, result string) {
+	j.emit(jsonEvent{Type: "tool_result", Name: name, Result: &result})
+}
+
+func (j *jsonEvents) OnToolProgress(name string, message string) {
+	j.emit(jsonEvent{Type: "tool_progress", Name: name, Message: message})
+}
+
+func (j *jsonEvents) OnCommandStart(name string, command string) {
+	j.emit(jsonEvent{Type: "command_start", Name: name, Command: command})
+}
+
+func (j *jsonEvents) OnCommandOutput(name string, line string) {
+	j.emit(jsonEvent{Type: "command_output", Name: name, Line: &line})
+}
+
+func (j *jsonEvents) OnCommandEnd(name string) {
+	j.emit(jsonEvent{Type: "command_end", Name: name})
+}
+
+func (j *jsonEvents) OnUsage(usage anthropic.Usage) {
+	j.emit(jsonEvent{Type: "usage", Usage: &jsonUsage{
+		InputTokens:              usage.InputTokens,
+		OutputTokens:             usage.OutputTokens,
+		CacheReadInputTokens:     usage.CacheReadInputTokens,
+		CacheCreationInputTokens: usage.CacheCreationInputTokens,
+	}})
+}
+
+func (j *jsonEvents) OnNotice(label string, message string) {
+	j.emit(jsonEvent{Type: "notice", Label: label, Message: message})
+}
+
+func (j *jsonEvents) OnError(err error) {
+	j.emit(jsonEvent{Type: "error", Message: err.Error()})
+}
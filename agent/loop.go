@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"fmt"
+
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// loopGuard limits how many tool calls and tokens one turn may use and notices the model repeating itself
+type loopGuard struct {
+	calls         int
+	nextCheck     int    // Call count at which the user is next asked to continue
+	lastSignature string // Name and input of the previous call
+	repeats       int    // Consecutive identical calls
+	nextRepeat    int    // Repeat count at which the user is next asked to continue
+	stopped       bool
+	reported      bool     // The model was asked once, without tools, to report what it did before the stop
+	recent        []string // Latest calls, listed when the user pauses the turn
+	spend         turnSpend
+}
+
+// allowToolCall records a call and, at the iteration limit, past the turn's budget or when the same
+// call keeps repeating, asks the user whether to continue. Once declined, every remaining call in the turn is refused.
+func (a *Agent) allowToolCall(guard *loopGuard, block anthropic.ToolUseBlock) bool {
+	if guard.stopped {
+		return false
+	}
+	guard.calls++
+	guard.recent = append(guard.recent, describeCall(block))
+	if len(guard.recent) > recentCallsShown {
+		guard.recent = guard.recent[1:]
+	}
+
+	signature := block.Name + string(block.Input)
+	if signature == guard.lastSignature {
+		guard.repeats++
+	} else {
+		guard.lastSignature = signature
+		guard.repeats = 1
+		guard.nextRepeat = a.config.Agent.RepeatedCallLimit
+	}
+
+	limit := a.config.Agent.MaxToolCalls
+	if guard.nextCheck == 0 {
+		guard.nextCheck = limit
+	}
+
+	label, reason := "[Loop Guard]", ""
+	switch {
+	case guard.nextRepeat > 0 && guard.repeats == guard.nextRepeat:
+		reason = fmt.Sprintf("The agent has called %s with identical input %d times in a row and may be stuck.", block.Name, guard.repeats)
+		guard.nextRepeat += a.config.Agent.RepeatedCallLimit
+	case limit > 0 && guard.calls > guard.nextCheck:
+		reason = fmt.Sprintf("The agent has used %d tool calls this turn.", guard.calls-1)
+		guard.nextCheck += limit
+	default:
+		if reason = a.overBudget(&guard.spend); reason == "" {
+			return true
+		}
+		label = "[Budget]"
+	}
+
+	fmt.Printf("%s: %s\n", a.uiManager.Paint(ui.StyleWarning, label), reason)
+	if a.confirm("Continue? [y/N] ", false) {
+		return true
+	}
+	guard.stopped = true
+	return false
+}
+
+// stoppedToolResult tells the model why its tool call was not executed
+func stoppedToolResult(guard *loopGuard) string {
+	return fmt.Sprintf("Not executed: the user stopped the agent after %d tool calls this turn. Summarize what you have done so far and what remains.", guard.calls-1)
+}
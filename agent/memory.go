@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"anthropic-chat/config"
+	"anthropic-chat/memory"
+	"anthropic-chat/project"
+	memorytools "anthropic-chat/tools/memory"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// MemoryFile implements the tools.MemoryKeeper interface with the working tree's project directory
+func (a *Agent) MemoryFile() string {
+	return a.projectDir().Path(project.MemoriesFile)
+}
+
+// recallMemories picks the saved facts that bear on a freshly compacted conversation, so what the
+// summary dropped comes back without the model having to search for it
+func (a *Agent) recallMemories(conversation []anthropic.MessageParam) {
+	a.recalled = nil
+	store, err := memory.Open(a.MemoryFile())
+	if err != nil || len(store.Facts) == 0 {
+		return
+	}
+	var text strings.Builder
+	for _, msg := range conversation {
+		for _, block := range msg.Content {
+			if block.OfText != nil {
+				text.WriteString(block.OfText.Text + "\n")
+			}
+		}
+	}
+	a.recalled = store.Search(text.String(), config.MemoryRecallLimit)
+	if len(a.recalled) > 0 {
+		a.events.OnNotice("Memory", fmt.Sprintf("Recalled %d saved fact(s) relevant to the compacted conversation", len(a.recalled)))
+	}
+}
+
+// memoryContext renders the recalled facts for the system prompt
+func (a *Agent) memoryContext() string {
+	if len(a.recalled) == 0 {
+		return ""
+	}
+	return "\n\n# Recalled facts\nFacts you saved with the memory tool that bear on this conversation, brought back after it was compacted:\n" + memorytools.Format(a.recalled) + "\n"
+}
+
+// memoriesCommand handles /memories: it lists the saved facts, searches them, or deletes one
+func (a *Agent) memoriesCommand(arg string) {
+	store, err := memory.Open(a.MemoryFile())
+	if err != nil {
+		fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+		return
+	}
+	if key, ok := strings.CutPrefix(arg, "delete "); ok {
+		key = strings.TrimSpace(key)
+		if !store.Delete(key) {
+			fmt.Printf("%s: Nothing is saved under %q\n\n", a.uiManager.Paint(ui.StyleError, "Error"), key)
+			return
+		}
+		if err := store.Save(); err != nil {
+			fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+			return
+		}
+		fmt.Printf("%s: Deleted %q\n\n", a.uiManager.Paint(ui.StyleSuccess, "Memory"), key)
+		return
+	}
+
+	facts, label := store.Facts, fmt.Sprintf("Saved facts (%s):", a.MemoryFile())
+	if arg != "" {
+		facts, label = store.Search(arg, config.MemoryRecallLimit), fmt.Sprintf("Facts matching %q:", arg)
+	}
+	if len(facts) == 0 && arg != "" {
+		fmt.Printf("%s: No saved facts match %q\n\n", a.uiManager.Paint(ui.StyleInfo, "Memory"), arg)
+		return
+	}
+	if len(facts) == 0 {
+		fmt.Printf("%s: No saved facts; the model saves them with the memory tool\n\n", a.uiManager.Paint(ui.StyleInfo, "Memory"))
+		return
+	}
+	var b strings.Builder
+	for _, fact := range facts {
+		fmt.Fprintf(&b, "  %s: %s %s\n", fact.Key, fact.Value, a.uiManager.Paint(ui.StyleOutput, "("+ui.FormatDuration(time.Since(fact.Updated))+" ago)"))
+	}
+	fmt.Printf("%s\n%s\n", a.uiManager.Paint(ui.StyleInfo, label), b.String())
+}
@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"fmt"
+
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// meterWarnPercent is where the meter turns yellow when background compaction is off
+const meterWarnPercent = 75
+
+// tokenMeter renders the conversation's estimated size against the input limit, like [42.3K/200K]
+func (a *Agent) tokenMeter(conversation []anthropic.MessageParam) string {
+	if !a.config.Agent.TokenMeter {
+		return ""
+	}
+	tokens, limit := a.estimateConversationTokens(conversation), a.config.MaxInputTokens()
+	warnAt := a.config.Agent.BackgroundCompaction
+	if warnAt <= 0 || warnAt >= 100 {
+		warnAt = meterWarnPercent
+	}
+
+	style := ui.StyleOutput
+	if tokens >= limit*warnAt/100 {
+		style = ui.StyleWarning
+	}
+	return a.uiManager.Paint(style, fmt.Sprintf("[%s/%s]", ui.FormatTokens(tokens), ui.FormatTokens(limit))) + " "
+}
@@ -0,0 +1,106 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/config"
+	"anthropic-chat/project"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const briefPrompt = `You are onboarding onto a software project. Below is a scan of the repository: its file tree, README and key
+configuration files. Write GOOCODE.md, a project brief that a coding assistant will read at the start of every
+future session instead of re-exploring the tree. Use Markdown with these sections:
+
+- Overview: what the project is and does, in a few sentences
+- Layout: the important directories and files and what lives where
+- Tech stack: languages, frameworks and notable dependencies
+- Commands: how to build, test, lint and run it, exactly as found in the configs
+- Conventions: code style, patterns and practices visible in the project
+
+Only state what the scan supports; say so when something is unclear. Keep it under 800 words.
+Reply with the file content only.
+
+%s`
+
+// runInit scans the project and asks the model to write a GOOCODE.md brief for future sessions
+func (a *Agent) runInit(ctx context.Context) {
+	dir := a.projectDir()
+	path := filepath.Join(dir.Root, project.BriefFile)
+	if _, err := os.Stat(path); err == nil {
+		if !a.confirm(fmt.Sprintf("%s already exists. Regenerate it? [y/N] ", a.displayPath(path)), false) {
+			fmt.Println()
+			return
+		}
+	}
+
+	fmt.Printf("%s: Scanning %s...\n", a.uiManager.Paint(ui.StyleInfo, "Init"), dir.Root)
+	scan, err := dir.Scan()
+	if err != nil {
+		fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+		return
+	}
+	// Configs and the README can hold credentials, which shouldn't reach the model
+	scan = a.redactToolResult("init", scan)
+
+	a.events.OnInferenceStart()
+	message, err := a.provider.NewMessage(ctx, anthropic.MessageNewParams{
+		Model:     anthropic.Model(a.config.Model().ID),
+		MaxTokens: int64(min(config.BriefMaxTokens, a.config.Model().MaxOutputTokens)),
+		Messages:  []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(fmt.Sprintf(briefPrompt, scan)))},
+	})
+	a.events.OnInferenceEnd()
+	if err != nil {
+		fmt.Printf("%s: failed to generate the project brief: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+		return
+	}
+
+	var brief strings.Builder
+	for _, content := range message.Content {
+		if textBlock, ok := content.AsAny().(anthropic.TextBlock); ok {
+			brief.WriteString(textBlock.Text)
+		}
+	}
+	text := strings.TrimSpace(stripMarkdownFence(brief.String()))
+	if text == "" {
+		fmt.Printf("%s: the model returned an empty brief\n\n", a.uiManager.Paint(ui.StyleError, "Error"))
+		return
+	}
+
+	lines := strings.Count(text, "\n") + 1
+	err = a.Approve(approval.Request{
+		Tool:    "init",
+		Summary: fmt.Sprintf("write %s with %d lines", a.displayPath(path), lines),
+		Paths:   []string{filepath.ToSlash(a.displayPath(path))},
+		Lines:   lines,
+	})
+	if err != nil {
+		fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+		return
+	}
+	if err := os.WriteFile(path, []byte(text+"\n"), 0o644); err != nil {
+		fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+		return
+	}
+	fmt.Printf("%s Wrote %s (%d lines); it is included in every session from now on\n\n", a.uiManager.Paint(ui.StyleSuccess, "Init:"), a.displayPath(path), lines)
+}
+
+// stripMarkdownFence removes a code fence wrapped around the whole reply
+func stripMarkdownFence(text string) string {
+	trimmed := strings.TrimSpace(text)
+	if !strings.HasPrefix(trimmed, "```") || !strings.HasSuffix(trimmed, "```") {
+		return text
+	}
+	_, body, ok := strings.Cut(trimmed, "\n")
+	if !ok {
+		return text
+	}
+	return strings.TrimSuffix(body, "```")
+}
@@ -0,0 +1,67 @@
+package agent
+
+import (
+	"anthropic-chat/cassette"
+	"anthropic-chat/config"
+)
+
+// Option configures an Agent created with New
+type Option func(*options)
+
+type options struct {
+	config       *config.Config
+	model        string
+	workingDir   string
+	systemPrompt string
+	input        func() (string, bool)
+	events       EventHandler
+	cassette     *cassette.Cassette
+	profile      string
+	onExit       func()
+}
+
+// WithConfig uses cfg instead of loading configuration from the environment
+func WithConfig(cfg *config.Config) Option {
+	return func(o *options) { o.config = cfg }
+}
+
+// WithModel selects the model by ID, overriding the configured one
+func WithModel(name string) Option {
+	return func(o *options) { o.model = name }
+}
+
+// WithWorkingDir sets the directory tools operate in (defaults to the current directory)
+func WithWorkingDir(dir string) Option {
+	return func(o *options) { o.workingDir = dir }
+}
+
+// WithSystemPrompt replaces the prompt loaded from system_prompt.txt
+func WithSystemPrompt(prompt string) Option {
+	return func(o *options) { o.systemPrompt = prompt }
+}
+
+// WithInput sets where Run and interactive prompts read user input from (defaults to stdin)
+func WithInput(readLine func() (string, bool)) Option {
+	return func(o *options) { o.input = readLine }
+}
+
+// WithEventHandler receives agent activity instead of the default terminal output
+func WithEventHandler(handler EventHandler) Option {
+	return func(o *options) { o.events = handler }
+}
+
+// WithCassette records tool results to c, or replays them from it instead of running the tools; pair it
+// with cassette.NewRecorder or cassette.NewPlayer as the provider
+func WithCassette(c *cassette.Cassette) Option {
+	return func(o *options) { o.cassette = c }
+}
+
+// WithProfile starts with the named profile from the settings file instead of GOOCODE_PROFILE
+func WithProfile(name string) Option {
+	return func(o *options) { o.profile = name }
+}
+
+// WithExitHook runs fn before the process exits on Ctrl-C, where deferred calls in main never run
+func WithExitHook(fn func()) Option {
+	return func(o *options) { o.onExit = fn }
+}
@@ -0,0 +1,258 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"anthropic-chat/config"
+	"anthropic-chat/tokenizer"
+	"anthropic-chat/tools"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// pinnedFile is a file whose current contents are sent with every request
+type pinnedFile struct {
+	path         string // Relative to the working directory
+	lastUsed     int    // Turn in which the model last referenced the file
+	pendingUnpin bool   // Will be unpinned before the next turn unless the user keeps it
+
+	// The file as last read, reused while its size and modification time are unchanged
+	content string
+	tokens  int
+	size    int64
+	modTime time.Time
+}
+
+// pinnedMessage is conversation text kept verbatim in every request, so summarization can't drop it
+type pinnedMessage struct {
+	id     string // msg-N, used to unpin it
+	source string // Where the text came from, e.g. "your reply" or "user note"
+	text   string
+}
+
+// Pin adds a file to the context sent with every request
+func (a *Agent) Pin(path string) error {
+	fullPath, err := a.ResolveFilePath(path)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return fmt.Errorf("failed to access %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory; pin individual files", path)
+	}
+	if info.Size() > config.PinMaxFileBytes {
+		return fmt.Errorf("%s is %s; files over %s cannot be pinned", path, ui.FormatBytesPlain(info.Size()), ui.FormatBytesPlain(config.PinMaxFileBytes))
+	}
+
+	rel := filepath.ToSlash(filepath.Clean(path))
+	for _, pin := range a.pins {
+		if pin.path == rel {
+			pin.lastUsed = a.turn
+			pin.pendingUnpin = false
+			return nil
+		}
+	}
+	a.pins = append(a.pins, &pinnedFile{path: rel, lastUsed: a.turn})
+	return nil
+}
+
+// PinFile implements the tools.Pinner interface, telling the user the model pinned a file
+func (a *Agent) PinFile(path string) error {
+	if err := a.Pin(path); err != nil {
+		return err
+	}
+	a.events.OnNotice("Pins", fmt.Sprintf("Pinned %s at the model's request (/unpin %s to stop sending it)", path, path))
+	return nil
+}
+
+// PinMessage keeps text from the conversation in every request and returns the ID to unpin it with
+func (a *Agent) PinMessage(source, text string) string {
+	a.nextMessagePin++
+	pin := &pinnedMessage{id: fmt.Sprintf("msg-%d", a.nextMessagePin), source: source, text: strings.TrimSpace(text)}
+	a.pinnedMessages = append(a.pinnedMessages, pin)
+	return pin.id
+}
+
+// UnpinMessage removes a pinned message by ID, reporting whether it was pinned
+func (a *Agent) UnpinMessage(id string) bool {
+	for i, pin := range a.pinnedMessages {
+		if pin.id == id {
+			a.pinnedMessages = append(a.pinnedMessages[:i], a.pinnedMessages[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Unpin removes a file from the pinned context, reporting whether it was pinned
+func (a *Agent) Unpin(path string) bool {
+	rel := filepath.ToSlash(filepath.Clean(path))
+	for i, pin := range a.pins {
+		if pin.path == rel {
+			a.pins = append(a.pins[:i], a.pins[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Pinned returns the pinned file paths
+func (a *Agent) Pinned() []string {
+	paths := make([]string, len(a.pins))
+	for i, pin := range a.pins {
+		paths[i] = pin.path
+	}
+	return paths
+}
+
+// keepPin vetoes a pending automatic unpin and resets the file's idle count
+func (a *Agent) keepPin(path string) bool {
+	rel := filepath.ToSlash(filepath.Clean(path))
+	for _, pin := range a.pins {
+		if pin.path == rel {
+			pin.pendingUnpin = false
+			pin.lastUsed = a.turn
+			return true
+		}
+	}
+	return false
+}
+
+// pinnedContext renders pinned messages and the current contents of every pinned file for the system prompt
+func (a *Agent) pinnedContext() string {
+	var b strings.Builder
+	if len(a.pinnedMessages) > 0 {
+		b.WriteString("\n\n# Pinned messages\nThe user pinned these messages from the conversation. They still apply, even if the turns they came from have since been summarized.\n")
+		for _, pin := range a.pinnedMessages {
+			fmt.Fprintf(&b, "\n## %s (%s)\n%s\n", pin.id, pin.source, pin.text)
+		}
+	}
+	if len(a.pins) == 0 {
+		return b.String()
+	}
+	b.WriteString("\n\n# Pinned files\nThese files are pinned; their current contents follow.\n")
+	for _, pin := range a.pins {
+		content, err := a.pinContent(pin)
+		if errors.Is(err, tools.ErrPathNotAllowed) || errors.Is(err, tools.ErrIgnored) {
+			continue // Outside the working directory, or denied, since /cd
+		}
+		if err != nil {
+			fmt.Fprintf(&b, "\n## %s\n(could not be read: %v)\n", pin.path, err)
+			continue
+		}
+		fmt.Fprintf(&b, "\n## %s\n```\n%s\n```\n", pin.path, strings.TrimRight(content, "\n"))
+	}
+	return b.String()
+}
+
+// trackPinUsage marks pinned files the model referenced in messages and flags idle ones for pruning
+func (a *Agent) trackPinUsage(messages []anthropic.MessageParam) {
+	if len(a.pins) == 0 {
+		return
+	}
+
+	var output strings.Builder
+	for _, msg := range messages {
+		if msg.Role != anthropic.MessageParamRoleAssistant {
+			continue
+		}
+		for _, block := range msg.Content {
+			if block.OfText != nil {
+				output.WriteString(block.OfText.Text)
+			}
+			if block.OfToolUse != nil {
+				input, _ := json.Marshal(block.OfToolUse.Input)
+				output.Write(input)
+			}
+			output.WriteByte('\n')
+		}
+	}
+	text := output.String()
+
+	idleTurns := a.config.Agent.PinIdleTurns
+	for _, pin := range a.pins {
+		if strings.Contains(text, pin.path) || strings.Contains(text, filepath.Base(pin.path)) {
+			pin.lastUsed = a.turn
+			continue
+		}
+		idle := a.turn - pin.lastUsed
+		if idleTurns <= 0 || idle < idleTurns || pin.pendingUnpin {
+			continue
+		}
+
+		tokens := a.pinTokens(pin)
+		if a.config.Agent.AutoUnpin {
+			pin.pendingUnpin = true
+			a.events.OnNotice("Pins", fmt.Sprintf("%s has not been used in %d turns and will be unpinned before your next message (~%s tokens). Type /keep %s to keep it", pin.path, idle, ui.FormatCount(tokens), pin.path))
+		} else if idle == idleTurns {
+			a.events.OnNotice("Pins", fmt.Sprintf("%s has not been used in %d turns; /unpin %s to reclaim ~%s tokens", pin.path, idle, pin.path, ui.FormatCount(tokens)))
+		}
+	}
+}
+
+// applyPendingUnpins removes files flagged for automatic unpinning that the user did not keep
+func (a *Agent) applyPendingUnpins() {
+	kept := a.pins[:0]
+	for _, pin := range a.pins {
+		if pin.pendingUnpin {
+			a.events.OnNotice("Pins", fmt.Sprintf("Unpinned %s", pin.path))
+			continue
+		}
+		kept = append(kept, pin)
+	}
+	a.pins = kept
+}
+
+// pinnedMessageTokens approximates the context a pinned message occupies
+func pinnedMessageTokens(pin *pinnedMessage) int {
+	return tokenizer.Count(pin.text)
+}
+
+// pinPreview shortens a pinned message to its start for /pins
+func pinPreview(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > config.PinPreviewChars {
+		return string(runes[:config.PinPreviewChars]) + "..."
+	}
+	return text
+}
+
+// pinTokens approximates the context a pinned file occupies
+func (a *Agent) pinTokens(pin *pinnedFile) int {
+	if _, err := a.pinContent(pin); err != nil {
+		return 0
+	}
+	return pin.tokens
+}
+
+// pinContent returns a pinned file's contents, reading and counting it again only when its size or
+// modification time changed, so estimating a request doesn't re-read every pin
+func (a *Agent) pinContent(pin *pinnedFile) (string, error) {
+	fullPath, err := a.ResolveFilePath(pin.path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() == pin.size && info.ModTime().Equal(pin.modTime) {
+		return pin.content, nil
+	}
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", err
+	}
+	pin.content, pin.tokens, pin.size, pin.modTime = string(content), tokenizer.Count(string(content)), info.Size(), info.ModTime()
+	return pin.content, nil
+}
@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/plugin"
+	"anthropic-chat/project"
+	plugintools "anthropic-chat/tools/plugin"
+)
+
+// toolNamePattern is what the API accepts as a tool name
+var toolNamePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// loadPlugins starts every plugin executable and registers its tools; built-in tools win name clashes.
+// Read-only mode starts none, since nothing keeps a plugin from writing files whatever it declares.
+func (a *Agent) loadPlugins(ctx context.Context) {
+	dirs := []string{a.config.Agent.PluginsDir}
+	if a.config.Agent.ProjectPlugins {
+		dirs = append(dirs, a.projectDir().Path(project.PluginsDir))
+	}
+
+	paths := plugin.Discover(dirs...)
+	if a.config.Security.ReadOnly {
+		if len(paths) > 0 {
+			log.Printf("Warning: %d plugin(s) not loaded: %v", len(paths), approval.ErrReadOnly)
+		}
+		return
+	}
+	for _, path := range paths {
+		p := plugin.New(path, a.workingDir)
+		description, err := p.Describe(ctx)
+		if err != nil {
+			log.Printf("Warning: plugin %s not loaded: %v", path, err)
+			p.Close()
+			continue
+		}
+
+		registered := 0
+		for _, spec := range description.Tools {
+			if err := a.registerPluginTool(p, spec); err != nil {
+				log.Printf("Warning: plugin %s: %v", description.Name, err)
+				continue
+			}
+			registered++
+		}
+		if registered == 0 {
+			p.Close()
+			continue
+		}
+		a.plugins = append(a.plugins, p)
+	}
+}
+
+func (a *Agent) registerPluginTool(p *plugin.Plugin, spec plugin.ToolSpec) error {
+	if !toolNamePattern.MatchString(spec.Name) {
+		return fmt.Errorf("tool name %q must be 1-64 letters, digits, _ or -", spec.Name)
+	}
+	if _, exists := a.toolRegistry.Get(spec.Name); exists {
+		return fmt.Errorf("tool %s skipped: a tool with that name is already registered", spec.Name)
+	}
+	tool, err := plugintools.NewPluginTool(p, spec)
+	if err != nil {
+		return err
+	}
+	a.toolRegistry.Register(tool)
+	return nil
+}
+
+func (a *Agent) closePlugins() {
+	for _, p := range a.plugins {
+		p.Close()
+	}
+	a.plugins = nil
+}
@@ -0,0 +1,147 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Response is a completed assistant message as seen by post-processors
+type Response struct {
+	Turn       int                // User turn the message answers, counting from 1
+	Message    *anthropic.Message // The full message, including any tool calls
+	Text       string             // All text blocks joined together
+	CodeBlocks []CodeBlock        // Fenced code blocks in Text, in order
+}
+
+// CodeBlock is a fenced code block found in a response
+type CodeBlock struct {
+	Language string // First word of the info string, e.g. "go" (empty when unlabelled)
+	Path     string // File the block is for, from "```go:main.go", "```go title=main.go" or a path on the line above
+	Info     string // The whole info string after the fence
+	Code     string // Contents, ending in a newline unless empty
+	Line     int    // Line of the opening fence in Text, counting from 1
+}
+
+// PostProcessor inspects each completed assistant message, for instance to apply the code blocks it
+// proposes or collect TODOs. An error is reported as a notice and does not stop the turn.
+type PostProcessor func(ctx context.Context, response Response) error
+
+// AddPostProcessor registers p to run after every assistant message, after any registered before it
+func (a *Agent) AddPostProcessor(p PostProcessor) {
+	a.postProcessors = append(a.postProcessors, p)
+}
+
+// postProcess hands a finished message to the registered post-processors
+func (a *Agent) postProcess(ctx context.Context, message *anthropic.Message) {
+	if len(a.postProcessors) == 0 {
+		return
+	}
+	var text strings.Builder
+	for _, block := range message.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	response := Response{
+		Turn:       a.turn + 1,
+		Message:    message,
+		Text:       text.String(),
+		CodeBlocks: ParseCodeBlocks(text.String()),
+	}
+	for i, p := range a.postProcessors {
+		if err := p(ctx, response); err != nil {
+			a.events.OnNotice("Post-processor", fmt.Sprintf("post-processor %d failed: %v", i+1, err))
+		}
+	}
+}
+
+// ParseCodeBlocks finds the fenced code blocks in Markdown text. A block left open at the end of the
+// text is not returned, since it was probably cut off.
+func ParseCodeBlocks(text string) []CodeBlock {
+	var blocks []CodeBlock
+	lines := strings.SplitAfter(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		fence, info, ok := ui.ParseFence(lines[i])
+		if !ok {
+			continue
+		}
+		var code strings.Builder
+		closed := false
+		j := i + 1
+		for ; j < len(lines); j++ {
+			if ui.ClosesFence(lines[j], fence) {
+				closed = true
+				break
+			}
+			code.WriteString(lines[j])
+		}
+		if !closed {
+			break
+		}
+
+		block := CodeBlock{Info: info, Code: code.String(), Line: i + 1}
+		block.Language, block.Path = parseInfo(info)
+		if block.Path == "" && i > 0 {
+			block.Path = headerPath(lines[i-1])
+		}
+		blocks = append(blocks, block)
+		i = j
+	}
+	return blocks
+}
+
+// parseInfo splits an info string such as "go:main.go", "go title=main.go" or "main.go" into a language
+// and a file path
+func parseInfo(info string) (language, filePath string) {
+	fields := strings.Fields(info)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	language = fields[0]
+	if before, after, found := strings.Cut(language, ":"); found {
+		language, filePath = before, after
+	}
+	for _, field := range fields[1:] {
+		for _, key := range []string{"title=", "file=", "path=", "filename="} {
+			if value, found := strings.CutPrefix(field, key); found && filePath == "" {
+				filePath = strings.Trim(value, `"'`)
+			}
+		}
+	}
+	// A bare file name in place of the language, as in "```main.go"
+	if filePath == "" && looksLikePath(language) {
+		filePath = language
+		language = strings.TrimPrefix(path.Ext(language), ".")
+	}
+	return language, filePath
+}
+
+// headerPath returns the file named by a header line just above a fence, such as "main.go:",
+// "**cmd/main.go**", "`main.go`" or "### main.go", or "" if the line is not just a path
+func headerPath(line string) string {
+	candidate := strings.TrimSpace(line)
+	candidate = strings.TrimLeft(candidate, "# ")
+	candidate = strings.TrimSuffix(candidate, ":")
+	candidate = strings.Trim(candidate, "*_`")
+	candidate = strings.TrimSuffix(candidate, ":")
+	if strings.ContainsAny(candidate, " \t") || !looksLikePath(candidate) {
+		return ""
+	}
+	return candidate
+}
+
+// looksLikePath reports whether s could be a relative file path: a name with an extension, possibly in
+// directories
+func looksLikePath(s string) bool {
+	if s == "" || strings.ContainsAny(s, " \t`*") || strings.Contains(s, "://") {
+		return false
+	}
+	ext := path.Ext(s)
+	return len(ext) > 1 && len(ext) <= 8 && !strings.HasSuffix(s, ".")
+}
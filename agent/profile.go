@@ -0,0 +1,170 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"anthropic-chat/config"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// profileOff switches back from a profile to the regular configuration
+const profileOff = "off"
+
+// setProfile makes the named profile active, or none for "" and profileOff. Its system prompt replaces
+// the regular one right away; the model it wants is returned for the caller to switch to, since that
+// may mean compacting the conversation.
+func (a *Agent) setProfile(name string) (string, error) {
+	if name == "" || name == profileOff {
+		a.profile = ""
+		a.promptTemplate = a.basePrompt
+		a.renderSystemPrompt()
+		return a.baseModel, nil
+	}
+	profile, ok := a.config.Agent.Profiles[name]
+	if !ok {
+		return "", fmt.Errorf("unknown profile %q (%s)", name, profileNames(a.config.Agent.Profiles))
+	}
+	prompt, err := profile.ProfilePrompt()
+	if err != nil {
+		return "", err
+	}
+	template := a.basePrompt
+	if prompt != "" {
+		template = prompt
+		if a.config.Security.ReadOnly {
+			template += readOnlyPrompt
+		}
+	}
+	if a.profile == "" {
+		// Remember the model in use, which /model may have changed, so /profile off goes back to it
+		a.baseModel = a.config.Model().ID
+	}
+	a.profile = name
+	a.promptTemplate = template
+	a.renderSystemPrompt()
+	if profile.Model == "" {
+		return a.baseModel, nil
+	}
+	return profile.Model, nil
+}
+
+// activeProfile returns the profile in use, if any
+func (a *Agent) activeProfile() (config.Profile, bool) {
+	if a.profile == "" {
+		return config.Profile{}, false
+	}
+	profile, ok := a.config.Agent.Profiles[a.profile]
+	return profile, ok
+}
+
+// profileAllows reports whether the active profile offers the tool to the model
+func (a *Agent) profileAllows(name string) bool {
+	if name == a.answerTool && name != "" {
+		return true // The run can't end without it
+	}
+	profile, ok := a.activeProfile()
+	return !ok || len(profile.Tools) == 0 || slices.Contains(profile.Tools, name)
+}
+
+// temperature returns the active profile's sampling temperature, if it sets one
+func (a *Agent) temperature() (float64, bool) {
+	profile, ok := a.activeProfile()
+	if !ok || profile.Temperature == nil {
+		return 0, false
+	}
+	return *profile.Temperature, true
+}
+
+// profileCommand handles /profile: with no argument it lists the profiles, otherwise it switches to one
+// (or back to the regular configuration with "off") and returns the conversation, compacted if the
+// profile's model has a smaller context window
+func (a *Agent) profileCommand(ctx context.Context, name string, conversation []anthropic.MessageParam) []anthropic.MessageParam {
+	if name == "" {
+		fmt.Printf("%s\n%s\n", a.uiManager.Paint(ui.StyleInfo, "Profiles:"), a.profileList())
+		return conversation
+	}
+	model, err := a.setProfile(name)
+	if err != nil {
+		fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+		return conversation
+	}
+	if model != a.config.Model().ID {
+		switched, err := a.switchModel(ctx, model, conversation)
+		if err != nil {
+			fmt.Printf("%s: %v\n", a.uiManager.Paint(ui.StyleWarning, "Warning"), err)
+		} else {
+			conversation = switched
+		}
+	}
+	if a.profile == "" {
+		fmt.Printf("%s regular configuration (%s)\n\n", a.uiManager.Paint(ui.StyleSuccess, "Profile:"), a.config.Model().ID)
+	} else {
+		fmt.Printf("%s %s (%s)\n\n", a.uiManager.Paint(ui.StyleSuccess, "Profile:"), a.profile, a.profileSummary(a.config.Agent.Profiles[a.profile]))
+	}
+	return conversation
+}
+
+// profileList shows each profile with what it changes, marking the active one
+func (a *Agent) profileList() string {
+	if len(a.config.Agent.Profiles) == 0 {
+		return fmt.Sprintf("  No profiles are defined; add them under profiles: in %s\n", config.SettingsFile())
+	}
+	var b strings.Builder
+	for _, name := range sortedProfiles(a.config.Agent.Profiles) {
+		profile := a.config.Agent.Profiles[name]
+		marker := " "
+		if name == a.profile {
+			marker = "*"
+		}
+		fmt.Fprintf(&b, " %s %s", marker, name)
+		if profile.Description != "" {
+			fmt.Fprintf(&b, " - %s", profile.Description)
+		}
+		fmt.Fprintf(&b, " (%s)\n", a.profileSummary(profile))
+	}
+	return b.String()
+}
+
+// profileSummary lists what a profile changes, e.g. "model claude-3-5-haiku-latest; tools: read_file, list_files"
+func (a *Agent) profileSummary(profile config.Profile) string {
+	var parts []string
+	if profile.Model != "" {
+		parts = append(parts, "model "+profile.Model)
+	}
+	if profile.SystemPrompt != "" || profile.SystemPromptFile != "" {
+		parts = append(parts, "own prompt")
+	}
+	if len(profile.Tools) > 0 {
+		parts = append(parts, "tools: "+strings.Join(profile.Tools, ", "))
+	}
+	if profile.Temperature != nil {
+		parts = append(parts, "temperature "+ui.FormatDecimal(*profile.Temperature, 1))
+	}
+	if len(parts) == 0 {
+		return "no changes"
+	}
+	return strings.Join(parts, "; ")
+}
+
+func sortedProfiles(profiles map[string]config.Profile) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// profileNames lists the defined profiles for error messages
+func profileNames(profiles map[string]config.Profile) string {
+	if len(profiles) == 0 {
+		return "no profiles are defined in " + config.SettingsFile()
+	}
+	return "available: " + strings.Join(sortedProfiles(profiles), ", ")
+}
@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"anthropic-chat/project"
+)
+
+// loadProject finds the .goocode directory for the working directory and applies its settings. When
+// the approval policy can't be read, or the user doesn't trust the one the repository provides, the
+// agent is left without one, so gated actions ask.
+func (a *Agent) loadProject() error {
+	a.project, _ = project.Find(a.workingDir)
+	if a.project != nil && a.project.Exists() {
+		_ = a.project.EnsureGitignore()
+	}
+	a.approvalPolicy = nil
+	file := a.config.Security.ApprovalPolicy
+	if file == "" && a.project != nil {
+		file = a.project.Permissions()
+		if file != "" && !a.trustProjectPolicy(file) {
+			return nil
+		}
+	}
+	policy, err := loadApprovalPolicy(file)
+	if err != nil {
+		return err
+	}
+	a.approvalPolicy = policy
+	if policy != nil {
+		a.events.OnNotice("Approval", "Using the approval policy in "+file)
+	}
+	return nil
+}
+
+// projectDir returns the working tree's project directory, found or not yet created
+func (a *Agent) projectDir() *project.Project {
+	if a.project != nil {
+		return a.project
+	}
+	return project.At(a.workingDir)
+}
+
+// projectContext is the system prompt section built from the project brief, instructions and memory
+func (a *Agent) projectContext() string {
+	dir := a.projectDir()
+	var b strings.Builder
+	if brief := dir.Brief(); brief != "" {
+		b.WriteString("\n\n# Project brief (" + project.BriefFile + ")\n" + brief)
+	}
+	if instructions := dir.Instructions(); instructions != "" {
+		b.WriteString("\n\n# Project instructions\n" + instructions)
+	}
+	if memory := dir.Memory(); memory != "" {
+		b.WriteString("\n\n# Project memory\nNotes kept from earlier sessions:\n" + memory)
+	}
+	return b.String()
+}
+
+// checkpointDir is where working tree snapshots go: the project's snapshots directory when there is one
+func (a *Agent) checkpointDir() string {
+	if a.project != nil {
+		return a.project.Path(project.SnapshotsDir)
+	}
+	return a.config.Session.CheckpointDir
+}
+
+// knowledgeDir is where knowledge bases are stored: the project's index directory when there is one
+func (a *Agent) knowledgeDir() string {
+	if a.project != nil {
+		return a.project.Path(project.IndexDir)
+	}
+	return a.config.Knowledge.Dir
+}
+
+// customCommand expands input when it invokes one of the custom slash commands
+func (a *Agent) customCommand(input string) (string, bool) {
+	if !strings.HasPrefix(input, "/") {
+		return "", false
+	}
+	name, args, _ := strings.Cut(strings.TrimPrefix(input, "/"), " ")
+	for _, command := range a.customCommands() {
+		if command.Name == name {
+			return command.Expand(strings.TrimSpace(args)), true
+		}
+	}
+	return "", false
+}
+
+// slashCommand is a custom slash command and where it was defined
+type slashCommand struct {
+	project.Command
+	personal bool // From ~/.goocode/commands rather than the project
+}
+
+// customCommands merges the user's personal commands with the project's; the project's win name clashes
+// so everyone on a team gets the same workflow
+func (a *Agent) customCommands() []slashCommand {
+	var commands []slashCommand
+	names := map[string]bool{}
+	if a.project != nil {
+		for _, command := range a.project.Commands() {
+			commands = append(commands, slashCommand{Command: command})
+			names[command.Name] = true
+		}
+	}
+	for _, command := range project.LoadCommands(a.config.Agent.CommandsDir) {
+		if !names[command.Name] {
+			commands = append(commands, slashCommand{Command: command, personal: true})
+		}
+	}
+	sort.Slice(commands, func(i, j int) bool { return commands[i].Name < commands[j].Name })
+	return commands
+}
+
+// customCommandList describes the custom commands for the welcome screen
+func (a *Agent) customCommandList() string {
+	var b strings.Builder
+	for _, command := range a.customCommands() {
+		source := ""
+		if command.personal {
+			source = " (personal)"
+		}
+		fmt.Fprintf(&b, "  /%s - %s%s\n", command.Name, command.Description, source)
+	}
+	return b.String()
+}
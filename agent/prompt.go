@@ -0,0 +1,81 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/exec"
+	"os/user"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// gitBranchTimeout bounds the git call made while rendering the system prompt
+const gitBranchTimeout = 2 * time.Second
+
+// promptData is what system prompt templates can refer to, as in {{.WorkingDir}} or {{.Vars.team}}
+type promptData struct {
+	WorkingDir string
+	OS         string
+	Arch       string
+	Date       string // YYYY-MM-DD
+	GitBranch  string // Empty outside a git repository
+	User       string
+	Vars       map[string]string // From GOOCODE_PROMPT_VAR_<NAME>, keyed by lowercase name
+}
+
+// renderSystemPrompt fills in the system prompt template for the current working directory.
+// A template that doesn't parse or execute is used as plain text.
+func (a *Agent) renderSystemPrompt() {
+	a.systemPrompt = a.promptTemplate
+	if !strings.Contains(a.promptTemplate, "{{") {
+		return
+	}
+	tmpl, err := template.New("system prompt").Option("missingkey=zero").Parse(a.promptTemplate)
+	if err != nil {
+		log.Printf("Warning: system prompt template: %v. Using it unrendered.", err)
+		return
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, a.promptData()); err != nil {
+		log.Printf("Warning: system prompt template: %v. Using it unrendered.", err)
+		return
+	}
+	a.systemPrompt = b.String()
+}
+
+func (a *Agent) promptData() promptData {
+	data := promptData{
+		WorkingDir: a.workingDir,
+		OS:         runtime.GOOS,
+		Arch:       runtime.GOARCH,
+		Date:       time.Now().Format(time.DateOnly),
+		GitBranch:  gitBranch(a.workingDir),
+		Vars:       a.config.Agent.PromptVars,
+	}
+	if current, err := user.Current(); err == nil {
+		data.User = current.Username
+	} else {
+		data.User = os.Getenv("USER")
+	}
+	return data
+}
+
+// gitBranch returns the branch checked out in dir, or the short commit on a detached HEAD
+func gitBranch(dir string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), gitBranchTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	branch := strings.TrimSpace(string(out))
+	if branch == "HEAD" {
+		if out, err = exec.CommandContext(ctx, "git", "-C", dir, "rev-parse", "--short", "HEAD").Output(); err == nil {
+			branch = strings.TrimSpace(string(out))
+		}
+	}
+	return branch
+}
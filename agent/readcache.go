@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"os"
+	"time"
+
+	"anthropic-chat/config"
+)
+
+// readCacheEntry is a file version the model has already been shown
+type readCacheEntry struct {
+	size    int64
+	modTime time.Time
+	content []byte
+}
+
+// CachedRead returns what was last read from fullPath if the file hasn't changed since
+func (a *Agent) CachedRead(fullPath string, info os.FileInfo) ([]byte, bool) {
+	entry, ok := a.reads[fullPath]
+	if !ok || entry.size != info.Size() || !entry.modTime.Equal(info.ModTime()) {
+		return nil, false
+	}
+	return entry.content, true
+}
+
+// RememberRead records that the model has seen content as the version of fullPath described by info
+func (a *Agent) RememberRead(fullPath string, info os.FileInfo, content []byte) {
+	if a.reads == nil || a.readBytes+len(content) > config.ReadCacheMaxBytes {
+		a.forgetReads()
+	}
+	if previous, ok := a.reads[fullPath]; ok {
+		a.readBytes -= len(previous.content)
+	}
+	a.reads[fullPath] = readCacheEntry{size: info.Size(), modTime: info.ModTime(), content: content}
+	a.readBytes += len(content)
+	a.noteWorkspaceFile(fullPath, info, false)
+}
+
+// forgetReads empties the read cache, for when compaction removed file contents from the conversation
+func (a *Agent) forgetReads() {
+	a.reads = make(map[string]readCacheEntry)
+	a.readBytes = 0
+}
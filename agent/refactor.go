@@ -0,0 +1,238 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"anthropic-chat/checkpoint"
+	"anthropic-chat/tools/testrunner"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const maxVerifyOutput = 4000
+
+const refactorPlanPrompt = `I want to carry out this refactor: %s
+
+Explore the relevant code first if you need to. Do NOT change any files yet.
+Then reply with a step plan as a JSON array inside a ` + "```json" + ` code block, where each element is
+{"title": "...", "description": "..."}. Each step must be small enough to implement and verify on its own,
+and the project should build and pass its tests after every step.`
+
+const refactorStepPrompt = `Refactor goal: %s
+
+Implement step %d of %d now: %s
+%s
+
+Only make the changes for this step. The project will be built and tested automatically when you finish.`
+
+// refactorStep is one entry of the model-produced plan
+type refactorStep struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+var jsonBlockPattern = regexp.MustCompile("(?s)```(?:json)?\\s*(\\[.*?\\])\\s*```")
+
+// runRefactor drives the guided refactor workflow: plan, then one verified and checkpointed step per turn
+func (a *Agent) runRefactor(ctx context.Context, goal string, conversation []anthropic.MessageParam) ([]anthropic.MessageParam, error) {
+	conversation, err := a.RunTurn(ctx, conversation, fmt.Sprintf(refactorPlanPrompt, goal))
+	if err != nil {
+		return conversation, err
+	}
+
+	steps, err := parseRefactorPlan(lastAssistantText(conversation))
+	if err != nil {
+		a.printRefactor(ui.StyleError, "Could not read a step plan from the response: %v", err)
+		return conversation, nil
+	}
+
+	a.printRefactor(ui.StyleNotice, "Plan with %d steps:", len(steps))
+	for i, step := range steps {
+		fmt.Printf("  %d. %s\n", i+1, step.Title)
+	}
+	if !a.confirm("Start the refactor? [y/N] ", false) {
+		a.printRefactor(ui.StyleNotice, "Refactor cancelled")
+		return conversation, nil
+	}
+
+	store := checkpoint.NewStore(a.checkpointDir(), a.workingDir)
+	for i, step := range steps {
+		cp, err := store.Create(fmt.Sprintf("refactor step %d: %s", i+1, step.Title))
+		if err != nil {
+			return conversation, err
+		}
+		a.printRefactor(ui.StyleNotice, "Step %d/%d: %s (checkpoint %s)", i+1, len(steps), step.Title, cp.ID)
+		stepStart := a.workspaceSeq
+
+		conversation, err = a.RunTurn(ctx, conversation, fmt.Sprintf(refactorStepPrompt, goal, i+1, len(steps), step.Title, step.Description))
+		if err != nil {
+			return conversation, err
+		}
+
+		verified, unverifiable := false, false
+		for attempt := 0; ; attempt++ {
+			ok, report, err := a.verifyRefactorStep(ctx)
+			if err != nil {
+				a.printRefactor(ui.StyleWarning, "Step %d could not be verified: %v", i+1, err)
+				unverifiable = true
+				break
+			}
+			if ok {
+				a.printRefactor(ui.StyleSuccess, "Step %d verified", i+1)
+				verified = true
+				break
+			}
+			a.printRefactor(ui.StyleError, "Step %d failed verification", i+1)
+			if attempt >= a.config.Refactor.MaxFixAttempts {
+				break
+			}
+			conversation, err = a.RunTurn(ctx, conversation, fmt.Sprintf("Verification failed after step %d:\n%s\n\nFix the problem. Do not start the next step.", i+1, report))
+			if err != nil {
+				return conversation, err
+			}
+		}
+		a.saveSession(ctx, conversation)
+
+		if !verified {
+			if unverifiable {
+				fmt.Print("Set GOOCODE_VERIFY_COMMAND to a command that checks the project. [r]ollback this step, [c]ontinue unverified, or [a]bort? ")
+			} else {
+				fmt.Print("Verification still failing. [r]ollback this step, [c]ontinue anyway, or [a]bort? ")
+			}
+			answer, _ := a.getUserMessage()
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "c", "continue":
+			case "r", "rollback":
+				return a.rollbackRefactor(store, cp, i+1, stepStart, conversation), nil
+			default:
+				a.printRefactor(ui.StyleNotice, "Refactor stopped after step %d; files left as they are", i+1)
+				return conversation, nil
+			}
+		}
+
+		last := i == len(steps)-1
+		reviewEvery := a.config.Refactor.ReviewEvery
+		if !last && reviewEvery > 0 && (i+1)%reviewEvery == 0 {
+			fmt.Printf("Continue with step %d (%s)? [Y/n/rollback] ", i+2, steps[i+1].Title)
+			answer, _ := a.getUserMessage()
+			switch strings.ToLower(strings.TrimSpace(answer)) {
+			case "", "y", "yes":
+			case "rollback", "r":
+				return a.rollbackRefactor(store, cp, i+1, stepStart, conversation), nil
+			default:
+				a.printRefactor(ui.StyleNotice, "Refactor paused after step %d", i+1)
+				return conversation, nil
+			}
+		}
+	}
+
+	a.printRefactor(ui.StyleSuccess, "Refactor complete: %d steps applied", len(steps))
+	return conversation, nil
+}
+
+// rollbackRefactor restores the checkpoint taken before step and tells the model about it. Only files
+// the agent wrote since stepStart are deleted, not ones the user created in the meantime.
+func (a *Agent) rollbackRefactor(store *checkpoint.Store, cp *checkpoint.Checkpoint, step, stepStart int, conversation []anthropic.MessageParam) []anthropic.MessageParam {
+	if err := store.Restore(cp.ID, a.writtenSince(stepStart)); err != nil {
+		a.printRefactor(ui.StyleError, "Rollback failed: %v", err)
+		return conversation
+	}
+	a.printRefactor(ui.StyleNotice, "Restored checkpoint %s; step %d was undone", cp.ID, step)
+	note := fmt.Sprintf("[NOTE] The user rolled back refactor step %d. All file changes from that step were discarded; files are as they were before it.", step)
+	return append(conversation, anthropic.NewUserMessage(anthropic.NewTextBlock(note)))
+}
+
+// verifyRefactorStep runs the configured verify command, or the project's tests when none is set. It
+// returns an error when there is nothing to verify with, which doesn't count as passing.
+func (a *Agent) verifyRefactorStep(ctx context.Context) (bool, string, error) {
+	if command := a.config.Refactor.VerifyCommand; command != "" {
+		shell, flag := "sh", "-c"
+		if runtime.GOOS == "windows" {
+			shell, flag = "cmd", "/C"
+		}
+		cmd := exec.CommandContext(ctx, shell, flag, command)
+		cmd.Dir = a.workingDir
+		var output bytes.Buffer
+		cmd.Stdout = &output
+		cmd.Stderr = &output
+		err := cmd.Run()
+
+		report := output.String()
+		if len(report) > maxVerifyOutput {
+			report = "...\n" + report[len(report)-maxVerifyOutput:]
+		}
+		return err == nil, fmt.Sprintf("$ %s\n%s", command, report), nil
+	}
+
+	summary, err := testrunner.Run(ctx, a.workingDir, a.workingDir, "", "", 0)
+	if err != nil {
+		return false, "", err
+	}
+	report, _ := json.MarshalIndent(summary, "", "  ")
+	return summary.Success, string(report), nil
+}
+
+func (a *Agent) printRefactor(style ui.Style, format string, args ...interface{}) {
+	fmt.Printf("%s: %s\n", a.uiManager.Paint(style, "[Refactor]"), fmt.Sprintf(format, args...))
+}
+
+// confirm asks a yes/no question, returning def on empty input
+func (a *Agent) confirm(prompt string, def bool) bool {
+	fmt.Print(prompt)
+	answer, ok := a.getUserMessage()
+	if !ok {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	case "n", "no":
+		return false
+	default:
+		return def
+	}
+}
+
+// parseRefactorPlan extracts the JSON step array from the model's reply
+func parseRefactorPlan(text string) ([]refactorStep, error) {
+	raw := text
+	if match := jsonBlockPattern.FindStringSubmatch(text); match != nil {
+		raw = match[1]
+	} else if start, end := strings.Index(text, "["), strings.LastIndex(text, "]"); start >= 0 && end > start {
+		raw = text[start : end+1]
+	}
+
+	var steps []refactorStep
+	if err := json.Unmarshal([]byte(raw), &steps); err != nil {
+		return nil, err
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("plan has no steps")
+	}
+	return steps, nil
+}
+
+// lastAssistantText returns the text of the most recent assistant message
+func lastAssistantText(conversation []anthropic.MessageParam) string {
+	for i := len(conversation) - 1; i >= 0; i-- {
+		if conversation[i].Role != anthropic.MessageParamRoleAssistant {
+			continue
+		}
+		var text strings.Builder
+		for _, block := range conversation[i].Content {
+			if block.OfText != nil {
+				text.WriteString(block.OfText.Text)
+			}
+		}
+		return text.String()
+	}
+	return ""
+}
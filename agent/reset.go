@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+
+	"anthropic-chat/compaction"
+	"anthropic-chat/session"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// clearConversation handles /clear: the conversation is dropped and its session moved to the trash,
+// while settings such as the model, working directory and verbosity stay as they are
+func (a *Agent) clearConversation(conversation []anthropic.MessageParam) {
+	previous := a.session.ID
+	trashed := false
+	if len(conversation) > 0 {
+		// Sessions are saved after every turn, so there is one to trash unless the first request never went out
+		if err := a.sessionStore.Delete(previous); err == nil {
+			trashed = true
+		}
+	}
+	dropped := a.startFresh()
+
+	message := fmt.Sprintf("Conversation cleared (%d messages%s)", len(conversation), dropped)
+	if trashed {
+		message += fmt.Sprintf("; restore it with `goocode sessions restore %s`", previous)
+	}
+	fmt.Printf("%s: %s\n\n", a.uiManager.Paint(ui.StyleSuccess, "Cleared"), message)
+}
+
+// newConversation handles /new: the current session is saved and a fresh one started
+func (a *Agent) newConversation(ctx context.Context, conversation []anthropic.MessageParam) {
+	saved := ""
+	if len(conversation) > 0 {
+		a.saveSession(ctx, conversation)
+		saved = fmt.Sprintf("Saved session %s", a.session.ID)
+		if a.session.Title != "" {
+			saved += fmt.Sprintf(" (%s)", a.session.Title)
+		}
+		saved += "; "
+	}
+	dropped := a.startFresh()
+	fmt.Printf("%s: %sstarted session %s%s\n\n", a.uiManager.Paint(ui.StyleSuccess, "New session"), saved, a.session.ID, dropped)
+}
+
+// startFresh begins a new session and forgets the state tied to the old conversation: pinned files and
+// notes, the task list, the read cache, compaction summaries and the turn count behind pin expiry and the token meter.
+// It returns a note on the pins that were dropped, if any.
+func (a *Agent) startFresh() string {
+	dropped := ""
+	if pins := len(a.pins) + len(a.pinnedMessages); pins > 0 {
+		dropped = fmt.Sprintf(", %d pin(s) dropped", pins)
+	}
+
+	a.session = session.New(a.workingDir)
+	a.pins = nil
+	a.pinnedMessages = nil
+	a.nextMessagePin = 0
+	a.forgetReads()
+	a.workspace = nil
+	a.stopBackgroundCompaction()
+	// Strategies such as hierarchical keep summaries of the conversation they compacted
+	if strategy, err := compaction.New(a.config.CompactionStrategy()); err == nil {
+		a.compaction = strategy
+	} else {
+		a.compaction, _ = compaction.New(compaction.DefaultStrategy)
+	}
+	a.turn = 0
+	a.lastResponse = ""
+	a.recalled = nil
+	return dropped
+}
@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"anthropic-chat/cassette"
+	"anthropic-chat/provider"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// resumable reports whether a failed stream is worth resuming: dropped connections and server-side
+// failures are, cancellations, requests the API rejected and diverged replays are not
+func resumable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil || errors.Is(err, context.Canceled) || provider.IsContextLengthError(err) || errors.Is(err, cassette.ErrDiverged) {
+		return false
+	}
+	var apiErr *anthropic.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= http.StatusInternalServerError || apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// partialText returns the text a cut-off message starts with. Anything after its first non-text block,
+// such as a tool call that may be incomplete, is dropped and regenerated by the resumed request.
+func partialText(message *anthropic.Message) string {
+	if message == nil {
+		return ""
+	}
+	var text strings.Builder
+	for _, block := range message.Content {
+		if block.Type != "text" {
+			break
+		}
+		text.WriteString(block.Text)
+	}
+	return text.String()
+}
+
+// allText reports whether a message holds nothing but text, so a cut-off one can be continued by prefilling it
+func allText(message *anthropic.Message) bool {
+	for _, block := range message.Content {
+		if block.Type != "text" {
+			return false
+		}
+	}
+	return true
+}
+
+// resumedMessage stitches the text received before a stream was cut off onto the message that continued it
+func resumedMessage(received string, continuation *anthropic.Message) (*anthropic.Message, error) {
+	rest := continuation.Content
+	if len(rest) > 0 && rest[0].Type == "text" {
+		received += rest[0].Text
+		rest = rest[1:]
+	}
+	data, err := json.Marshal(anthropic.NewTextBlock(received))
+	if err != nil {
+		return nil, fmt.Errorf("failed to stitch resumed message: %w", err)
+	}
+	var first anthropic.ContentBlockUnion
+	if err := first.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("failed to stitch resumed message: %w", err)
+	}
+	stitched := *continuation
+	stitched.Content = append([]anthropic.ContentBlockUnion{first}, rest...)
+
+	// Round-trip through JSON so the message carries the raw JSON the SDK's accessors read
+	if data, err = json.Marshal(stitched); err != nil {
+		return nil, fmt.Errorf("failed to stitch resumed message: %w", err)
+	}
+	var message anthropic.Message
+	if err := message.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("failed to stitch resumed message: %w", err)
+	}
+	return &message, nil
+}
@@ -0,0 +1,103 @@
+package agent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"anthropic-chat/config"
+	"anthropic-chat/ui"
+)
+
+// ToolStat aggregates the invocations of one tool
+type ToolStat struct {
+	Name        string
+	Calls       int
+	Errors      int
+	Duration    time.Duration // Cumulative execution time
+	OutputBytes int
+}
+
+// AverageDuration returns the mean execution time per call
+func (s ToolStat) AverageDuration() time.Duration {
+	if s.Calls == 0 {
+		return 0
+	}
+	return s.Duration / time.Duration(s.Calls)
+}
+
+// recordToolCall adds one tool invocation to the statistics
+func (a *Agent) recordToolCall(name string, duration time.Duration, output string, failed bool) {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	if a.toolStats == nil {
+		a.toolStats = make(map[string]*ToolStat)
+	}
+	stat, ok := a.toolStats[name]
+	if !ok {
+		stat = &ToolStat{Name: name}
+		a.toolStats[name] = stat
+	}
+	stat.Calls++
+	stat.Duration += duration
+	stat.OutputBytes += len(output)
+	if failed {
+		stat.Errors++
+	}
+}
+
+// ToolStats returns per-tool statistics for this session, slowest cumulative time first
+func (a *Agent) ToolStats() []ToolStat {
+	a.statsMu.Lock()
+	defer a.statsMu.Unlock()
+	stats := make([]ToolStat, 0, len(a.toolStats))
+	for _, stat := range a.toolStats {
+		stats = append(stats, *stat)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Duration != stats[j].Duration {
+			return stats[i].Duration > stats[j].Duration
+		}
+		return stats[i].Name < stats[j].Name
+	})
+	return stats
+}
+
+// showSessionReport prints the session summary and the tool usage as an interactive session ends
+func (a *Agent) showSessionReport() {
+	if a.uiManager.Verbosity() == config.UIQuiet {
+		return
+	}
+	a.showExitSummary()
+	if report := formatToolStats(a.ToolStats()); report != "" {
+		fmt.Printf("\n%s\n%s", a.uiManager.Paint(ui.StyleInfo, "Tool usage this session:"), report)
+	}
+}
+
+// formatToolStats renders the statistics as a table, or "" when no tools have run
+func formatToolStats(stats []ToolStat) string {
+	if len(stats) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "  %-22s %6s %7s %10s %10s %10s\n", "TOOL", "CALLS", "ERRORS", "TOTAL", "AVG", "OUTPUT")
+	var total ToolStat
+	for _, stat := range stats {
+		fmt.Fprintf(&b, "  %-22s %6s %7s %10s %10s %10s\n", stat.Name, ui.FormatCount(stat.Calls), errorRate(stat), ui.FormatDuration(stat.Duration), ui.FormatDuration(stat.AverageDuration()), ui.FormatBytes(int64(stat.OutputBytes)))
+		total.Calls += stat.Calls
+		total.Errors += stat.Errors
+		total.Duration += stat.Duration
+		total.OutputBytes += stat.OutputBytes
+	}
+	fmt.Fprintf(&b, "  %-22s %6s %7s %10s %10s %10s\n", "total", ui.FormatCount(total.Calls), errorRate(total), ui.FormatDuration(total.Duration), ui.FormatDuration(total.AverageDuration()), ui.FormatBytes(int64(total.OutputBytes)))
+	return b.String()
+}
+
+func errorRate(stat ToolStat) string {
+	if stat.Calls == 0 {
+		return ui.FormatDecimal(0, 0) + "%"
+	}
+	return ui.FormatDecimal(float64(stat.Errors)/float64(stat.Calls)*100, 0) + "%"
+}
@@ -0,0 +1,21 @@
+package agent
+
+import (
+	"strings"
+
+	"anthropic-chat/tools"
+)
+
+// pathSuggestions offers near-matches when a tool failed on a path that doesn't exist, so a typo costs
+// one corrected call instead of a search
+func (a *Agent) pathSuggestions(err error) string {
+	missing, ok := tools.MissingPath(err)
+	if !ok {
+		return ""
+	}
+	suggestions := tools.SuggestPaths(a.workingDir, missing, a.Ignored)
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return "\nDid you mean: " + strings.Join(suggestions, ", ") + "?"
+}
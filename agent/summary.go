@@ -0,0 +1,159 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"anthropic-chat/checkpoint"
+	"anthropic-chat/config"
+	"anthropic-chat/ui"
+)
+
+// sessionStartLabel marks the snapshot taken when an interactive session starts
+const sessionStartLabel = "session start"
+
+// sessionSnapshot is the working tree as it was before the session first changed it, for /summary
+type sessionSnapshot struct {
+	store      *checkpoint.Store
+	checkpoint *checkpoint.Checkpoint // nil until the first gated action
+	err        error                  // Why the snapshot couldn't be taken
+	workingDir string
+}
+
+// startSessionSnapshot prepares the summary of an interactive session. The tree is copied only when the
+// first action that could change it is approved, so sessions that only read never pay for the copy.
+func (a *Agent) startSessionSnapshot() {
+	if !a.config.Agent.SessionSummary {
+		return
+	}
+	a.snapshot = &sessionSnapshot{workingDir: a.workingDir}
+}
+
+// takeSessionSnapshot snapshots the working tree the first time it is called in a session
+func (a *Agent) takeSessionSnapshot() {
+	if a.snapshot == nil || a.snapshot.checkpoint != nil || a.snapshot.err != nil {
+		return
+	}
+	store := checkpoint.NewStore(a.checkpointDir(), a.snapshot.workingDir)
+	store.Prune(sessionStartLabel, config.SessionSnapshotMaxHours*time.Hour)
+	cp, err := store.Create(sessionStartLabel)
+	if err != nil {
+		log.Printf("Warning: the session summary is unavailable: %v", err)
+		a.snapshot.err = err
+		return
+	}
+	a.snapshot.store, a.snapshot.checkpoint = store, cp
+}
+
+// dropSessionSnapshot deletes the session start snapshot
+func (a *Agent) dropSessionSnapshot() {
+	if a.snapshot == nil {
+		return
+	}
+	if a.snapshot.checkpoint != nil {
+		if err := a.snapshot.store.Delete(a.snapshot.checkpoint.ID); err != nil {
+			log.Printf("Warning: failed to delete the session snapshot: %v", err)
+		}
+	}
+	a.snapshot = nil
+}
+
+// restartSessionSnapshot summarizes the changes in the previous working directory after /cd and starts
+// tracking the new one
+func (a *Agent) restartSessionSnapshot() {
+	if a.snapshot == nil {
+		return
+	}
+	if a.uiManager.Verbosity() != config.UIQuiet {
+		a.showExitSummary()
+	}
+	a.dropSessionSnapshot()
+	a.commands = nil
+	a.startSessionSnapshot()
+}
+
+// sessionSummary lists the files changed since the session started, with line counts, and the shell
+// commands that ran; changes made outside the agent meanwhile are included too
+func (a *Agent) sessionSummary() (string, error) {
+	switch {
+	case a.snapshot == nil:
+		return "", fmt.Errorf("session summaries are turned off (GOOCODE_SESSION_SUMMARY=false)")
+	case a.snapshot.err != nil:
+		return "", fmt.Errorf("no snapshot of the working tree could be taken: %w", a.snapshot.err)
+	}
+	var changes []checkpoint.Change
+	if a.snapshot.checkpoint != nil {
+		var err error
+		if changes, err = a.snapshot.store.Changes(a.snapshot.checkpoint.ID); err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	if len(changes) == 0 {
+		fmt.Fprintf(&b, "No files changed in %s\n", a.snapshot.workingDir)
+	} else {
+		fmt.Fprintf(&b, "Files changed in %s:\n", a.snapshot.workingDir)
+		added, removed := 0, 0
+		for _, change := range changes {
+			counts := "(binary)"
+			switch {
+			case change.Large:
+				counts = "(too large to count)"
+			case !change.Binary:
+				counts = lineCounts(change.Added, change.Removed)
+				added += change.Added
+				removed += change.Removed
+			}
+			fmt.Fprintf(&b, "  %-9s %-50s %s\n", change.Kind, change.Path, counts)
+		}
+		fmt.Fprintf(&b, "  %d file(s), %s\n", len(changes), lineCounts(added, removed))
+	}
+
+	if len(a.commands) > 0 {
+		fmt.Fprintf(&b, "Commands run (%d):\n", len(a.commands))
+		shown := a.commands
+		if len(shown) > config.SummaryCommands {
+			fmt.Fprintf(&b, "  ... %d earlier\n", len(shown)-config.SummaryCommands)
+			shown = shown[len(shown)-config.SummaryCommands:]
+		}
+		for _, command := range shown {
+			first, _, multiline := strings.Cut(command, "\n")
+			if multiline {
+				first += " ..."
+			}
+			fmt.Fprintf(&b, "  $ %s\n", first)
+		}
+	}
+	return b.String(), nil
+}
+
+// showSessionSummary prints the session summary, or why there is none
+func (a *Agent) showSessionSummary() {
+	summary, err := a.sessionSummary()
+	if err != nil {
+		fmt.Printf("%s: %v\n\n", a.uiManager.Paint(ui.StyleError, "Error"), err)
+		return
+	}
+	fmt.Printf("%s\n%s\n", a.uiManager.Paint(ui.StyleInfo, "Session summary:"), summary)
+}
+
+// showExitSummary prints the session summary on exit, unless nothing was changed or run
+func (a *Agent) showExitSummary() {
+	if a.snapshot == nil || a.snapshot.checkpoint == nil {
+		return
+	}
+	if len(a.commands) == 0 {
+		if changes, err := a.snapshot.store.Changes(a.snapshot.checkpoint.ID); err != nil || len(changes) == 0 {
+			return
+		}
+	}
+	fmt.Println()
+	a.showSessionSummary()
+}
+
+func lineCounts(added, removed int) string {
+	return fmt.Sprintf("+%d -%d", added, removed)
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"anthropic-chat/session"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// sessionPrompt is the system prompt with the session's /system override applied
+func (a *Agent) sessionPrompt() string {
+	override := a.session.Prompt
+	if override == nil {
+		return a.systemPrompt
+	}
+	prompt := a.systemPrompt
+	if override.Replace != "" {
+		prompt = override.Replace
+	}
+	for _, addition := range override.Append {
+		prompt += "\n\n" + addition
+	}
+	return prompt
+}
+
+// systemCommand handles /system: with no argument it shows the system prompt in effect, and otherwise
+// appends to it, replaces it or resets it for the current session. The override is saved with the
+// session, so it stays in force wherever the session is picked up again.
+func (a *Agent) systemCommand(ctx context.Context, arg string, conversation []anthropic.MessageParam) {
+	verb, text, _ := strings.Cut(arg, " ")
+	text = strings.TrimSpace(text)
+	override := a.session.Prompt
+	switch verb {
+	case "":
+		label := "System prompt"
+		if override != nil {
+			label = fmt.Sprintf("System prompt (%s for this session)", overrideSummary(override))
+		}
+		fmt.Printf("%s\n%s\n\n", a.uiManager.Paint(ui.StyleInfo, label+":"), a.sessionPrompt())
+		return
+	case "append", "replace":
+		if text == "" {
+			fmt.Printf("%s: usage: /system %s <text>\n\n", a.uiManager.Paint(ui.StyleError, "Error"), verb)
+			return
+		}
+		if override == nil {
+			override = &session.PromptOverride{}
+		}
+		if verb == "append" {
+			override.Append = append(override.Append, text)
+		} else {
+			override.Replace = text
+		}
+		a.session.Prompt = override
+		fmt.Printf("%s %s for this session\n\n", a.uiManager.Paint(ui.StyleSuccess, "System prompt:"), overrideSummary(override))
+	case "reset":
+		if override == nil {
+			fmt.Printf("%s: The system prompt is not overridden\n\n", a.uiManager.Paint(ui.StyleInfo, "System prompt"))
+			return
+		}
+		a.session.Prompt = nil
+		fmt.Printf("%s back to the configured prompt\n\n", a.uiManager.Paint(ui.StyleSuccess, "System prompt:"))
+	default:
+		fmt.Printf("%s: usage: /system [append <text>|replace <text>|reset]\n\n", a.uiManager.Paint(ui.StyleError, "Error"))
+		return
+	}
+	// An empty conversation is saved with its first turn
+	if len(conversation) > 0 {
+		a.saveSession(ctx, conversation)
+	}
+}
+
+// overrideSummary describes an override, e.g. "replaced, 2 additions"
+func overrideSummary(override *session.PromptOverride) string {
+	var parts []string
+	if override.Replace != "" {
+		parts = append(parts, "replaced")
+	}
+	switch len(override.Append) {
+	case 0:
+	case 1:
+		parts = append(parts, "1 addition")
+	default:
+		parts = append(parts, fmt.Sprintf("%d additions", len(override.Append)))
+	}
+	return strings.Join(parts, ", ")
+}
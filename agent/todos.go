@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"anthropic-chat/session"
+	"anthropic-chat/tools"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// todoMarkers are the checkboxes a task list is drawn with, by status
+var todoMarkers = map[string]string{
+	tools.TodoPending:    "[ ]",
+	tools.TodoInProgress: "[>]",
+	tools.TodoDone:       "[x]",
+}
+
+// SetTodos implements the tools.TodoKeeper interface; the new list is saved with the session and shown
+// after the turn
+func (a *Agent) SetTodos(todos []tools.Todo) {
+	a.session.Todos = make([]session.Todo, len(todos))
+	for i, todo := range todos {
+		a.session.Todos[i] = session.Todo{Content: todo.Content, Status: todo.Status}
+	}
+	a.todosChanged = true
+}
+
+// todoContext renders the task list for the system prompt, so it outlives the turns that wrote it
+func (a *Agent) todoContext() string {
+	if len(a.session.Todos) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\n\n# Task list\nYour task list from manage_todos, as you last left it. It is kept across compaction; update it as you make progress.\n")
+	for _, todo := range a.session.Todos {
+		fmt.Fprintf(&b, "- %s %s\n", todoMarkers[todo.Status], todo.Content)
+	}
+	return b.String()
+}
+
+// showTodos prints the task list with its progress
+func (a *Agent) showTodos() {
+	a.todosChanged = false
+	if len(a.session.Todos) == 0 {
+		fmt.Printf("%s: No task list\n\n", a.uiManager.Paint(ui.StyleInfo, "Tasks"))
+		return
+	}
+	done := 0
+	var b strings.Builder
+	for _, todo := range a.session.Todos {
+		line := todoMarkers[todo.Status] + " " + todo.Content
+		switch todo.Status {
+		case tools.TodoDone:
+			done++
+			line = a.uiManager.Paint(ui.StyleOutput, line)
+		case tools.TodoInProgress:
+			line = a.uiManager.Paint(ui.StyleWarning, line)
+		}
+		b.WriteString("  " + line + "\n")
+	}
+	fmt.Printf("%s\n%s\n", a.uiManager.Paint(ui.StyleInfo, fmt.Sprintf("Tasks (%d of %d done):", done, len(a.session.Todos))), b.String())
+}
+
+// todosCommand handles /todos: it shows the task list, or with "clear" drops it
+func (a *Agent) todosCommand(ctx context.Context, arg string, conversation []anthropic.MessageParam) {
+	switch arg {
+	case "":
+		a.showTodos()
+	case "clear":
+		a.session.Todos = nil
+		a.todosChanged = false
+		fmt.Printf("%s: Task list cleared\n\n", a.uiManager.Paint(ui.StyleSuccess, "Tasks"))
+		// An empty conversation is saved with its first turn
+		if len(conversation) > 0 {
+			a.saveSession(ctx, conversation)
+		}
+	default:
+		fmt.Printf("%s: Usage: /todos [clear]\n\n", a.uiManager.Paint(ui.StyleError, "Error"))
+	}
+}
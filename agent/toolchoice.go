@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"fmt"
+
+	"anthropic-chat/config"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// ForceTool sets the tool choice for the next turn: "any" requires some tool, "none" forbids them and a
+// tool name requires that tool. "auto" or "" cancels it.
+func (a *Agent) ForceTool(choice string) error {
+	if err := a.checkToolChoice(choice); err != nil {
+		return err
+	}
+	a.nextToolChoice = choice
+	if choice == config.ToolChoiceAuto {
+		a.nextToolChoice = ""
+	}
+	return nil
+}
+
+// checkToolChoice rejects choices naming a tool that isn't registered
+func (a *Agent) checkToolChoice(choice string) error {
+	switch choice {
+	case "", config.ToolChoiceAuto, config.ToolChoiceAny, config.ToolChoiceNone:
+		return nil
+	}
+	if _, ok := a.toolRegistry.Get(choice); !ok {
+		return fmt.Errorf("unknown tool %q (expected a tool name, %s, %s or %s)", choice, config.ToolChoiceAny, config.ToolChoiceNone, config.ToolChoiceAuto)
+	}
+	return nil
+}
+
+// startToolChoice picks the choice for a new turn's first request: one forced with ForceTool, otherwise
+// GOOCODE_TOOL_CHOICE
+func (a *Agent) startToolChoice() {
+	choice := a.nextToolChoice
+	a.nextToolChoice = ""
+	if choice == "" {
+		choice = a.config.Agent.ToolChoice
+		if err := a.checkToolChoice(choice); err != nil {
+			a.events.OnNotice("Tool Choice", fmt.Sprintf("GOOCODE_TOOL_CHOICE: %v; using %s", err, config.ToolChoiceAuto))
+			choice = ""
+		}
+	}
+	a.toolChoice = choice
+}
+
+// pendingToolChoice is the choice the next turn will start with, without using up one forced with
+// ForceTool, and whether it was forced
+func (a *Agent) pendingToolChoice() (string, bool) {
+	if a.nextToolChoice != "" {
+		return a.nextToolChoice, true
+	}
+	choice := a.config.Agent.ToolChoice
+	if choice == "" || a.checkToolChoice(choice) != nil {
+		choice = config.ToolChoiceAuto
+	}
+	return choice, false
+}
+
+// relaxToolChoice goes back to letting the model decide after the first response of a turn, so a forced
+// tool isn't called over and over; "none" holds for the whole turn
+func (a *Agent) relaxToolChoice() {
+	if a.toolChoice != config.ToolChoiceNone {
+		a.toolChoice = ""
+	}
+}
+
+// toolChoiceParam translates the active choice into the request parameter; auto is the API default
+func (a *Agent) toolChoiceParam() anthropic.ToolChoiceUnionParam {
+	switch a.toolChoice {
+	case "", config.ToolChoiceAuto:
+		return anthropic.ToolChoiceUnionParam{}
+	case config.ToolChoiceAny:
+		return anthropic.ToolChoiceUnionParam{OfAny: &anthropic.ToolChoiceAnyParam{}}
+	case config.ToolChoiceNone:
+		return anthropic.ToolChoiceUnionParam{OfNone: &anthropic.ToolChoiceNoneParam{}}
+	default:
+		return anthropic.ToolChoiceParamOfTool(a.toolChoice)
+	}
+}
+
+// toolChoiceDescription says what a choice means for the next turn
+func toolChoiceDescription(choice string) string {
+	switch choice {
+	case "", config.ToolChoiceAuto:
+		return "the model decides whether to use tools"
+	case config.ToolChoiceAny:
+		return "the next response must use a tool"
+	case config.ToolChoiceNone:
+		return "the next turn is answered without tools"
+	default:
+		return "the next response must call " + choice
+	}
+}
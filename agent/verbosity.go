@@ -0,0 +1,47 @@
+package agent
+
+import (
+	"fmt"
+
+	"anthropic-chat/config"
+)
+
+// verbosityPrompts steer response length; normal adds nothing so the base prompt is unchanged
+var verbosityPrompts = map[string]string{
+	config.VerbosityTerse: "\n\n# Response length\nBe terse. Make the change or give the answer with at most a sentence or two of explanation. " +
+		"Prefer showing the diff or command over describing it. Skip summaries, caveats and restating the question.",
+	config.VerbosityDetailed: "\n\n# Response length\nBe thorough. Explain your reasoning, the alternatives you considered and the trade-offs, " +
+		"and walk through important changes step by step.",
+}
+
+// SetVerbosity changes the response length preference
+func (a *Agent) SetVerbosity(level string) error {
+	switch level {
+	case config.VerbosityTerse, config.VerbosityNormal, config.VerbosityDetailed:
+	default:
+		return fmt.Errorf("unknown verbosity %q (expected %s, %s or %s)", level, config.VerbosityTerse, config.VerbosityNormal, config.VerbosityDetailed)
+	}
+	a.config.Agent.Verbosity = level
+	return nil
+}
+
+// Verbosity returns the active response length preference
+func (a *Agent) Verbosity() string {
+	if a.config.Agent.Verbosity == "" {
+		return config.VerbosityNormal
+	}
+	return a.config.Agent.Verbosity
+}
+
+// maxOutputTokens scales the output budget with verbosity. Terse keeps enough room for tool calls that write files.
+func (a *Agent) maxOutputTokens() int {
+	limit := a.config.MaxTokens()
+	switch a.Verbosity() {
+	case config.VerbosityTerse:
+		return limit / 2
+	case config.VerbosityDetailed:
+		return min(limit*2, a.config.Model().MaxOutputTokens)
+	default:
+		return limit
+	}
+}
@@ -0,0 +1,115 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"anthropic-chat/config"
+)
+
+// gitWarmStartTimeout bounds each git call made for the warm start
+const gitWarmStartTimeout = 5 * time.Second
+
+// loadGitContext reads the recent git log and the uncommitted changes of the working directory into
+// the system prompt when GOOCODE_GIT_WARM_START is set, so the model knows what was just being worked
+// on. It is read as the session starts and again after /cd, and otherwise left as it was so the prompt
+// stays cacheable.
+func (a *Agent) loadGitContext() {
+	a.gitContext = ""
+	if !a.config.Agent.GitWarmStart {
+		return
+	}
+	history, err := a.git("log", fmt.Sprintf("-%d", config.GitWarmStartCommits), "--date=relative", "--format=%h %s (%an, %ad)")
+	if err != nil {
+		return // Not a git repository, or no commits yet
+	}
+	// Paths are relative to the working directory, and changes outside it are left out
+	status, _ := a.git("status", "--short", "--", ".")
+	status, files := a.visibleStatus(status)
+	diff, _ := a.git("diff", "HEAD", "--relative")
+	diff = a.visibleDiff(diff)
+
+	var b strings.Builder
+	b.WriteString("\n\n# Recent work (git)\nWhat was being worked on in this repository when the session started, so the user doesn't have to explain it:")
+	if branch := gitBranch(a.workingDir); branch != "" {
+		b.WriteString("\n\nBranch: " + branch)
+	}
+	b.WriteString("\n\nRecent commits, newest first:\n" + strings.TrimRight(history, "\n"))
+	if status != "" {
+		b.WriteString("\n\nUncommitted changes (git status --short):\n" + strings.TrimRight(status, "\n"))
+	}
+	if diff != "" {
+		if len(diff) > config.GitWarmStartDiffBytes {
+			diff = diff[:strings.LastIndex(diff[:config.GitWarmStartDiffBytes], "\n")+1] + "[diff truncated; run git diff for the rest]\n"
+		}
+		b.WriteString("\n\nUncommitted diff against HEAD:\n```diff\n" + diff + "```")
+	}
+	a.gitContext = b.String()
+	if a.redactor != nil {
+		a.gitContext, _ = a.redactor.Redact(a.gitContext)
+	}
+
+	commits := strings.Count(history, "\n")
+	if files > 0 {
+		a.events.OnNotice("Git", fmt.Sprintf("Warm start: added %d recent commit(s) and the uncommitted changes to %d file(s) to the context", commits, files))
+	} else {
+		a.events.OnNotice("Git", fmt.Sprintf("Warm start: added %d recent commit(s) to the context; there are no uncommitted changes", commits))
+	}
+}
+
+// git runs a git command in the working directory and returns its output
+func (a *Agent) git(args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitWarmStartTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, "git", append([]string{"-C", a.workingDir, "--no-pager"}, args...)...).Output()
+	return string(out), err
+}
+
+// visibleStatus drops the files .goocodeignore denies from git status --short output and returns the
+// rest with the number of files it lists
+func (a *Agent) visibleStatus(status string) (string, int) {
+	var b strings.Builder
+	files := 0
+	for _, line := range strings.Split(status, "\n") {
+		if len(line) < 4 {
+			continue
+		}
+		path := line[3:]
+		if _, renamed, ok := strings.Cut(path, " -> "); ok {
+			path = renamed
+		}
+		// Untracked directories are listed once, as "?? dir/", so directory rules must apply to them
+		path = strings.Trim(path, `"`)
+		isDir := strings.HasSuffix(path, "/")
+		if a.Ignored(filepath.Join(a.workingDir, filepath.FromSlash(strings.TrimSuffix(path, "/"))), isDir) {
+			continue
+		}
+		b.WriteString(line + "\n")
+		files++
+	}
+	return b.String(), files
+}
+
+// visibleDiff drops the files .goocodeignore denies from a git diff
+func (a *Agent) visibleDiff(diff string) string {
+	var b strings.Builder
+	for _, file := range strings.Split(diff, "\ndiff --git ") {
+		if file == "" {
+			continue
+		}
+		if !strings.HasPrefix(file, "diff --git ") {
+			file = "diff --git " + file
+		}
+		header, _, _ := strings.Cut(file, "\n")
+		path, _, _ := strings.Cut(strings.TrimPrefix(header, "diff --git a/"), " b/")
+		if a.Ignored(filepath.Join(a.workingDir, filepath.FromSlash(path)), false) {
+			continue
+		}
+		b.WriteString(strings.TrimSuffix(file, "\n") + "\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"anthropic-chat/watch"
+)
+
+const (
+	maxChangesListed = 20
+	watchSettleQuiet = 100 * time.Millisecond
+	watchSettleMax   = time.Second
+)
+
+// startWatcher begins recording external changes to the working directory when watch mode is enabled
+func (a *Agent) startWatcher() {
+	a.stopWatcher()
+	if !a.config.Agent.WatchFiles {
+		return
+	}
+	watcher, err := watch.New(a.workingDir)
+	if err != nil {
+		log.Printf("Warning: watch mode disabled: %v", err)
+		return
+	}
+	a.watcher = watcher
+}
+
+func (a *Agent) stopWatcher() {
+	if a.watcher != nil {
+		a.watcher.Close()
+		a.watcher = nil
+	}
+}
+
+// externalChangesNote describes files changed since the last turn, or "" when nothing changed
+func (a *Agent) externalChangesNote() string {
+	if a.watcher == nil {
+		return ""
+	}
+	changes := a.watcher.Drain()
+	if len(changes) == 0 {
+		return ""
+	}
+
+	parts := make([]string, 0, min(len(changes), maxChangesListed))
+	for i, change := range changes {
+		if i == maxChangesListed {
+			parts = append(parts, fmt.Sprintf("and %d more", len(changes)-i))
+			break
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", change.Path, change.Kind))
+	}
+	a.events.OnNotice("Watch", fmt.Sprintf("%d file(s) changed outside the agent", len(changes)))
+	return "[Files changed externally since your last turn: " + strings.Join(parts, ", ") + ". Re-read them before relying on earlier contents.]\n\n"
+}
+
+// discardOwnChanges drops the events caused by the agent's own tool calls during a turn, which started at
+// workspace sequence number seq: files it wrote and that are still as it left them, and the paths it removed.
+// Whatever else changed meanwhile, such as a file the user edited, is reported with the next turn.
+func (a *Agent) discardOwnChanges(seq int) {
+	removed := a.removed
+	a.removed = nil
+	if a.watcher == nil {
+		return
+	}
+	a.watcher.Settle(watchSettleQuiet, watchSettleMax)
+
+	written := make(map[string]bool)
+	for path, file := range a.workspace {
+		if !file.written || file.seq <= seq {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil || info.Size() != file.size || !info.ModTime().Equal(file.modTime) {
+			continue // Changed again since the agent wrote it
+		}
+		if rel, err := filepath.Rel(a.workingDir, path); err == nil {
+			written[filepath.ToSlash(rel)] = true
+		}
+	}
+	a.watcher.Discard(func(change watch.Change) bool {
+		if written[change.Path] {
+			return true
+		}
+		if change.Kind != watch.Deleted {
+			return false
+		}
+		path := filepath.Join(a.workingDir, filepath.FromSlash(change.Path))
+		for _, dir := range removed {
+			if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+				return true
+			}
+		}
+		return false
+	})
+}
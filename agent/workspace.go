@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"anthropic-chat/ui"
+)
+
+// workspaceFile is what the model last saw of a file it read or wrote this session
+type workspaceFile struct {
+	size    int64
+	modTime time.Time
+	read    bool
+	written bool
+	seq     int // Larger for files used more recently
+}
+
+// FileChanged implements the tools.FileTracker interface
+func (a *Agent) FileChanged(fullPath string) {
+	info, err := os.Stat(fullPath)
+	if os.IsNotExist(err) {
+		a.removed = append(a.removed, fullPath)
+		return
+	}
+	if err != nil {
+		return
+	}
+	a.noteWorkspaceFile(fullPath, info, true)
+}
+
+// FileRead implements the tools.FileTracker interface
+func (a *Agent) FileRead(fullPath string, info os.FileInfo) {
+	a.noteWorkspaceFile(fullPath, info, false)
+}
+
+// noteWorkspaceFile records that the model read or wrote the version of fullPath described by info
+func (a *Agent) noteWorkspaceFile(fullPath string, info os.FileInfo, written bool) {
+	if a.workspace == nil {
+		a.workspace = make(map[string]*workspaceFile)
+	}
+	file, ok := a.workspace[fullPath]
+	if !ok {
+		file = &workspaceFile{}
+		a.workspace[fullPath] = file
+	}
+	a.workspaceSeq++
+	file.size, file.modTime, file.seq = info.Size(), info.ModTime(), a.workspaceSeq
+	file.read = file.read || !written
+	file.written = file.written || written
+}
+
+// writtenSince lists the files, relative to the working directory, the model wrote after the workspace
+// sequence number seq
+func (a *Agent) writtenSince(seq int) []string {
+	var paths []string
+	for path, file := range a.workspace {
+		if !file.written || file.seq <= seq {
+			continue
+		}
+		if rel, err := filepath.Rel(a.workingDir, path); err == nil {
+			paths = append(paths, rel)
+		}
+	}
+	return paths
+}
+
+// workspaceState lists the files the model has read or written this session as they are now, most
+// recently used first, so it needn't list or re-read files to find out what it already knows. Files
+// changed on disk since the model last saw them are flagged. The block stops at the configured size.
+func (a *Agent) workspaceState() string {
+	limit := a.config.Agent.WorkspaceStateBytes
+	if limit <= 0 || len(a.workspace) == 0 {
+		return ""
+	}
+
+	paths := make([]string, 0, len(a.workspace))
+	for path := range a.workspace {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool { return a.workspace[paths[i]].seq > a.workspace[paths[j]].seq })
+
+	var b strings.Builder
+	b.WriteString("\n\n# Workspace state\nFiles you have read or written this session, most recent first, as they are on disk now. Only re-read files marked as changed or deleted.\n")
+	header := b.Len()
+	for i, path := range paths {
+		rel, err := filepath.Rel(a.workingDir, path)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue // From before a /cd
+		}
+		line := "- " + filepath.ToSlash(rel) + " " + a.workspace[path].describe(path) + "\n"
+		if b.Len()+len(line) > limit && b.Len() > header {
+			fmt.Fprintf(&b, "- ... and %d less recent file(s)\n", len(paths)-i)
+			break
+		}
+		b.WriteString(line)
+	}
+	if b.Len() == header {
+		return ""
+	}
+	return b.String()
+}
+
+// describe gives the file's size, age and flags, checking it on disk
+func (f *workspaceFile) describe(path string) string {
+	var flags []string
+	if f.read {
+		flags = append(flags, "read")
+	}
+	if f.written {
+		flags = append(flags, "written")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Sprintf("(%s; deleted since)", strings.Join(flags, ", "))
+	}
+	if info.Size() != f.size || !info.ModTime().Equal(f.modTime) {
+		flags = append(flags, "changed since you last saw it")
+	}
+	return fmt.Sprintf("(%s, modified %s; %s)", ui.FormatBytesPlain(info.Size()), modifiedAgo(info.ModTime()), strings.Join(flags, ", "))
+}
+
+// modifiedAgo is a rough age such as "5m ago", precise enough to tell recent edits from old files
+func modifiedAgo(t time.Time) string {
+	age := time.Since(t)
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return fmt.Sprintf("%dm ago", int(age/time.Minute))
+	case age < 48*time.Hour:
+		return fmt.Sprintf("%dh ago", int(age/time.Hour))
+	}
+	return fmt.Sprintf("%dd ago", int(age/(24*time.Hour)))
+}
@@ -0,0 +1,188 @@
+package approval
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// allowlistHeader starts a newly written allowlist file
+const allowlistHeader = "# Commands starting with one of these prefixes run without asking (one per line).\n# Added by answering 'a' at an approval prompt; remove a line to be asked again.\n"
+
+// Allowlist is the set of command prefixes the user chose to always allow in a project
+type Allowlist struct {
+	path     string
+	project  string // Root of the project the list belongs to, noted in the file
+	Prefixes []string
+}
+
+// ProjectAllowlist loads the user's allowlist for the project rooted at root from dir. Lists are kept
+// per user, outside the repository, so a cloned project can't ship pre-approved prefixes.
+func ProjectAllowlist(dir, root string) (*Allowlist, error) {
+	sum := sha256.Sum256([]byte(root))
+	list, err := LoadAllowlist(filepath.Join(dir, filepath.Base(root)+"-"+hex.EncodeToString(sum[:6])+".txt"))
+	if list != nil {
+		list.project = root
+	}
+	return list, err
+}
+
+// Path returns the file the list is saved to
+func (l *Allowlist) Path() string {
+	return l.path
+}
+
+// LoadAllowlist reads the allowlist at path, returning an empty one when it does not exist yet
+func LoadAllowlist(path string) (*Allowlist, error) {
+	list := &Allowlist{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return list, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read command allowlist: %w", err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = normalizeCommand(line); line != "" && !strings.HasPrefix(line, "#") {
+			list.Prefixes = append(list.Prefixes, line)
+		}
+	}
+	return list, nil
+}
+
+// Allows reports whether command is a single simple command starting with an allowed prefix.
+// Commands chained, piped, redirected, quoted or substituting other commands always ask, as do
+// flags that run another program, so an allowed "go test" never lets "go test && rm -rf ~" or
+// "go test -exec sh" through.
+func (l *Allowlist) Allows(command string) bool {
+	if !simpleCommand(command) || runsAnything(strings.Fields(command)[0]) {
+		return false
+	}
+	command = normalizeCommand(command)
+	for _, prefix := range l.Prefixes {
+		if command == prefix || strings.HasPrefix(command, prefix+" ") {
+			return !hasExecFlag(strings.Fields(command[len(prefix):]))
+		}
+	}
+	return false
+}
+
+// Add allows commands starting with prefix and saves the list
+func (l *Allowlist) Add(prefix string) error {
+	prefix = normalizeCommand(prefix)
+	if prefix == "" || slices.Contains(l.Prefixes, prefix) {
+		return nil
+	}
+	l.Prefixes = append(l.Prefixes, prefix)
+	return l.save()
+}
+
+// Remove stops allowing prefix and saves the list, reporting whether it was there
+func (l *Allowlist) Remove(prefix string) (bool, error) {
+	i := slices.Index(l.Prefixes, normalizeCommand(prefix))
+	if i < 0 {
+		return false, nil
+	}
+	l.Prefixes = slices.Delete(l.Prefixes, i, i+1)
+	return true, l.save()
+}
+
+func (l *Allowlist) save() error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create allowlist directory: %w", err)
+	}
+	content := allowlistHeader
+	if l.project != "" {
+		content += "# Project: " + l.project + "\n"
+	}
+	content += strings.Join(l.Prefixes, "\n") + "\n"
+	if err := os.WriteFile(l.path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write command allowlist: %w", err)
+	}
+	return nil
+}
+
+// interpreters run whatever code or command they are given, so no prefix of theirs is ever offered
+var interpreters = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "fish": true, "dash": true, "ksh": true, "csh": true, "tcsh": true,
+	"pwsh": true, "powershell": true, "cmd": true, "python": true, "python2": true, "python3": true, "pypy": true,
+	"node": true, "deno": true, "bun": true, "ruby": true, "perl": true, "php": true, "lua": true, "rscript": true,
+	"java": true, "osascript": true, "awk": true, "gawk": true, "sed": true, "find": true, "env": true, "xargs": true,
+	"sudo": true, "doas": true, "su": true, "nohup": true, "time": true, "timeout": true, "nice": true, "watch": true,
+	"exec": true, "eval": true, "command": true, "builtin": true, "source": true, "ssh": true, "npx": true,
+	"bunx": true, "uvx": true, "pipx": true,
+}
+
+// runSubcommands run a named script or program, so they are only allowed together with its name,
+// as in "npm run build"
+var runSubcommands = map[string]bool{"run": true, "exec": true, "x": true, "dlx": true, "run-script": true}
+
+// execFlags make an otherwise harmless command run another program, such as go test -exec
+var execFlags = map[string]bool{
+	"-exec": true, "--exec": true, "-toolexec": true, "--toolexec": true, "-c": true, "--command": true,
+	"-e": true, "--eval": true, "--shell": true, "--script-shell": true, "--require": true, "--config-cmd": true,
+}
+
+// SuggestPrefix picks the prefix to offer for always allowing command: the program and its subcommand,
+// such as "go test" for "go test ./...", or "" when command can't be allowlisted. Interpreters and
+// commands without a subcommand are never offered, and a run subcommand needs the script's name.
+func SuggestPrefix(command string) string {
+	if !simpleCommand(command) {
+		return ""
+	}
+	words := strings.Fields(command)
+	if runsAnything(words[0]) || len(words) < 2 || !isSubcommand(words[1]) || hasExecFlag(words[1:]) {
+		return ""
+	}
+	prefix := words[:2]
+	if runSubcommands[words[1]] {
+		if len(words) < 3 || !isSubcommand(words[2]) {
+			return ""
+		}
+		prefix = words[:3]
+	}
+	return strings.Join(prefix, " ")
+}
+
+// runsAnything reports whether program is a shell, interpreter or wrapper that runs what it's given
+func runsAnything(program string) bool {
+	name := strings.ToLower(filepath.Base(program))
+	name = strings.TrimSuffix(name, ".exe")
+	return interpreters[strings.TrimRight(name, "0123456789.")] || interpreters[name]
+}
+
+// hasExecFlag reports whether args pass a flag that runs another program
+func hasExecFlag(args []string) bool {
+	for _, arg := range args {
+		flag, _, _ := strings.Cut(arg, "=")
+		if execFlags[flag] {
+			return true
+		}
+	}
+	return false
+}
+
+// isSubcommand reports whether word looks like a subcommand rather than a flag, path or argument
+func isSubcommand(word string) bool {
+	for _, r := range word {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == ':') {
+			return false
+		}
+	}
+	return word != "" && word[0] != '-'
+}
+
+// simpleCommand reports whether command runs one program, without chaining, pipes, redirection,
+// substitution or quoted arguments, which could carry a script
+func simpleCommand(command string) bool {
+	return strings.TrimSpace(command) != "" && !strings.ContainsAny(command, ";&|<>`$\n\r'\"\\")
+}
+
+func normalizeCommand(command string) string {
+	return strings.Join(strings.Fields(command), " ")
+}
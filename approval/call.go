@@ -0,0 +1,62 @@
+package approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// callSummaryChars bounds how much of a tool's input a call summary shows
+const callSummaryChars = 200
+
+// pathFields are the input fields that name files, by convention across built-in and plugin tools
+var pathFields = []string{"path", "paths", "source", "destination"}
+
+// CallRequest describes a tool call before it runs, taking affected paths and the command from its input
+func CallRequest(tool string, input json.RawMessage) Request {
+	req := Request{Tool: tool, Summary: fmt.Sprintf("call %s with %s", tool, Shorten(string(input), callSummaryChars))}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(input, &fields) != nil {
+		return req
+	}
+	for _, name := range pathFields {
+		var one string
+		var many []string
+		switch {
+		case json.Unmarshal(fields[name], &one) == nil && one != "":
+			req.Paths = append(req.Paths, one)
+		case json.Unmarshal(fields[name], &many) == nil:
+			req.Paths = append(req.Paths, many...)
+		}
+	}
+	json.Unmarshal(fields["command"], &req.Command)
+	return req
+}
+
+// Shorten cuts text to at most max bytes for a summary, on a character boundary, marking the cut with "..."
+func Shorten(text string, max int) string {
+	if len(text) <= max {
+		return text
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	return text[:cut] + "..."
+}
+
+// EvaluateCall decides a tool call before the tool runs. Only rules that name tools are considered,
+// and not those with max_lines, since only the tool itself knows how big its change is.
+// It returns rule 0 when no rule decides, leaving the call to the tool's own approval.
+func (p *Policy) EvaluateCall(req Request) (Action, int) {
+	for i, rule := range p.Rules {
+		if len(rule.Tools) == 0 || rule.MaxLines > 0 {
+			continue
+		}
+		if rule.matches(req) {
+			return rule.Action, i + 1
+		}
+	}
+	return Allow, 0
+}
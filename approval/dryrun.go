@@ -0,0 +1,27 @@
+package approval
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DryRunError is returned instead of approving an action while dry-run mode is on, describing what would have happened
+type DryRunError struct {
+	Request Request
+}
+
+func (e *DryRunError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Dry run, nothing was changed. %s would %s", e.Request.Tool, e.Request.Summary)
+	if len(e.Request.Paths) > 0 {
+		fmt.Fprintf(&b, "\nFiles: %s", strings.Join(e.Request.Paths, ", "))
+	}
+	if e.Request.Command != "" {
+		fmt.Fprintf(&b, "\nCommand: %s", e.Request.Command)
+	}
+	if e.Request.Diff != "" {
+		fmt.Fprintf(&b, "\nDiff:\n%s", truncateDiff(e.Request.Diff))
+	}
+	b.WriteString("\nThe user is reviewing the plan; carry on as if this step had succeeded, and don't retry it.")
+	return b.String()
+}
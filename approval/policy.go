@@ -0,0 +1,215 @@
+package approval
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Action is the outcome of evaluating a request against the policy
+type Action string
+
+const (
+	Allow Action = "allow" // Proceed without asking
+	Deny  Action = "deny"  // Refuse without asking
+	Ask   Action = "ask"   // Ask the user; treated as deny in headless runs
+)
+
+// Request describes an action a tool wants to take
+type Request struct {
+	Tool    string
+	Summary string   // Human-readable description shown when asking
+	Paths   []string // Files affected, relative to the working directory
+	Lines   int      // Lines added or changed, when known
+	Command string   // Shell command, for command tools
+	Diff    string   // Proposed change, when available
+	// Proposed holds the new content of the one file an edit writes, for the user to change before
+	// approving; the tool writes what it holds afterwards (nil = not editable)
+	Proposed *string
+	// Dangerous marks destructive commands, which need approval unless a rule explicitly decides them
+	Dangerous bool
+}
+
+// Risk is a rough low/medium/high rating shown to remote approvers
+func (r Request) Risk() string {
+	switch {
+	case r.Lines > 500 || len(r.Paths) > 20:
+		return "high"
+	case r.Command != "" || r.Lines > 100 || len(r.Paths) > 1:
+		return "medium"
+	}
+	return "low"
+}
+
+// Rule matches requests and decides them. Empty fields match anything.
+// Allow and ask rules match when every path matches; deny rules match when any path does.
+type Rule struct {
+	Tools    []string `yaml:"tools,omitempty"`     // Tool names, or "*"
+	Paths    []string `yaml:"paths,omitempty"`     // Globs relative to the working directory; ** matches any depth, a trailing / a whole directory
+	Commands []string `yaml:"commands,omitempty"`  // Command globs such as "go test*"
+	MaxLines int      `yaml:"max_lines,omitempty"` // Only match changes of at most this many lines
+	Action   Action   `yaml:"action"`
+}
+
+// Policy is an ordered approval matrix; the first matching rule decides
+type Policy struct {
+	Default Action `yaml:"default,omitempty"`
+	Rules   []Rule `yaml:"rules"`
+}
+
+// Load reads a YAML policy file
+func Load(file string) (*Policy, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approval policy: %w", err)
+	}
+	var policy Policy
+	if err := yaml.Unmarshal(data, &policy); err != nil {
+		return nil, fmt.Errorf("failed to parse approval policy %s: %w", file, err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid approval policy %s: %w", file, err)
+	}
+	return &policy, nil
+}
+
+func (p *Policy) validate() error {
+	valid := func(a Action) bool { return a == Allow || a == Deny || a == Ask }
+	if p.Default != "" && !valid(p.Default) {
+		return fmt.Errorf("default must be allow, deny or ask, not %q", p.Default)
+	}
+	for i, rule := range p.Rules {
+		if !valid(rule.Action) {
+			return fmt.Errorf("rule %d: action must be allow, deny or ask, not %q", i+1, rule.Action)
+		}
+	}
+	return nil
+}
+
+// Evaluate returns the decision for req and the 1-based index of the rule that made it (0 = default)
+func (p *Policy) Evaluate(req Request) (Action, int) {
+	for i, rule := range p.Rules {
+		if rule.matches(req) {
+			return rule.Action, i + 1
+		}
+	}
+	if p.Default == "" {
+		return Ask, 0
+	}
+	return p.Default, 0
+}
+
+func (r Rule) matches(req Request) bool {
+	if len(r.Tools) > 0 && !contains(r.Tools, req.Tool) && !contains(r.Tools, "*") {
+		return false
+	}
+	if r.MaxLines > 0 && req.Lines > r.MaxLines {
+		return false
+	}
+	if len(r.Commands) > 0 {
+		if req.Command == "" || !matchAny(r.Commands, req.Command, matchCommand) {
+			return false
+		}
+	}
+	if len(r.Paths) > 0 {
+		if len(req.Paths) == 0 {
+			return false
+		}
+		if r.Action == Deny {
+			for _, p := range req.Paths {
+				if matchAny(r.Paths, p, MatchPath) {
+					return true
+				}
+			}
+			return false
+		}
+		for _, p := range req.Paths {
+			if !matchAny(r.Paths, p, MatchPath) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+func matchAny(patterns []string, value string, match func(string, string) bool) bool {
+	for _, pattern := range patterns {
+		if match(pattern, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchCommand matches a command against a glob where * also spans spaces and slashes
+func matchCommand(pattern, command string) bool {
+	command = strings.TrimSpace(command)
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == command
+	}
+	if !strings.HasPrefix(command, parts[0]) {
+		return false
+	}
+	rest := command[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(rest, part)
+		if idx < 0 {
+			return false
+		}
+		rest = rest[idx+len(part):]
+	}
+	return strings.HasSuffix(rest, parts[len(parts)-1])
+}
+
+// MatchPath reports whether a slash-separated relative path matches a glob supporting ** and directory prefixes
+func MatchPath(pattern, name string) bool {
+	directory := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimPrefix(path.Clean("/"+pattern), "/")
+	if directory {
+		pattern += "/**"
+	}
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		ok, err := path.Match(pattern[0], name[0])
+		if err != nil || !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// ErrDenied is wrapped by errors returned when an action is refused
+var ErrDenied = errors.New("action not approved")
+
+// ErrReadOnly is wrapped by errors returned for actions refused because the session is read-only
+var ErrReadOnly = errors.New("blocked by read-only mode")
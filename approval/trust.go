@@ -0,0 +1,76 @@
+package approval
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// TrustedPolicies records the repository-provided approval policies the user accepted, with a hash of
+// each one's contents, so a policy that changes, say with a pull, is asked about again
+type TrustedPolicies struct {
+	path   string
+	hashes map[string]string // Policy file path to the SHA-256 of the contents accepted
+}
+
+// LoadTrustedPolicies reads the accepted policies recorded at path, which need not exist yet
+func LoadTrustedPolicies(path string) (*TrustedPolicies, error) {
+	trusted := &TrustedPolicies{path: path, hashes: map[string]string{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return trusted, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trusted policies: %w", err)
+	}
+	if err := json.Unmarshal(data, &trusted.hashes); err != nil {
+		return nil, fmt.Errorf("failed to parse trusted policies %s: %w", path, err)
+	}
+	return trusted, nil
+}
+
+// Trusted reports whether the user accepted policyFile with its current contents
+func (t *TrustedPolicies) Trusted(policyFile string) bool {
+	sum, err := policyHash(policyFile)
+	return err == nil && t.hashes[policyKey(policyFile)] == sum
+}
+
+// Trust accepts policyFile with its current contents and saves the record
+func (t *TrustedPolicies) Trust(policyFile string) error {
+	sum, err := policyHash(policyFile)
+	if err != nil {
+		return err
+	}
+	t.hashes[policyKey(policyFile)] = sum
+	data, err := json.MarshalIndent(t.hashes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create trusted policies directory: %w", err)
+	}
+	if err := os.WriteFile(t.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write trusted policies: %w", err)
+	}
+	return nil
+}
+
+func policyKey(policyFile string) string {
+	if abs, err := filepath.Abs(policyFile); err == nil {
+		return abs
+	}
+	return policyFile
+}
+
+func policyHash(policyFile string) (string, error) {
+	data, err := os.ReadFile(policyFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read approval policy: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
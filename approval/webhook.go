@@ -0,0 +1,274 @@
+package approval
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxDiffBytes bounds the diff sent with a request
+const maxDiffBytes = 8000
+
+// Decision is a remote approver's answer
+type Decision struct {
+	Approved bool   `json:"approved"`
+	Reason   string `json:"reason,omitempty"`
+	By       string `json:"by,omitempty"`
+}
+
+// webhookPayload is posted to the webhook for every request. Text makes it readable in Slack.
+type webhookPayload struct {
+	ID          string    `json:"id"`
+	Text        string    `json:"text"`
+	Tool        string    `json:"tool"`
+	Summary     string    `json:"summary"`
+	Risk        string    `json:"risk"`
+	Paths       []string  `json:"paths,omitempty"`
+	Lines       int       `json:"lines,omitempty"`
+	Command     string    `json:"command,omitempty"`
+	Diff        string    `json:"diff,omitempty"`
+	CallbackURL string    `json:"callback_url"`
+	ApproveURL  string    `json:"approve_url"`
+	DenyURL     string    `json:"deny_url"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// Webhook sends approval requests to a remote endpoint and waits for the decision to be posted back
+type Webhook struct {
+	url       string
+	publicURL string
+	timeout   time.Duration
+	client    *http.Client
+	server    *http.Server
+
+	mu      sync.Mutex
+	pending map[string]pendingRequest
+}
+
+// pendingRequest is a request waiting for its decision at the callback URL
+type pendingRequest struct {
+	decisions chan Decision
+	summary   string
+}
+
+// confirmPage is served for the approve and deny links, so a link unfurler or mail scanner that opens
+// them decides nothing; the decision takes the button's POST
+var confirmPage = template.Must(template.New("confirm").Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>GooCode approval</title></head>
+<body>
+<p>GooCode wants to {{.Summary}}.</p>
+<form method="post"><button type="submit">{{.Action}}</button></form>
+</body></html>
+`))
+
+// NewWebhook starts the callback listener on listenAddr. publicURL is the address approvers reach it on.
+func NewWebhook(url, listenAddr, publicURL string, timeout time.Duration) (*Webhook, error) {
+	listener, err := net.Listen("tcp", listenAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start approval callback listener: %w", err)
+	}
+	if publicURL == "" {
+		publicURL = "http://" + listener.Addr().String()
+	}
+
+	w := &Webhook{
+		url:       url,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+		timeout:   timeout,
+		client:    &http.Client{Timeout: 30 * time.Second},
+		pending:   make(map[string]pendingRequest),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /approvals/{id}", w.handleDecision)
+	mux.HandleFunc("GET /approvals/{id}/{decision}", w.handleConfirm)
+	mux.HandleFunc("POST /approvals/{id}/{decision}", w.handleLink)
+	w.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go w.server.Serve(listener)
+	return w, nil
+}
+
+// Close stops the callback listener
+func (w *Webhook) Close() error {
+	return w.server.Close()
+}
+
+// Request posts req to the webhook and waits for a decision. A decision in the webhook's own
+// response is used directly; otherwise one must arrive at the callback URL before the timeout.
+func (w *Webhook) Request(ctx context.Context, req Request) (Decision, error) {
+	id, err := newRequestID()
+	if err != nil {
+		return Decision{}, err
+	}
+	decisions := make(chan Decision, 1)
+	w.mu.Lock()
+	w.pending[id] = pendingRequest{decisions: decisions, summary: req.Summary}
+	w.mu.Unlock()
+	defer func() {
+		w.mu.Lock()
+		delete(w.pending, id)
+		w.mu.Unlock()
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, w.timeout)
+	defer cancel()
+
+	callback := w.publicURL + "/approvals/" + id
+	deadline, _ := ctx.Deadline()
+	payload := webhookPayload{
+		ID:          id,
+		Tool:        req.Tool,
+		Summary:     req.Summary,
+		Risk:        req.Risk(),
+		Paths:       req.Paths,
+		Lines:       req.Lines,
+		Command:     req.Command,
+		Diff:        truncateDiff(req.Diff),
+		CallbackURL: callback,
+		ApproveURL:  callback + "/approve",
+		DenyURL:     callback + "/deny",
+		ExpiresAt:   deadline,
+	}
+	payload.Text = fmt.Sprintf("GooCode wants to %s (%s risk). Approve: %s  Deny: %s", req.Summary, payload.Risk, payload.ApproveURL, payload.DenyURL)
+
+	decision, err := w.post(ctx, payload)
+	if err != nil {
+		return Decision{}, err
+	}
+	if decision != nil {
+		return *decision, nil
+	}
+
+	select {
+	case decision := <-decisions:
+		return decision, nil
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return Decision{}, fmt.Errorf("no approval decision within %s", w.timeout)
+		}
+		return Decision{}, ctx.Err()
+	}
+}
+
+// post sends the payload, returning the decision if the webhook answered synchronously
+func (w *Webhook) post(ctx context.Context, payload webhookPayload) (*Decision, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode approval request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create approval request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("approval webhook failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read approval webhook response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("approval webhook returned %s", resp.Status)
+	}
+
+	// Slack and most relays just answer "ok"; only a JSON body with "approved" is a decision
+	var reply struct {
+		Approved *bool  `json:"approved"`
+		Reason   string `json:"reason"`
+		By       string `json:"by"`
+	}
+	if json.Unmarshal(data, &reply) != nil || reply.Approved == nil {
+		return nil, nil
+	}
+	return &Decision{Approved: *reply.Approved, Reason: reply.Reason, By: reply.By}, nil
+}
+
+// handleDecision accepts a JSON Decision posted to the callback URL
+func (w *Webhook) handleDecision(rw http.ResponseWriter, r *http.Request) {
+	var decision Decision
+	if err := json.NewDecoder(io.LimitReader(r.Body, 64*1024)).Decode(&decision); err != nil {
+		http.Error(rw, "expected a JSON body like {\"approved\": true}", http.StatusBadRequest)
+		return
+	}
+	w.deliver(rw, r.PathValue("id"), decision)
+}
+
+// handleConfirm answers a visit to an approve or deny link with a page asking to confirm it
+func (w *Webhook) handleConfirm(rw http.ResponseWriter, r *http.Request) {
+	action := map[string]string{"approve": "Approve", "deny": "Deny"}[r.PathValue("decision")]
+	if action == "" {
+		http.NotFound(rw, r)
+		return
+	}
+	w.mu.Lock()
+	pending, ok := w.pending[r.PathValue("id")]
+	w.mu.Unlock()
+	if !ok {
+		http.Error(rw, "unknown, expired or already decided approval request", http.StatusNotFound)
+		return
+	}
+	rw.Header().Set("Content-Type", "text/html; charset=utf-8")
+	rw.Header().Set("Cache-Control", "no-store")
+	confirmPage.Execute(rw, struct{ Summary, Action string }{pending.summary, action})
+}
+
+// handleLink accepts the confirmation posted from an approve or deny link's page
+func (w *Webhook) handleLink(rw http.ResponseWriter, r *http.Request) {
+	switch r.PathValue("decision") {
+	case "approve":
+		w.deliver(rw, r.PathValue("id"), Decision{Approved: true, By: "link"})
+	case "deny":
+		w.deliver(rw, r.PathValue("id"), Decision{Approved: false, By: "link"})
+	default:
+		http.NotFound(rw, r)
+	}
+}
+
+func (w *Webhook) deliver(rw http.ResponseWriter, id string, decision Decision) {
+	w.mu.Lock()
+	pending, ok := w.pending[id]
+	delete(w.pending, id)
+	w.mu.Unlock()
+	if !ok {
+		http.Error(rw, "unknown, expired or already decided approval request", http.StatusNotFound)
+		return
+	}
+	pending.decisions <- decision
+
+	outcome := "denied"
+	if decision.Approved {
+		outcome = "approved"
+	}
+	fmt.Fprintf(rw, "Request %s\n", outcome)
+}
+
+// newRequestID returns an unguessable ID, since knowing it is enough to answer the request
+func newRequestID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate approval request ID: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func truncateDiff(diff string) string {
+	if len(diff) <= maxDiffBytes {
+		return diff
+	}
+	return diff[:maxDiffBytes] + "\n... (diff truncated)"
+}
@@ -0,0 +1,298 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// hashField is how the hash is appended to each line; it is always the last field
+const hashField = `,"hash":"`
+
+// tailChunk is how much of the log is read at a time when looking for its last entry
+const tailChunk = 64 * 1024
+
+// Entry records one executed tool call. Each entry carries the hash of the one before it, so editing,
+// removing or reordering lines breaks the chain.
+type Entry struct {
+	Seq          int             `json:"seq"`
+	Time         time.Time       `json:"time"`
+	Session      string          `json:"session,omitempty"`
+	WorkingDir   string          `json:"working_dir"`
+	Tool         string          `json:"tool"`
+	Input        json.RawMessage `json:"input"`
+	OutputSHA256 string          `json:"output_sha256"` // Of the result the model was given
+	OutputBytes  int             `json:"output_bytes"`
+	Failed       bool            `json:"failed,omitempty"`
+	Approval     string          `json:"approval"` // How gated actions were decided, or "not required"
+	PrevHash     string          `json:"prev_hash"`
+	Hash         string          `json:"hash,omitempty"`
+}
+
+// head is the sequence number and hash of the newest entry, kept next to the log in HeadPath so that
+// lines cut off the end are noticed
+type head struct {
+	Seq  int    `json:"seq"`
+	Hash string `json:"hash"`
+}
+
+// HeadPath is where the newest entry of the log at path is recorded
+func HeadPath(path string) string {
+	return path + ".head"
+}
+
+// Log appends hash-chained entries to a JSON lines file
+type Log struct {
+	path string
+	mu   sync.Mutex
+}
+
+// New creates an audit log writing to path
+func New(path string) *Log {
+	return &Log{path: path}
+}
+
+// Path returns the log file
+func (l *Log) Path() string {
+	return l.path
+}
+
+// Record fills in the entry's sequence number, time and chain hashes and appends it to the log
+func (l *Log) Record(entry Entry, output string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+	// Other sessions may append to the same log; reading the chain's end and appending must not interleave
+	unlock, err := lock(file)
+	if err != nil {
+		return fmt.Errorf("failed to lock audit log: %w", err)
+	}
+	defer unlock()
+
+	// The chain continues from the recorded head, so sessions that ran one after another share it and
+	// entries cut off the end leave a gap instead of a shorter chain that still verifies
+	previous, err := readHead(l.path)
+	if err != nil {
+		return err
+	}
+	if previous == nil {
+		if previous, err = lastEntry(file); err != nil {
+			return fmt.Errorf("failed to read audit log: %w", err)
+		}
+	}
+	entry.Seq, entry.PrevHash = 1, ""
+	if previous != nil {
+		entry.Seq, entry.PrevHash = previous.Seq+1, previous.Hash
+	}
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now().UTC()
+	}
+	sum := sha256.Sum256([]byte(output))
+	entry.OutputSHA256, entry.OutputBytes = hex.EncodeToString(sum[:]), len(output)
+	if !json.Valid(entry.Input) {
+		entry.Input, _ = json.Marshal(string(entry.Input))
+	}
+	entry.Hash = ""
+	body, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	line := seal(body)
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return writeHead(l.path, head{Seq: entry.Seq, Hash: lineHash(line)})
+}
+
+// readHead returns the recorded newest entry of the log at path, or nil for a log written before heads
+// were kept
+func readHead(path string) (*head, error) {
+	data, err := os.ReadFile(HeadPath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log head: %w", err)
+	}
+	var h head
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("failed to parse audit log head %s: %w", HeadPath(path), err)
+	}
+	return &h, nil
+}
+
+// writeHead replaces the recorded newest entry, through a rename so a crash can't leave half a file
+func writeHead(path string, h head) error {
+	data, err := json.Marshal(h)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit log head: %w", err)
+	}
+	tmp := HeadPath(path) + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write audit log head: %w", err)
+	}
+	if err := os.Rename(tmp, HeadPath(path)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write audit log head: %w", err)
+	}
+	return nil
+}
+
+// lastEntry returns the sequence number and hash of the last line of file, or nil for an empty file
+func lastEntry(file *os.File) (*head, error) {
+	last, err := lastLine(file)
+	if err != nil || last == nil {
+		return nil, err
+	}
+	h := &head{Hash: lineHash(last)}
+	var previous Entry
+	if json.Unmarshal(last, &previous) == nil {
+		h.Seq = previous.Seq
+	}
+	return h, nil
+}
+
+// seal appends the hash of body, a JSON object without a hash, as its last field
+func seal(body []byte) []byte {
+	sum := sha256.Sum256(body)
+	line := append(bytes.TrimSuffix(body, []byte("}")), hashField...)
+	line = append(line, hex.EncodeToString(sum[:])...)
+	return append(line, `"}`...)
+}
+
+// unseal splits a line into the body that was hashed and the hash recorded for it
+func unseal(line []byte) (body []byte, hash string, ok bool) {
+	i := bytes.LastIndex(line, []byte(hashField))
+	if i < 0 || !bytes.HasSuffix(line, []byte(`"}`)) {
+		return nil, "", false
+	}
+	body = append(bytes.Clone(line[:i]), '}')
+	return body, string(line[i+len(hashField) : len(line)-2]), true
+}
+
+// lineHash is the hash a line records for itself, or the hash of the whole line when it can't be read,
+// so that a damaged line still breaks the chain instead of silently restarting it
+func lineHash(line []byte) string {
+	if _, hash, ok := unseal(line); ok {
+		return hash
+	}
+	sum := sha256.Sum256(line)
+	return hex.EncodeToString(sum[:])
+}
+
+// lastLine returns the last non-empty line of file, or nil for an empty file
+func lastLine(file *os.File) ([]byte, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	end := info.Size()
+	var tail []byte
+	for offset := end; offset > 0; {
+		size := min(int64(tailChunk), offset)
+		offset -= size
+		chunk := make([]byte, size)
+		if _, err := file.ReadAt(chunk, offset); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+		tail = append(chunk, tail...)
+		trimmed := bytes.TrimRight(tail, "\n")
+		if i := bytes.LastIndexByte(trimmed, '\n'); i >= 0 {
+			return trimmed[i+1:], nil
+		}
+		if offset == 0 && len(trimmed) > 0 {
+			return trimmed, nil
+		}
+	}
+	return nil, nil
+}
+
+// Problem is a line that breaks the chain
+type Problem struct {
+	Line   int
+	Reason string
+}
+
+// Verify walks the log at path and reports the number of entries and every line that breaks the chain,
+// including a last line that isn't the head recorded beside the log
+func Verify(path string) (int, []Problem, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var problems []Problem
+	entries, prevHash, prevSeq := 0, "", 0
+	for number := 1; ; number++ {
+		line, err := reader.ReadBytes('\n')
+		line = bytes.TrimRight(line, "\n")
+		if len(line) > 0 {
+			entries++
+			if reason := check(line, prevHash, prevSeq); reason != "" {
+				problems = append(problems, Problem{Line: number, Reason: reason})
+			}
+			var entry Entry
+			if json.Unmarshal(line, &entry) == nil {
+				prevSeq = entry.Seq
+			}
+			prevHash = lineHash(line)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return entries, problems, fmt.Errorf("failed to read audit log: %w", err)
+		}
+	}
+
+	recorded, err := readHead(path)
+	if err != nil {
+		return entries, problems, err
+	}
+	if recorded != nil && (recorded.Seq != prevSeq || recorded.Hash != prevHash) {
+		problems = append(problems, Problem{Line: entries + 1, Reason: fmt.Sprintf("the log ends at entry %d but %s records entry %d as the newest (lines removed from the end)", prevSeq, filepath.Base(HeadPath(path)), recorded.Seq)})
+	}
+	return entries, problems, nil
+}
+
+// check says what is wrong with line given the hash and sequence number of the line before it
+func check(line []byte, prevHash string, prevSeq int) string {
+	body, hash, ok := unseal(line)
+	if !ok {
+		return "not a sealed audit entry"
+	}
+	var entry Entry
+	if err := json.Unmarshal(body, &entry); err != nil {
+		return fmt.Sprintf("unreadable entry: %v", err)
+	}
+	if sum := sha256.Sum256(body); hex.EncodeToString(sum[:]) != hash {
+		return "contents don't match the entry's hash (edited)"
+	}
+	if entry.PrevHash != prevHash {
+		return "previous hash doesn't match the line before (lines removed, inserted or reordered)"
+	}
+	if entry.Seq != prevSeq+1 {
+		return fmt.Sprintf("sequence number %d follows %d", entry.Seq, prevSeq)
+	}
+	return ""
+}
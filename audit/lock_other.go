@@ -0,0 +1,16 @@
+//go:build !windows
+
+package audit
+
+import (
+	"os"
+	"syscall"
+)
+
+// lock holds an exclusive lock on file until unlock is called, so concurrent sessions append in turn
+func lock(file *os.File) (unlock func(), err error) {
+	if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+		return nil, err
+	}
+	return func() { syscall.Flock(int(file.Fd()), syscall.LOCK_UN) }, nil
+}
@@ -0,0 +1,19 @@
+//go:build windows
+
+package audit
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lock holds an exclusive lock on file until unlock is called, so concurrent sessions append in turn
+func lock(file *os.File) (unlock func(), err error) {
+	handle := windows.Handle(file.Fd())
+	overlapped := new(windows.Overlapped)
+	if err := windows.LockFileEx(handle, windows.LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, overlapped); err != nil {
+		return nil, err
+	}
+	return func() { windows.UnlockFileEx(handle, 0, 1, 0, overlapped) }, nil
+}
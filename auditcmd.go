@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"anthropic-chat/audit"
+	"anthropic-chat/config"
+)
+
+// runAuditCommand implements `goocode audit verify [file]`, checking the tool call audit log's hash chain
+func runAuditCommand(args []string) error {
+	if len(args) == 0 || args[0] != "verify" {
+		return fmt.Errorf("usage: goocode audit verify [file]")
+	}
+	flags := flag.NewFlagSet("audit verify", flag.ContinueOnError)
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	path := flags.Arg(0)
+	if path == "" {
+		path = config.NewConfig().Security.AuditLog
+	}
+	if path == "" {
+		return fmt.Errorf("no audit log given and GOOCODE_AUDIT_LOG is not set")
+	}
+
+	entries, problems, err := audit.Verify(path)
+	if err != nil {
+		return err
+	}
+	for _, problem := range problems {
+		fmt.Printf("line %d: %s\n", problem.Line, problem.Reason)
+	}
+	if len(problems) > 0 {
+		fmt.Printf("%s: %d entries, chain BROKEN at %d line(s)\n", path, entries, len(problems))
+		os.Exit(1)
+	}
+	fmt.Printf("%s: %d entries, chain intact\n", path, entries)
+	return nil
+}
@@ -0,0 +1,88 @@
+package batch
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Item is one independent prompt in a batch and the file its answer is written to
+type Item struct {
+	ID     string `json:"id"`               // custom_id sent with the request
+	Prompt string `json:"-"`                // Full user message, including any file content; not kept in the manifest
+	Source string `json:"source,omitempty"` // Input file the prompt was built from
+	Output string `json:"output"`           // Where the response is written
+}
+
+// Job records a submitted batch so its results can be collected later, even from another process
+type Job struct {
+	BatchID   string    `json:"batch_id"`
+	Model     string    `json:"model"`
+	MaxTokens int       `json:"max_tokens"`
+	CreatedAt time.Time `json:"created_at"`
+	Items     []Item    `json:"items"`
+}
+
+// Store keeps job manifests in a directory, one JSON file per batch
+type Store struct {
+	dir string
+}
+
+// NewStore creates a store rooted at dir
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+// Save writes the job's manifest
+func (s *Store) Save(job *Job) error {
+	if err := os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create batch directory: %w", err)
+	}
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode batch job: %w", err)
+	}
+	path := filepath.Join(s.dir, job.BatchID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write batch job: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Load reads the manifest of a submitted batch
+func (s *Store) Load(batchID string) (*Job, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, batchID+".json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no batch job %s in %s", batchID, s.dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read batch job: %w", err)
+	}
+	var job Job
+	if err := json.Unmarshal(data, &job); err != nil {
+		return nil, fmt.Errorf("failed to parse batch job %s: %w", batchID, err)
+	}
+	return &job, nil
+}
+
+// List returns every stored job, newest first
+func (s *Store) List() ([]*Job, error) {
+	files, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]*Job, 0, len(files))
+	for _, file := range files {
+		job, err := s.Load(filepath.Base(file[:len(file)-len(".json")]))
+		if err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.After(jobs[j].CreatedAt) })
+	return jobs, nil
+}
@@ -0,0 +1,127 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/config"
+	"anthropic-chat/ui"
+)
+
+// FromFiles builds one item per file under root matching patterns, asking prompt about each file.
+// Answers are written to outDir mirroring the file's path, with a .md suffix. Binary and oversized files are skipped and returned.
+// Paths skip reports true for, such as those .goocodeignore denies, are left out without being read.
+func FromFiles(root string, patterns []string, prompt, outDir string, skip func(fullPath string, isDir bool) bool) ([]Item, []string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" || entry.Name() == ".goocode" || (path != root && skip(path, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if skip(path, false) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		for _, pattern := range patterns {
+			if entry.Type().IsRegular() && approval.MatchPath(pattern, rel) {
+				files = append(files, rel)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	sort.Strings(files)
+
+	var items []Item
+	var skipped []string
+	for _, rel := range files {
+		content, err := os.ReadFile(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		switch {
+		case len(content) > config.BatchMaxFileBytes:
+			skipped = append(skipped, fmt.Sprintf("%s (larger than %s)", rel, ui.FormatBytes(config.BatchMaxFileBytes)))
+			continue
+		case bytes.IndexByte(content, 0) >= 0:
+			skipped = append(skipped, rel+" (binary)")
+			continue
+		}
+		items = append(items, Item{
+			ID:     itemID(len(items)),
+			Prompt: fmt.Sprintf("%s\n\nFile: %s\n\n```\n%s\n```", prompt, rel, strings.TrimRight(string(content), "\n")),
+			Source: rel,
+			Output: filepath.Join(outDir, filepath.FromSlash(rel)+".md"),
+		})
+	}
+	return items, skipped, nil
+}
+
+// promptLine is one line of a prompts file
+type promptLine struct {
+	Name   string `json:"name"`   // Output file name without extension (defaults to the line number)
+	Prompt string `json:"prompt"` // The full prompt
+	Output string `json:"output"` // Output path, overriding outDir/name.md
+}
+
+// FromPrompts builds items from a JSONL file with one {"prompt": ..., "name": ..., "output": ...} object per line
+func FromPrompts(path, outDir string) ([]Item, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open prompts file: %w", err)
+	}
+	defer file.Close()
+
+	var items []Item
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), config.BatchMaxFileBytes*2)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var entry promptLine
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+		}
+		if entry.Prompt == "" {
+			return nil, fmt.Errorf("%s:%d: prompt is empty", path, lineNumber)
+		}
+		if entry.Name == "" {
+			entry.Name = fmt.Sprintf("%d", lineNumber)
+		}
+		if entry.Output == "" {
+			entry.Output = filepath.Join(outDir, entry.Name+".md")
+		}
+		items = append(items, Item{ID: itemID(len(items)), Prompt: entry.Prompt, Output: entry.Output})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read prompts file: %w", err)
+	}
+	return items, nil
+}
+
+// itemID returns a custom_id valid for the batches API
+func itemID(index int) string {
+	return fmt.Sprintf("item-%05d", index+1)
+}
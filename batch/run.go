@@ -0,0 +1,137 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"anthropic-chat/config"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// systemPrompt tells the model its reply is saved as-is, with nobody to ask follow-up questions
+const systemPrompt = "You are GooCode running one item of an offline batch job. Your reply is written directly to a file, so respond with only the requested content: no preamble, no questions, no offers of further help."
+
+// Submit sends items to the Message Batches API and returns the job to save for later collection
+func Submit(ctx context.Context, service *anthropic.MessageBatchService, model string, maxTokens int, items []Item) (*Job, error) {
+	if len(items) == 0 {
+		return nil, fmt.Errorf("nothing to submit")
+	}
+	if len(items) > config.BatchMaxRequests {
+		return nil, fmt.Errorf("%d prompts exceed the batch limit of %d; split the job", len(items), config.BatchMaxRequests)
+	}
+
+	requests := make([]anthropic.MessageBatchNewParamsRequest, len(items))
+	for i, item := range items {
+		requests[i] = anthropic.MessageBatchNewParamsRequest{
+			CustomID: item.ID,
+			Params: anthropic.MessageBatchNewParamsRequestParams{
+				Model:     anthropic.Model(model),
+				MaxTokens: int64(maxTokens),
+				System:    []anthropic.TextBlockParam{{Text: systemPrompt}},
+				Messages:  []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(item.Prompt))},
+			},
+		}
+	}
+
+	created, err := service.New(ctx, anthropic.MessageBatchNewParams{Requests: requests})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create batch: %w", err)
+	}
+	return &Job{BatchID: created.ID, Model: model, MaxTokens: maxTokens, CreatedAt: time.Now(), Items: items}, nil
+}
+
+// Wait polls the batch every interval until it has ended, calling progress after each poll
+func Wait(ctx context.Context, service *anthropic.MessageBatchService, batchID string, interval time.Duration, progress func(*anthropic.MessageBatch)) (*anthropic.MessageBatch, error) {
+	for {
+		status, err := service.Get(ctx, batchID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check batch %s: %w", batchID, err)
+		}
+		if progress != nil {
+			progress(status)
+		}
+		if status.ProcessingStatus == anthropic.MessageBatchProcessingStatusEnded {
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Summary reports what Collect wrote
+type Summary struct {
+	Written  int
+	Failures []string // One "output: reason" line per item without a usable answer
+}
+
+// Collect downloads the results of an ended batch and writes each answer to its item's output file
+func Collect(ctx context.Context, service *anthropic.MessageBatchService, job *Job) (Summary, error) {
+	items := make(map[string]Item, len(job.Items))
+	for _, item := range job.Items {
+		items[item.ID] = item
+	}
+
+	summary := Summary{}
+	stream := service.ResultsStreaming(ctx, job.BatchID)
+	defer stream.Close()
+	for stream.Next() {
+		response := stream.Current()
+		item, ok := items[response.CustomID]
+		if !ok {
+			continue
+		}
+		delete(items, response.CustomID)
+
+		result := response.Result
+		switch result.Type {
+		case "succeeded":
+			if err := writeOutput(item.Output, messageText(result.Message)); err != nil {
+				return summary, err
+			}
+			summary.Written++
+			if result.Message.StopReason == anthropic.StopReasonMaxTokens {
+				summary.Failures = append(summary.Failures, item.Output+": written, but cut off at the max token limit")
+			}
+		case "errored":
+			summary.Failures = append(summary.Failures, fmt.Sprintf("%s: %s", item.Output, result.Error.Error.Message))
+		default:
+			summary.Failures = append(summary.Failures, fmt.Sprintf("%s: %s", item.Output, result.Type))
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return summary, fmt.Errorf("failed to download results of batch %s: %w", job.BatchID, err)
+	}
+	for _, item := range items {
+		summary.Failures = append(summary.Failures, item.Output+": missing from results")
+	}
+	return summary, nil
+}
+
+func messageText(message anthropic.Message) string {
+	var parts []string
+	for _, block := range message.Content {
+		if text, ok := block.AsAny().(anthropic.TextBlock); ok {
+			parts = append(parts, text.Text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(parts, "\n")) + "\n"
+}
+
+func writeOutput(path, content string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
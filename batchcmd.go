@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"anthropic-chat/batch"
+	"anthropic-chat/config"
+	"anthropic-chat/ignore"
+	"anthropic-chat/provider"
+	"anthropic-chat/redact"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// runBatchCommand implements `goocode batch <submit|status|results|list|cancel>`
+func runBatchCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: goocode batch <submit|status|results|list|cancel> [flags] [pattern...|batch-id]")
+	}
+
+	cfg := config.NewConfig()
+	store := batch.NewStore(cfg.Batch.Dir)
+
+	action := args[0]
+	flags := flag.NewFlagSet("batch "+action, flag.ContinueOnError)
+	prompt := flags.String("prompt", "", "For submit: instruction applied to every file matching the patterns")
+	prompts := flags.String("prompts", "", "For submit: JSONL file with one {\"prompt\", \"name\", \"output\"} object per line")
+	outDir := flags.String("out", "batch-output", "For submit: directory answers are written to")
+	modelName := flags.String("model", "", "For submit: model to use (defaults to GOOCODE_MODEL or "+config.DefaultModel+")")
+	maxTokens := flags.Int("max-tokens", 0, fmt.Sprintf("For submit: output token limit per prompt (defaults to %d, or the model's limit if lower)", config.MaxOutputTokens))
+	wait := flags.Bool("wait", false, "For submit and results: wait for the batch to end, then write the results")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	if action == "list" {
+		jobs, err := store.List()
+		if err != nil {
+			return err
+		}
+		for _, job := range jobs {
+			fmt.Printf("%s  %s  %d prompt(s)  %s\n", job.BatchID, job.CreatedAt.Format("2006-01-02 15:04"), len(job.Items), job.Model)
+		}
+		return nil
+	}
+
+	if cfg.API.Platform != provider.PlatformAnthropic {
+		return fmt.Errorf("batch jobs need the Anthropic API, not %s", cfg.API.Platform)
+	}
+	ctx := context.Background()
+	client, err := newAnthropicClient(ctx, cfg.API)
+	if err != nil {
+		return err
+	}
+	service := &client.Messages.Batches
+
+	switch action {
+	case "submit":
+		if *modelName != "" {
+			model, _ := config.LookupModel(*modelName)
+			cfg.SetModel(model)
+		}
+		if *maxTokens == 0 {
+			*maxTokens = cfg.MaxTokens()
+		}
+		items, err := planBatch(*prompt, *prompts, *outDir, flags.Args())
+		if err != nil {
+			return err
+		}
+		if cfg.Security.RedactSecrets {
+			redactPrompts(items)
+		}
+		job, err := batch.Submit(ctx, service, cfg.Model().ID, *maxTokens, items)
+		if err != nil {
+			return err
+		}
+		if err := store.Save(job); err != nil {
+			return err
+		}
+		fmt.Printf("Submitted batch %s with %d prompt(s)\n", job.BatchID, len(job.Items))
+		if !*wait {
+			fmt.Printf("Run `goocode batch results %s` once it has ended (usually within an hour, at most 24 hours)\n", job.BatchID)
+			return nil
+		}
+		return collectBatch(ctx, service, store, cfg, job.BatchID, true)
+	case "status", "results", "cancel":
+		if flags.NArg() != 1 {
+			return fmt.Errorf("usage: goocode batch %s <batch-id>", action)
+		}
+		batchID := flags.Arg(0)
+		switch action {
+		case "status":
+			status, err := service.Get(ctx, batchID)
+			if err != nil {
+				return fmt.Errorf("failed to check batch %s: %w", batchID, err)
+			}
+			printBatchStatus(status)
+			return nil
+		case "cancel":
+			status, err := service.Cancel(ctx, batchID)
+			if err != nil {
+				return fmt.Errorf("failed to cancel batch %s: %w", batchID, err)
+			}
+			printBatchStatus(status)
+			return nil
+		default:
+			return collectBatch(ctx, service, store, cfg, batchID, *wait)
+		}
+	default:
+		return fmt.Errorf("unknown batch action %q", action)
+	}
+}
+
+// planBatch builds the items to submit from either a prompt plus file patterns or a prompts file
+func planBatch(prompt, promptsFile, outDir string, patterns []string) ([]batch.Item, error) {
+	if (prompt == "") == (promptsFile == "") {
+		return nil, fmt.Errorf("specify either --prompt with file patterns or --prompts <file.jsonl>")
+	}
+
+	var items []batch.Item
+	if promptsFile != "" {
+		var err error
+		if items, err = batch.FromPrompts(promptsFile, outDir); err != nil {
+			return nil, err
+		}
+	} else {
+		if len(patterns) == 0 {
+			return nil, fmt.Errorf("usage: goocode batch submit --prompt <instruction> <pattern...> (e.g. 'pkg/**/*.go')")
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get current directory: %w", err)
+		}
+		var skipped []string
+		if items, skipped, err = batch.FromFiles(cwd, patterns, prompt, outDir, ignore.New(cwd).Ignored); err != nil {
+			return nil, err
+		}
+		for _, file := range skipped {
+			fmt.Printf("Skipped %s\n", file)
+		}
+	}
+
+	// Results may be collected from another directory, so outputs are stored as absolute paths
+	for i := range items {
+		output, err := filepath.Abs(items[i].Output)
+		if err != nil {
+			return nil, fmt.Errorf("invalid output path %s: %w", items[i].Output, err)
+		}
+		items[i].Output = output
+	}
+	return items, nil
+}
+
+// redactPrompts replaces secrets in the prompts before they are sent, saying how many were hidden
+func redactPrompts(items []batch.Item) {
+	redactor := redact.New()
+	hidden := 0
+	for i := range items {
+		var redactions []redact.Redaction
+		items[i].Prompt, redactions = redactor.Redact(items[i].Prompt)
+		hidden += redact.Total(redactions)
+	}
+	if hidden > 0 {
+		fmt.Printf("Redacted %d secret(s) from the prompts\n", hidden)
+	}
+}
+
+// collectBatch writes the results of an ended batch, optionally waiting for it to end first
+func collectBatch(ctx context.Context, service *anthropic.MessageBatchService, store *batch.Store, cfg *config.Config, batchID string, wait bool) error {
+	job, err := store.Load(batchID)
+	if err != nil {
+		return err
+	}
+
+	status, err := service.Get(ctx, batchID)
+	if err != nil {
+		return fmt.Errorf("failed to check batch %s: %w", batchID, err)
+	}
+	if status.ProcessingStatus != anthropic.MessageBatchProcessingStatusEnded {
+		if !wait {
+			printBatchStatus(status)
+			return fmt.Errorf("batch %s has not ended yet; pass --wait to wait for it", batchID)
+		}
+		interval := time.Duration(cfg.Batch.PollInterval) * time.Second
+		if _, err := batch.Wait(ctx, service, batchID, interval, printBatchStatus); err != nil {
+			return err
+		}
+	}
+
+	summary, err := batch.Collect(ctx, service, job)
+	if err != nil {
+		return err
+	}
+	for _, failure := range summary.Failures {
+		fmt.Fprintf(os.Stderr, "Failed: %s\n", failure)
+	}
+	fmt.Printf("Wrote %d of %d result(s)\n", summary.Written, len(job.Items))
+	return nil
+}
+
+func printBatchStatus(status *anthropic.MessageBatch) {
+	counts := status.RequestCounts
+	fmt.Printf("%s: %s (%d processing, %d succeeded, %d errored, %d canceled, %d expired)\n",
+		status.ID, status.ProcessingStatus, counts.Processing, counts.Succeeded, counts.Errored, counts.Canceled, counts.Expired)
+}
@@ -0,0 +1,226 @@
+package cassette
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// ErrDiverged is returned on replay when the agent asks for something other than what was recorded
+var ErrDiverged = errors.New("replay diverged from the recording")
+
+// Interaction is one recorded model request and its response
+type Interaction struct {
+	Kind     string            `json:"kind"`     // "stream" or "message"
+	Messages int               `json:"messages"` // Conversation length of the request
+	Digest   string            `json:"digest"`   // Hash of the request's system prompt and messages, to catch divergence
+	Events   []json.RawMessage `json:"events,omitempty"`
+	Message  json.RawMessage   `json:"message,omitempty"`
+	Error    string            `json:"error,omitempty"` // "context_length", "overloaded" or the error message
+}
+
+// ToolCall is one recorded tool call with the result the model was given
+type ToolCall struct {
+	Name    string          `json:"name"`
+	Input   json.RawMessage `json:"input"`
+	Result  string          `json:"result"`
+	IsError bool            `json:"is_error,omitempty"`
+}
+
+// Cassette holds a session's user input, model responses and tool results, so a change to the agent
+// loop can be checked by replaying a known-good session without the network. The file holds one JSON
+// event per line; while recording each one is appended as it happens, so a crashed session still leaves
+// a usable file and a long one isn't rewritten on every addition.
+type Cassette struct {
+	Inputs       []string
+	Interactions []Interaction
+	ToolCalls    []ToolCall
+
+	mu        sync.Mutex
+	path      string
+	replaying bool
+	next      int // Next interaction to replay
+	nextTool  int // Next tool call to replay
+	nextInput int // Next input line to replay
+}
+
+// event is one line of a cassette file; exactly one field is set
+type event struct {
+	Input       *string      `json:"input,omitempty"`
+	Interaction *Interaction `json:"interaction,omitempty"`
+	ToolCall    *ToolCall    `json:"tool_call,omitempty"`
+}
+
+// New starts an empty recording at path, replacing any file there
+func New(path string) (*Cassette, error) {
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to create cassette: %w", err)
+	}
+	return &Cassette{path: path}, nil
+}
+
+// Load reads a recording for replay
+func Load(path string) (*Cassette, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette: %w", err)
+	}
+	defer file.Close()
+	c := &Cassette{path: path, replaying: true}
+	decoder := json.NewDecoder(file)
+	for line := 1; ; line++ {
+		var e event
+		if err := decoder.Decode(&e); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s, event %d: %w", path, line, err)
+		}
+		switch {
+		case e.Input != nil:
+			c.Inputs = append(c.Inputs, *e.Input)
+		case e.Interaction != nil:
+			c.Interactions = append(c.Interactions, *e.Interaction)
+		case e.ToolCall != nil:
+			c.ToolCalls = append(c.ToolCalls, *e.ToolCall)
+		default:
+			return nil, fmt.Errorf("failed to parse cassette %s: event %d is empty", path, line)
+		}
+	}
+	return c, nil
+}
+
+// Replaying reports whether tool results come from the recording; false for a nil cassette
+func (c *Cassette) Replaying() bool {
+	return c != nil && c.replaying
+}
+
+// RecordTool adds a tool call's result while recording; it does nothing on a nil or replaying cassette
+func (c *Cassette) RecordTool(name string, input json.RawMessage, result string, isError bool) error {
+	if c == nil || c.replaying {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	call := ToolCall{Name: name, Input: input, Result: result, IsError: isError}
+	c.ToolCalls = append(c.ToolCalls, call)
+	return c.append(event{ToolCall: &call})
+}
+
+// RecordInput adds a line the user typed while recording; it does nothing on a nil or replaying cassette
+func (c *Cassette) RecordInput(line string) error {
+	if c == nil || c.replaying {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Inputs = append(c.Inputs, line)
+	return c.append(event{Input: &line})
+}
+
+// ReplayInput returns the next line the user typed in the recording; false once there are no more
+func (c *Cassette) ReplayInput() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nextInput >= len(c.Inputs) {
+		return "", false
+	}
+	c.nextInput++
+	return c.Inputs[c.nextInput-1], true
+}
+
+// ReplayTool returns the recorded result of the next tool call, which must have the same name and input
+func (c *Cassette) ReplayTool(name string, input json.RawMessage) (string, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.nextTool >= len(c.ToolCalls) {
+		return "", false, fmt.Errorf("%w: %s was called after the %d recorded tool call(s)", ErrDiverged, name, len(c.ToolCalls))
+	}
+	call := c.ToolCalls[c.nextTool]
+	c.nextTool++
+	if call.Name != name || !sameJSON(call.Input, input) {
+		return "", false, fmt.Errorf("%w: tool call %d was %s %s, now %s %s", ErrDiverged, c.nextTool, call.Name, call.Input, name, input)
+	}
+	return call.Result, call.IsError, nil
+}
+
+// Remaining reports how much of a replayed recording was not used
+func (c *Cassette) Remaining() (interactions, toolCalls, inputs int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.Interactions) - c.next, len(c.ToolCalls) - c.nextTool, len(c.Inputs) - c.nextInput
+}
+
+func (c *Cassette) record(interaction Interaction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.Interactions = append(c.Interactions, interaction)
+	return c.append(event{Interaction: &interaction})
+}
+
+// take returns the next recorded interaction, checking it answers the same request
+func (c *Cassette) take(kind string, system []anthropic.TextBlockParam, messages []anthropic.MessageParam) (Interaction, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.next >= len(c.Interactions) {
+		return Interaction{}, fmt.Errorf("%w: request %d was made after the %d recorded one(s)", ErrDiverged, c.next+1, len(c.Interactions))
+	}
+	interaction := c.Interactions[c.next]
+	c.next++
+	switch {
+	case interaction.Kind != kind:
+		return Interaction{}, fmt.Errorf("%w: request %d was a %s request, now a %s request", ErrDiverged, c.next, interaction.Kind, kind)
+	case interaction.Digest != digest(system, messages):
+		return Interaction{}, fmt.Errorf("%w: request %d had %d message(s), now %d with a different system prompt or content", ErrDiverged, c.next, interaction.Messages, len(messages))
+	}
+	return interaction, nil
+}
+
+// append writes one event to the end of the file
+func (c *Cassette) append(e event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode cassette event: %w", err)
+	}
+	file, err := os.OpenFile(c.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// digest hashes the request's system prompt and messages; replayed responses and tool results make them
+// identical unless the agent changed how it builds the prompt or the conversation
+func digest(system []anthropic.TextBlockParam, messages []anthropic.MessageParam) string {
+	data, err := json.Marshal(struct {
+		System   []anthropic.TextBlockParam `json:"system"`
+		Messages []anthropic.MessageParam   `json:"messages"`
+	}{system, messages})
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+func sameJSON(a, b json.RawMessage) bool {
+	var compactA, compactB bytes.Buffer
+	if json.Compact(&compactA, a) != nil || json.Compact(&compactB, b) != nil {
+		return bytes.Equal(a, b)
+	}
+	return bytes.Equal(compactA.Bytes(), compactB.Bytes())
+}
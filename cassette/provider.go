@@ -0,0 +1,205 @@
+package cassette
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"anthropic-chat/provider"
+	"anthropic-chat/tokenizer"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Recorder passes requests on to a real provider, recording each response
+type Recorder struct {
+	inner    provider.Provider
+	cassette *Cassette
+}
+
+// NewRecorder wraps inner so its responses are recorded to c
+func NewRecorder(inner provider.Provider, c *Cassette) *Recorder {
+	return &Recorder{inner: inner, cassette: c}
+}
+
+// Name returns the wrapped provider's name
+func (r *Recorder) Name() string {
+	return r.inner.Name()
+}
+
+// StreamMessage starts a stream whose events are recorded once it ends
+func (r *Recorder) StreamMessage(ctx context.Context, params anthropic.MessageNewParams) provider.Stream {
+	return &recordingStream{
+		Stream:      r.inner.StreamMessage(ctx, params),
+		cassette:    r.cassette,
+		interaction: Interaction{Kind: "stream", Messages: len(params.Messages), Digest: digest(params.System, params.Messages)},
+	}
+}
+
+// NewMessage performs the request and records its response
+func (r *Recorder) NewMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	message, err := r.inner.NewMessage(ctx, params)
+	interaction := Interaction{Kind: "message", Messages: len(params.Messages), Digest: digest(params.System, params.Messages), Error: errorCode(err)}
+	if err == nil {
+		interaction.Message = json.RawMessage(message.RawJSON())
+	}
+	if recordErr := r.cassette.record(interaction); recordErr != nil {
+		return nil, recordErr
+	}
+	return message, err
+}
+
+// CountTokens is passed through unrecorded; replays estimate counts instead
+func (r *Recorder) CountTokens(ctx context.Context, params anthropic.MessageCountTokensParams) (int, error) {
+	return r.inner.CountTokens(ctx, params)
+}
+
+// recordingStream keeps the raw events of a stream and records them when it ends or is closed
+type recordingStream struct {
+	provider.Stream
+	cassette    *Cassette
+	interaction Interaction
+	recorded    bool
+	recordErr   error
+}
+
+func (s *recordingStream) Next() bool {
+	if s.Stream.Next() {
+		s.interaction.Events = append(s.interaction.Events, json.RawMessage(s.Stream.Current().RawJSON()))
+		return true
+	}
+	s.finish()
+	return false
+}
+
+func (s *recordingStream) Err() error {
+	if s.recordErr != nil {
+		return s.recordErr
+	}
+	return s.Stream.Err()
+}
+
+func (s *recordingStream) Close() error {
+	s.finish()
+	return s.Stream.Close()
+}
+
+func (s *recordingStream) finish() {
+	if s.recorded {
+		return
+	}
+	s.recorded = true
+	s.interaction.Error = errorCode(s.Stream.Err())
+	s.recordErr = s.cassette.record(s.interaction)
+}
+
+// Player answers requests from a recording without touching the network
+type Player struct {
+	cassette *Cassette
+}
+
+// NewPlayer creates a provider that replays c
+func NewPlayer(c *Cassette) *Player {
+	return &Player{cassette: c}
+}
+
+// Name returns the provider name
+func (p *Player) Name() string {
+	return "replay"
+}
+
+// StreamMessage replays the next recorded stream
+func (p *Player) StreamMessage(ctx context.Context, params anthropic.MessageNewParams) provider.Stream {
+	interaction, err := p.cassette.take("stream", params.System, params.Messages)
+	if err != nil {
+		return &replayStream{err: err, index: -1}
+	}
+	stream := &replayStream{err: errorFromCode(interaction.Error), index: -1}
+	for _, raw := range interaction.Events {
+		var event anthropic.MessageStreamEventUnion
+		if err := json.Unmarshal(raw, &event); err != nil {
+			return &replayStream{err: fmt.Errorf("invalid event in cassette: %w", err), index: -1}
+		}
+		stream.events = append(stream.events, event)
+	}
+	return stream
+}
+
+// NewMessage replays the next recorded message
+func (p *Player) NewMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	interaction, err := p.cassette.take("message", params.System, params.Messages)
+	if err != nil {
+		return nil, err
+	}
+	if interaction.Error != "" {
+		return nil, errorFromCode(interaction.Error)
+	}
+	var message anthropic.Message
+	if err := json.Unmarshal(interaction.Message, &message); err != nil {
+		return nil, fmt.Errorf("invalid message in cassette: %w", err)
+	}
+	return &message, nil
+}
+
+// CountTokens estimates tokens with the local tokenizer, as the mock provider does
+func (p *Player) CountTokens(ctx context.Context, params anthropic.MessageCountTokensParams) (int, error) {
+	return tokenizer.CountParams(params), nil
+}
+
+// replayStream iterates over recorded events, then reports the recorded error, if any
+type replayStream struct {
+	events []anthropic.MessageStreamEventUnion
+	index  int
+	err    error
+	done   bool
+}
+
+func (s *replayStream) Next() bool {
+	if s.index+1 >= len(s.events) {
+		s.done = true
+		return false
+	}
+	s.index++
+	return true
+}
+
+func (s *replayStream) Current() anthropic.MessageStreamEventUnion {
+	return s.events[s.index]
+}
+
+func (s *replayStream) Err() error {
+	if !s.done {
+		return nil
+	}
+	return s.err
+}
+
+func (s *replayStream) Close() error {
+	return nil
+}
+
+// errorCode records err so that errorFromCode can give back an error the agent treats the same way
+func errorCode(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case provider.IsContextLengthError(err):
+		return "context_length"
+	case provider.IsOverloadedError(err):
+		return "overloaded"
+	}
+	return err.Error()
+}
+
+func errorFromCode(code string) error {
+	switch code {
+	case "":
+		return nil
+	case "context_length":
+		return provider.ErrContextLength
+	case "overloaded":
+		return provider.ErrOverloaded
+	}
+	return errors.New(code)
+}
@@ -0,0 +1,149 @@
+package checkpoint
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// binarySniffBytes is how much of a file is checked for NUL bytes to tell binary from text
+const binarySniffBytes = 8000
+
+// Change is a file that differs between a checkpoint and the working tree
+type Change struct {
+	Path    string
+	Kind    string // created, modified or deleted
+	Added   int    // Lines added
+	Removed int    // Lines removed
+	Binary  bool   // Line counts don't apply
+	Large   bool   // Now over the snapshot size limit, so lines aren't counted
+}
+
+// Changes compares the working tree with the checkpoint. Line counts compare the lines of each version as
+// multisets, so a moved line isn't counted; files that were too large to snapshot are left out, and ones
+// that have grown too large are reported without line counts.
+func (s *Store) Changes(id string) ([]Change, error) {
+	cp, err := s.Load(id)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]bool, len(cp.Files))
+	for _, rel := range cp.Files {
+		stored[rel] = true
+	}
+	untracked := make(map[string]bool, len(cp.Untracked))
+	for _, rel := range cp.Untracked {
+		untracked[rel] = true
+	}
+
+	var changes []Change
+	present := map[string]bool{}
+	err = s.walk(func(rel string, info os.FileInfo) error {
+		present[rel] = true
+		if untracked[rel] {
+			return nil
+		}
+		if info.Size() > maxFileSize {
+			// A file that grew past the limit is still reported, without reading it
+			kind := "created"
+			if stored[rel] {
+				kind = "modified"
+			}
+			changes = append(changes, Change{Path: filepath.ToSlash(rel), Kind: kind, Large: true})
+			return nil
+		}
+		current, err := os.ReadFile(filepath.Join(s.workingDir, rel))
+		if err != nil {
+			return nil
+		}
+		if !stored[rel] {
+			changes = append(changes, lineChange(rel, "created", nil, current))
+			return nil
+		}
+		previous, err := os.ReadFile(s.FilePath(cp, rel))
+		if err != nil {
+			return fmt.Errorf("checkpoint is missing %s: %w", rel, err)
+		}
+		if !bytes.Equal(previous, current) {
+			changes = append(changes, lineChange(rel, "modified", previous, current))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare with checkpoint: %w", err)
+	}
+	for _, rel := range cp.Files {
+		if present[rel] {
+			continue
+		}
+		previous, err := os.ReadFile(s.FilePath(cp, rel))
+		if err != nil {
+			return nil, fmt.Errorf("checkpoint is missing %s: %w", rel, err)
+		}
+		changes = append(changes, lineChange(rel, "deleted", previous, nil))
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+// Delete removes a checkpoint and its files
+func (s *Store) Delete(id string) error {
+	if _, err := s.Load(id); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(s.root, id))
+}
+
+// Prune deletes the checkpoints with label created more than age ago, such as those left behind by a
+// session that was killed
+func (s *Store) Prune(label string, age time.Duration) {
+	checkpoints, _ := s.List()
+	for _, cp := range checkpoints {
+		if cp.Label == label && time.Since(cp.CreatedAt) > age {
+			s.Delete(cp.ID)
+		}
+	}
+}
+
+// lineChange counts the lines that differ between two versions of a file
+func lineChange(rel, kind string, previous, current []byte) Change {
+	change := Change{Path: filepath.ToSlash(rel), Kind: kind}
+	if isBinary(previous) || isBinary(current) {
+		change.Binary = true
+		return change
+	}
+	counts := map[string]int{}
+	for _, line := range splitLines(previous) {
+		counts[line]++
+	}
+	for _, line := range splitLines(current) {
+		if counts[line] > 0 {
+			counts[line]--
+		} else {
+			change.Added++
+		}
+	}
+	for _, n := range counts {
+		change.Removed += n
+	}
+	return change
+}
+
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+	lines := bytes.Split(bytes.TrimSuffix(data, []byte("\n")), []byte("\n"))
+	result := make([]string, len(lines))
+	for i, line := range lines {
+		result[i] = string(line)
+	}
+	return result
+}
+
+func isBinary(data []byte) bool {
+	return bytes.IndexByte(data[:min(len(data), binarySniffBytes)], 0) >= 0
+}
@@ -0,0 +1,227 @@
+package checkpoint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	maxFileSize  = 5 << 20   // Files larger than this are left out of snapshots
+	maxTotalSize = 200 << 20 // Refuse to snapshot trees larger than this
+)
+
+// skipDirs are never snapshotted or touched by restore
+var skipDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, ".venv": true, "__pycache__": true,
+}
+
+// Checkpoint is a snapshot of the working tree at a point in time
+type Checkpoint struct {
+	ID         string    `json:"id"`
+	Label      string    `json:"label"`
+	WorkingDir string    `json:"working_dir"`
+	CreatedAt  time.Time `json:"created_at"`
+	Files      []string  `json:"files"`               // Paths stored in the snapshot
+	Untracked  []string  `json:"untracked,omitempty"` // Paths present but too large to store
+}
+
+// Store keeps checkpoints for one working directory
+type Store struct {
+	root       string
+	workingDir string
+}
+
+// NewStore creates a checkpoint store for workingDir under baseDir, namespaced per project
+func NewStore(baseDir, workingDir string) *Store {
+	abs, err := filepath.Abs(workingDir)
+	if err != nil {
+		abs = workingDir
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return &Store{
+		root:       filepath.Join(baseDir, hex.EncodeToString(sum[:])[:12]),
+		workingDir: abs,
+	}
+}
+
+// Create snapshots every regular file in the working directory
+func (s *Store) Create(label string) (*Checkpoint, error) {
+	now := time.Now()
+	cp := &Checkpoint{
+		ID:         now.Format("20060102-150405.000"),
+		Label:      label,
+		WorkingDir: s.workingDir,
+		CreatedAt:  now,
+	}
+	filesDir := filepath.Join(s.root, cp.ID, "files")
+
+	var total int64
+	err := s.walk(func(rel string, info os.FileInfo) error {
+		if info.Size() > maxFileSize {
+			cp.Untracked = append(cp.Untracked, rel)
+			return nil
+		}
+		total += info.Size()
+		if total > maxTotalSize {
+			return fmt.Errorf("working directory exceeds the %d MB checkpoint limit", maxTotalSize>>20)
+		}
+		if err := copyFile(filepath.Join(s.workingDir, rel), filepath.Join(filesDir, rel), info.Mode()); err != nil {
+			return err
+		}
+		cp.Files = append(cp.Files, rel)
+		return nil
+	})
+	if err != nil {
+		os.RemoveAll(filepath.Join(s.root, cp.ID))
+		return nil, fmt.Errorf("failed to create checkpoint: %w", err)
+	}
+
+	if err := s.writeManifest(cp); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// Restore returns the working tree to the state captured by the checkpoint. Of the files created since,
+// only those listed in created (relative to the working directory) are removed, so files someone else
+// added meanwhile survive; large untracked files are left alone.
+func (s *Store) Restore(id string, created []string) error {
+	cp, err := s.Load(id)
+	if err != nil {
+		return err
+	}
+
+	known := make(map[string]bool, len(cp.Files)+len(cp.Untracked))
+	for _, rel := range cp.Files {
+		known[rel] = true
+	}
+	for _, rel := range cp.Untracked {
+		known[rel] = true
+	}
+
+	for _, rel := range created {
+		rel = filepath.Clean(rel)
+		if known[rel] || !filepath.IsLocal(rel) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.workingDir, rel)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", rel, err)
+		}
+	}
+
+	filesDir := filepath.Join(s.root, cp.ID, "files")
+	for _, rel := range cp.Files {
+		src := filepath.Join(filesDir, rel)
+		info, err := os.Stat(src)
+		if err != nil {
+			return fmt.Errorf("checkpoint is missing %s: %w", rel, err)
+		}
+		if err := copyFile(src, filepath.Join(s.workingDir, rel), info.Mode()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load reads a checkpoint manifest
+func (s *Store) Load(id string) (*Checkpoint, error) {
+	data, err := os.ReadFile(filepath.Join(s.root, id, "manifest.json"))
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint %s not found: %w", id, err)
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to parse checkpoint %s: %w", id, err)
+	}
+	return &cp, nil
+}
+
+// List returns the checkpoints for this working directory, oldest first
+func (s *Store) List() ([]*Checkpoint, error) {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var checkpoints []*Checkpoint
+	for _, entry := range entries {
+		if cp, err := s.Load(entry.Name()); err == nil {
+			checkpoints = append(checkpoints, cp)
+		}
+	}
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].CreatedAt.Before(checkpoints[j].CreatedAt)
+	})
+	return checkpoints, nil
+}
+
+// FilePath returns where the checkpointed copy of rel is stored
+func (s *Store) FilePath(cp *Checkpoint, rel string) string {
+	return filepath.Join(s.root, cp.ID, "files", rel)
+}
+
+func (s *Store) writeManifest(cp *Checkpoint) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Join(s.root, cp.ID), 0o755); err != nil {
+		return fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	return os.WriteFile(filepath.Join(s.root, cp.ID, "manifest.json"), data, 0o644)
+}
+
+// walk visits every regular file under the working directory, skipping ignored directories and the
+// store itself when it lives inside the tree
+func (s *Store) walk(visit func(rel string, info os.FileInfo) error) error {
+	base, _ := filepath.Abs(filepath.Dir(s.root))
+	return filepath.Walk(s.workingDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != s.workingDir && (skipDirs[info.Name()] || path == base) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.workingDir, path)
+		if err != nil {
+			return err
+		}
+		return visit(rel, info)
+	})
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", dst, err)
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy %s: %w", src, err)
+	}
+	return out.Close()
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"anthropic-chat/config"
+
+	"github.com/spf13/cobra"
+)
+
+// newRootCommand builds the goocode command line: chatting is the default, with subcommands for
+// one-shot runs, the MCP server and the maintenance tasks
+func newRootCommand() *cobra.Command {
+	opts := &chatOptions{}
+	root := &cobra.Command{
+		Use:   "goocode",
+		Short: "A coding assistant for your terminal, powered by Claude",
+		Long: "GooCode chats with Claude about the code in a working directory, reading and changing files and running commands with your approval.\n\n" +
+			"Without a subcommand it starts an interactive chat, or answers the prompt given with -p and exits.",
+		Args:              cobra.NoArgs,
+		SilenceUsage:      true,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runChat(opts)
+		},
+	}
+	addChatFlags(root, opts)
+
+	chat := &cobra.Command{
+		Use:               "chat",
+		Short:             "Start an interactive chat (the default)",
+		Args:              cobra.NoArgs,
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runChat(opts)
+		},
+	}
+	addChatFlags(chat, opts)
+
+	run := &cobra.Command{
+		Use:   "run <prompt>",
+		Short: "Answer one prompt and exit; piped stdin is added to it",
+		Example: "  goocode run \"list every HTTP route in this service\"\n" +
+			"  cat error.log | goocode run \"explain this failure\"",
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.prompt = strings.Join(args, " ")
+			}
+			if opts.prompt == "" {
+				return errors.New("run needs a prompt, e.g. goocode run \"explain this repository\"")
+			}
+			return runChat(opts)
+		},
+	}
+	addChatFlags(run, opts)
+
+	root.AddCommand(chat, run,
+		subcommand("Serve the file and search tools over MCP on stdio",
+			"goocode serve [--dir path] [--read-only]", nil, runMCPServeCommand, "mcp-serve"),
+		subcommand("List, search, archive, delete, restore or purge saved sessions",
+			"goocode sessions <list|search|archive|delete|restore|purge> [--older-than age] [--project path] [--all] [session-id...]",
+			[]string{"list", "search", "archive", "delete", "restore", "purge"}, runSessionsCommand),
+		subcommand("Check the environment and suggest fixes",
+			"goocode doctor [--dir path] [--offline]", nil, runDoctorCommand),
+		subcommand("Show where settings come from and the configuration in effect",
+			"goocode config [show|path]", []string{"show", "path"}, runConfigCommand),
+		subcommand("Ask for the API key and default model and save them to the settings file",
+			"goocode setup", nil, runSetupCommand),
+		subcommand("Create the .goocode project directory",
+			"goocode init [dir]", nil, runInitCommand),
+		subcommand("Run prompts over many files with the Message Batches API",
+			"goocode batch <submit|status|results|list|cancel> [flags] [pattern...|batch-id]",
+			[]string{"submit", "status", "results", "list", "cancel"}, runBatchCommand),
+		subcommand("Manage the project knowledge base",
+			"goocode kb <add|search|list|remove|clear> [--project dir] [path...|query]",
+			[]string{"add", "search", "list", "remove", "clear"}, runKBCommand),
+		subcommand("Manage the semantic code search index",
+			"goocode index <update|search|status|clear> [--project dir] [query]",
+			[]string{"update", "search", "status", "clear"}, runIndexCommand),
+		subcommand("Verify the hash chain of the tool call audit log",
+			"goocode audit verify [file]", []string{"verify"}, runAuditCommand),
+	)
+	return root
+}
+
+// addChatFlags defines the flags of a chat or one-shot run on cmd. Those not backed by the configuration
+// fall back to an environment variable of their own.
+func addChatFlags(cmd *cobra.Command, opts *chatOptions) {
+	flags := cmd.Flags()
+	flags.StringVar(&opts.provider, "provider", envOr("GOOCODE_PROVIDER", "anthropic"), "Model backend to use: anthropic or mock (env GOOCODE_PROVIDER)")
+	flags.StringVar(&opts.scenario, "scenario", os.Getenv("GOOCODE_SCENARIO"), "Scenario file with canned responses for the mock provider (env GOOCODE_SCENARIO)")
+	flags.StringVar(&opts.model, "model", "", "Model to use (defaults to GOOCODE_MODEL or "+config.DefaultModel+")")
+	flags.BoolVar(&opts.headless, "headless", false, "Never prompt for approval; actions not allowed by GOOCODE_APPROVAL_POLICY are denied (env GOOCODE_HEADLESS)")
+	flags.BoolVar(&opts.readOnly, "read-only", false, "Explore without risk: tools that change files or run commands are left out (env GOOCODE_READ_ONLY)")
+	flags.StringVar(&opts.outputFormat, "output-format", envOr("GOOCODE_OUTPUT_FORMAT", "text"), "Output format: text, or stream-json for one JSON event per line on stdout (env GOOCODE_OUTPUT_FORMAT)")
+	flags.StringVarP(&opts.prompt, "prompt", "p", "", "Answer this prompt and exit instead of starting a chat; piped stdin is added to it, e.g. cat error.log | goocode -p \"explain this failure\"")
+	flags.StringVar(&opts.record, "record", "", "Record the input, the model's responses and the tool results to this cassette file")
+	flags.StringVar(&opts.replay, "replay", "", "Replay a cassette written with --record instead of calling the model or running tools")
+	flags.StringVar(&opts.profile, "profile", "", "Start with this profile from the settings file (defaults to GOOCODE_PROFILE)")
+	flags.StringVar(&opts.schemaFile, "json-schema", "", "With -p, end with a JSON answer matching this JSON Schema file, printed alone on stdout")
+	flags.StringVar(&opts.artifactsDir, "artifacts", "", "Directory for generated reports, diagrams and docs (defaults to GOOCODE_ARTIFACTS_DIR or .goocode/artifacts)")
+
+	cmd.RegisterFlagCompletionFunc("provider", cobra.FixedCompletions([]string{"anthropic", "mock"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("output-format", cobra.FixedCompletions([]string{"text", "stream-json"}, cobra.ShellCompDirectiveNoFileComp))
+	cmd.RegisterFlagCompletionFunc("model", completeModels)
+	cmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	cmd.RegisterFlagCompletionFunc("prompt", cobra.NoFileCompletions)
+	cmd.MarkFlagFilename("scenario", "json")
+	cmd.MarkFlagFilename("record", "jsonl", "json")
+	cmd.MarkFlagFilename("replay", "jsonl", "json")
+	cmd.MarkFlagFilename("json-schema", "json")
+	cmd.MarkFlagDirname("artifacts")
+}
+
+// subcommand wraps a command that parses its own flags, such as `goocode doctor`. Its first argument
+// completes to one of actions, when it has them.
+func subcommand(short, usage string, actions []string, run func(args []string) error, aliases ...string) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   strings.TrimPrefix(usage, "goocode "),
+		Aliases:               aliases,
+		Short:                 short,
+		DisableFlagParsing:    true,
+		DisableFlagsInUseLine: true,
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) == 0 && actions != nil {
+				return actions, cobra.ShellCompDirectiveNoFileComp
+			}
+			return nil, cobra.ShellCompDirectiveDefault
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if slices.Equal(args, []string{"--help"}) || slices.Equal(args, []string{"-h"}) {
+				if err := cmd.Help(); err != nil {
+					return err
+				}
+				// The flags are the command's own, so its parser lists them
+				if actions != nil {
+					args = []string{actions[0], "-h"}
+				}
+				fmt.Println()
+			}
+			err := run(args)
+			if errors.Is(err, flag.ErrHelp) {
+				return nil // The command printed its flags
+			}
+			return err
+		},
+	}
+	// Cobra knows none of the flags, so its usage leaves the flag list to the command
+	cmd.SetUsageTemplate(subcommandUsage)
+	return cmd
+}
+
+// subcommandUsage is cobra's usage without the flag section
+const subcommandUsage = `Usage:
+  {{.UseLine}}{{if gt (len .Aliases) 0}}
+
+Aliases:
+  {{.NameAndAliases}}{{end}}
+`
+
+// completing reports whether args ask for a completion script or for completions, which run on every
+// tab press and must not read the settings or .env files
+func completing(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "completion", cobra.ShellCompRequestCmd, cobra.ShellCompNoDescRequestCmd:
+		return true
+	}
+	return false
+}
+
+func completeModels(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var ids []string
+	for _, model := range config.Models {
+		ids = append(ids, model.ID)
+	}
+	return ids, cobra.ShellCompDirectiveNoFileComp
+}
+
+func completeProfiles(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	settings, err := config.ReadSettings(config.SettingsFile())
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for name, profile := range settings.Profiles {
+		names = append(names, name+"\t"+profile.Description)
+	}
+	slices.Sort(names)
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// legacyFlags rewrites chat flags written with one dash, such as -provider mock, which the standard flag
+// package accepted, to their two-dash form; left alone they would parse as -p with the rest as its value
+func legacyFlags(root *cobra.Command, args []string) []string {
+	rewritten := slices.Clone(args)
+	for i, arg := range rewritten {
+		if arg == "--" {
+			break
+		}
+		if len(arg) < 3 || arg[0] != '-' || arg[1] == '-' {
+			continue
+		}
+		name, _, _ := strings.Cut(arg[1:], "=")
+		if f := root.Flags().Lookup(name); f != nil {
+			rewritten[i] = "-" + arg
+		}
+	}
+	return rewritten
+}
+
+// envOr returns the environment variable name, or fallback when it is unset or empty
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
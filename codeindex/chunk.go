@@ -0,0 +1,44 @@
+package codeindex
+
+import (
+	"strings"
+)
+
+// Chunk is a run of lines from a source file
+type Chunk struct {
+	StartLine int // 1-based, inclusive
+	EndLine   int
+	Text      string
+}
+
+// ChunkCode splits text into chunks of at most maxLines lines that overlap by overlap lines.
+// A chunk ends early at a blank line in its last quarter, so chunks tend to hold whole functions.
+func ChunkCode(text string, maxLines, overlap int) []Chunk {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if overlap >= maxLines {
+		overlap = maxLines / 2
+	}
+
+	var chunks []Chunk
+	for start := 0; start < len(lines); {
+		end := min(start+maxLines, len(lines))
+		if end < len(lines) {
+			for i := end - 1; i > end-maxLines/4 && i > start; i-- {
+				if strings.TrimSpace(lines[i]) == "" {
+					end = i
+					break
+				}
+			}
+		}
+
+		body := strings.Join(lines[start:end], "\n")
+		if strings.TrimSpace(body) != "" {
+			chunks = append(chunks, Chunk{StartLine: start + 1, EndLine: end, Text: body})
+		}
+		if end == len(lines) {
+			break
+		}
+		start = max(end-overlap, start+1)
+	}
+	return chunks
+}
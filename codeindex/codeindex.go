@@ -0,0 +1,277 @@
+package codeindex
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/embeddings"
+)
+
+const (
+	chunkLines   = 60     // Lines per chunk; about one function in most languages
+	chunkOverlap = 10     // Lines shared by neighbouring chunks so code at a boundary is found
+	maxFileSize  = 200000 // Larger files are usually generated or data
+	maxFiles     = 20000  // Files indexed per project; the rest of a huge tree is left out
+	embedBatch   = 32     // Chunks embedded per Embed call
+)
+
+// codeExtensions are the file types indexed
+var codeExtensions = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true,
+	".java": true, ".kt": true, ".scala": true, ".rb": true, ".php": true, ".rs": true, ".swift": true,
+	".c": true, ".h": true, ".cc": true, ".cpp": true, ".hpp": true, ".cs": true, ".m": true,
+	".sh": true, ".bash": true, ".sql": true, ".proto": true, ".graphql": true, ".lua": true,
+	".ex": true, ".exs": true, ".erl": true, ".hs": true, ".ml": true, ".clj": true, ".dart": true,
+	".vue": true, ".svelte": true, ".tf": true, ".yaml": true, ".yml": true, ".toml": true,
+	".md": true,
+}
+
+// skipDirs are never indexed
+var skipDirs = map[string]bool{
+	".git": true, ".goocode": true, "node_modules": true, "vendor": true, ".venv": true, "venv": true,
+	"__pycache__": true, "dist": true, "build": true, "target": true, ".next": true,
+}
+
+// Index is a semantic index of a project's source code
+type Index struct {
+	store      *embeddings.Store
+	embedder   embeddings.Embedder
+	workingDir string
+
+	// Skip, if set, leaves paths out of the index, such as those .goocodeignore denies
+	Skip func(fullPath string, isDir bool) bool
+	// Redact, if set, hides secrets in chunks before they are sent to the embedder or stored
+	Redact func(text string) string
+}
+
+// UpdateResult summarizes an indexing run
+type UpdateResult struct {
+	Indexed   int // Files embedded because they were new or changed
+	Unchanged int
+	Removed   int // Files dropped because they no longer exist
+	Chunks    int // Chunks embedded in this run
+	Skipped   int // Files left out because the project has more than maxFiles
+}
+
+// Open loads the code index for workingDir from baseDir, namespaced per project
+func Open(baseDir, workingDir string, embedder embeddings.Embedder) (*Index, error) {
+	abs, path := storePath(baseDir, workingDir)
+	store, err := embeddings.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if store.Embedder != "" && store.Embedder != embedder.Name() {
+		// Vectors from different embedders can't be compared, so the index is rebuilt
+		store.Documents, store.Versions = nil, nil
+	}
+	store.Embedder = embedder.Name()
+	if store.Versions == nil {
+		store.Versions = make(map[string]string)
+	}
+	return &Index{store: store, embedder: embedder, workingDir: abs}, nil
+}
+
+// Clear deletes the code index for workingDir
+func Clear(baseDir, workingDir string) error {
+	_, path := storePath(baseDir, workingDir)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear code index: %w", err)
+	}
+	return nil
+}
+
+// storePath returns the absolute project directory and its store file
+func storePath(baseDir, workingDir string) (string, string) {
+	abs, err := filepath.Abs(workingDir)
+	if err != nil {
+		abs = workingDir
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return abs, filepath.Join(baseDir, hex.EncodeToString(sum[:])[:12]+"-code.json")
+}
+
+// Update embeds new and changed files and drops deleted ones; unchanged files are not re-read
+func (x *Index) Update(ctx context.Context, progress func(source string, chunks int)) (UpdateResult, error) {
+	var result UpdateResult
+	files, skipped, err := x.files()
+	if err != nil {
+		return result, err
+	}
+	result.Skipped = skipped
+
+	seen := make(map[string]bool, len(files))
+	dirty := false
+	for _, file := range files {
+		source, version := file.source, file.version
+		seen[source] = true
+		if x.store.Versions[source] == version {
+			result.Unchanged++
+			continue
+		}
+		chunks, err := x.indexFile(ctx, source)
+		if err != nil {
+			// Keep what was indexed so far; the next update resumes from there
+			if dirty {
+				x.store.Save()
+			}
+			return result, err
+		}
+		x.store.Versions[source] = version
+		dirty = true
+		result.Indexed++
+		result.Chunks += chunks
+		if progress != nil && chunks > 0 {
+			progress(source, chunks)
+		}
+	}
+
+	for source := range x.store.Versions {
+		if !seen[source] {
+			x.store.Remove(source)
+			dirty = true
+			result.Removed++
+		}
+	}
+
+	if !dirty {
+		return result, nil
+	}
+	return result, x.store.Save()
+}
+
+// indexedFile is a candidate source with its fingerprint
+type indexedFile struct {
+	source  string
+	version string
+}
+
+func (x *Index) skip(fullPath string, isDir bool) bool {
+	return x.Skip != nil && x.Skip(fullPath, isDir)
+}
+
+// files lists the project's source files, returning how many were left out over maxFiles
+func (x *Index) files() ([]indexedFile, int, error) {
+	var files []indexedFile
+	skipped := 0
+	err := filepath.WalkDir(x.workingDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable directories are skipped rather than failing the whole index
+			return nil
+		}
+		if d.IsDir() {
+			if path != x.workingDir && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".") || x.skip(path, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if x.skip(path, false) {
+			return nil
+		}
+		if !codeExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxFileSize || info.Size() == 0 {
+			return nil
+		}
+		if len(files) >= maxFiles {
+			skipped++
+			return nil
+		}
+		rel, err := filepath.Rel(x.workingDir, path)
+		if err != nil {
+			return nil
+		}
+		files = append(files, indexedFile{
+			source:  filepath.ToSlash(rel),
+			version: fmt.Sprintf("%d-%d", info.Size(), info.ModTime().UnixNano()),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to walk %s: %w", x.workingDir, err)
+	}
+	return files, skipped, nil
+}
+
+// indexFile embeds one file's chunks and returns how many were stored
+func (x *Index) indexFile(ctx context.Context, source string) (int, error) {
+	content, err := os.ReadFile(filepath.Join(x.workingDir, filepath.FromSlash(source)))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", source, err)
+	}
+	if bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0 {
+		x.store.Replace(source, nil)
+		return 0, nil
+	}
+
+	chunks := ChunkCode(string(content), chunkLines, chunkOverlap)
+	docs := make([]embeddings.Document, 0, len(chunks))
+	for start := 0; start < len(chunks); start += embedBatch {
+		batch := chunks[start:min(start+embedBatch, len(chunks))]
+		texts := make([]string, len(batch))
+		for i := range batch {
+			if x.Redact != nil {
+				batch[i].Text = x.Redact(batch[i].Text)
+			}
+		}
+		for i, chunk := range batch {
+			// The path is embedded with the code so file and package names contribute to matches
+			texts[i] = source + "\n" + chunk.Text
+		}
+		vectors, err := x.embedder.Embed(ctx, texts)
+		if err != nil {
+			return 0, fmt.Errorf("failed to embed %s: %w", source, err)
+		}
+		for i, chunk := range batch {
+			docs = append(docs, embeddings.Document{
+				ID:     fmt.Sprintf("%s#L%d", source, chunk.StartLine),
+				Source: source,
+				Title:  fmt.Sprintf("lines %d-%d", chunk.StartLine, chunk.EndLine),
+				Text:   chunk.Text,
+				Vector: vectors[i],
+			})
+		}
+	}
+
+	x.store.Replace(source, docs)
+	return len(docs), nil
+}
+
+// Search returns the chunks most relevant to query, limited to sources under prefix when it is set
+func (x *Index) Search(ctx context.Context, query, prefix string, limit int) ([]embeddings.Result, error) {
+	if len(x.store.Documents) == 0 {
+		return nil, nil
+	}
+	vectors, err := x.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	prefix = strings.Trim(filepath.ToSlash(filepath.Clean(prefix)), "/")
+	if prefix == "" || prefix == "." {
+		return x.store.Search(vectors[0], limit), nil
+	}
+
+	var matches []embeddings.Result
+	for _, result := range x.store.Search(vectors[0], 0) {
+		if result.Source == prefix || strings.HasPrefix(result.Source, prefix+"/") {
+			matches = append(matches, result)
+			if limit > 0 && len(matches) == limit {
+				break
+			}
+		}
+	}
+	return matches, nil
+}
+
+// Stats returns the number of files and chunks in the index
+func (x *Index) Stats() (files, chunks int) {
+	return len(x.store.Versions), len(x.store.Documents)
+}
@@ -0,0 +1,81 @@
+package compaction
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+
+	"anthropic-chat/tokenizer"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// duplicateMarker starts the text that replaces a repeated tool result
+const duplicateMarker = "[same output as the earlier "
+
+// DedupeToolResults replaces each text tool result that repeats an earlier one, such as a file read
+// again without changes, with a short reference to the first. The first occurrence is kept, so earlier
+// messages never change and a result already replaced stays so. Results shorter than minChars and errors
+// are left alone. It returns the deduplicated conversation and the estimated tokens it saved.
+func DedupeToolResults(conversation []anthropic.MessageParam, minChars int) ([]anthropic.MessageParam, int) {
+	calls := make(map[string]string) // Tool names by call ID
+	seen := make(map[[sha256.Size]byte]string)
+	var deduped []anthropic.MessageParam
+	saved := 0
+	for i, msg := range conversation {
+		var content []anthropic.ContentBlockParamUnion
+		for j, block := range msg.Content {
+			if block.OfToolUse != nil {
+				calls[block.OfToolUse.ID] = block.OfToolUse.Name
+			}
+			result := block.OfToolResult
+			if result == nil || (result.IsError.Valid() && result.IsError.Value) {
+				continue
+			}
+			text, ok := resultText(result)
+			if !ok || len(text) < minChars || strings.HasPrefix(text, duplicateMarker) {
+				continue
+			}
+			sum := sha256.Sum256([]byte(text))
+			first, repeated := seen[sum]
+			if !repeated {
+				seen[sum] = result.ToolUseID
+				continue
+			}
+
+			name := calls[first]
+			if name == "" {
+				name = "tool"
+			}
+			reference := fmt.Sprintf("%s%s call (%s), unchanged; refer to that result, or re-run the tool if it is no longer in the conversation]", duplicateMarker, name, first)
+			if content == nil {
+				content = append([]anthropic.ContentBlockParamUnion{}, msg.Content...)
+			}
+			content[j] = anthropic.NewToolResultBlock(result.ToolUseID, reference, false)
+			saved += tokenizer.Count(text) - tokenizer.Count(reference)
+		}
+		if content == nil {
+			continue
+		}
+		if deduped == nil {
+			deduped = append([]anthropic.MessageParam{}, conversation...)
+		}
+		deduped[i] = anthropic.MessageParam{Role: msg.Role, Content: content}
+	}
+	if deduped == nil {
+		return conversation, 0
+	}
+	return deduped, saved
+}
+
+// resultText joins the text of a tool result, reporting false for results with images or documents
+func resultText(result *anthropic.ToolResultBlockParam) (string, bool) {
+	var b strings.Builder
+	for _, part := range result.Content {
+		if part.OfText == nil {
+			return "", false
+		}
+		b.WriteString(part.OfText.Text)
+	}
+	return b.String(), true
+}
@@ -0,0 +1,81 @@
+package compaction
+
+import (
+	"context"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// charsPerToken matches the agent's rough token estimate
+const charsPerToken = 4
+
+// Emergency shrinks a conversation the API rejected as too long. Unlike the regular strategies it
+// also touches the recent window: every tool result but the latest is elided, everything before
+// the kept messages is summarized, and oversized tool output that is left is truncated.
+func Emergency(ctx context.Context, conversation []anthropic.MessageParam, opts Options) ([]anthropic.MessageParam, error) {
+	compacted := append([]anthropic.MessageParam{}, conversation...)
+	for i := 0; i < len(compacted)-1; i++ {
+		compacted[i], _ = elideToolResults(compacted[i])
+	}
+	if fits(ctx, compacted, opts) {
+		return compacted, nil
+	}
+
+	compacted, err := (&SummarizeOldest{}).Compact(ctx, compacted, opts)
+	if fits(ctx, compacted, opts) {
+		return compacted, err
+	}
+
+	// The latest tool output alone is too big; keep the start of each result within the budget
+	last := len(compacted) - 1
+	if last >= 0 {
+		compacted[last] = truncateToolResults(compacted[last], opts.TargetTokens*charsPerToken/2)
+	}
+	return compacted, err
+}
+
+func fits(ctx context.Context, conversation []anthropic.MessageParam, opts Options) bool {
+	tokens, err := opts.CountTokens(ctx, conversation)
+	return err == nil && tokens < opts.TargetTokens
+}
+
+// truncateToolResults cuts the text of msg's tool results so together they stay under maxChars
+func truncateToolResults(msg anthropic.MessageParam, maxChars int) anthropic.MessageParam {
+	results := 0
+	for _, block := range msg.Content {
+		if block.OfToolResult != nil {
+			results++
+		}
+	}
+	if results == 0 {
+		return msg
+	}
+	budget := maxChars / results
+
+	content := make([]anthropic.ContentBlockParamUnion, len(msg.Content))
+	for i, block := range msg.Content {
+		content[i] = block
+		if block.OfToolResult == nil {
+			continue
+		}
+		text := ""
+		for _, part := range block.OfToolResult.Content {
+			if part.OfText != nil {
+				text += part.OfText.Text
+			}
+		}
+		if len(text) <= budget {
+			continue
+		}
+		cut := budget
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		isError := block.OfToolResult.IsError.Valid() && block.OfToolResult.IsError.Value
+		note := fmt.Sprintf("\n[output truncated from %d to %d characters to fit the context window]", len(text), cut)
+		content[i] = anthropic.NewToolResultBlock(block.OfToolResult.ToolUseID, text[:cut]+note, isError)
+	}
+	return anthropic.MessageParam{Role: msg.Role, Content: content}
+}
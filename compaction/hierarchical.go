@@ -0,0 +1,187 @@
+package compaction
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	// turnsPerSegment is how many user turns are condensed into one segment summary
+	turnsPerSegment = 10
+	// maxSegmentSummaries is how many segment summaries are kept before rolling the oldest into the session summary
+	maxSegmentSummaries = 5
+)
+
+func init() {
+	Register("hierarchical", func() Strategy { return &Hierarchical{} })
+}
+
+// Hierarchical keeps layered summaries: one per segment of turns, rolled up into a session summary.
+// Each compaction only summarizes full segments of messages that arrived since the previous one, so
+// earlier segments are never re-summarized from scratch and a partial one isn't summarized twice.
+type Hierarchical struct {
+	sessionSummary   string
+	segmentSummaries []string
+	lastComposed     string
+}
+
+// Name returns the strategy name
+func (h *Hierarchical) Name() string {
+	return "hierarchical"
+}
+
+// Compact folds new messages outside the recent window into segment summaries
+func (h *Hierarchical) Compact(ctx context.Context, conversation []anthropic.MessageParam, opts Options) ([]anthropic.MessageParam, error) {
+	// Skip our own summary message from the previous compaction
+	start := 0
+	if len(conversation) > 0 && messageText(conversation[0]) == h.lastComposed && h.lastComposed != "" {
+		start = 1
+	}
+
+	split := SplitPoint(conversation, opts.KeepRecent)
+	if split <= start {
+		return conversation, nil
+	}
+
+	// A last segment short of turnsPerSegment is carried forward verbatim and summarized once it is full,
+	// unless keeping it would leave the conversation over the target
+	segments := splitIntoSegments(conversation[start:split], turnsPerSegment)
+	var tail []anthropic.MessageParam
+	if n := len(segments); n > 0 && countTurns(segments[n-1]) < turnsPerSegment {
+		tail = segments[n-1]
+		if h.fits(ctx, opts, tail, conversation[split:]) {
+			segments = segments[:n-1]
+		} else {
+			tail = nil
+		}
+	}
+	if len(segments) == 0 {
+		return conversation, nil
+	}
+
+	for _, segment := range segments {
+		summary, err := summarizeText(ctx, opts, segment)
+		if err != nil {
+			return conversation[split:], fmt.Errorf("segment summary failed, truncated instead: %w", err)
+		}
+		h.segmentSummaries = append(h.segmentSummaries, summary)
+	}
+
+	if len(h.segmentSummaries) > maxSegmentSummaries {
+		if err := h.rollUp(ctx, opts); err != nil {
+			return conversation[split:], fmt.Errorf("session summary failed, truncated instead: %w", err)
+		}
+	}
+
+	h.lastComposed = h.compose()
+	compacted := []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(h.lastComposed))}
+	compacted = append(compacted, tail...)
+	return append(compacted, conversation[split:]...), nil
+}
+
+// fits reports whether the conversation is under the target with the full segments replaced by a summary
+// and the tail kept; the new summary is estimated by the current one, being the small part
+func (h *Hierarchical) fits(ctx context.Context, opts Options, tail, recent []anthropic.MessageParam) bool {
+	if opts.CountTokens == nil || opts.TargetTokens <= 0 {
+		return false
+	}
+	kept := []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(h.compose()))}
+	kept = append(append(kept, tail...), recent...)
+	tokens, err := opts.CountTokens(ctx, kept)
+	return err == nil && tokens < opts.TargetTokens
+}
+
+// rollUp merges the oldest segment summaries into the session summary, keeping the newest ones separate
+func (h *Hierarchical) rollUp(ctx context.Context, opts Options) error {
+	keep := maxSegmentSummaries / 2
+	oldest := h.segmentSummaries[:len(h.segmentSummaries)-keep]
+
+	var text strings.Builder
+	if h.sessionSummary != "" {
+		text.WriteString("Session summary so far:\n" + h.sessionSummary + "\n\n")
+	}
+	text.WriteString("Later segment summaries, oldest first:\n")
+	for i, summary := range oldest {
+		fmt.Fprintf(&text, "%d. %s\n", i+1, summary)
+	}
+
+	merged, err := summarizeText(ctx, opts, []anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock(text.String())),
+	})
+	if err != nil {
+		return err
+	}
+
+	h.sessionSummary = merged
+	h.segmentSummaries = append([]string{}, h.segmentSummaries[len(h.segmentSummaries)-keep:]...)
+	return nil
+}
+
+// compose renders the layered summaries as a single message
+func (h *Hierarchical) compose() string {
+	var text strings.Builder
+	text.WriteString("[CONVERSATION SUMMARY]\n")
+	if h.sessionSummary != "" {
+		text.WriteString("Session overview:\n" + h.sessionSummary + "\n\n")
+	}
+	if len(h.segmentSummaries) > 0 {
+		text.WriteString("Recent segments, oldest first:\n")
+		for i, summary := range h.segmentSummaries {
+			fmt.Fprintf(&text, "%d. %s\n", i+1, summary)
+		}
+	}
+	return strings.TrimSpace(text.String())
+}
+
+// splitIntoSegments groups messages into runs of at most turns user turns
+func splitIntoSegments(messages []anthropic.MessageParam, turns int) [][]anthropic.MessageParam {
+	var segments [][]anthropic.MessageParam
+	segmentStart, count := 0, 0
+	for i, msg := range messages {
+		if !startsTurn(msg) {
+			continue
+		}
+		if count == turns {
+			segments = append(segments, messages[segmentStart:i])
+			segmentStart, count = i, 0
+		}
+		count++
+	}
+	if segmentStart < len(messages) {
+		segments = append(segments, messages[segmentStart:])
+	}
+	return segments
+}
+
+// countTurns counts the user turns that start in messages
+func countTurns(messages []anthropic.MessageParam) int {
+	turns := 0
+	for _, msg := range messages {
+		if startsTurn(msg) {
+			turns++
+		}
+	}
+	return turns
+}
+
+func summarizeText(ctx context.Context, opts Options, messages []anthropic.MessageParam) (string, error) {
+	summary, err := opts.Summarize(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+	text := strings.TrimSpace(strings.TrimPrefix(messageText(*summary), "[CONVERSATION SUMMARY]"))
+	return strings.ReplaceAll(text, "\n", " "), nil
+}
+
+func messageText(msg anthropic.MessageParam) string {
+	var text strings.Builder
+	for _, block := range msg.Content {
+		if block.OfText != nil {
+			text.WriteString(block.OfText.Text)
+		}
+	}
+	return text.String()
+}
@@ -0,0 +1,107 @@
+package compaction
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const elidedToolResult = "[tool result removed to save context; re-run the tool if needed]"
+
+func init() {
+	Register("summarize-oldest", func() Strategy { return &SummarizeOldest{} })
+	Register("sliding-window", func() Strategy { return &SlidingWindow{} })
+	Register("drop-tool-results-first", func() Strategy { return &DropToolResultsFirst{} })
+}
+
+// SummarizeOldest replaces everything but the recent messages with a single summary
+type SummarizeOldest struct{}
+
+// Name returns the strategy name
+func (s *SummarizeOldest) Name() string {
+	return "summarize-oldest"
+}
+
+// Compact summarizes the older prefix of the conversation
+func (s *SummarizeOldest) Compact(ctx context.Context, conversation []anthropic.MessageParam, opts Options) ([]anthropic.MessageParam, error) {
+	split := SplitPoint(conversation, opts.KeepRecent)
+	if split == 0 {
+		return conversation, nil
+	}
+
+	summary, err := opts.Summarize(ctx, conversation[:split])
+	if err != nil {
+		// Fall back to simple truncation so the turn can still proceed
+		return conversation[split:], fmt.Errorf("summary failed, truncated instead: %w", err)
+	}
+
+	compacted := []anthropic.MessageParam{*summary}
+	return append(compacted, conversation[split:]...), nil
+}
+
+// SlidingWindow drops older messages without calling the model, suited to rapid edit loops
+type SlidingWindow struct{}
+
+// Name returns the strategy name
+func (s *SlidingWindow) Name() string {
+	return "sliding-window"
+}
+
+// Compact keeps only the most recent messages
+func (s *SlidingWindow) Compact(ctx context.Context, conversation []anthropic.MessageParam, opts Options) ([]anthropic.MessageParam, error) {
+	return conversation[SplitPoint(conversation, opts.KeepRecent):], nil
+}
+
+// DropToolResultsFirst elides old tool output, oldest first, before resorting to summarization.
+// Tool results (file contents, command output) dominate token usage but are cheap to reproduce.
+type DropToolResultsFirst struct{}
+
+// Name returns the strategy name
+func (s *DropToolResultsFirst) Name() string {
+	return "drop-tool-results-first"
+}
+
+// Compact elides tool results outside the recent window until the conversation fits
+func (s *DropToolResultsFirst) Compact(ctx context.Context, conversation []anthropic.MessageParam, opts Options) ([]anthropic.MessageParam, error) {
+	split := SplitPoint(conversation, opts.KeepRecent)
+	compacted := append([]anthropic.MessageParam{}, conversation...)
+
+	for i := 0; i < split; i++ {
+		elided, changed := elideToolResults(compacted[i])
+		if !changed {
+			continue
+		}
+		compacted[i] = elided
+
+		tokens, err := opts.CountTokens(ctx, compacted)
+		if err == nil && tokens < opts.TargetTokens {
+			return compacted, nil
+		}
+	}
+
+	return (&SummarizeOldest{}).Compact(ctx, compacted, opts)
+}
+
+// elideToolResults replaces the content of every tool_result block in msg with a placeholder
+func elideToolResults(msg anthropic.MessageParam) (anthropic.MessageParam, bool) {
+	changed := false
+	content := make([]anthropic.ContentBlockParamUnion, len(msg.Content))
+	for i, block := range msg.Content {
+		content[i] = block
+		if block.OfToolResult == nil || isElided(block.OfToolResult) {
+			continue
+		}
+		isError := block.OfToolResult.IsError.Valid() && block.OfToolResult.IsError.Value
+		content[i] = anthropic.NewToolResultBlock(block.OfToolResult.ToolUseID, elidedToolResult, isError)
+		changed = true
+	}
+	if !changed {
+		return msg, false
+	}
+	return anthropic.MessageParam{Role: msg.Role, Content: content}, true
+}
+
+func isElided(result *anthropic.ToolResultBlockParam) bool {
+	return len(result.Content) == 1 && result.Content[0].OfText != nil && result.Content[0].OfText.Text == elidedToolResult
+}
@@ -0,0 +1,87 @@
+package compaction
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// SummarizeFunc condenses a slice of messages into a single summary message
+type SummarizeFunc func(ctx context.Context, messages []anthropic.MessageParam) (*anthropic.MessageParam, error)
+
+// CountFunc returns the token count of a conversation
+type CountFunc func(ctx context.Context, conversation []anthropic.MessageParam) (int, error)
+
+// Options carries what a strategy needs from the agent
+type Options struct {
+	KeepRecent   int // Number of most recent messages that must survive compaction verbatim
+	TargetTokens int // Token count the compacted conversation should fit under
+	Summarize    SummarizeFunc
+	CountTokens  CountFunc
+}
+
+// Strategy shrinks a conversation that has grown past the input token limit
+type Strategy interface {
+	Name() string
+	Compact(ctx context.Context, conversation []anthropic.MessageParam, opts Options) ([]anthropic.MessageParam, error)
+}
+
+// DefaultStrategy is used when no strategy is configured
+const DefaultStrategy = "summarize-oldest"
+
+var strategies = map[string]func() Strategy{}
+
+// Register makes a strategy selectable by name
+func Register(name string, factory func() Strategy) {
+	strategies[name] = factory
+}
+
+// New returns the strategy registered under name
+func New(name string) (Strategy, error) {
+	if name == "" {
+		name = DefaultStrategy
+	}
+	factory, ok := strategies[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown compaction strategy %q (available: %v)", name, Names())
+	}
+	return factory(), nil
+}
+
+// Names lists the registered strategy names
+func Names() []string {
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SplitPoint returns the index where the kept suffix of roughly keepRecent messages starts.
+// The split is moved earlier so the suffix never begins with tool results whose tool_use was cut off.
+func SplitPoint(conversation []anthropic.MessageParam, keepRecent int) int {
+	split := len(conversation) - keepRecent
+	if split <= 0 {
+		return 0
+	}
+	for split > 0 && !startsTurn(conversation[split]) {
+		split--
+	}
+	return split
+}
+
+// startsTurn reports whether msg is a user message of plain content (not tool results)
+func startsTurn(msg anthropic.MessageParam) bool {
+	if msg.Role != anthropic.MessageParamRoleUser {
+		return false
+	}
+	for _, block := range msg.Content {
+		if block.OfToolResult != nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,53 @@
+package compaction
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// FlattenToolHistory rewrites tool_use and tool_result blocks as plain text so the history
+// can be sent to a model that doesn't accept tool blocks
+func FlattenToolHistory(conversation []anthropic.MessageParam) []anthropic.MessageParam {
+	flattened := make([]anthropic.MessageParam, 0, len(conversation))
+	for _, msg := range conversation {
+		content := make([]anthropic.ContentBlockParamUnion, 0, len(msg.Content))
+		for _, block := range msg.Content {
+			switch {
+			case block.OfToolUse != nil:
+				input, _ := json.Marshal(block.OfToolUse.Input)
+				content = append(content, anthropic.NewTextBlock(fmt.Sprintf("[Called tool %s with input %s]", block.OfToolUse.Name, input)))
+			case block.OfToolResult != nil:
+				var text strings.Builder
+				for _, part := range block.OfToolResult.Content {
+					if part.OfText != nil {
+						text.WriteString(part.OfText.Text)
+					}
+				}
+				label := "Tool result"
+				if block.OfToolResult.IsError.Valid() && block.OfToolResult.IsError.Value {
+					label = "Tool error"
+				}
+				content = append(content, anthropic.NewTextBlock(fmt.Sprintf("[%s] %s", label, text.String())))
+			default:
+				content = append(content, block)
+			}
+		}
+		flattened = append(flattened, anthropic.MessageParam{Role: msg.Role, Content: content})
+	}
+	return flattened
+}
+
+// HasToolBlocks reports whether any message contains tool_use or tool_result blocks
+func HasToolBlocks(conversation []anthropic.MessageParam) bool {
+	for _, msg := range conversation {
+		for _, block := range msg.Content {
+			if block.OfToolUse != nil || block.OfToolResult != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
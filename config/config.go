@@ -2,28 +2,76 @@ package config
 
 import (
 	"os"
-
-	"github.com/joho/godotenv"
+	"path/filepath"
+	"strconv"
+	"strings"
 )
 
 // Config holds all configuration for the application
 type Config struct {
-	API      APIConfig
-	Agent    AgentConfig
-	Security SecurityConfig
-	UI       UIConfig
+	API        APIConfig
+	Agent      AgentConfig
+	Security   SecurityConfig
+	UI         UIConfig
+	Session    SessionConfig
+	Refactor   RefactorConfig
+	Knowledge  KnowledgeConfig
+	Embeddings EmbeddingsConfig
+	Telemetry  TelemetryConfig
+	Batch      BatchConfig
 }
 
 // APIConfig holds API-related configuration
 type APIConfig struct {
-	Key string
+	Key                   string
+	Platform              string // anthropic (default), bedrock or vertex
+	Region                string // Cloud region for bedrock (overrides the AWS configuration) and vertex
+	VertexProject         string // Google Cloud project for vertex (empty = the credentials' project)
+	RequestsPerMinute     int    // 0 = learn from API rate limit headers
+	TokensPerMinute       int    // 0 = learn from API rate limit headers
+	MaxConcurrentRequests int    // 0 = unlimited
 }
 
 // AgentConfig holds agent behavior configuration
 type AgentConfig struct {
-	SystemPromptFile string
-	WorkingDir       string
-	TokenLimits      TokenLimits
+	Model                ModelInfo
+	SystemPromptFile     string
+	WorkingDir           string
+	TokenLimits          TokenLimits
+	CompactionStrategy   string            // summarize-oldest, sliding-window, drop-tool-results-first, hierarchical
+	TokenMeter           bool              // Show the conversation's estimated size next to the prompt
+	BackgroundCompaction int               // Percent of the input limit at which compaction starts in the background (0 = only compact when full)
+	DedupeToolResults    bool              // Replace tool results that repeat an earlier one with a reference to it
+	PinIdleTurns         int               // Unused turns before a pinned file is flagged (0 = never)
+	AutoUnpin            bool              // Unpin idle files automatically instead of only suggesting it
+	ShowCostPreview      bool              // Show estimated tokens and cost before each turn's first request
+	CostConfirmThreshold float64           // Ask before sending requests whose input costs at least this many USD (0 = never)
+	TurnTokenBudget      int               // Tokens one turn's requests may use before asking the user to continue (0 = unlimited)
+	TurnCostBudget       float64           // USD one turn's requests may cost before asking the user to continue (0 = unlimited)
+	Verbosity            string            // Response length preference: terse, normal or detailed
+	ToolChoice           string            // Tool use required at the start of each turn: auto, any, none or a tool name
+	WatchFiles           bool              // Tell the model about files changed outside the agent between turns
+	WorkspaceStateBytes  int               // Size cap of the workspace state block listing files read or changed (0 = off)
+	MaxToolCalls         int               // Tool calls per turn before asking the user to continue (0 = unlimited)
+	RepeatedCallLimit    int               // Identical consecutive tool calls before asking the user (0 = never)
+	StreamRetries        int               // Times a response stream cut off by the network is resumed
+	OutputContinuations  int               // Times a text response cut off at the output token limit is continued
+	SessionSummary       bool              // Snapshot the working tree at startup and summarize the changes on exit
+	GitWarmStart         bool              // Start each session with the recent git log and the uncommitted diff in the system prompt
+	FallbackModels       []string          // Models to try in order, for the rest of a turn, when the active one is overloaded or rate limited
+	ShellTimeout         int               // Default seconds a shell command may run before the shell is restarted
+	ShellMaxTimeout      int               // Most seconds the model may ask a shell command to run for
+	ShellOutputLimit     int               // Bytes of shell output returned to the model
+	SnippetBackend       string            // Where run_snippet runs code: process or docker
+	SnippetTimeout       int               // Default seconds a snippet may run
+	PluginsDir           string            // Executables here provide extra tools over the plugin protocol
+	CommandsDir          string            // Personal custom slash commands, one Markdown prompt per command
+	PromptVars           map[string]string // Values for {{.Vars.name}} in the system prompt, from GOOCODE_PROMPT_VAR_<NAME>
+	ProjectPlugins       bool              // Also load plugins from the project's .goocode/plugins (they run with your permissions)
+
+	// Named agent profiles from the settings file, and the one to start with (empty = none)
+	Profiles map[string]Profile
+	Profile  string
 }
 
 // TokenLimits holds token management configuration
@@ -38,27 +86,133 @@ type TokenLimits struct {
 // SecurityConfig holds security-related configuration
 type SecurityConfig struct {
 	AllowDangerousCommands bool
-	RequireApproval        bool
+	RequireApproval        bool   // Without a policy, ask before every write and command instead of allowing them
+	RedactSecrets          bool   // Replace API keys, tokens and other secrets in tool output before the model sees it
+	RedactionLog           string // Audit log of redactions (rule and count only, never the secret)
+	AllowlistDir           string // Command prefixes the user always allows, one file per project, kept outside the repository
+	AuditLog               string // Hash-chained log of every executed tool call (empty = off)
+	ApprovalPolicy         string // YAML approval matrix deciding gated tool actions before anyone is asked
+	TrustedPolicies        string // Repository approval policies the user accepted, with their content hashes
+	Headless               bool   // Never prompt for approval; actions the policy leaves undecided are denied
+	ReadOnly               bool   // Leave out tools that change files or run commands, and refuse anything that asks for approval
+	ApprovalWebhook        string // In headless runs, undecided actions are posted here for a remote decision
+	ApprovalListen         string // Address the approval callback listener binds to
+	ApprovalCallbackURL    string // Public base URL of the callback listener (defaults to the listen address)
+	ApprovalTimeout        int    // Seconds to wait for a remote decision before denying
 }
 
 // UIConfig holds UI-related configuration
 type UIConfig struct {
-	ShowThinking   bool
-	AnimationSpeed int // milliseconds
-	ColorOutput    bool
+	ShowThinking    bool
+	AnimationSpeed  int // milliseconds between spinner redraws
+	ColorOutput     bool
+	LongOutput      string // What to do with very long responses: collapse, pager or off
+	LongOutputLines int    // Lines shown before a response counts as long
+	HighlightStyle  string // Chroma style for code blocks in responses ("off" disables highlighting)
+	Verbosity       string // How much besides the assistant's text is printed: quiet, normal or verbose
+	Notify          string // How to signal finished turns, errors and approval prompts: off, bell, osc9 or desktop
+	NotifyAfter     int    // Seconds a turn must run before its end is notified; errors and approvals always are
+	Locale          string // Locale for number separators, e.g. de_DE (empty = LC_ALL, LC_NUMERIC or LANG)
+}
+
+// SessionConfig holds session persistence configuration
+type SessionConfig struct {
+	Dir           string // Where session files are stored
+	TrashDays     int    // Days a deleted session stays recoverable before purge
+	CheckpointDir string // Where working tree checkpoints are stored
+	RecentDirs    string // File listing recently used working directories, offered at startup
+	ArtifactsDir  string // Where emit_artifact writes, one subdirectory per session (empty = .goocode/artifacts in the working directory)
+}
+
+// RefactorConfig holds guided refactor workflow configuration
+type RefactorConfig struct {
+	ReviewEvery    int    // Pause for user review after this many steps (0 = never)
+	VerifyCommand  string // Shell command used to verify each step (empty = auto-detected tests)
+	MaxFixAttempts int    // Times the agent may try to fix a failed verification before asking the user
+}
+
+// KnowledgeConfig holds project documentation knowledge base configuration
+type KnowledgeConfig struct {
+	Dir string // Where per-project embedding stores are kept
+}
+
+// EmbeddingsConfig selects the embeddings backend used by retrieval features
+type EmbeddingsConfig struct {
+	Provider      string // hash (local, default), voyage, openai or ollama
+	Model         string // Provider model name (empty = provider default)
+	VoyageAPIKey  string
+	OpenAIAPIKey  string
+	OpenAIBaseURL string // For OpenAI-compatible servers, including local ones
+	OllamaHost    string
+}
+
+// TelemetryConfig controls OpenTelemetry export; endpoints and headers come from the standard OTEL_EXPORTER_OTLP_* variables
+type TelemetryConfig struct {
+	Enabled     bool   // Export traces and metrics over OTLP
+	ServiceName string // service.name reported with every span and metric
+}
+
+// BatchConfig holds offline batch job configuration
+type BatchConfig struct {
+	Dir          string // Where submitted job manifests are kept
+	PollInterval int    // Seconds between status checks while waiting
 }
 
 // Load loads configuration from environment and defaults
 func Load() (*Config, error) {
-	// Load environment variables from .env file (if it exists)
-	_ = godotenv.Load()
+	// Load environment variables from .env files (if they exist)
+	LoadDefaultEnvFiles()
+
+	model, _ := LookupModel(DefaultModel)
+	if name := os.Getenv("GOOCODE_MODEL"); name != "" {
+		model, _ = LookupModel(name)
+	}
 
 	config := &Config{
 		API: APIConfig{
-			Key: os.Getenv("ANTHROPIC_API_KEY"),
+			Key:                   os.Getenv("ANTHROPIC_API_KEY"),
+			Platform:              envString("GOOCODE_PLATFORM", "anthropic"),
+			Region:                os.Getenv("GOOCODE_REGION"),
+			VertexProject:         os.Getenv("GOOCODE_VERTEX_PROJECT"),
+			RequestsPerMinute:     envInt("GOOCODE_REQUESTS_PER_MINUTE", 0),
+			TokensPerMinute:       envInt("GOOCODE_TOKENS_PER_MINUTE", 0),
+			MaxConcurrentRequests: envInt("GOOCODE_MAX_CONCURRENT_REQUESTS", MaxConcurrentRequests),
 		},
 		Agent: AgentConfig{
-			SystemPromptFile: "system_prompt.txt",
+			Model:                model,
+			SystemPromptFile:     "system_prompt.txt",
+			CompactionStrategy:   os.Getenv("GOOCODE_COMPACTION_STRATEGY"),
+			TokenMeter:           envBool("GOOCODE_TOKEN_METER", true),
+			BackgroundCompaction: envInt("GOOCODE_BACKGROUND_COMPACTION", BackgroundCompactionPercent),
+			DedupeToolResults:    envBool("GOOCODE_DEDUPE_RESULTS", true),
+			PinIdleTurns:         envInt("GOOCODE_PIN_IDLE_TURNS", PinIdleTurns),
+			AutoUnpin:            envBool("GOOCODE_AUTO_UNPIN", false),
+			ShowCostPreview:      envBool("GOOCODE_COST_PREVIEW", true),
+			CostConfirmThreshold: envFloat("GOOCODE_COST_CONFIRM_USD", CostConfirmThreshold),
+			TurnTokenBudget:      envInt("GOOCODE_TURN_TOKEN_BUDGET", 0),
+			TurnCostBudget:       envFloat("GOOCODE_TURN_COST_BUDGET", 0),
+			Verbosity:            envString("GOOCODE_VERBOSITY", VerbosityNormal),
+			ToolChoice:           envString("GOOCODE_TOOL_CHOICE", ToolChoiceAuto),
+			WatchFiles:           envBool("GOOCODE_WATCH", false),
+			WorkspaceStateBytes:  envInt("GOOCODE_WORKSPACE_STATE_BYTES", WorkspaceStateBytes),
+			MaxToolCalls:         envInt("GOOCODE_MAX_TOOL_CALLS", MaxToolCallsPerTurn),
+			RepeatedCallLimit:    envInt("GOOCODE_REPEATED_CALL_LIMIT", RepeatedToolCallLimit),
+			StreamRetries:        envInt("GOOCODE_STREAM_RETRIES", StreamRetries),
+			OutputContinuations:  envInt("GOOCODE_OUTPUT_CONTINUATIONS", OutputContinuations),
+			FallbackModels:       envList("GOOCODE_FALLBACK_MODELS"),
+			SessionSummary:       envBool("GOOCODE_SESSION_SUMMARY", true),
+			GitWarmStart:         envBool("GOOCODE_GIT_WARM_START", false),
+			ShellTimeout:         envInt("GOOCODE_SHELL_TIMEOUT", ShellTimeoutSeconds),
+			ShellMaxTimeout:      envInt("GOOCODE_SHELL_MAX_TIMEOUT", ShellMaxTimeoutSeconds),
+			ShellOutputLimit:     envInt("GOOCODE_SHELL_OUTPUT_LIMIT", ShellOutputLimit),
+			SnippetBackend:       envString("GOOCODE_SNIPPET_BACKEND", "process"),
+			SnippetTimeout:       envInt("GOOCODE_SNIPPET_TIMEOUT", SnippetTimeoutSeconds),
+			PluginsDir:           envString("GOOCODE_PLUGINS_DIR", goocodeDir("plugins")),
+			CommandsDir:          envString("GOOCODE_COMMANDS_DIR", goocodeDir("commands")),
+			PromptVars:           envPrefixed(PromptVarPrefix),
+			ProjectPlugins:       envBool("GOOCODE_PROJECT_PLUGINS", false),
+			Profiles:             loadProfiles(),
+			Profile:              os.Getenv("GOOCODE_PROFILE"),
 			TokenLimits: TokenLimits{
 				MaxOutputTokens:    MaxOutputTokens,
 				MaxInputTokens:     MaxInputTokens,
@@ -69,24 +223,156 @@ func Load() (*Config, error) {
 		},
 		Security: SecurityConfig{
 			AllowDangerousCommands: false,
-			RequireApproval:        true,
+			RequireApproval:        envBool("GOOCODE_REQUIRE_APPROVAL", true),
+			RedactSecrets:          envBool("GOOCODE_REDACT_SECRETS", true),
+			RedactionLog:           goocodeDir("redactions.log"),
+			AllowlistDir:           envString("GOOCODE_ALLOWLIST_DIR", goocodeDir("allowed_commands")),
+			AuditLog:               os.Getenv("GOOCODE_AUDIT_LOG"),
+			ApprovalPolicy:         os.Getenv("GOOCODE_APPROVAL_POLICY"),
+			TrustedPolicies:        envString("GOOCODE_TRUSTED_POLICIES", goocodeDir("trusted_policies.json")),
+			Headless:               envBool("GOOCODE_HEADLESS", false),
+			ReadOnly:               envBool("GOOCODE_READ_ONLY", false),
+			ApprovalWebhook:        os.Getenv("GOOCODE_APPROVAL_WEBHOOK"),
+			ApprovalListen:         envString("GOOCODE_APPROVAL_LISTEN", ApprovalListenAddr),
+			ApprovalCallbackURL:    os.Getenv("GOOCODE_APPROVAL_CALLBACK_URL"),
+			ApprovalTimeout:        envInt("GOOCODE_APPROVAL_TIMEOUT", ApprovalTimeoutSeconds),
 		},
 		UI: UIConfig{
-			ShowThinking:   true,
-			AnimationSpeed: 500,
-			ColorOutput:    true,
+			ShowThinking:    true,
+			AnimationSpeed:  200,
+			ColorOutput:     true,
+			LongOutput:      envString("GOOCODE_LONG_OUTPUT", LongOutputCollapse),
+			LongOutputLines: envInt("GOOCODE_LONG_OUTPUT_LINES", LongOutputLines),
+			HighlightStyle:  envString("GOOCODE_HIGHLIGHT_STYLE", HighlightStyle),
+			Verbosity:       envString("GOOCODE_UI_VERBOSITY", UINormal),
+			Notify:          envString("GOOCODE_NOTIFY", NotifyOff),
+			NotifyAfter:     envInt("GOOCODE_NOTIFY_AFTER", NotifyAfterSeconds),
+			Locale:          envString("GOOCODE_LOCALE", ""),
+		},
+		Session: SessionConfig{
+			Dir:           goocodeDir("sessions"),
+			TrashDays:     SessionTrashDays,
+			CheckpointDir: goocodeDir("checkpoints"),
+			RecentDirs:    goocodeDir("recent_dirs.json"),
+			ArtifactsDir:  os.Getenv("GOOCODE_ARTIFACTS_DIR"),
+		},
+		Refactor: RefactorConfig{
+			ReviewEvery:    envInt("GOOCODE_REFACTOR_REVIEW_EVERY", RefactorReviewEvery),
+			VerifyCommand:  os.Getenv("GOOCODE_VERIFY_COMMAND"),
+			MaxFixAttempts: RefactorMaxFixAttempts,
+		},
+		Knowledge: KnowledgeConfig{
+			Dir: goocodeDir("kb"),
+		},
+		Embeddings: EmbeddingsConfig{
+			Provider:      envString("GOOCODE_EMBEDDER", "hash"),
+			Model:         os.Getenv("GOOCODE_EMBEDDING_MODEL"),
+			VoyageAPIKey:  os.Getenv("VOYAGE_API_KEY"),
+			OpenAIAPIKey:  os.Getenv("OPENAI_API_KEY"),
+			OpenAIBaseURL: os.Getenv("OPENAI_BASE_URL"),
+			OllamaHost:    os.Getenv("OLLAMA_HOST"),
+		},
+		Telemetry: TelemetryConfig{
+			Enabled:     envBool("GOOCODE_TELEMETRY", os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT") != ""),
+			ServiceName: envString("OTEL_SERVICE_NAME", TelemetryServiceName),
+		},
+		Batch: BatchConfig{
+			Dir:          goocodeDir("batches"),
+			PollInterval: envInt("GOOCODE_BATCH_POLL_SECONDS", BatchPollSeconds),
 		},
 	}
 
+	config.SetModel(model)
 	return config, nil
 }
 
+// envInt reads an integer environment variable, returning def when unset or invalid
+func envInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// envBool reads a boolean environment variable, returning def when unset or invalid
+func envBool(name string, def bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(name))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// envFloat reads a decimal environment variable, returning def when unset or invalid
+func envFloat(name string, def float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(name), 64)
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// envString reads a string environment variable, returning def when unset
+func envString(name string, def string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return def
+}
+
+// envList reads a comma-separated environment variable, skipping empty items
+func envList(name string) []string {
+	var items []string
+	for _, item := range strings.Split(os.Getenv(name), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envPrefixed collects the environment variables starting with prefix, keyed by the rest of their name in lowercase
+func envPrefixed(prefix string) map[string]string {
+	values := map[string]string{}
+	for _, entry := range os.Environ() {
+		name, value, _ := strings.Cut(entry, "=")
+		if key, ok := strings.CutPrefix(name, prefix); ok && key != "" {
+			values[strings.ToLower(key)] = value
+		}
+	}
+	return values
+}
+
+// goocodeDir returns a subdirectory of ~/.goocode
+func goocodeDir(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(".goocode", name)
+	}
+	return filepath.Join(home, ".goocode", name)
+}
+
 // NewConfig creates a new configuration with default values
 func NewConfig() *Config {
 	config, _ := Load()
 	return config
 }
 
+// Model returns the active model
+func (c *Config) Model() ModelInfo {
+	return c.Agent.Model
+}
+
+// SetModel switches the active model and scales token limits to its context window
+func (c *Config) SetModel(model ModelInfo) {
+	c.Agent.Model = model
+	limits := &c.Agent.TokenLimits
+	limits.MaxInputTokens = min(MaxInputTokens, model.ContextWindow)
+	limits.WarningThreshold = limits.MaxInputTokens * WarningThreshold / MaxInputTokens
+	limits.MaxOutputTokens = min(MaxOutputTokens, model.MaxOutputTokens)
+}
+
 // MaxTokens returns the maximum output token limit for API calls
 func (c *Config) MaxTokens() int {
 	return c.Agent.TokenLimits.MaxOutputTokens
@@ -102,6 +388,11 @@ func (c *Config) MaxInputTokens() int {
 	return c.Agent.TokenLimits.MaxInputTokens
 }
 
+// CompactionStrategy returns the configured conversation compaction strategy name
+func (c *Config) CompactionStrategy() string {
+	return c.Agent.CompactionStrategy
+}
+
 // WarningThreshold returns the token count at which to show warnings
 func (c *Config) WarningThreshold() int {
 	return c.Agent.TokenLimits.WarningThreshold
@@ -9,8 +9,243 @@ const (
 	WarningThreshold   = 190000 // Show warning at this input token count
 	RecentMessagesKeep = 6      // Keep last 3 exchanges (6 messages)
 	SummaryTokenTarget = 2000   // Target token count for summary
+
+	BackgroundCompactionPercent = 80  // Start compacting in the background at this share of the input limit
+	ExactTokenCountPercent      = 75  // Above this share of the input limit, token estimates are confirmed with the API
+	DedupeMinChars              = 400 // Repeated tool results shorter than this are kept, as a reference would save little
+)
+
+// Agent loop constants
+const (
+	MaxToolCallsPerTurn   = 25 // Tool calls in one turn before asking the user to continue
+	RepeatedToolCallLimit = 3  // Identical consecutive tool calls treated as a likely loop
+)
+
+// Response verbosity levels
+const (
+	VerbosityTerse    = "terse"
+	VerbosityNormal   = "normal"
+	VerbosityDetailed = "detailed"
+)
+
+// Tool choices besides naming a tool, which forces that one
+const (
+	ToolChoiceAuto = "auto" // The model decides whether to use tools
+	ToolChoiceAny  = "any"  // The model must use some tool
+	ToolChoiceNone = "none" // The model must answer without tools
+)
+
+// Cost preview constants
+const (
+	CostConfirmThreshold = 1.00 // USD of input above which a request needs confirmation
+
+	CacheWritePriceFactor = 1.25 // Price of writing prompt cache tokens, relative to the input price
+	CacheReadPriceFactor  = 0.1  // Price of reading prompt cache tokens, relative to the input price
+)
+
+// Read cache constants
+const (
+	ReadCacheMaxBytes = 20 << 20 // File content remembered for unchanged re-reads before the cache starts over
+)
+
+// Workspace state constants
+const (
+	WorkspaceStateBytes = 4000 // Default size cap of the workspace state block sent with each request
+)
+
+// Git warm start constants
+const (
+	GitWarmStartCommits   = 10    // Recent commits listed at session start
+	GitWarmStartDiffBytes = 12000 // The uncommitted diff is cut off at this size
+)
+
+// Pinned context constants
+const (
+	PinIdleTurns    = 5      // Turns a pinned file may go unreferenced before pruning is suggested
+	PinMaxFileBytes = 100000 // Larger files are too expensive to resend with every request
+	PinPreviewChars = 60     // Characters of a pinned message shown by /pins
+)
+
+// read_file constants
+const (
+	ReadFileMaxBytes = 100000 // Larger files are read as an outline plus one chunk at a time
+	ReadChunkBytes   = 30000  // Target size of one chunk of a large file
+	ReadOutlineBytes = 10000  // Outlines of large files are cut off at this size
+)
+
+// Path schema constants
+const (
+	PathListMaxItems = 50 // Paths accepted by one path-list field, e.g. stat_file's paths
+)
+
+// multi_edit constants
+const (
+	MultiEditMaxEdits    = 100      // Edits accepted by one call
+	MultiEditMaxBytes    = 10 << 20 // Larger files are not edited in memory
+	MultiEditDiffContext = 2        // Unchanged lines shown around each hunk of the approval diff
+)
+
+// rename_symbol constants
+const (
+	RenameMaxFileBytes    = 1 << 20 // Larger files are skipped by the whole-word text fallback
+	RenameDiffContext     = 2       // Unchanged lines shown around each hunk of the diff
+	RenameResultDiffBytes = 20000   // The diff returned to the model is cut off at this size
+)
+
+// manage_todos constants
+const (
+	TodoMaxItems = 50 // Items in the model's task list
+)
+
+// memory constants
+const (
+	MemoryValueMaxChars = 2000 // Longest fact the memory tool stores
+	MemoryMaxFacts      = 500  // Facts kept per project
+	MemoryRecallLimit   = 10   // Facts brought back into the system prompt after compaction
+)
+
+// stat_file constants
+const (
+	StatLineCountMaxBytes = 50 << 20 // Larger files are not read to count their lines
+	StatGitTimeoutSeconds = 5
+)
+
+// read_many_files constants
+const (
+	ReadManyMaxBytes = 100000 // Combined bytes of file content returned by one call
+	ReadManyMaxFiles = 100    // Files read by one call; further matches are only listed
+)
+
+// list_files constants
+const (
+	ListFilesMaxEntries  = 500 // Entries listed in full; larger listings are summarized instead
+	ListSummaryTopGroups = 12  // Extensions and directories shown in a summary
+	ListSummaryTopLevel  = 40  // Top-level entries shown in a summary
+)
+
+// Directory tool constants
+const (
+	DirectoryEntriesShown = 20 // Entries named in a remove_directory result
+)
+
+// Path suggestion constants
+const (
+	PathSuggestions      = 3     // Near-matches offered when a tool is given a path that doesn't exist
+	PathSuggestScanLimit = 20000 // Entries of the working tree compared against a missing path
+)
+
+// Batch job constants
+const (
+	BatchMaxRequests  = 100000 // Requests the Message Batches API accepts in one batch
+	BatchMaxFileBytes = 200000 // Larger input files are skipped rather than sent
+	BatchPollSeconds  = 30     // Status checks while waiting for a batch to end
+)
+
+// Rate limiting constants
+const (
+	MaxConcurrentRequests = 2 // Inference, token counting and summarization calls in flight at once
+)
+
+// Guided refactor constants
+const (
+	RefactorReviewEvery    = 1 // Pause for review after every step by default
+	RefactorMaxFixAttempts = 2 // Fix attempts after a failed verification before asking the user
+)
+
+// Session store constants
+const (
+	SessionTrashDays         = 30   // Deleted sessions are purged after this many days
+	SessionTitleContextChars = 2000 // Characters of the first message used to generate a title
+	SessionSearchResults     = 20   // Maximum sessions shown by /sessions
+	SummaryCommands          = 30   // Commands listed in the end-of-session summary
+	SessionSnapshotMaxHours  = 24   // Session start snapshots older than this were left by killed sessions
+)
+
+// BriefMaxTokens bounds the GOOCODE.md project brief written by /init
+const BriefMaxTokens = 4000
+
+// Long response handling constants
+const (
+	LongOutputCollapse = "collapse" // Hide lines past the limit until /expand
+	LongOutputPager    = "pager"    // Page lines past the limit
+	LongOutputOff      = "off"      // Always stream everything
+	LongOutputLines    = 200        // Lines streamed before a response is treated as long
+)
+
+// Terminal output levels
+const (
+	UIQuiet   = "quiet"   // Only assistant text and approval prompts
+	UINormal  = "normal"  // Tool calls, results shortened to ToolResultPreviewLines, and notices
+	UIVerbose = "verbose" // Full tool results, token usage and raw API stream events as well
+
+	ToolResultPreviewLines = 20 // Lines of a tool result shown at the normal level
+)
+
+// Notification styles for finished turns, errors and approval prompts
+const (
+	NotifyOff     = "off"     // No notifications
+	NotifyBell    = "bell"    // Ring the terminal bell
+	NotifyOSC9    = "osc9"    // OSC 9 escape, shown as a desktop notification by iTerm2, WezTerm, Windows Terminal and others
+	NotifyDesktop = "desktop" // The OS notification service (notify-send or osascript), falling back to the bell
+
+	NotifyAfterSeconds = 10 // Finished turns shorter than this don't notify
+)
+
+// HighlightStyle is the default chroma style for code blocks in responses
+const HighlightStyle = "monokai"
+
+// Approval prompt constants
+const (
+	ApprovalDiffLines = 60 // Lines of a proposed change's diff shown at the approval prompt
+)
+
+// Remote approval constants
+const (
+	ApprovalListenAddr     = "localhost:8787" // Callback listener address for webhook approvals
+	ApprovalTimeoutSeconds = 300              // Wait for a remote decision before denying
 )
 
+// Persistent shell constants
+const (
+	ShellTimeoutSeconds    = 120   // Default time limit for a shell command
+	ShellMaxTimeoutSeconds = 600   // Longest time limit the model may ask for
+	ShellOutputLimit       = 30000 // Bytes of command output kept (beginning and end)
+
+	SnippetTimeoutSeconds = 30    // Default time limit for a run_snippet program
+	SnippetOutputLimit    = 20000 // Bytes of snippet output kept (beginning and end)
+)
+
+// Doctor constants
+const (
+	DoctorTimeoutSeconds = 20 // Budget for the API test request and plugin startups together
+)
+
+// MCP server constants
+const (
+	MCPMaxMessageBytes = 10 << 20 // Longest JSON-RPC message read from a client
+)
+
+// One-shot mode constants
+const (
+	PipedInputLimit = 100000 // Bytes of piped stdin added to a -p prompt (beginning and end)
+	AnswerRetries   = 2      // Extra turns asking for a structured answer when a --json-schema run ends without one
+)
+
+// StreamRetries is how many times a response stream cut off by the network is resumed
+const StreamRetries = 3
+
+// OutputContinuations is how many times a text response cut off at the output token limit is continued
+const OutputContinuations = 3
+
+// PromptVarPrefix marks environment variables that become {{.Vars.name}} in the system prompt
+const PromptVarPrefix = "GOOCODE_PROMPT_VAR_"
+
+// TelemetryServiceName is the default service.name on exported traces and metrics
+const TelemetryServiceName = "goocode"
+
+// TelemetryFlushSeconds bounds how long exiting waits for pending traces and metrics to be exported
+const TelemetryFlushSeconds = 5
+
 // Safety constants for command execution
 var DangerousCommands = []string{
 	"rm", "rmdir", "del", "erase",
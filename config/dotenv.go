@@ -0,0 +1,180 @@
+package config
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+)
+
+var (
+	envMu          sync.Mutex
+	envSources     = map[string]string{}   // Variable -> .env file that set it, for variables loaded by LoadEnvFiles
+	envLoaded      = map[string]bool{}     // .env files that were read
+	envIgnored     = map[string][]string{} // Project .env file -> variables it may not set
+	defaultEnvOnce sync.Once
+)
+
+// projectEnvKeys are the variables a project's .env file may set: display and conversation preferences
+// only. A cloned repository must not be able to redirect the API (and the key with it), supply
+// credentials, run commands or loosen approvals, budgets and other safeguards.
+var projectEnvKeys = map[string]bool{
+	"GOOCODE_MODEL": true, "GOOCODE_VERBOSITY": true, "GOOCODE_UI_VERBOSITY": true, "GOOCODE_TOOL_CHOICE": true,
+	"GOOCODE_COMPACTION_STRATEGY": true, "GOOCODE_BACKGROUND_COMPACTION": true, "GOOCODE_DEDUPE_RESULTS": true,
+	"GOOCODE_TOKEN_METER": true, "GOOCODE_COST_PREVIEW": true, "GOOCODE_HIGHLIGHT_STYLE": true,
+	"GOOCODE_LONG_OUTPUT": true, "GOOCODE_LONG_OUTPUT_LINES": true, "GOOCODE_LOCALE": true, "GOOCODE_NOTIFY": true,
+	"GOOCODE_NOTIFY_AFTER": true, "GOOCODE_PIN_IDLE_TURNS": true, "GOOCODE_AUTO_UNPIN": true, "GOOCODE_WATCH": true,
+	"GOOCODE_WORKSPACE_STATE_BYTES": true, "GOOCODE_SESSION_SUMMARY": true, "GOOCODE_GIT_WARM_START": true,
+	"GOOCODE_SHELL_OUTPUT_LIMIT": true, "GOOCODE_SHELL_TIMEOUT": true, "GOOCODE_SNIPPET_TIMEOUT": true,
+	"GOOCODE_STREAM_RETRIES": true, "GOOCODE_OUTPUT_CONTINUATIONS": true, "GOOCODE_REFACTOR_REVIEW_EVERY": true,
+}
+
+// ProjectEnvAllowed reports whether a project's .env file may set the variable name
+func ProjectEnvAllowed(name string) bool {
+	return projectEnvKeys[name] || strings.HasPrefix(name, PromptVarPrefix)
+}
+
+// userEnvFiles are the .env files the user controls: next to the binary and in ~/.goocode
+func userEnvFiles() []string {
+	var files []string
+	if exe, err := os.Executable(); err == nil {
+		if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+			exe = resolved
+		}
+		files = append(files, filepath.Join(filepath.Dir(exe), ".env"))
+	}
+	return append(files, goocodeDir(".env"))
+}
+
+// EnvFiles returns the .env files GooCode reads, lowest precedence first: next to the binary,
+// in ~/.goocode, in the current directory and, when set, in the selected working directory
+func EnvFiles(workingDir string) []string {
+	files := userEnvFiles()
+	if cwd, err := os.Getwd(); err == nil {
+		files = append(files, filepath.Join(cwd, ".env"))
+	}
+	if workingDir != "" {
+		files = append(files, filepath.Join(workingDir, ".env"))
+	}
+
+	// The same file listed twice (say, the working directory is the current one) only counts once
+	seen := map[string]bool{}
+	unique := files[:0]
+	for _, file := range files {
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+		if !seen[file] {
+			seen[file] = true
+			unique = append(unique, file)
+		}
+	}
+	return unique
+}
+
+// LoadEnvFiles applies the .env files that exist, in order, with later files overriding earlier ones.
+// Variables set in the real environment are never overridden.
+func LoadEnvFiles(files ...string) {
+	loadEnvFiles(false, files)
+}
+
+// LoadProjectEnvFiles applies .env files found in a project, which may be a cloned repository, like
+// LoadEnvFiles but only with the variables ProjectEnvAllowed accepts; the rest are ignored with a warning
+func LoadProjectEnvFiles(files ...string) {
+	loadEnvFiles(true, files)
+}
+
+func loadEnvFiles(project bool, files []string) {
+	envMu.Lock()
+	defer envMu.Unlock()
+	for _, file := range files {
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+		values, err := godotenv.Read(file)
+		if err != nil {
+			continue
+		}
+		envLoaded[file] = true
+		if project {
+			var ignored []string
+			for name := range values {
+				if !ProjectEnvAllowed(name) {
+					ignored = append(ignored, name)
+					delete(values, name)
+				}
+			}
+			if len(ignored) > 0 && envIgnored[file] == nil {
+				sort.Strings(ignored)
+				envIgnored[file] = ignored
+				log.Printf("Warning: ignoring %s from %s; a project .env file may only set display and conversation preferences (put the rest in ~/.goocode/.env or the environment)", strings.Join(ignored, ", "), file)
+			}
+		}
+		applyEnv(file, values)
+	}
+}
+
+// applyEnv sets values from source, overriding earlier sources but never the real environment; callers hold envMu
+func applyEnv(source string, values map[string]string) {
+	for name, value := range values {
+		if _, set := os.LookupEnv(name); set && envSources[name] == "" {
+			continue
+		}
+		os.Setenv(name, value)
+		envSources[name] = source
+	}
+}
+
+// LoadDefaultEnvFiles applies the settings file and then the .env files that don't depend on the working
+// directory, once per process
+func LoadDefaultEnvFiles() {
+	defaultEnvOnce.Do(func() {
+		path := SettingsFile()
+		if settings, err := ReadSettings(path); err != nil {
+			log.Printf("Warning: %v", err)
+		} else {
+			ApplySettings(path, settings)
+		}
+		loadEnvFiles(false, userEnvFiles())
+		if cwd, err := os.Getwd(); err == nil {
+			loadEnvFiles(true, []string{filepath.Join(cwd, ".env")})
+		}
+	})
+}
+
+// EnvFileStatus reports whether file was read and the variables it was not allowed to set
+func EnvFileStatus(file string) (loaded bool, ignored []string) {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	envMu.Lock()
+	defer envMu.Unlock()
+	return envLoaded[file], envIgnored[file]
+}
+
+// EnvSource returns the .env file a variable was loaded from, or "" when it came from the real environment
+func EnvSource(name string) string {
+	envMu.Lock()
+	defer envMu.Unlock()
+	return envSources[name]
+}
+
+// LoadedEnvFiles returns the .env files that set at least one variable, sorted
+func LoadedEnvFiles() []string {
+	envMu.Lock()
+	defer envMu.Unlock()
+	seen := map[string]bool{}
+	var files []string
+	for _, file := range envSources {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+	sort.Strings(files)
+	return files
+}
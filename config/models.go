@@ -0,0 +1,36 @@
+package config
+
+// ModelInfo describes the limits and capabilities of a model
+type ModelInfo struct {
+	ID              string
+	ContextWindow   int
+	MaxOutputTokens int
+	SupportsTools   bool
+	InputPrice      float64 // USD per million input tokens (0 = unknown)
+	OutputPrice     float64 // USD per million output tokens (0 = unknown)
+}
+
+// DefaultModel is the model used when none is configured
+const DefaultModel = "claude-3-7-sonnet-latest"
+
+// Models is the catalog of models GooCode knows the limits of
+var Models = []ModelInfo{
+	{ID: "claude-opus-4-0", ContextWindow: 200000, MaxOutputTokens: 32000, SupportsTools: true, InputPrice: 15, OutputPrice: 75},
+	{ID: "claude-sonnet-4-0", ContextWindow: 200000, MaxOutputTokens: 64000, SupportsTools: true, InputPrice: 3, OutputPrice: 15},
+	{ID: "claude-3-7-sonnet-latest", ContextWindow: 200000, MaxOutputTokens: 64000, SupportsTools: true, InputPrice: 3, OutputPrice: 15},
+	{ID: "claude-3-5-sonnet-latest", ContextWindow: 200000, MaxOutputTokens: 8192, SupportsTools: true, InputPrice: 3, OutputPrice: 15},
+	{ID: "claude-3-5-haiku-latest", ContextWindow: 200000, MaxOutputTokens: 8192, SupportsTools: true, InputPrice: 0.8, OutputPrice: 4},
+	{ID: "claude-3-haiku-20240307", ContextWindow: 200000, MaxOutputTokens: 4096, SupportsTools: true, InputPrice: 0.25, OutputPrice: 1.25},
+	{ID: "claude-2.1", ContextWindow: 200000, MaxOutputTokens: 4096, SupportsTools: false, InputPrice: 8, OutputPrice: 24},
+	{ID: "claude-2.0", ContextWindow: 100000, MaxOutputTokens: 4096, SupportsTools: false, InputPrice: 8, OutputPrice: 24},
+}
+
+// LookupModel returns the catalog entry for id; unknown models get conservative defaults
+func LookupModel(id string) (ModelInfo, bool) {
+	for _, model := range Models {
+		if model.ID == id {
+			return model, true
+		}
+	}
+	return ModelInfo{ID: id, ContextWindow: MaxInputTokens, MaxOutputTokens: MaxOutputTokens, SupportsTools: true}, false
+}
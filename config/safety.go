@@ -0,0 +1,23 @@
+package config
+
+import "strings"
+
+// IsDangerousCommand reports whether any part of a shell command matches the dangerous command lists
+func IsDangerousCommand(command string) bool {
+	for _, pattern := range DangerousPatterns {
+		if pattern.MatchString(command) {
+			return true
+		}
+	}
+	segments := strings.FieldsFunc(command, func(r rune) bool { return r == ';' || r == '&' || r == '|' || r == '\n' })
+	for _, segment := range segments {
+		segment = strings.Join(strings.Fields(segment), " ")
+		segment = strings.TrimPrefix(segment, "sudo ")
+		for _, dangerous := range DangerousCommands {
+			if segment == dangerous || strings.HasPrefix(segment, dangerous+" ") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings are the user defaults kept in the config file written by `goocode setup`.
+// They are the lowest layer of configuration: .env files and the environment override them.
+type Settings struct {
+	APIKey     string             `yaml:"api_key,omitempty"`
+	Model      string             `yaml:"model,omitempty"`
+	WorkingDir string             `yaml:"working_dir,omitempty"`
+	Profiles   map[string]Profile `yaml:"profiles,omitempty"`
+}
+
+// Profile bundles the settings of one agent personality, such as a reviewer or a test writer, chosen
+// with --profile or /profile; empty fields keep the regular configuration
+type Profile struct {
+	Description      string   `yaml:"description,omitempty"`
+	Model            string   `yaml:"model,omitempty"`
+	SystemPrompt     string   `yaml:"system_prompt,omitempty"`
+	SystemPromptFile string   `yaml:"system_prompt_file,omitempty"` // Relative to the settings file's directory
+	Tools            []string `yaml:"tools,omitempty"`              // The only tools offered to the model
+	Temperature      *float64 `yaml:"temperature,omitempty"`
+}
+
+// SettingsFile returns $XDG_CONFIG_HOME/goocode/config.yaml, with XDG_CONFIG_HOME defaulting to ~/.config
+func SettingsFile() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return filepath.Join(".config", "goocode", "config.yaml")
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "goocode", "config.yaml")
+}
+
+// ReadSettings loads the settings file at path; a missing file gives empty settings
+func ReadSettings(path string) (*Settings, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &Settings{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+	var settings Settings
+	if err := yaml.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("invalid settings file %s: %w", path, err)
+	}
+	return &settings, nil
+}
+
+// WriteSettings saves settings to path, readable only by the user since they may hold the API key
+func WriteSettings(path string, settings *Settings) error {
+	data, err := yaml.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create settings directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
+
+// ApplySettings provides the settings as defaults for the environment variables they correspond to
+func ApplySettings(path string, settings *Settings) {
+	values := map[string]string{}
+	for name, value := range map[string]string{
+		"ANTHROPIC_API_KEY":   settings.APIKey,
+		"GOOCODE_MODEL":       settings.Model,
+		"GOOCODE_WORKING_DIR": settings.WorkingDir,
+	} {
+		if value != "" {
+			values[name] = value
+		}
+	}
+	envMu.Lock()
+	defer envMu.Unlock()
+	applyEnv(path, values)
+}
+
+// loadProfiles reads the profiles from the settings file; problems with the file are reported when it
+// is applied at startup
+func loadProfiles() map[string]Profile {
+	settings, err := ReadSettings(SettingsFile())
+	if err != nil {
+		return nil
+	}
+	return settings.Profiles
+}
+
+// ProfilePrompt returns the profile's system prompt, reading system_prompt_file if it is set, or "" to
+// keep the regular one
+func (p Profile) ProfilePrompt() (string, error) {
+	if p.SystemPromptFile == "" {
+		return p.SystemPrompt, nil
+	}
+	path := p.SystemPromptFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(filepath.Dir(SettingsFile()), path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read profile system prompt: %w", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"anthropic-chat/config"
+)
+
+// configPrefixes are the environment variables `goocode config` lists
+var configPrefixes = []string{"GOOCODE_", "ANTHROPIC_"}
+
+// runConfigCommand implements `goocode config [show|path]`, showing the settings file, the .env files
+// and the variables they set, so it's clear where each value in effect comes from
+func runConfigCommand(args []string) error {
+	action := "show"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+	switch action {
+	case "path":
+		fmt.Println(config.SettingsFile())
+		return nil
+	case "show":
+	default:
+		return fmt.Errorf("usage: goocode config [show|path] [--dir path]")
+	}
+
+	flags := flag.NewFlagSet("config show", flag.ContinueOnError)
+	cwd, _ := os.Getwd()
+	dir := flags.String("dir", cwd, "Working directory whose .env file is included")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	config.LoadDefaultEnvFiles()
+	config.LoadProjectEnvFiles(filepath.Join(*dir, ".env"))
+	cfg := config.NewConfig()
+
+	settings := config.SettingsFile()
+	if _, err := os.Stat(settings); errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Settings file: %s (not created yet; run goocode setup)\n", settings)
+	} else {
+		fmt.Printf("Settings file: %s\n", settings)
+	}
+	files := slices.DeleteFunc(config.LoadedEnvFiles(), func(file string) bool { return file == settings })
+	if len(files) == 0 {
+		fmt.Println(".env files:   none")
+	} else {
+		fmt.Printf(".env files:   %s\n", strings.Join(files, ", "))
+	}
+	fmt.Printf("Platform:     %s\n", cfg.API.Platform)
+	fmt.Printf("Model:        %s\n", cfg.Model().ID)
+	fmt.Printf("Sessions:     %s\n\n", cfg.Session.Dir)
+
+	var names []string
+	for _, entry := range os.Environ() {
+		name, _, _ := strings.Cut(entry, "=")
+		for _, prefix := range configPrefixes {
+			if strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	if len(names) == 0 {
+		fmt.Println("No GOOCODE_ or ANTHROPIC_ variables are set; everything uses its default (see the README)")
+		return nil
+	}
+	slices.Sort(names)
+	fmt.Println("Variables:")
+	for _, name := range names {
+		source := config.EnvSource(name)
+		switch source {
+		case "":
+			source = "environment"
+		case settings:
+			source = "settings file"
+		}
+		fmt.Printf("  %s=%s (%s)\n", name, maskSecret(name, os.Getenv(name)), source)
+	}
+	return nil
+}
+
+// maskSecret hides the value of a variable that holds a credential, keeping a few characters to tell
+// keys apart
+func maskSecret(name, value string) string {
+	for _, word := range []string{"KEY", "TOKEN", "SECRET", "PASSWORD"} {
+		if strings.Contains(name, word) {
+			if len(value) <= 8 {
+				return "****"
+			}
+			return value[:4] + "..." + value[len(value)-4:]
+		}
+	}
+	return value
+}
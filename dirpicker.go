@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"anthropic-chat/agent"
+	"anthropic-chat/config"
+	"anthropic-chat/session"
+	"anthropic-chat/ui"
+)
+
+// promptForDirectory asks for the working directory, listing recent ones to pick by number. On a terminal
+// the path can be tab-completed and invalid or overly broad choices are asked again; piped input is read
+// once, as before.
+func promptForDirectory(scanner *bufio.Scanner) (string, error) {
+	cfg := config.NewConfig()
+	uiManager := ui.NewManager(cfg.UI)
+	recent := session.RecentDirs(cfg.Session.RecentDirs)
+	interactive := uiManager.CanEditLines()
+
+	readLine := func(prompt string) (string, error) {
+		if interactive {
+			history := slices.Clone(recent)
+			slices.Reverse(history)
+			editor := &ui.LineEditor{Complete: completeDirectory, History: history}
+			return editor.ReadLine(prompt)
+		}
+		fmt.Print(prompt)
+		if !scanner.Scan() {
+			return "", fmt.Errorf("failed to read input")
+		}
+		return scanner.Text(), nil
+	}
+
+	if len(recent) > 0 {
+		fmt.Println("Recent directories:")
+		for i, dir := range recent {
+			fmt.Printf("  %d) %s\n", i+1, dir)
+		}
+	}
+	defaultDir := os.Getenv("GOOCODE_WORKING_DIR")
+	prompt := "Enter the directory you'd like to work in (or press Enter for current directory): "
+	if defaultDir != "" {
+		prompt = fmt.Sprintf("Enter the directory you'd like to work in (or press Enter for %s): ", defaultDir)
+	}
+	if len(recent) > 0 {
+		prompt = strings.Replace(prompt, "Enter the directory", "Enter a number or the directory", 1)
+	}
+
+	for {
+		input, err := readLine(prompt)
+		if errors.Is(err, ui.ErrInterrupted) {
+			return "", err
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read input: %w", err)
+		}
+
+		dir, err := chooseDirectory(strings.TrimSpace(input), defaultDir, recent)
+		if err != nil {
+			if !interactive {
+				return "", err
+			}
+			fmt.Printf("%s: %v\n", uiManager.Paint(ui.StyleError, "Error"), err)
+			continue
+		}
+
+		if reason := agent.BroadDirectory(dir); reason != "" {
+			fmt.Printf("%s: %s is %s, so every file under it is within reach of the tools\n", uiManager.Paint(ui.StyleWarning, "⚠️  Warning"), dir, reason)
+			if interactive {
+				answer, err := readLine("Use it anyway? [y/N] ")
+				if err != nil {
+					return "", fmt.Errorf("failed to read input: %w", err)
+				}
+				if answer = strings.ToLower(strings.TrimSpace(answer)); answer != "y" && answer != "yes" {
+					continue
+				}
+			}
+		}
+
+		if err := session.AddRecentDir(cfg.Session.RecentDirs, dir); err != nil {
+			log.Printf("Warning: failed to record recent directory: %v", err)
+		}
+		return dir, nil
+	}
+}
+
+// chooseDirectory turns the answer to the directory prompt into an absolute, validated path
+func chooseDirectory(input, defaultDir string, recent []string) (string, error) {
+	if input == "" {
+		input = defaultDir
+	}
+	if input == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return "", fmt.Errorf("failed to get current directory: %w", err)
+		}
+		return cwd, nil
+	}
+	// A number picks from the recent list, unless a directory by that name exists here
+	if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(recent) {
+		if _, err := os.Stat(input); err != nil {
+			input = recent[n-1]
+		}
+	}
+
+	dir, err := resolveDirectory(input)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(dir)
+}
+
+// completeDirectory lists the subdirectories that the path in line could be completed to, each ending in a
+// separator. Hidden directories are only offered once their leading dot has been typed.
+func completeDirectory(line string) []string {
+	path := line
+	home := ""
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return nil
+		}
+		path = home + strings.TrimPrefix(path, "~")
+		if line == "~" {
+			return []string{"~" + string(filepath.Separator)}
+		}
+	}
+
+	dir, base := filepath.Split(path)
+	readDir := dir
+	if readDir == "" {
+		readDir = "."
+	}
+	entries, err := os.ReadDir(readDir)
+	if err != nil {
+		return nil
+	}
+
+	var candidates []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, base) || (strings.HasPrefix(name, ".") && !strings.HasPrefix(base, ".")) {
+			continue
+		}
+		if !entry.IsDir() {
+			// Follow symlinks to directories
+			info, err := os.Stat(filepath.Join(readDir, name))
+			if err != nil || !info.IsDir() {
+				continue
+			}
+		}
+		candidate := dir + name + string(filepath.Separator)
+		if home != "" {
+			candidate = "~" + strings.TrimPrefix(candidate, home)
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates
+}
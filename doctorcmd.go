@@ -0,0 +1,387 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/config"
+	"anthropic-chat/embeddings"
+	"anthropic-chat/lsp"
+	"anthropic-chat/mcp"
+	"anthropic-chat/plugin"
+	"anthropic-chat/project"
+	"anthropic-chat/provider"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"golang.org/x/term"
+)
+
+// Doctor check outcomes
+const (
+	checkOK   = "ok"
+	checkWarn = "warn" // Works, but something is degraded or missing
+	checkFail = "fail" // Keeps GooCode from working
+)
+
+// doctorCheck is the outcome of one check, with what to do about it when it isn't ok
+type doctorCheck struct {
+	name   string
+	status string
+	detail string
+	fix    string
+}
+
+// languageServerInstall says how to get each language server GooCode can launch
+var languageServerInstall = map[string]string{
+	"gopls":                      "go install golang.org/x/tools/gopls@latest",
+	"pyright-langserver":         "npm install -g pyright",
+	"typescript-language-server": "npm install -g typescript-language-server typescript",
+}
+
+// runDoctorCommand implements `goocode doctor [--dir dir] [--offline]`, checking the environment and
+// printing a fix for each problem. It fails when any check does, so scripts can gate on it.
+func runDoctorCommand(args []string) error {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	cwd, _ := os.Getwd()
+	dir := flags.String("dir", cwd, "Working directory to check")
+	offline := flags.Bool("offline", false, "Skip the test request to the model API")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	checks := []doctorCheck{checkSettings()}
+	config.LoadDefaultEnvFiles()
+	config.LoadProjectEnvFiles(filepath.Join(*dir, ".env"))
+	cfg := config.NewConfig()
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.DoctorTimeoutSeconds*time.Second)
+	defer cancel()
+	checks = append(checks, checkCredentials(cfg))
+	if !*offline && checks[len(checks)-1].status != checkFail {
+		checks = append(checks, checkAPI(ctx, cfg))
+	}
+	checks = append(checks, checkApprovalPolicy(cfg, *dir), checkWritable(*dir), checkSessionDir(cfg), checkGit(*dir), checkTerminal(cfg))
+	checks = append(checks, checkLanguageServers()...)
+	checks = append(checks, checkEmbedder(cfg))
+	checks = append(checks, checkPlugins(ctx, cfg, *dir)...)
+	checks = append(checks, checkMCPServe(ctx, *dir))
+
+	uiManager := ui.NewManager(cfg.UI)
+	styles := map[string]ui.Style{checkOK: ui.StyleSuccess, checkWarn: ui.StyleWarning, checkFail: ui.StyleError}
+	failed, warned := 0, 0
+	for _, check := range checks {
+		fmt.Printf("%s %s: %s\n", uiManager.Paint(styles[check.status], fmt.Sprintf("[%-4s]", check.status)), check.name, check.detail)
+		if check.fix != "" && check.status != checkOK {
+			fmt.Printf("       Fix: %s\n", check.fix)
+		}
+		switch check.status {
+		case checkFail:
+			failed++
+		case checkWarn:
+			warned++
+		}
+	}
+	fmt.Println()
+	if failed > 0 {
+		return fmt.Errorf("doctor found %d problem(s) and %d warning(s)", failed, warned)
+	}
+	fmt.Printf("%d check(s) passed with %d warning(s)\n", len(checks)-warned, warned)
+	return nil
+}
+
+// checkSettings parses the user settings file written by `goocode setup`
+func checkSettings() doctorCheck {
+	path := config.SettingsFile()
+	check := doctorCheck{name: "Settings file"}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		check.status, check.detail = checkOK, path+" doesn't exist; using environment variables only"
+		return check
+	}
+	if _, err := config.ReadSettings(path); err != nil {
+		check.status, check.detail = checkFail, err.Error()
+		check.fix = "correct the YAML, or delete the file and run `goocode setup` again"
+		return check
+	}
+	check.status, check.detail = checkOK, path
+	return check
+}
+
+// checkCredentials looks for what the configured platform authenticates with
+func checkCredentials(cfg *config.Config) doctorCheck {
+	check := doctorCheck{name: "Credentials", status: checkOK}
+	switch cfg.API.Platform {
+	case provider.PlatformAnthropic:
+		if cfg.API.Key == "" {
+			check.status, check.detail = checkFail, "no Anthropic API key"
+			check.fix = "run `goocode setup`, or set ANTHROPIC_API_KEY (keys are at console.anthropic.com)"
+			return check
+		}
+		source := config.EnvSource("ANTHROPIC_API_KEY")
+		if source == "" {
+			source = "the environment"
+		}
+		check.detail = fmt.Sprintf("API key ...%s from %s", cfg.API.Key[max(0, len(cfg.API.Key)-4):], source)
+	case provider.PlatformBedrock, provider.PlatformVertex:
+		check.detail = fmt.Sprintf("%s platform in region %q, using cloud credentials", cfg.API.Platform, cfg.API.Region)
+		if cfg.API.Region == "" && cfg.API.Platform == provider.PlatformVertex {
+			check.status = checkWarn
+			check.fix = "set GOOCODE_REGION to the region your models are enabled in"
+		}
+	default:
+		check.status, check.detail = checkFail, fmt.Sprintf("unknown platform %q", cfg.API.Platform)
+		check.fix = "set GOOCODE_PLATFORM to anthropic, bedrock or vertex"
+	}
+	return check
+}
+
+// checkAPI sends a one-token request to the configured model, which costs a fraction of a cent
+func checkAPI(ctx context.Context, cfg *config.Config) doctorCheck {
+	check := doctorCheck{name: "Model API"}
+	client, err := newAnthropicClient(ctx, cfg.API)
+	if err != nil {
+		check.status, check.detail = checkFail, err.Error()
+		check.fix = "check the platform's credentials; see Cloud Platforms in the README"
+		return check
+	}
+	started := time.Now()
+	_, err = client.Messages.New(ctx, anthropic.MessageNewParams{
+		Model:     anthropic.Model(cfg.Model().ID),
+		MaxTokens: 1,
+		Messages:  []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock("ping"))},
+	})
+	var apiErr *anthropic.Error
+	switch {
+	case err == nil:
+		check.status, check.detail = checkOK, fmt.Sprintf("%s answered in %s", cfg.Model().ID, ui.FormatDuration(time.Since(started)))
+	case errors.As(err, &apiErr) && apiErr.StatusCode == 401:
+		check.status, check.detail = checkFail, "the API key was rejected"
+		check.fix = "create a new key at console.anthropic.com and run `goocode setup`"
+	case errors.As(err, &apiErr) && apiErr.StatusCode == 403:
+		check.status, check.detail = checkFail, "the credentials may not use "+cfg.Model().ID
+		check.fix = "enable the model for your account or cloud project, or pick another with GOOCODE_MODEL"
+	case errors.As(err, &apiErr) && apiErr.StatusCode == 404:
+		check.status, check.detail = checkFail, fmt.Sprintf("model %s was not found", cfg.Model().ID)
+		check.fix = "set GOOCODE_MODEL to a model your account has; /model in a session lists the known ones"
+	case errors.As(err, &apiErr) && (apiErr.StatusCode == 429 || apiErr.StatusCode >= 500):
+		check.status, check.detail = checkWarn, fmt.Sprintf("the API is busy (HTTP %d); the credentials look fine", apiErr.StatusCode)
+		check.fix = "try again in a minute"
+	case errors.Is(err, context.DeadlineExceeded):
+		check.status, check.detail = checkFail, "no answer within the timeout"
+		check.fix = "check your network connection, firewall and HTTPS_PROXY"
+	default:
+		check.status, check.detail = checkFail, err.Error()
+		check.fix = "check your network connection and credentials"
+	}
+	return check
+}
+
+// checkApprovalPolicy parses the approval matrix a session would load
+func checkApprovalPolicy(cfg *config.Config, dir string) doctorCheck {
+	check := doctorCheck{name: "Approval policy", status: checkOK}
+	file, fromRepo := cfg.Security.ApprovalPolicy, false
+	if p, ok := project.Find(dir); ok && file == "" {
+		file = p.Permissions()
+		fromRepo = file != ""
+	}
+	if file == "" {
+		check.detail = "none; every write and command asks first"
+		if !cfg.Security.RequireApproval {
+			check.detail = "none; gated actions run without asking, except destructive ones"
+		}
+		return check
+	}
+	if _, err := approval.Load(file); err != nil {
+		check.status, check.detail = checkFail, err.Error()
+		check.fix = "fix the file; sessions refuse to start with an unreadable policy"
+		return check
+	}
+	check.detail = file
+	if trusted, err := approval.LoadTrustedPolicies(cfg.Security.TrustedPolicies); fromRepo && (err != nil || !trusted.Trusted(file)) {
+		check.status, check.detail = checkWarn, file+" is provided by the repository and hasn't been accepted, so it isn't used"
+		check.fix = "start an interactive session here to review and accept it"
+	}
+	return check
+}
+
+// checkWritable makes sure tools can create files in the working directory
+func checkWritable(dir string) doctorCheck {
+	check := doctorCheck{name: "Working directory"}
+	file, err := os.CreateTemp(dir, ".goocode-doctor-*")
+	if err != nil {
+		check.status, check.detail = checkFail, fmt.Sprintf("can't write to %s: %v", dir, err)
+		check.fix = "fix the directory's permissions, or pass --read-only to explore it without writing"
+		return check
+	}
+	file.Close()
+	os.Remove(file.Name())
+	check.status, check.detail = checkOK, dir+" is writable"
+	return check
+}
+
+// checkSessionDir makes sure conversations can be saved
+func checkSessionDir(cfg *config.Config) doctorCheck {
+	check := doctorCheck{name: "Session store"}
+	if err := os.MkdirAll(cfg.Session.Dir, 0o755); err != nil {
+		check.status, check.detail = checkWarn, fmt.Sprintf("can't create %s: %v", cfg.Session.Dir, err)
+		check.fix = "make ~/.goocode writable; sessions won't be saved until then"
+		return check
+	}
+	check.status, check.detail = checkOK, cfg.Session.Dir
+	return check
+}
+
+// checkGit looks for git, which stat_file, /summary, /diff and checkpoints use
+func checkGit(dir string) doctorCheck {
+	check := doctorCheck{name: "Git"}
+	out, err := exec.Command("git", "--version").Output()
+	if err != nil {
+		check.status, check.detail = checkWarn, "git is not installed or not on PATH"
+		check.fix = "install git; file status, session summaries and checkpoints need it"
+		return check
+	}
+	check.status, check.detail = checkOK, strings.TrimSpace(string(out))
+	cmd := exec.Command("git", "rev-parse", "--show-toplevel")
+	cmd.Dir = dir
+	if top, err := cmd.Output(); err == nil {
+		check.detail += ", repository at " + strings.TrimSpace(string(top))
+	} else {
+		check.status, check.detail = checkWarn, check.detail+", but the working directory is not in a repository"
+		check.fix = "run `git init` to get change tracking and checkpoints"
+	}
+	return check
+}
+
+// checkTerminal reports what the terminal can show
+func checkTerminal(cfg *config.Config) doctorCheck {
+	caps := ui.DetectCapabilities(cfg.UI.ColorOutput)
+	check := doctorCheck{name: "Terminal", status: checkOK}
+	if !caps.Terminal {
+		check.status, check.detail = checkWarn, "stdout is not a terminal; output is plain text"
+		check.fix = "nothing to do for scripts; run GooCode in a terminal for colors and spinners"
+		return check
+	}
+	var features []string
+	if caps.Color {
+		features = append(features, "color")
+	}
+	if caps.Cursor {
+		features = append(features, "animations")
+	}
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil {
+		features = append(features, fmt.Sprintf("%d columns", width))
+	}
+	check.detail = fmt.Sprintf("TERM=%s (%s)", os.Getenv("TERM"), strings.Join(features, ", "))
+	if !caps.Color && cfg.UI.ColorOutput {
+		check.status = checkWarn
+		check.fix = "set TERM to a color terminal type such as xterm-256color, and unset NO_COLOR"
+	}
+	return check
+}
+
+// checkLanguageServers looks for the language servers behind go_to_definition and friends
+func checkLanguageServers() []doctorCheck {
+	var checks []doctorCheck
+	for _, server := range lsp.DefaultServers {
+		check := doctorCheck{name: "Language server (" + server.Language + ")", status: checkOK}
+		if path, err := exec.LookPath(server.Command); err == nil {
+			check.detail = path
+		} else {
+			check.status, check.detail = checkWarn, server.Command+" is not on PATH; code navigation for "+server.Language+" is unavailable"
+			check.fix = languageServerInstall[server.Command]
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// checkEmbedder makes sure the knowledge base and semantic search can start
+func checkEmbedder(cfg *config.Config) doctorCheck {
+	check := doctorCheck{name: "Embeddings"}
+	if _, err := embeddings.New(cfg.Embeddings); err != nil {
+		check.status, check.detail = checkWarn, err.Error()
+		check.fix = "set the embedder's API key, or GOOCODE_EMBEDDER=hash for the offline embedder"
+		return check
+	}
+	name := cfg.Embeddings.Provider
+	if name == "" {
+		name = "hash"
+	}
+	check.status, check.detail = checkOK, name+" embedder"
+	return check
+}
+
+// checkMCPServe starts `goocode serve` for dir, as an MCP client would, and asks it for its tools.
+// GooCode doesn't connect to MCP servers itself; this is the one it offers to other agents and editors.
+func checkMCPServe(ctx context.Context, dir string) doctorCheck {
+	check := doctorCheck{name: "MCP server", fix: fmt.Sprintf("run goocode serve --dir %s by hand to see why it fails", dir)}
+	executable, err := os.Executable()
+	if err != nil {
+		check.status, check.detail = checkFail, fmt.Sprintf("can't find the goocode executable: %v", err)
+		return check
+	}
+	requests := `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"` + mcp.ProtocolVersion + `","capabilities":{},"clientInfo":{"name":"goocode doctor","version":"1"}}}` + "\n" +
+		`{"jsonrpc":"2.0","id":2,"method":"tools/list"}` + "\n"
+	cmd := exec.CommandContext(ctx, executable, "serve", "--dir", dir, "--read-only")
+	cmd.Stdin = strings.NewReader(requests)
+	out, err := cmd.Output()
+	if err != nil {
+		check.status, check.detail = checkFail, fmt.Sprintf("goocode serve failed: %v", err)
+		return check
+	}
+	var listed struct {
+		Result struct {
+			Tools []json.RawMessage `json:"tools"`
+		} `json:"result"`
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	if len(lines) < 2 || json.Unmarshal([]byte(lines[1]), &listed) != nil {
+		check.status, check.detail = checkFail, "goocode serve didn't answer initialize and tools/list"
+		return check
+	}
+	if listed.Error != nil {
+		check.status, check.detail = checkFail, "tools/list failed: "+listed.Error.Message
+		return check
+	}
+	check.status, check.detail, check.fix = checkOK, fmt.Sprintf("goocode serve answers over stdio with %d read-only tool(s)", len(listed.Result.Tools)), ""
+	return check
+}
+
+// checkPlugins starts each tool plugin a session would load and asks it for its tools
+func checkPlugins(ctx context.Context, cfg *config.Config, dir string) []doctorCheck {
+	dirs := []string{cfg.Agent.PluginsDir}
+	if p, ok := project.Find(dir); ok && cfg.Agent.ProjectPlugins {
+		dirs = append(dirs, p.Path(project.PluginsDir))
+	}
+	paths := plugin.Discover(dirs...)
+	if len(paths) == 0 {
+		return []doctorCheck{{name: "Plugins", status: checkOK, detail: "none installed in " + strings.Join(dirs, ", ")}}
+	}
+	var checks []doctorCheck
+	for _, path := range paths {
+		check := doctorCheck{name: "Plugin " + filepath.Base(path)}
+		p := plugin.New(path, dir)
+		description, err := p.Describe(ctx)
+		p.Close()
+		if err != nil {
+			check.status, check.detail = checkFail, err.Error()
+			check.fix = fmt.Sprintf("run %s by hand to see why it fails, or remove it from %s", path, filepath.Dir(path))
+		} else {
+			check.status, check.detail = checkOK, fmt.Sprintf("%s with %d tool(s)", description.Name, len(description.Tools))
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
@@ -0,0 +1,151 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log"
+	"math"
+	"strings"
+	"unicode"
+
+	"anthropic-chat/config"
+)
+
+// DefaultDimensions is the vector size used by the local hash embedder
+const DefaultDimensions = 512
+
+// Embedder turns text into fixed-size vectors for similarity search
+type Embedder interface {
+	Name() string
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// New creates the configured embedder. Hosted providers without an API key fall back to the
+// local hash embedder so retrieval keeps working offline and in restricted environments.
+func New(cfg config.EmbeddingsConfig) (Embedder, error) {
+	switch cfg.Provider {
+	case "", "hash":
+		return NewHashEmbedder(DefaultDimensions), nil
+	case "voyage":
+		if cfg.VoyageAPIKey == "" {
+			log.Printf("Warning: VOYAGE_API_KEY is not set, using the local hash embedder")
+			return NewHashEmbedder(DefaultDimensions), nil
+		}
+		return NewVoyageEmbedder(cfg.VoyageAPIKey, cfg.Model), nil
+	case "openai":
+		// A custom base URL usually points at a local compatible server that needs no key
+		if cfg.OpenAIAPIKey == "" && cfg.OpenAIBaseURL == "" {
+			log.Printf("Warning: OPENAI_API_KEY is not set, using the local hash embedder")
+			return NewHashEmbedder(DefaultDimensions), nil
+		}
+		return NewOpenAIEmbedder(cfg.OpenAIAPIKey, cfg.Model, cfg.OpenAIBaseURL), nil
+	case "ollama":
+		return NewOllamaEmbedder(cfg.OllamaHost, cfg.Model), nil
+	default:
+		return nil, fmt.Errorf("unknown embedder %q (available: hash, voyage, openai, ollama)", cfg.Provider)
+	}
+}
+
+// HashEmbedder is a local, offline embedder based on feature hashing of words and word pairs
+type HashEmbedder struct {
+	dims int
+}
+
+// NewHashEmbedder creates a new HashEmbedder producing vectors of the given size
+func NewHashEmbedder(dims int) *HashEmbedder {
+	if dims <= 0 {
+		dims = DefaultDimensions
+	}
+	return &HashEmbedder{dims: dims}
+}
+
+// Name identifies the embedder so stores built with a different one can be detected
+func (e *HashEmbedder) Name() string {
+	return fmt.Sprintf("hash-%d", e.dims)
+}
+
+// Embed hashes each text into a normalized vector
+func (e *HashEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		vector := make([]float32, e.dims)
+		words := tokenize(text)
+		for j, word := range words {
+			e.add(vector, word, 1)
+			if j > 0 {
+				e.add(vector, words[j-1]+" "+word, 0.5)
+			}
+		}
+		normalize(vector)
+		vectors[i] = vector
+	}
+	return vectors, nil
+}
+
+// add folds one feature into the vector, using a second hash bit for the sign to reduce collisions
+func (e *HashEmbedder) add(vector []float32, feature string, weight float32) {
+	h := fnv.New64a()
+	h.Write([]byte(feature))
+	sum := h.Sum64()
+	if sum>>63 == 1 {
+		weight = -weight
+	}
+	vector[sum%uint64(e.dims)] += weight
+}
+
+// stopWords are too common to carry meaning
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true,
+	"for": true, "from": true, "in": true, "is": true, "it": true, "of": true, "on": true, "or": true,
+	"that": true, "the": true, "this": true, "to": true, "was": true, "we": true, "with": true,
+}
+
+// tokenize lowercases text and splits it into words, dropping stop words
+func tokenize(text string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := fields[:0]
+	for _, field := range fields {
+		if !stopWords[field] {
+			words = append(words, field)
+		}
+	}
+	return words
+}
+
+// normalize scales the vector to unit length
+func normalize(vector []float32) {
+	var sum float64
+	for _, v := range vector {
+		sum += float64(v) * float64(v)
+	}
+	if sum == 0 {
+		return
+	}
+	norm := float32(math.Sqrt(sum))
+	for i := range vector {
+		vector[i] /= norm
+	}
+}
+
+// Cosine returns the cosine similarity of two vectors
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
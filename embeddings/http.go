@@ -0,0 +1,80 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// httpClient is shared by the remote embedders
+var httpClient = &http.Client{Timeout: 60 * time.Second}
+
+// postJSON sends body as JSON to url and decodes the JSON response into out
+func postJSON(ctx context.Context, url string, headers map[string]string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read embedding response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("embedding request failed with %s: %s", resp.Status, truncate(string(data), 300))
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse embedding response: %w", err)
+	}
+	return nil
+}
+
+// indexedEmbeddings is the response shape shared by the OpenAI and Voyage APIs
+type indexedEmbeddings struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// vectors orders the embeddings by input index
+func (r indexedEmbeddings) vectors(count int) ([][]float32, error) {
+	vectors := make([][]float32, count)
+	for _, item := range r.Data {
+		if item.Index < 0 || item.Index >= count {
+			return nil, fmt.Errorf("embedding response has out of range index %d", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	for i, vector := range vectors {
+		if vector == nil {
+			return nil, fmt.Errorf("embedding response is missing input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	return s[:max] + "..."
+}
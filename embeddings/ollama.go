@@ -0,0 +1,55 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	defaultOllamaModel = "nomic-embed-text"
+	defaultOllamaHost  = "http://localhost:11434"
+)
+
+// OllamaEmbedder runs a local embedding model through an Ollama server, so nothing leaves the machine
+type OllamaEmbedder struct {
+	host  string
+	model string
+}
+
+// NewOllamaEmbedder creates a new OllamaEmbedder; an empty host or model selects the defaults
+func NewOllamaEmbedder(host, model string) *OllamaEmbedder {
+	if host == "" {
+		host = defaultOllamaHost
+	}
+	if !strings.Contains(host, "://") {
+		host = "http://" + host
+	}
+	if model == "" {
+		model = defaultOllamaModel
+	}
+	return &OllamaEmbedder{host: strings.TrimSuffix(host, "/"), model: model}
+}
+
+// Name identifies the embedder and model
+func (e *OllamaEmbedder) Name() string {
+	return "ollama:" + e.model
+}
+
+// Embed requests embeddings for texts in one call
+func (e *OllamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp struct {
+		Embeddings [][]float32 `json:"embeddings"`
+	}
+	err := postJSON(ctx, e.host+"/api/embed", nil, map[string]interface{}{
+		"model": e.model,
+		"input": texts,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d inputs", len(resp.Embeddings), len(texts))
+	}
+	return resp.Embeddings, nil
+}
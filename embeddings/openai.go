@@ -0,0 +1,51 @@
+package embeddings
+
+import (
+	"context"
+	"strings"
+)
+
+const (
+	defaultOpenAIModel   = "text-embedding-3-small"
+	defaultOpenAIBaseURL = "https://api.openai.com/v1"
+)
+
+// OpenAIEmbedder uses the OpenAI embeddings API or any server compatible with it
+type OpenAIEmbedder struct {
+	apiKey  string
+	model   string
+	baseURL string
+}
+
+// NewOpenAIEmbedder creates a new OpenAIEmbedder; an empty model or baseURL selects the defaults
+func NewOpenAIEmbedder(apiKey, model, baseURL string) *OpenAIEmbedder {
+	if model == "" {
+		model = defaultOpenAIModel
+	}
+	if baseURL == "" {
+		baseURL = defaultOpenAIBaseURL
+	}
+	return &OpenAIEmbedder{apiKey: apiKey, model: model, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Name identifies the embedder and model
+func (e *OpenAIEmbedder) Name() string {
+	return "openai:" + e.model
+}
+
+// Embed requests embeddings for texts in one call
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	headers := map[string]string{}
+	if e.apiKey != "" {
+		headers["Authorization"] = "Bearer " + e.apiKey
+	}
+	var resp indexedEmbeddings
+	err := postJSON(ctx, e.baseURL+"/embeddings", headers, map[string]interface{}{
+		"model": e.model,
+		"input": texts,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.vectors(len(texts))
+}
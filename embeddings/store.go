@@ -0,0 +1,109 @@
+package embeddings
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// Document is a chunk of text stored with its embedding
+type Document struct {
+	ID     string    `json:"id"`
+	Source string    `json:"source"`          // File the chunk came from, relative to the project
+	Title  string    `json:"title,omitempty"` // Heading path within the source
+	Text   string    `json:"text"`
+	Vector []float32 `json:"vector"`
+}
+
+// Result is a document matched by a search
+type Result struct {
+	Document
+	Score float64 `json:"score"`
+}
+
+// Store is a JSON-file backed vector store
+type Store struct {
+	path      string
+	Embedder  string            `json:"embedder"`
+	Documents []Document        `json:"documents"`
+	Versions  map[string]string `json:"versions,omitempty"` // Optional per-source fingerprint, so unchanged sources can be skipped
+}
+
+// Open loads the store at path, returning an empty store when it does not exist yet
+func Open(path string) (*Store, error) {
+	store := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embeddings store: %w", err)
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to parse embeddings store %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// Save writes the store atomically
+func (s *Store) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create embeddings directory: %w", err)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to encode embeddings store: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write embeddings store: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Replace swaps every document from source for docs
+func (s *Store) Replace(source string, docs []Document) {
+	s.Remove(source)
+	s.Documents = append(s.Documents, docs...)
+}
+
+// Remove deletes every document from source and returns how many were removed
+func (s *Store) Remove(source string) int {
+	kept := s.Documents[:0]
+	for _, doc := range s.Documents {
+		if doc.Source != source {
+			kept = append(kept, doc)
+		}
+	}
+	removed := len(s.Documents) - len(kept)
+	s.Documents = kept
+	delete(s.Versions, source)
+	return removed
+}
+
+// Sources returns the number of documents stored per source
+func (s *Store) Sources() map[string]int {
+	sources := make(map[string]int)
+	for _, doc := range s.Documents {
+		sources[doc.Source]++
+	}
+	return sources
+}
+
+// Search returns the k documents most similar to query
+func (s *Store) Search(query []float32, k int) []Result {
+	results := make([]Result, 0, len(s.Documents))
+	for _, doc := range s.Documents {
+		results = append(results, Result{Document: doc, Score: Cosine(query, doc.Vector)})
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if k > 0 && len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
@@ -0,0 +1,42 @@
+package embeddings
+
+import (
+	"context"
+)
+
+const (
+	defaultVoyageModel = "voyage-3-lite"
+	voyageURL          = "https://api.voyageai.com/v1/embeddings"
+)
+
+// VoyageEmbedder uses the Voyage AI embeddings API
+type VoyageEmbedder struct {
+	apiKey string
+	model  string
+}
+
+// NewVoyageEmbedder creates a new VoyageEmbedder; an empty model selects the default
+func NewVoyageEmbedder(apiKey, model string) *VoyageEmbedder {
+	if model == "" {
+		model = defaultVoyageModel
+	}
+	return &VoyageEmbedder{apiKey: apiKey, model: model}
+}
+
+// Name identifies the embedder and model
+func (e *VoyageEmbedder) Name() string {
+	return "voyage:" + e.model
+}
+
+// Embed requests embeddings for texts in one call
+func (e *VoyageEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	var resp indexedEmbeddings
+	err := postJSON(ctx, voyageURL, map[string]string{"Authorization": "Bearer " + e.apiKey}, map[string]interface{}{
+		"model": e.model,
+		"input": texts,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.vectors(len(texts))
+}
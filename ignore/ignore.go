@@ -0,0 +1,157 @@
+package ignore
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"anthropic-chat/approval"
+)
+
+// FileName is the deny-list read from the root of the working directory
+const FileName = ".goocodeignore"
+
+// rule is one line of a .goocodeignore file
+type rule struct {
+	pattern string // Slash-separated glob, relative to the root
+	negate  bool   // A "!" line, which lets a path through again
+	dirOnly bool   // A pattern ending in "/", which only matches directories
+}
+
+// Matcher decides which paths under a root the .goocodeignore file there denies. The file is re-read
+// whenever it changes, so edits take effect without a restart. It is safe for concurrent use.
+type Matcher struct {
+	root string
+	real string // root with symlinks resolved
+
+	mu      sync.Mutex
+	rules   []rule
+	size    int64
+	modTime time.Time
+}
+
+// New returns a matcher for the .goocodeignore file in root, which need not exist
+func New(root string) *Matcher {
+	real, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		real = root
+	}
+	return &Matcher{root: root, real: real}
+}
+
+// Root returns the directory the matcher's patterns are relative to
+func (m *Matcher) Root() string {
+	return m.root
+}
+
+// Ignored reports whether the file denies fullPath, either itself or through one of its parent directories.
+// The path is matched as given and with symlinks resolved, so a link can't reach a denied file.
+func (m *Matcher) Ignored(fullPath string, isDir bool) bool {
+	rules := m.load()
+	if len(rules) == 0 {
+		return false
+	}
+	if m.denies(rules, m.root, fullPath, isDir) {
+		return true
+	}
+	real := Resolve(fullPath)
+	return real != fullPath && m.denies(rules, m.real, real, isDir)
+}
+
+// denies matches fullPath, relative to root, and each of its parents against the rules
+func (m *Matcher) denies(rules []rule, root, fullPath string, isDir bool) bool {
+	rel, err := filepath.Rel(root, fullPath)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return false
+	}
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for i := range parts {
+		last := i == len(parts)-1
+		if match(rules, strings.Join(parts[:i+1], "/"), !last || isDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// Resolve returns fullPath with symlinks resolved. A path that doesn't exist yet is resolved through
+// its nearest existing parent.
+func Resolve(fullPath string) string {
+	rest := ""
+	for dir := fullPath; ; dir = filepath.Dir(dir) {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(real, rest)
+		}
+		if dir == filepath.Dir(dir) {
+			return fullPath
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
+// load returns the rules, re-reading the file if its size or modification time changed
+func (m *Matcher) load() []rule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	info, err := os.Stat(filepath.Join(m.root, FileName))
+	if err != nil {
+		m.rules, m.size, m.modTime = nil, 0, time.Time{}
+		return nil
+	}
+	if info.Size() == m.size && info.ModTime().Equal(m.modTime) {
+		return m.rules
+	}
+	data, err := os.ReadFile(filepath.Join(m.root, FileName))
+	if err != nil {
+		return m.rules
+	}
+	m.rules, m.size, m.modTime = parse(data), info.Size(), info.ModTime()
+	return m.rules
+}
+
+// parse reads gitignore-style lines: blank lines and # comments are skipped, ! negates, a trailing /
+// matches only directories, and a pattern without a / inside it matches at any depth
+func parse(data []byte) []rule {
+	var rules []rule
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var r rule
+		if r.negate = strings.HasPrefix(line, "!"); r.negate {
+			line = line[1:]
+		}
+		if r.dirOnly = strings.HasSuffix(line, "/"); r.dirOnly {
+			line = strings.TrimRight(line, "/")
+		}
+		if !strings.Contains(line, "/") {
+			line = "**/" + line
+		}
+		r.pattern = strings.TrimPrefix(path.Clean("/"+line), "/")
+		if r.pattern != "" {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// match applies the rules to one path in order; the last rule that matches decides
+func match(rules []rule, rel string, isDir bool) bool {
+	ignored := false
+	for _, r := range rules {
+		if r.dirOnly && !isDir {
+			continue
+		}
+		if approval.MatchPath(r.pattern, rel) {
+			ignored = !r.negate
+		}
+	}
+	return ignored
+}
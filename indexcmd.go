@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/codeindex"
+	"anthropic-chat/config"
+	"anthropic-chat/embeddings"
+	"anthropic-chat/ignore"
+	"anthropic-chat/project"
+	"anthropic-chat/redact"
+)
+
+// runIndexCommand implements `goocode index <update|search|status|clear>`
+func runIndexCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: goocode index <update|search|status|clear> [--project dir] [query]")
+	}
+
+	cfg := config.NewConfig()
+	action := args[0]
+	flags := flag.NewFlagSet("index "+action, flag.ContinueOnError)
+	cwd, _ := os.Getwd()
+	projectDir := flags.String("project", cwd, "Project directory to index")
+	limit := flags.Int("limit", 5, "For search: maximum number of chunks to show")
+	path := flags.String("path", "", "For search: only files under this relative path")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	// Projects with a .goocode directory keep their index there
+	if dir, ok := project.Find(*projectDir); ok {
+		cfg.Knowledge.Dir = dir.Path(project.IndexDir)
+	}
+
+	if action == "clear" {
+		if err := codeindex.Clear(cfg.Knowledge.Dir, *projectDir); err != nil {
+			return err
+		}
+		fmt.Println("Code index cleared")
+		return nil
+	}
+
+	embedder, err := embeddings.New(cfg.Embeddings)
+	if err != nil {
+		return err
+	}
+	index, err := codeindex.Open(cfg.Knowledge.Dir, *projectDir, embedder)
+	if err != nil {
+		return err
+	}
+	if root, err := filepath.Abs(*projectDir); err == nil {
+		index.Skip = ignore.New(root).Ignored
+	}
+	if cfg.Security.RedactSecrets {
+		redactor := redact.New()
+		index.Redact = func(text string) string {
+			redacted, _ := redactor.Redact(text)
+			return redacted
+		}
+	}
+
+	switch action {
+	case "update":
+		result, err := index.Update(context.Background(), func(source string, chunks int) {
+			fmt.Printf("Indexed %s (%d chunks)\n", source, chunks)
+		})
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d file(s) indexed, %d unchanged, %d removed, %d chunk(s) embedded\n", result.Indexed, result.Unchanged, result.Removed, result.Chunks)
+		if result.Skipped > 0 {
+			fmt.Printf("%d file(s) left out: the project has more files than the index holds\n", result.Skipped)
+		}
+		return nil
+	case "search":
+		if _, err := index.Update(context.Background(), nil); err != nil {
+			return err
+		}
+		results, err := index.Search(context.Background(), strings.Join(flags.Args(), " "), *path, *limit)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			fmt.Printf("%.2f  %s  %s\n", result.Score, result.Source, result.Title)
+		}
+		return nil
+	case "status":
+		files, chunks := index.Stats()
+		fmt.Printf("%d file(s), %d chunk(s), embedded with %s\n", files, chunks, embedder.Name())
+		return nil
+	default:
+		return fmt.Errorf("unknown index action %q", action)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"anthropic-chat/project"
+)
+
+// runInitCommand implements `goocode init [dir]`, scaffolding the .goocode project directory
+func runInitCommand(args []string) error {
+	flags := flag.NewFlagSet("init", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	dir := flags.Arg(0)
+	if dir == "" {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return fmt.Errorf("failed to get current directory: %w", err)
+		}
+		dir = cwd
+	}
+
+	p := project.At(dir)
+	created, err := p.Init()
+	for _, path := range created {
+		if rel, relErr := filepath.Rel(p.Root, path); relErr == nil {
+			path = rel
+		}
+		fmt.Println("  created", path)
+	}
+	if err != nil {
+		return err
+	}
+	if len(created) == 0 {
+		fmt.Printf("%s is already initialized\n", p.Path())
+		return nil
+	}
+	fmt.Printf("Initialized %s. Commit it; its .gitignore already excludes the transient parts.\n", p.Path())
+	return nil
+}
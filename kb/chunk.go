@@ -0,0 +1,107 @@
+package kb
+
+import (
+	"strings"
+)
+
+// Chunk is a section of a document small enough to embed and return as context
+type Chunk struct {
+	Title string // Heading path, e.g. "Architecture > Storage"
+	Text  string
+}
+
+// ChunkDocument splits text into chunks of at most maxChars, breaking at Markdown headings and then paragraphs
+func ChunkDocument(text string, maxChars int) []Chunk {
+	var chunks []Chunk
+	var headings []string
+	var section []string
+	inFence := false
+
+	flush := func() {
+		body := strings.TrimSpace(strings.Join(section, "\n"))
+		section = nil
+		if body == "" {
+			return
+		}
+		var path []string
+		for _, heading := range headings {
+			if heading != "" {
+				path = append(path, heading)
+			}
+		}
+		title := strings.Join(path, " > ")
+		for _, part := range splitParagraphs(body, maxChars) {
+			chunks = append(chunks, Chunk{Title: title, Text: part})
+		}
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+		}
+		if level, heading := headingLevel(trimmed); !inFence && level > 0 {
+			flush()
+			if level <= len(headings) {
+				headings = headings[:level-1]
+			}
+			for len(headings) < level-1 {
+				headings = append(headings, "")
+			}
+			headings = append(headings, heading)
+			section = append(section, line)
+			continue
+		}
+		section = append(section, line)
+	}
+	flush()
+	return chunks
+}
+
+// headingLevel returns the level and text of an ATX Markdown heading, or 0 if line is not one
+func headingLevel(line string) (int, string) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || level >= len(line) || line[level] != ' ' {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(strings.Trim(line[level:], "# "))
+}
+
+// splitParagraphs packs paragraphs into pieces of at most maxChars, hard-splitting oversized paragraphs
+func splitParagraphs(text string, maxChars int) []string {
+	if len(text) <= maxChars {
+		return []string{text}
+	}
+
+	var parts []string
+	var current strings.Builder
+	for _, paragraph := range strings.Split(text, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		if current.Len() > 0 && current.Len()+len(paragraph)+2 > maxChars {
+			parts = append(parts, current.String())
+			current.Reset()
+		}
+		for len(paragraph) > maxChars {
+			cut := strings.LastIndexAny(paragraph[:maxChars], " \n")
+			if cut <= 0 {
+				cut = maxChars
+			}
+			parts = append(parts, strings.TrimSpace(paragraph[:cut]))
+			paragraph = strings.TrimSpace(paragraph[cut:])
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n\n")
+		}
+		current.WriteString(paragraph)
+	}
+	if current.Len() > 0 {
+		parts = append(parts, current.String())
+	}
+	return parts
+}
@@ -0,0 +1,214 @@
+package kb
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/embeddings"
+)
+
+const (
+	maxChunkChars = 1500    // Chunks are kept small so search results fit comfortably in context
+	maxFileSize   = 1 << 20 // Larger files are unlikely to be hand-written documentation
+	embedBatch    = 32      // Chunks embedded per Embed call
+)
+
+// docExtensions are the file types ingested when adding a directory
+var docExtensions = map[string]bool{
+	".md": true, ".markdown": true, ".mdx": true, ".txt": true, ".rst": true, ".adoc": true,
+}
+
+// skipDirs are never searched for documentation
+var skipDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, ".venv": true, "__pycache__": true,
+}
+
+// KnowledgeBase indexes a project's own documentation for retrieval
+type KnowledgeBase struct {
+	store      *embeddings.Store
+	embedder   embeddings.Embedder
+	workingDir string
+}
+
+// AddResult summarizes an ingestion run
+type AddResult struct {
+	Files  int
+	Chunks int
+}
+
+// Open loads the knowledge base for workingDir from baseDir, namespaced per project
+func Open(baseDir, workingDir string, embedder embeddings.Embedder) (*KnowledgeBase, error) {
+	abs, path := storePath(baseDir, workingDir)
+	store, err := embeddings.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if store.Embedder != "" && store.Embedder != embedder.Name() {
+		return nil, fmt.Errorf("knowledge base was built with the %s embedder, not %s; run `goocode kb clear` and add the docs again", store.Embedder, embedder.Name())
+	}
+	store.Embedder = embedder.Name()
+	return &KnowledgeBase{store: store, embedder: embedder, workingDir: abs}, nil
+}
+
+// Clear deletes the knowledge base for workingDir
+func Clear(baseDir, workingDir string) error {
+	_, path := storePath(baseDir, workingDir)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to clear knowledge base: %w", err)
+	}
+	return nil
+}
+
+// storePath returns the absolute project directory and its store file
+func storePath(baseDir, workingDir string) (string, string) {
+	abs, err := filepath.Abs(workingDir)
+	if err != nil {
+		abs = workingDir
+	}
+	sum := sha256.Sum256([]byte(abs))
+	return abs, filepath.Join(baseDir, hex.EncodeToString(sum[:])[:12]+".json")
+}
+
+// Add chunks and indexes every document under path, replacing earlier versions of the same files
+func (k *KnowledgeBase) Add(ctx context.Context, path string, progress func(source string, chunks int)) (AddResult, error) {
+	var result AddResult
+	root := path
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(k.workingDir, root)
+	}
+	info, err := os.Stat(root)
+	if err != nil {
+		return result, fmt.Errorf("failed to access %s: %w", path, err)
+	}
+
+	var files []string
+	if !info.IsDir() {
+		files = append(files, root)
+	} else {
+		err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if skipDirs[d.Name()] {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if docExtensions[strings.ToLower(filepath.Ext(p))] {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return result, fmt.Errorf("failed to walk %s: %w", path, err)
+		}
+	}
+
+	for _, file := range files {
+		chunks, err := k.addFile(ctx, file)
+		if err != nil {
+			return result, err
+		}
+		if chunks == 0 {
+			continue
+		}
+		result.Files++
+		result.Chunks += chunks
+		if progress != nil {
+			progress(k.source(file), chunks)
+		}
+	}
+
+	if err := k.store.Save(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// addFile embeds one file's chunks and returns how many were stored
+func (k *KnowledgeBase) addFile(ctx context.Context, file string) (int, error) {
+	info, err := os.Stat(file)
+	if err != nil || info.Size() > maxFileSize {
+		return 0, nil
+	}
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	source := k.source(file)
+	chunks := ChunkDocument(string(content), maxChunkChars)
+	docs := make([]embeddings.Document, 0, len(chunks))
+	for start := 0; start < len(chunks); start += embedBatch {
+		batch := chunks[start:min(start+embedBatch, len(chunks))]
+		texts := make([]string, len(batch))
+		for i, chunk := range batch {
+			// The heading path is embedded with the text so section names contribute to matches
+			texts[i] = chunk.Title + "\n" + chunk.Text
+		}
+		vectors, err := k.embedder.Embed(ctx, texts)
+		if err != nil {
+			return 0, fmt.Errorf("failed to embed %s: %w", source, err)
+		}
+		for i, chunk := range batch {
+			docs = append(docs, embeddings.Document{
+				ID:     fmt.Sprintf("%s#%d", source, start+i),
+				Source: source,
+				Title:  chunk.Title,
+				Text:   chunk.Text,
+				Vector: vectors[i],
+			})
+		}
+	}
+
+	k.store.Replace(source, docs)
+	return len(docs), nil
+}
+
+// Remove drops every chunk from sources under path and returns how many were removed
+func (k *KnowledgeBase) Remove(path string) (int, error) {
+	prefix := k.source(path)
+	removed := 0
+	for source := range k.store.Sources() {
+		if source == prefix || strings.HasPrefix(source, prefix+"/") || prefix == "." {
+			removed += k.store.Remove(source)
+		}
+	}
+	return removed, k.store.Save()
+}
+
+// Search returns the chunks most relevant to query
+func (k *KnowledgeBase) Search(ctx context.Context, query string, limit int) ([]embeddings.Result, error) {
+	if len(k.store.Documents) == 0 {
+		return nil, nil
+	}
+	vectors, err := k.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	return k.store.Search(vectors[0], limit), nil
+}
+
+// Sources returns the number of chunks indexed per source file
+func (k *KnowledgeBase) Sources() map[string]int {
+	return k.store.Sources()
+}
+
+// source returns a file's path relative to the project, using forward slashes
+func (k *KnowledgeBase) source(file string) string {
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(k.workingDir, file)
+	}
+	rel, err := filepath.Rel(k.workingDir, file)
+	if err != nil {
+		return filepath.ToSlash(file)
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"anthropic-chat/config"
+	"anthropic-chat/embeddings"
+	"anthropic-chat/kb"
+	"anthropic-chat/project"
+)
+
+// runKBCommand implements `goocode kb <add|search|list|remove|clear>`
+func runKBCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: goocode kb <add|search|list|remove|clear> [--project dir] [path...|query]")
+	}
+
+	cfg := config.NewConfig()
+	action := args[0]
+	flags := flag.NewFlagSet("kb "+action, flag.ContinueOnError)
+	cwd, _ := os.Getwd()
+	projectDir := flags.String("project", cwd, "Project directory the knowledge base belongs to")
+	limit := flags.Int("limit", 5, "For search: maximum number of passages to show")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	// Projects with a .goocode directory keep their index there
+	if dir, ok := project.Find(*projectDir); ok {
+		cfg.Knowledge.Dir = dir.Path(project.IndexDir)
+	}
+
+	if action == "clear" {
+		if err := kb.Clear(cfg.Knowledge.Dir, *projectDir); err != nil {
+			return err
+		}
+		fmt.Println("Knowledge base cleared")
+		return nil
+	}
+
+	embedder, err := embeddings.New(cfg.Embeddings)
+	if err != nil {
+		return err
+	}
+	base, err := kb.Open(cfg.Knowledge.Dir, *projectDir, embedder)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "add":
+		if flags.NArg() == 0 {
+			return fmt.Errorf("usage: goocode kb add <path...>")
+		}
+		for _, path := range flags.Args() {
+			result, err := base.Add(context.Background(), path, func(source string, chunks int) {
+				fmt.Printf("Indexed %s (%d chunks)\n", source, chunks)
+			})
+			if err != nil {
+				return err
+			}
+			fmt.Printf("%s: %d file(s), %d chunk(s)\n", path, result.Files, result.Chunks)
+		}
+		return nil
+	case "search":
+		results, err := base.Search(context.Background(), strings.Join(flags.Args(), " "), *limit)
+		if err != nil {
+			return err
+		}
+		for _, result := range results {
+			fmt.Printf("%.2f  %s  %s\n", result.Score, result.Source, result.Title)
+		}
+		return nil
+	case "list":
+		sources := base.Sources()
+		names := make([]string, 0, len(sources))
+		for name := range sources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Printf("%4d chunks  %s\n", sources[name], name)
+		}
+		return nil
+	case "remove":
+		for _, path := range flags.Args() {
+			removed, err := base.Remove(path)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Removed %d chunk(s) from %s\n", removed, path)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown kb action %q", action)
+	}
+}
@@ -0,0 +1,433 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Client is a minimal JSON-RPC client for a single language server process
+type Client struct {
+	server  ServerConfig
+	rootDir string
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+
+	writeMu sync.Mutex
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]chan rpcMessage
+	opened  map[string]int
+	done    chan struct{}
+	readErr error
+}
+
+// Start spawns the language server for rootDir and performs the initialize handshake
+func Start(ctx context.Context, server ServerConfig, rootDir string) (*Client, error) {
+	if _, err := exec.LookPath(server.Command); err != nil {
+		return nil, fmt.Errorf("language server %s not found in PATH: %w", server.Command, err)
+	}
+
+	cmd := exec.Command(server.Command, server.Args...)
+	cmd.Dir = rootDir
+	isolate(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open language server stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open language server stdout: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start %s: %w", server.Command, err)
+	}
+
+	c := &Client{
+		server:  server,
+		rootDir: rootDir,
+		cmd:     cmd,
+		stdin:   stdin,
+		pending: make(map[int64]chan rpcMessage),
+		opened:  make(map[string]int),
+		done:    make(chan struct{}),
+	}
+	go c.readLoop(bufio.NewReader(stdout))
+
+	if err := c.initialize(ctx); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Client) initialize(ctx context.Context) error {
+	rootURI := pathToURI(c.rootDir)
+	params := map[string]interface{}{
+		"processId": os.Getpid(),
+		"rootUri":   rootURI,
+		"workspaceFolders": []map[string]string{
+			{"uri": rootURI, "name": filepath.Base(c.rootDir)},
+		},
+		"capabilities": map[string]interface{}{
+			"textDocument": map[string]interface{}{
+				"definition": map[string]interface{}{"linkSupport": true},
+				"references": map[string]interface{}{},
+				"rename":     map[string]interface{}{},
+				"documentSymbol": map[string]interface{}{
+					"hierarchicalDocumentSymbolSupport": true,
+				},
+			},
+		},
+	}
+	if err := c.Call(ctx, "initialize", params, nil); err != nil {
+		return fmt.Errorf("language server initialize failed: %w", err)
+	}
+	return c.Notify("initialized", map[string]interface{}{})
+}
+
+// Call sends a request and decodes the result into result (which may be nil)
+func (c *Client) Call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	c.mu.Lock()
+	c.nextID++
+	id := c.nextID
+	ch := make(chan rpcMessage, 1)
+	c.pending[id] = ch
+	c.mu.Unlock()
+
+	defer func() {
+		c.mu.Lock()
+		delete(c.pending, id)
+		c.mu.Unlock()
+	}()
+
+	if err := c.write(rpcMessage{JSONRPC: "2.0", ID: &id, Method: method, Params: mustMarshal(params)}); err != nil {
+		return err
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return fmt.Errorf("language server exited: %v", c.readErr)
+	case msg := <-ch:
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result != nil && len(msg.Result) > 0 {
+			return json.Unmarshal(msg.Result, result)
+		}
+		return nil
+	}
+}
+
+// Notify sends a notification that expects no response
+func (c *Client) Notify(method string, params interface{}) error {
+	return c.write(rpcMessage{JSONRPC: "2.0", Method: method, Params: mustMarshal(params)})
+}
+
+// OpenDocument makes the server aware of the current contents of path
+func (c *Client) OpenDocument(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	c.mu.Lock()
+	version, isOpen := c.opened[path]
+	c.opened[path] = version + 1
+	c.mu.Unlock()
+
+	uri := pathToURI(path)
+	if isOpen {
+		return c.Notify("textDocument/didChange", map[string]interface{}{
+			"textDocument":   map[string]interface{}{"uri": uri, "version": version + 1},
+			"contentChanges": []map[string]string{{"text": string(content)}},
+		})
+	}
+	return c.Notify("textDocument/didOpen", map[string]interface{}{
+		"textDocument": textDocumentItem{
+			URI:        uri,
+			LanguageID: c.server.LanguageIDFor(path),
+			Version:    1,
+			Text:       string(content),
+		},
+	})
+}
+
+// syncOpened sends the current contents of every open document, closing those deleted since
+func (c *Client) syncOpened() error {
+	c.mu.Lock()
+	paths := make([]string, 0, len(c.opened))
+	for path := range c.opened {
+		paths = append(paths, path)
+	}
+	c.mu.Unlock()
+
+	for _, path := range paths {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			c.mu.Lock()
+			delete(c.opened, path)
+			c.mu.Unlock()
+			if err := c.Notify("textDocument/didClose", map[string]interface{}{"textDocument": textDocumentIdentifier{URI: pathToURI(path)}}); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := c.OpenDocument(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Definition returns the definition locations of the symbol at pos in path
+func (c *Client) Definition(ctx context.Context, path string, pos Position) ([]Location, error) {
+	if err := c.OpenDocument(path); err != nil {
+		return nil, err
+	}
+	var raw json.RawMessage
+	params := textDocumentPositionParams{TextDocument: textDocumentIdentifier{URI: pathToURI(path)}, Position: pos}
+	if err := c.Call(ctx, "textDocument/definition", params, &raw); err != nil {
+		return nil, err
+	}
+	return decodeLocations(raw)
+}
+
+// References returns all references to the symbol at pos in path
+func (c *Client) References(ctx context.Context, path string, pos Position, includeDeclaration bool) ([]Location, error) {
+	if err := c.OpenDocument(path); err != nil {
+		return nil, err
+	}
+	params := referenceParams{}
+	params.TextDocument = textDocumentIdentifier{URI: pathToURI(path)}
+	params.Position = pos
+	params.Context.IncludeDeclaration = includeDeclaration
+
+	var locations []Location
+	if err := c.Call(ctx, "textDocument/references", params, &locations); err != nil {
+		return nil, err
+	}
+	return locations, nil
+}
+
+// Rename returns the edits, by file path, that rename the symbol at pos in path to newName. Every
+// document opened earlier is synced first, so the edits' ranges refer to the files as they are on disk.
+func (c *Client) Rename(ctx context.Context, path string, pos Position, newName string) (map[string][]TextEdit, error) {
+	if err := c.syncOpened(); err != nil {
+		return nil, err
+	}
+	if err := c.OpenDocument(path); err != nil {
+		return nil, err
+	}
+	params := renameParams{NewName: newName}
+	params.TextDocument = textDocumentIdentifier{URI: pathToURI(path)}
+	params.Position = pos
+
+	var result *workspaceEdit
+	if err := c.Call(ctx, "textDocument/rename", params, &result); err != nil {
+		return nil, err
+	}
+	edits := make(map[string][]TextEdit)
+	if result == nil {
+		return edits, nil
+	}
+	for uri, changes := range result.Changes {
+		edits[URIToPath(uri)] = append(edits[URIToPath(uri)], changes...)
+	}
+	for _, change := range result.DocumentChanges {
+		path := URIToPath(change.TextDocument.URI)
+		edits[path] = append(edits[path], change.Edits...)
+	}
+	return edits, nil
+}
+
+// DocumentSymbols returns the symbols declared in path
+func (c *Client) DocumentSymbols(ctx context.Context, path string) ([]DocumentSymbol, error) {
+	if err := c.OpenDocument(path); err != nil {
+		return nil, err
+	}
+	var raw []json.RawMessage
+	params := map[string]interface{}{"textDocument": textDocumentIdentifier{URI: pathToURI(path)}}
+	if err := c.Call(ctx, "textDocument/documentSymbol", params, &raw); err != nil {
+		return nil, err
+	}
+
+	symbols := make([]DocumentSymbol, 0, len(raw))
+	for _, item := range raw {
+		var info symbolInformation
+		if err := json.Unmarshal(item, &info); err == nil && info.Location.URI != "" {
+			symbols = append(symbols, DocumentSymbol{
+				Name:           info.Name,
+				Detail:         info.ContainerName,
+				Kind:           info.Kind,
+				Range:          info.Location.Range,
+				SelectionRange: info.Location.Range,
+			})
+			continue
+		}
+		var symbol DocumentSymbol
+		if err := json.Unmarshal(item, &symbol); err != nil {
+			return nil, fmt.Errorf("failed to decode document symbol: %w", err)
+		}
+		symbols = append(symbols, symbol)
+	}
+	return symbols, nil
+}
+
+// Close shuts the language server down and releases its resources
+func (c *Client) Close() error {
+	select {
+	case <-c.done:
+	default:
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		_ = c.Call(ctx, "shutdown", nil, nil)
+		cancel()
+		_ = c.Notify("exit", nil)
+	}
+	_ = c.stdin.Close()
+	kill(c.cmd)
+	return c.cmd.Wait()
+}
+
+func (c *Client) write(msg rpcMessage) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode message: %w", err)
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if _, err := fmt.Fprintf(c.stdin, "Content-Length: %d\r\n\r\n", len(body)); err != nil {
+		return fmt.Errorf("failed to write to language server: %w", err)
+	}
+	if _, err := c.stdin.Write(body); err != nil {
+		return fmt.Errorf("failed to write to language server: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) readLoop(reader *bufio.Reader) {
+	defer close(c.done)
+	for {
+		msg, err := readMessage(reader)
+		if err != nil {
+			c.readErr = err
+			return
+		}
+
+		switch {
+		case msg.ID != nil && msg.Method != "":
+			// Server-initiated request (configuration, progress); answer with null
+			_ = c.write(rpcMessage{JSONRPC: "2.0", ID: msg.ID, Result: json.RawMessage("null")})
+		case msg.ID != nil:
+			c.mu.Lock()
+			ch, ok := c.pending[*msg.ID]
+			c.mu.Unlock()
+			if ok {
+				ch <- msg
+			}
+		}
+	}
+}
+
+func readMessage(reader *bufio.Reader) (rpcMessage, error) {
+	length := -1
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return rpcMessage{}, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			break
+		}
+		if name, value, ok := strings.Cut(line, ":"); ok && strings.EqualFold(name, "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil {
+				return rpcMessage{}, fmt.Errorf("invalid Content-Length header: %w", err)
+			}
+		}
+	}
+	if length < 0 {
+		return rpcMessage{}, fmt.Errorf("missing Content-Length header")
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(reader, body); err != nil {
+		return rpcMessage{}, err
+	}
+
+	var msg rpcMessage
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return rpcMessage{}, fmt.Errorf("failed to decode message: %w", err)
+	}
+	return msg, nil
+}
+
+func decodeLocations(raw json.RawMessage) ([]Location, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+
+	var single Location
+	if err := json.Unmarshal(raw, &single); err == nil && single.URI != "" {
+		return []Location{single}, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, fmt.Errorf("failed to decode locations: %w", err)
+	}
+	locations := make([]Location, 0, len(items))
+	for _, item := range items {
+		var link locationLink
+		if err := json.Unmarshal(item, &link); err == nil && link.TargetURI != "" {
+			locations = append(locations, Location{URI: link.TargetURI, Range: link.TargetSelectionRange})
+			continue
+		}
+		var location Location
+		if err := json.Unmarshal(item, &location); err != nil {
+			return nil, fmt.Errorf("failed to decode location: %w", err)
+		}
+		locations = append(locations, location)
+	}
+	return locations, nil
+}
+
+func mustMarshal(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+func pathToURI(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+}
+
+// URIToPath converts a file:// URI back into a local filesystem path
+func URIToPath(uri string) string {
+	parsed, err := url.Parse(uri)
+	if err != nil || parsed.Scheme != "file" {
+		return uri
+	}
+	return filepath.FromSlash(parsed.Path)
+}
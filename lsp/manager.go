@@ -0,0 +1,118 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const shutdownTimeout = 2 * time.Second
+
+// ServerConfig describes how to launch a language server and which files it handles
+type ServerConfig struct {
+	Language   string
+	Command    string
+	Args       []string
+	Extensions map[string]string // file extension -> LSP languageId
+}
+
+// LanguageIDFor returns the languageId to report for path
+func (s ServerConfig) LanguageIDFor(path string) string {
+	if id, ok := s.Extensions[strings.ToLower(filepath.Ext(path))]; ok {
+		return id
+	}
+	return s.Language
+}
+
+// DefaultServers are the language servers GooCode knows how to launch
+var DefaultServers = []ServerConfig{
+	{
+		Language:   "go",
+		Command:    "gopls",
+		Args:       []string{"serve"},
+		Extensions: map[string]string{".go": "go"},
+	},
+	{
+		Language:   "python",
+		Command:    "pyright-langserver",
+		Args:       []string{"--stdio"},
+		Extensions: map[string]string{".py": "python", ".pyi": "python"},
+	},
+	{
+		Language: "typescript",
+		Command:  "typescript-language-server",
+		Args:     []string{"--stdio"},
+		Extensions: map[string]string{
+			".ts": "typescript", ".tsx": "typescriptreact",
+			".js": "javascript", ".jsx": "javascriptreact",
+			".mjs": "javascript", ".cjs": "javascript",
+		},
+	},
+}
+
+// Manager lazily starts and caches one language server per language and root
+type Manager struct {
+	servers []ServerConfig
+	clients map[string]*Client
+	mu      sync.Mutex
+}
+
+// NewManager creates a new language server manager using DefaultServers
+func NewManager() *Manager {
+	return &Manager{
+		servers: DefaultServers,
+		clients: make(map[string]*Client),
+	}
+}
+
+// ServerFor returns the server configuration that handles path
+func (m *Manager) ServerFor(path string) (ServerConfig, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, server := range m.servers {
+		if _, ok := server.Extensions[ext]; ok {
+			return server, nil
+		}
+	}
+	return ServerConfig{}, fmt.Errorf("no language server configured for %s files", ext)
+}
+
+// ClientFor returns a running client able to answer queries about path within rootDir
+func (m *Manager) ClientFor(ctx context.Context, rootDir string, path string) (*Client, error) {
+	server, err := m.ServerFor(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := server.Language + "\x00" + rootDir
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if client, ok := m.clients[key]; ok {
+		select {
+		case <-client.done:
+			delete(m.clients, key)
+		default:
+			return client, nil
+		}
+	}
+
+	client, err := Start(ctx, server, rootDir)
+	if err != nil {
+		return nil, err
+	}
+	m.clients[key] = client
+	return client, nil
+}
+
+// Close shuts down every running language server
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, client := range m.clients {
+		_ = client.Close()
+		delete(m.clients, key)
+	}
+}
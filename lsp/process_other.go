@@ -0,0 +1,24 @@
+//go:build !windows
+
+package lsp
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// isolate puts the language server in its own process group, so Ctrl-C at the prompt doesn't reach it
+// and whatever it started dies with it
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// kill stops the language server and everything in its process group
+func kill(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		_ = cmd.Process.Kill()
+	}
+}
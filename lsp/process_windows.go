@@ -0,0 +1,15 @@
+//go:build windows
+
+package lsp
+
+import "os/exec"
+
+// isolate is a no-op on Windows
+func isolate(cmd *exec.Cmd) {}
+
+// kill stops the language server; processes it started may outlive it
+func kill(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		_ = cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,121 @@
+package lsp
+
+import "encoding/json"
+
+// Position is a zero-based line/character offset in a text document
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a span between two positions in a text document
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location points at a range inside a document identified by URI
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// locationLink is the alternative definition result shape some servers return
+type locationLink struct {
+	TargetURI            string `json:"targetUri"`
+	TargetSelectionRange Range  `json:"targetSelectionRange"`
+}
+
+// DocumentSymbol is a hierarchical symbol returned by textDocument/documentSymbol
+type DocumentSymbol struct {
+	Name           string           `json:"name"`
+	Detail         string           `json:"detail,omitempty"`
+	Kind           int              `json:"kind"`
+	Range          Range            `json:"range"`
+	SelectionRange Range            `json:"selectionRange"`
+	Children       []DocumentSymbol `json:"children,omitempty"`
+}
+
+// symbolInformation is the flat symbol shape used by older servers
+type symbolInformation struct {
+	Name          string   `json:"name"`
+	Kind          int      `json:"kind"`
+	Location      Location `json:"location"`
+	ContainerName string   `json:"containerName,omitempty"`
+}
+
+type textDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+type textDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+type textDocumentPositionParams struct {
+	TextDocument textDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+type referenceParams struct {
+	textDocumentPositionParams
+	Context struct {
+		IncludeDeclaration bool `json:"includeDeclaration"`
+	} `json:"context"`
+}
+
+type renameParams struct {
+	textDocumentPositionParams
+	NewName string `json:"newName"`
+}
+
+// TextEdit replaces a range of a document with new text
+type TextEdit struct {
+	Range   Range  `json:"range"`
+	NewText string `json:"newText"`
+}
+
+// workspaceEdit is the result of textDocument/rename, in either of its two shapes
+type workspaceEdit struct {
+	Changes         map[string][]TextEdit `json:"changes,omitempty"`
+	DocumentChanges []struct {
+		TextDocument textDocumentIdentifier `json:"textDocument"`
+		Edits        []TextEdit             `json:"edits"`
+	} `json:"documentChanges,omitempty"`
+}
+
+// rpcMessage covers requests, responses and notifications on the wire
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      *int64          `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// SymbolKindName returns the readable name for an LSP SymbolKind value
+func SymbolKindName(kind int) string {
+	names := []string{
+		"", "file", "module", "namespace", "package", "class", "method", "property",
+		"field", "constructor", "enum", "interface", "function", "variable", "constant",
+		"string", "number", "boolean", "array", "object", "key", "null", "enum_member",
+		"struct", "event", "operator", "type_parameter",
+	}
+	if kind > 0 && kind < len(names) {
+		return names[kind]
+	}
+	return "unknown"
+}
@@ -0,0 +1,156 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+
+	"anthropic-chat/config"
+	"anthropic-chat/tools"
+)
+
+// ProtocolVersion is the MCP revision the server implements
+const ProtocolVersion = "2024-11-05"
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// Server exposes a tool registry over the Model Context Protocol's stdio transport: one JSON-RPC
+// message per line in each direction
+type Server struct {
+	name     string
+	version  string
+	registry *tools.Registry
+	context  tools.ToolContext
+}
+
+// NewServer serves the tools in registry, running them in toolContext
+func NewServer(name, version string, registry *tools.Registry, toolContext tools.ToolContext) *Server {
+	return &Server{name: name, version: version, registry: registry, context: toolContext}
+}
+
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type toolInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	InputSchema any    `json:"inputSchema"`
+}
+
+type textContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type callResult struct {
+	Content []textContent `json:"content"`
+	IsError bool          `json:"isError,omitempty"`
+}
+
+// Serve answers requests read from in until it is closed or ctx is done. Tool calls run one at a time,
+// in the order they arrive.
+func (s *Server) Serve(ctx context.Context, in io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), config.MCPMaxMessageBytes)
+	encoder := json.NewEncoder(out)
+	for scanner.Scan() {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var msg message
+		if err := json.Unmarshal(line, &msg); err != nil {
+			if err := encoder.Encode(errorResponse(json.RawMessage("null"), codeParseError, "parse error: "+err.Error())); err != nil {
+				return err
+			}
+			continue
+		}
+		if msg.ID == nil {
+			continue // Notifications, such as notifications/initialized, need no answer
+		}
+		if err := encoder.Encode(s.handle(ctx, msg)); err != nil {
+			return fmt.Errorf("failed to write response: %w", err)
+		}
+	}
+	return scanner.Err()
+}
+
+// handle answers one request
+func (s *Server) handle(ctx context.Context, msg message) response {
+	switch msg.Method {
+	case "initialize":
+		// Clients that need another revision close the connection when they see this one
+		return result(msg.ID, map[string]any{
+			"protocolVersion": ProtocolVersion,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": s.name, "version": s.version},
+		})
+	case "ping":
+		return result(msg.ID, map[string]any{})
+	case "tools/list":
+		var list []toolInfo
+		for _, tool := range s.registry.All() {
+			list = append(list, toolInfo{Name: tool.Name, Description: tool.Description, InputSchema: tool.InputSchema})
+		}
+		return result(msg.ID, map[string]any{"tools": list})
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(msg.Params, &params); err != nil || params.Name == "" {
+			return errorResponse(msg.ID, codeInvalidParams, "tools/call needs a tool name and arguments")
+		}
+		if _, ok := s.registry.Get(params.Name); !ok {
+			return errorResponse(msg.ID, codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name))
+		}
+		if len(params.Arguments) == 0 || string(params.Arguments) == "null" {
+			params.Arguments = json.RawMessage("{}")
+		}
+		output, err := s.registry.Execute(ctx, s.context, params.Name, params.Arguments)
+		if err != nil {
+			log.Printf("%s failed: %v", params.Name, err)
+			return result(msg.ID, callResult{Content: []textContent{{Type: "text", Text: tools.FormatError(err)}}, IsError: true})
+		}
+		return result(msg.ID, callResult{Content: []textContent{{Type: "text", Text: output}}})
+	case "":
+		return errorResponse(msg.ID, codeInvalidRequest, "request has no method")
+	}
+	return errorResponse(msg.ID, codeMethodNotFound, fmt.Sprintf("method %q is not supported", msg.Method))
+}
+
+func result(id json.RawMessage, value any) response {
+	return response{JSONRPC: "2.0", ID: id, Result: value}
+}
+
+func errorResponse(id json.RawMessage, code int, text string) response {
+	return response{JSONRPC: "2.0", ID: id, Error: &rpcError{Code: code, Message: text}}
+}
@@ -0,0 +1,107 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/ignore"
+	"anthropic-chat/project"
+	"anthropic-chat/tools"
+)
+
+// Workspace is the tool context of a server: every path stays inside its root, .goocodeignore is enforced,
+// and gated actions are decided by the approval policy alone, since nobody can be asked over stdio
+type Workspace struct {
+	root    string
+	ignores *ignore.Matcher
+	policy  *approval.Policy // nil without a policy: gated actions run, destructive ones are refused
+}
+
+// NewWorkspace confines tools to root, deciding gated actions with policy
+func NewWorkspace(root string, policy *approval.Policy) (*Workspace, error) {
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+	if info, err := os.Stat(abs); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", root)
+	}
+	if _, err := filepath.EvalSymlinks(abs); err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", root, err)
+	}
+	return &Workspace{root: abs, ignores: ignore.New(abs), policy: policy}, nil
+}
+
+// WorkingDir returns the directory tools are confined to
+func (w *Workspace) WorkingDir() string {
+	return w.root
+}
+
+// ResolveFilePath resolves relativePath inside the workspace, refusing paths that leave it or that
+// .goocodeignore denies
+func (w *Workspace) ResolveFilePath(relativePath string) (string, error) {
+	cleanPath := filepath.Clean(relativePath)
+	if filepath.IsAbs(cleanPath) || cleanPath == ".." || strings.HasPrefix(cleanPath, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %s is outside the served directory: %w", relativePath, tools.ErrPathNotAllowed)
+	}
+	fullPath := filepath.Join(w.root, cleanPath)
+
+	if !tools.Within(fullPath, w.root) {
+		return "", fmt.Errorf("path %s links outside the served directory: %w", relativePath, tools.ErrPathNotAllowed)
+	}
+
+	info, statErr := os.Stat(fullPath)
+	if w.Ignored(fullPath, statErr == nil && info.IsDir()) {
+		return "", fmt.Errorf("%s: %w", relativePath, tools.ErrIgnored)
+	}
+	return fullPath, nil
+}
+
+// Ignored implements the tools.IgnoreChecker interface with the workspace's .goocodeignore
+func (w *Workspace) Ignored(fullPath string, isDir bool) bool {
+	return w.ignores.Ignored(fullPath, isDir)
+}
+
+// Approve implements the tools.Approver interface: the policy decides, and whatever it would ask about
+// is refused, as in a headless run without an approver
+func (w *Workspace) Approve(req approval.Request) error {
+	for _, path := range req.Paths {
+		if project.Protected(w.root, filepath.Join(w.root, filepath.FromSlash(strings.TrimSuffix(path, "/")))) {
+			return fmt.Errorf("%w: %s would change %s, which decides what tools may do", approval.ErrDenied, req.Summary, path)
+		}
+	}
+	action, rule := approval.Allow, 0
+	if w.policy != nil {
+		action, rule = w.policy.Evaluate(req)
+	}
+	if rule == 0 && action == approval.Allow && req.Dangerous {
+		action = approval.Ask
+	}
+	switch action {
+	case approval.Allow:
+		return nil
+	case approval.Deny:
+		return fmt.Errorf("%w: %s was denied by the approval policy", approval.ErrDenied, req.Summary)
+	}
+	return fmt.Errorf("%w: %s needs approval, which the MCP server can't ask for; add an allow rule to the approval policy", approval.ErrDenied, req.Summary)
+}
+
+// CheckToolCall implements the tools.Gate interface with the policy's per-tool rules
+func (w *Workspace) CheckToolCall(toolName string, input json.RawMessage) error {
+	if w.policy == nil {
+		return nil
+	}
+	req := approval.CallRequest(toolName, input)
+	action, rule := w.policy.EvaluateCall(req)
+	switch {
+	case rule == 0 || action == approval.Allow:
+		return nil
+	case action == approval.Deny:
+		return fmt.Errorf("%w: %s was denied by the approval policy (rule %d)", approval.ErrDenied, req.Summary, rule)
+	}
+	return fmt.Errorf("%w: %s needs approval (rule %d), which the MCP server can't ask for", approval.ErrDenied, req.Summary, rule)
+}
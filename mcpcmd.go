@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime/debug"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/config"
+	"anthropic-chat/embeddings"
+	"anthropic-chat/lsp"
+	"anthropic-chat/mcp"
+	"anthropic-chat/project"
+	"anthropic-chat/tools"
+	codeindextools "anthropic-chat/tools/codeindex"
+	"anthropic-chat/tools/file"
+	kbtools "anthropic-chat/tools/kb"
+	lsptools "anthropic-chat/tools/lsp"
+	"anthropic-chat/tools/outline"
+)
+
+// runMCPServeCommand implements `goocode serve [--dir dir] [--read-only]`, serving GooCode's file,
+// search and edit tools to other agents and editors over MCP's stdio transport
+func runMCPServeCommand(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	cwd, _ := os.Getwd()
+	dir := flags.String("dir", cwd, "Directory the tools are confined to")
+	readOnly := flags.Bool("read-only", false, "Serve only the tools that don't change files (also set by GOOCODE_READ_ONLY)")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	// The served directory's .env overrides the ones loaded at startup, so it is read before the config
+	config.LoadProjectEnvFiles(filepath.Join(*dir, ".env"))
+	cfg := config.NewConfig()
+	*readOnly = *readOnly || cfg.Security.ReadOnly
+
+	// The same policy as an interactive session decides edits; nobody can be asked, so "ask" refuses
+	var policy *approval.Policy
+	policyFile := cfg.Security.ApprovalPolicy
+	if p, ok := project.Find(*dir); ok && policyFile == "" {
+		// A repository's own policy could allow anything, so it needs accepting in a session first
+		if file := p.Permissions(); file != "" {
+			if trusted, err := approval.LoadTrustedPolicies(cfg.Security.TrustedPolicies); err == nil && trusted.Trusted(file) {
+				policyFile = file
+			} else {
+				log.Printf("Ignoring the approval policy in %s, which hasn't been accepted in an interactive session", file)
+			}
+		}
+	}
+	if policyFile != "" {
+		var err error
+		if policy, err = approval.Load(policyFile); err != nil {
+			return err
+		}
+	}
+	workspace, err := mcp.NewWorkspace(*dir, policy)
+	if err != nil {
+		return err
+	}
+
+	lspManager := lsp.NewManager()
+	defer lspManager.Close()
+	registry := tools.NewRegistry()
+	registry.Register(file.NewReadFileTool())
+	registry.Register(file.NewReadManyFilesTool())
+	registry.Register(file.NewListFilesTool())
+	registry.Register(outline.NewOutlineFileTool())
+	registry.Register(file.NewStatFileTool())
+	if !*readOnly {
+		registry.Register(file.NewMultiEditTool())
+		registry.Register(file.NewDuplicateFileTool())
+		registry.Register(file.NewCreateDirectoryTool())
+	}
+	registry.Register(lsptools.NewGoToDefinitionTool(lspManager))
+	registry.Register(lsptools.NewFindReferencesTool(lspManager))
+	registry.Register(lsptools.NewDocumentSymbolsTool(lspManager))
+	if !*readOnly {
+		registry.Register(lsptools.NewRenameSymbolTool(lspManager))
+	}
+	if embedder, err := embeddings.New(cfg.Embeddings); err == nil {
+		knowledgeDir := cfg.Knowledge.Dir
+		if p, ok := project.Find(*dir); ok {
+			knowledgeDir = p.Path(project.IndexDir)
+		}
+		registry.Register(kbtools.NewSearchTool(knowledgeDir, embedder))
+		registry.Register(codeindextools.NewSemanticSearchTool(knowledgeDir, embedder))
+	} else {
+		log.Printf("Knowledge base and code search disabled: %v", err)
+	}
+
+	version := "dev"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	log.Printf("Serving %d tools for %s over MCP stdio", len(registry.All()), workspace.WorkingDir())
+	if err := mcp.NewServer("goocode", version, registry, workspace).Serve(ctx, os.Stdin, os.Stdout); err != nil {
+		return fmt.Errorf("MCP server stopped: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package memory
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Fact is one remembered key and value
+type Fact struct {
+	Key     string    `json:"key"`
+	Value   string    `json:"value"`
+	Updated time.Time `json:"updated"`
+}
+
+// Store is a JSON-file backed set of facts, keyed case-insensitively
+type Store struct {
+	path  string
+	Facts []Fact `json:"facts"`
+}
+
+// Open loads the store at path, returning an empty store when it does not exist yet
+func Open(path string) (*Store, error) {
+	store := &Store{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read memory store: %w", err)
+	}
+	if err := json.Unmarshal(data, store); err != nil {
+		return nil, fmt.Errorf("failed to parse memory store %s: %w", path, err)
+	}
+	return store, nil
+}
+
+// Save writes the store atomically
+func (s *Store) Save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create memory directory: %w", err)
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode memory store: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write memory store: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Set saves value under key, reporting whether it replaced an earlier value
+func (s *Store) Set(key, value string) bool {
+	fact := Fact{Key: strings.TrimSpace(key), Value: strings.TrimSpace(value), Updated: time.Now().UTC()}
+	if i := s.index(key); i >= 0 {
+		s.Facts[i] = fact
+		return true
+	}
+	s.Facts = append(s.Facts, fact)
+	sort.Slice(s.Facts, func(i, j int) bool { return strings.ToLower(s.Facts[i].Key) < strings.ToLower(s.Facts[j].Key) })
+	return false
+}
+
+// Get returns the fact saved under key
+func (s *Store) Get(key string) (Fact, bool) {
+	if i := s.index(key); i >= 0 {
+		return s.Facts[i], true
+	}
+	return Fact{}, false
+}
+
+// Delete removes the fact saved under key, reporting whether there was one
+func (s *Store) Delete(key string) bool {
+	i := s.index(key)
+	if i < 0 {
+		return false
+	}
+	s.Facts = append(s.Facts[:i], s.Facts[i+1:]...)
+	return true
+}
+
+// Search returns up to limit facts sharing words with text, the best matches first. Words in a fact's
+// key count double, and more recently updated facts win ties.
+func (s *Store) Search(text string, limit int) []Fact {
+	words := make(map[string]bool)
+	for _, word := range wordsOf(text) {
+		words[word] = true
+	}
+	type scored struct {
+		fact  Fact
+		score int
+	}
+	var matches []scored
+	for _, fact := range s.Facts {
+		score := 0
+		for _, word := range unique(wordsOf(fact.Key)) {
+			if words[word] {
+				score += 2
+			}
+		}
+		for _, word := range unique(wordsOf(fact.Value)) {
+			if words[word] {
+				score++
+			}
+		}
+		if score > 0 {
+			matches = append(matches, scored{fact, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].fact.Updated.After(matches[j].fact.Updated)
+	})
+	facts := make([]Fact, 0, min(len(matches), limit))
+	for _, match := range matches[:min(len(matches), limit)] {
+		facts = append(facts, match.fact)
+	}
+	return facts
+}
+
+func (s *Store) index(key string) int {
+	key = strings.TrimSpace(key)
+	for i, fact := range s.Facts {
+		if strings.EqualFold(fact.Key, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// wordsOf splits text into lowercase words of three or more letters or digits, so short filler
+// words don't make every fact look relevant
+func wordsOf(text string) []string {
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) >= 3 && !stopWords[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+func unique(words []string) []string {
+	seen := make(map[string]bool, len(words))
+	var out []string
+	for _, word := range words {
+		if !seen[word] {
+			seen[word] = true
+			out = append(out, word)
+		}
+	}
+	return out
+}
+
+// stopWords are too common to make a fact relevant
+var stopWords = map[string]bool{
+	"the": true, "and": true, "for": true, "are": true, "but": true, "not": true, "you": true, "all": true,
+	"can": true, "has": true, "have": true, "this": true, "that": true, "with": true, "from": true,
+	"was": true, "were": true, "will": true, "use": true, "uses": true, "into": true, "should": true,
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"anthropic-chat/ui"
+)
+
+// readPipedInput reads piped stdin, keeping the beginning and end of input longer than limit bytes.
+// Only about limit bytes are held at a time, however much is piped in.
+func readPipedInput(r io.Reader, limit int) (string, error) {
+	head := make([]byte, limit)
+	n, err := io.ReadFull(r, head)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return string(head[:n]), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read stdin: %w", err)
+	}
+
+	// Past the limit, keep the first half and a rolling window of the last half
+	half := limit / 2
+	tail := append([]byte(nil), head[half:]...)
+	total := int64(n)
+	chunk := make([]byte, 32<<10)
+	for {
+		m, err := r.Read(chunk)
+		total += int64(m)
+		tail = append(tail, chunk[:m]...)
+		if len(tail) > half {
+			tail = append(tail[:0], tail[len(tail)-half:]...)
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to read stdin: %w", err)
+		}
+	}
+	if total <= int64(limit) {
+		return string(head), nil
+	}
+	return fmt.Sprintf("%s\n[... %s of stdin omitted ...]\n%s", head[:half], ui.FormatBytesPlain(total-int64(2*half)), tail), nil
+}
+
+// oneShotPrompt adds piped input to the prompt given with -p
+func oneShotPrompt(prompt, input string) string {
+	if strings.TrimSpace(input) == "" {
+		return prompt
+	}
+	return fmt.Sprintf("%s\n\n<stdin>\n%s\n</stdin>", prompt, strings.TrimRight(input, "\n"))
+}
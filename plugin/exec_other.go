@@ -0,0 +1,29 @@
+//go:build !windows
+
+package plugin
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+func isExecutable(info os.FileInfo) bool {
+	return info.Mode().IsRegular() && info.Mode().Perm()&0o111 != 0
+}
+
+// isolate puts the plugin in its own process group, so Ctrl-C at the prompt doesn't reach it and
+// whatever it started dies with it
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// kill stops the plugin and everything in its process group
+func kill(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package plugin
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func isExecutable(info os.FileInfo) bool {
+	switch strings.ToLower(filepath.Ext(info.Name())) {
+	case ".exe", ".bat", ".cmd":
+		return info.Mode().IsRegular()
+	}
+	return false
+}
+
+// isolate is a no-op on Windows
+func isolate(cmd *exec.Cmd) {}
+
+// kill stops the plugin; processes it started may outlive it
+func kill(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,246 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	describeTimeout = 10 * time.Second // Time a plugin has to start and list its tools
+	stderrKeepBytes = 2000             // Trailing stderr included when a plugin fails
+	maxMessageBytes = 16 * 1024 * 1024
+)
+
+// Plugin is an external executable that provides tools over newline-delimited JSON on stdin and stdout.
+// It is started on first use and restarted if it exits or a call is abandoned.
+type Plugin struct {
+	path string
+	dir  string
+
+	mu       sync.Mutex // Serializes calls; plugins handle one request at a time
+	cmd      *exec.Cmd
+	stdin    io.WriteCloser
+	messages chan message
+	done     chan struct{}
+	stderr   *tailWriter
+	nextID   int64
+}
+
+// New prepares the plugin at path; the process starts on the first call, in dir
+func New(path, dir string) *Plugin {
+	return &Plugin{path: path, dir: dir}
+}
+
+// Path returns the plugin executable
+func (p *Plugin) Path() string {
+	return p.path
+}
+
+// Describe starts the plugin and asks for its tools
+func (p *Plugin) Describe(ctx context.Context) (*Description, error) {
+	ctx, cancel := context.WithTimeout(ctx, describeTimeout)
+	defer cancel()
+
+	var description Description
+	if err := p.call(ctx, "describe", describeParams{Protocol: ProtocolVersion}, nil, &description); err != nil {
+		return nil, err
+	}
+	if description.Name == "" {
+		description.Name = strings.TrimSuffix(filepath.Base(p.path), filepath.Ext(p.path))
+	}
+	return &description, nil
+}
+
+// Execute runs one of the plugin's tools; progress receives any progress notifications sent meanwhile
+func (p *Plugin) Execute(ctx context.Context, tool string, input json.RawMessage, workingDir string, progress func(string)) (string, error) {
+	if len(input) == 0 {
+		input = json.RawMessage("{}")
+	}
+	var result executeResult
+	err := p.call(ctx, "execute", executeParams{Tool: tool, Input: input, WorkingDir: workingDir}, progress, &result)
+	return result.Output, err
+}
+
+// Close stops the plugin process
+func (p *Plugin) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stop()
+}
+
+func (p *Plugin) call(ctx context.Context, method string, params interface{}, progress func(string), result interface{}) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd == nil {
+		if err := p.start(); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+	p.nextID++
+	id := p.nextID
+	line, err := json.Marshal(message{ID: &id, Method: method, Params: data})
+	if err != nil {
+		return fmt.Errorf("failed to encode %s request: %w", method, err)
+	}
+	if _, err := p.stdin.Write(append(line, '\n')); err != nil {
+		p.stop()
+		return fmt.Errorf("plugin %s is not accepting requests: %w", p.name(), err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// The plugin may still be working on the request, so its next reply can't be trusted
+			p.stop()
+			return ctx.Err()
+		case <-p.done:
+			p.stop()
+			return fmt.Errorf("plugin %s exited%s", p.name(), p.stderr.note())
+		case msg := <-p.messages:
+			switch {
+			case msg.ID == nil && msg.Method == "progress":
+				var notification progressParams
+				if progress != nil && json.Unmarshal(msg.Params, &notification) == nil && notification.Message != "" {
+					progress(notification.Message)
+				}
+			case msg.ID != nil && *msg.ID == id:
+				if msg.Error != nil {
+					return msg.Error
+				}
+				if err := json.Unmarshal(msg.Result, result); err != nil {
+					return fmt.Errorf("plugin %s sent an invalid %s result: %w", p.name(), method, err)
+				}
+				return nil
+			}
+		}
+	}
+}
+
+func (p *Plugin) start() error {
+	cmd := exec.Command(p.path)
+	cmd.Dir = p.dir
+	isolate(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open plugin stdin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open plugin stdout: %w", err)
+	}
+	p.stderr = &tailWriter{}
+	cmd.Stderr = p.stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start plugin %s: %w", p.path, err)
+	}
+
+	p.cmd, p.stdin = cmd, stdin
+	p.messages = make(chan message)
+	p.done = make(chan struct{})
+	go readMessages(stdout, p.messages, p.done)
+	return nil
+}
+
+// readMessages forwards decoded lines until stdout closes; lines that aren't JSON (stray prints) are ignored
+func readMessages(stdout io.Reader, messages chan<- message, done chan struct{}) {
+	defer close(done)
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageBytes)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err == nil {
+			messages <- msg
+		}
+	}
+}
+
+func (p *Plugin) stop() {
+	if p.cmd == nil {
+		return
+	}
+	p.stdin.Close()
+	kill(p.cmd)
+	p.cmd.Wait()
+	// Unblock the reader if it is waiting to hand over a message nobody will read
+	go func(messages chan message, done chan struct{}) {
+		for {
+			select {
+			case <-messages:
+			case <-done:
+				return
+			}
+		}
+	}(p.messages, p.done)
+	p.cmd, p.stdin = nil, nil
+}
+
+func (p *Plugin) name() string {
+	return filepath.Base(p.path)
+}
+
+// Discover returns the executables in dirs, sorted by name within each directory; missing directories are skipped
+func Discover(dirs ...string) []string {
+	var paths []string
+	for _, dir := range dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		names := []string{}
+		for _, entry := range entries {
+			info, err := entry.Info()
+			if err != nil || entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || !isExecutable(info) {
+				continue
+			}
+			names = append(names, entry.Name())
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	return paths
+}
+
+// tailWriter keeps the last stderrKeepBytes written to it
+type tailWriter struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (w *tailWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.data = append(w.data, b...)
+	if len(w.data) > stderrKeepBytes {
+		w.data = w.data[len(w.data)-stderrKeepBytes:]
+	}
+	return len(b), nil
+}
+
+// note formats the captured stderr for an error message
+func (w *tailWriter) note() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	text := strings.TrimSpace(string(w.data))
+	if text == "" {
+		return ""
+	}
+	return ":\n" + text
+}
@@ -0,0 +1,62 @@
+package plugin
+
+import "encoding/json"
+
+// ProtocolVersion is sent with describe so plugins can reject hosts they don't understand
+const ProtocolVersion = 1
+
+// message is one line of newline-delimited JSON exchanged with a plugin.
+// Requests carry an ID and a method; responses echo the ID with a result or an error;
+// notifications from the plugin (such as progress) have a method but no ID.
+type message struct {
+	ID     *int64          `json:"id,omitempty"`
+	Method string          `json:"method,omitempty"`
+	Params json.RawMessage `json:"params,omitempty"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  *Error          `json:"error,omitempty"`
+}
+
+// Error is a failure reported by a plugin
+type Error struct {
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ToolSpec describes a tool a plugin provides
+type ToolSpec struct {
+	Name             string          `json:"name"`
+	Description      string          `json:"description"`
+	InputSchema      json.RawMessage `json:"input_schema"`
+	RequiresApproval bool            `json:"requires_approval,omitempty"` // Ask before every call, like built-in tools that change files
+}
+
+// describeParams is sent with the describe request
+type describeParams struct {
+	Protocol int `json:"protocol"`
+}
+
+// Description is the plugin's answer to describe
+type Description struct {
+	Name  string     `json:"name"`
+	Tools []ToolSpec `json:"tools"`
+}
+
+// executeParams asks the plugin to run one of its tools
+type executeParams struct {
+	Tool       string          `json:"tool"`
+	Input      json.RawMessage `json:"input"`
+	WorkingDir string          `json:"working_dir"`
+}
+
+// executeResult is the plugin's answer to execute
+type executeResult struct {
+	Output string `json:"output"`
+}
+
+// progressParams accompanies a progress notification sent while a tool runs
+type progressParams struct {
+	Message string `json:"message"`
+}
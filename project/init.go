@@ -0,0 +1,62 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// scaffold is the content `goocode init` writes for files that don't exist yet
+var scaffold = []struct{ name, content string }{
+	{InstructionsFile, `# Project instructions
+
+<!-- Everything in this file is added to GooCode's system prompt for this project.
+Describe conventions, build and test commands, and anything the agent should always know. -->
+`},
+	{MemoryFile, `# Project memory
+
+<!-- Notes GooCode keeps across sessions. Add to it with /remember <note>. -->
+`},
+	{PermissionsFile, `# Approval policy for this project (used when GOOCODE_APPROVAL_POLICY is unset).
+# The first matching rule decides; see the README for the format.
+default: allow
+rules:
+  - paths: [".git/"]
+    action: deny
+`},
+	{filepath.Join(CommandsDir, "review.md"), `Review the current changes
+Review the uncommitted changes in this repository for bugs, missing tests and style problems. $ARGUMENTS
+`},
+}
+
+// Init creates the .goocode directory layout, returning the paths it created.
+// Existing files are left untouched, so it is safe to run again.
+func (p *Project) Init() ([]string, error) {
+	var created []string
+	for _, dir := range []string{CommandsDir, IndexDir, SnapshotsDir, ArtifactsDir} {
+		path := p.Path(dir)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		if err := os.MkdirAll(path, 0o755); err != nil {
+			return created, fmt.Errorf("failed to create %s: %w", path, err)
+		}
+		created = append(created, path+string(filepath.Separator))
+	}
+
+	for _, file := range scaffold {
+		path := p.Path(file.name)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		if err := os.WriteFile(path, []byte(file.content), 0o644); err != nil {
+			return created, fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		created = append(created, path)
+	}
+
+	if err := p.EnsureGitignore(); err != nil {
+		return created, err
+	}
+	return created, nil
+}
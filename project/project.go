@@ -0,0 +1,245 @@
+package project
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"anthropic-chat/ignore"
+)
+
+// DirName is the per-project directory GooCode manages
+const DirName = ".goocode"
+
+// Parts of the project directory
+const (
+	InstructionsFile = "instructions.md"  // Added to the system prompt (committed)
+	MemoryFile       = "memory.md"        // Notes kept across sessions, added to the system prompt (committed)
+	MemoriesFile     = "memories.json"    // Facts saved with the memory tool, recalled after compaction (committed)
+	PermissionsFile  = "permissions.yaml" // Approval policy used when GOOCODE_APPROVAL_POLICY is unset (committed)
+	CommandsDir      = "commands"         // Custom slash commands, one Markdown prompt per command (committed)
+	PluginsDir       = "plugins"          // Tool plugin executables, loaded only when GOOCODE_PROJECT_PLUGINS=true (committed)
+	IndexDir         = "index"            // Knowledge base index (transient)
+	SnapshotsDir     = "snapshots"        // Working tree checkpoints (transient)
+	ArtifactsDir     = "artifacts"        // Generated reports and docs (transient)
+)
+
+// ConfigFile is a project approval policy kept at the root of the working tree instead of in .goocode
+const ConfigFile = ".goocode.yaml"
+
+// transient lists the regenerated parts that are kept out of version control
+var transient = []string{IndexDir + "/", SnapshotsDir + "/", ArtifactsDir + "/"}
+
+// Project is a working tree's .goocode directory
+type Project struct {
+	Root string // The directory containing .goocode
+}
+
+// At returns the project rooted at dir, whether or not .goocode exists yet
+func At(dir string) *Project {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return &Project{Root: dir}
+}
+
+// Find looks for a .goocode directory or a .goocode.yaml file in dir and its parents, up to the
+// repository root. The global ~/.goocode directory is never mistaken for a project.
+func Find(dir string) (*Project, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, false
+	}
+	home, _ := os.UserHomeDir()
+	for {
+		if dir != home {
+			if info, err := os.Stat(filepath.Join(dir, DirName)); err == nil && info.IsDir() {
+				return &Project{Root: dir}, true
+			}
+			if info, err := os.Stat(filepath.Join(dir, ConfigFile)); err == nil && info.Mode().IsRegular() {
+				return &Project{Root: dir}, true
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return nil, false
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, false
+		}
+		dir = parent
+	}
+}
+
+// Path returns a path inside the .goocode directory
+func (p *Project) Path(parts ...string) string {
+	return filepath.Join(append([]string{p.Root, DirName}, parts...)...)
+}
+
+// Exists reports whether the .goocode directory has been created
+func (p *Project) Exists() bool {
+	info, err := os.Stat(p.Path())
+	return err == nil && info.IsDir()
+}
+
+// Instructions returns the project instructions, or "" if there are none
+func (p *Project) Instructions() string {
+	return p.readText(InstructionsFile)
+}
+
+// Memory returns the project memory notes, or "" if there are none
+func (p *Project) Memory() string {
+	return p.readText(MemoryFile)
+}
+
+// Remember appends a note to the project memory
+func (p *Project) Remember(note string) error {
+	if err := os.MkdirAll(p.Path(), 0o755); err != nil {
+		return fmt.Errorf("failed to create project directory: %w", err)
+	}
+	f, err := os.OpenFile(p.Path(MemoryFile), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open project memory: %w", err)
+	}
+	if _, err := fmt.Fprintf(f, "- %s\n", strings.TrimSpace(note)); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write project memory: %w", err)
+	}
+	return f.Close()
+}
+
+// Permissions returns the path of the project's approval policy, or "" if it has none.
+// .goocode/permissions.yaml wins over .goocode.yaml when both exist.
+func (p *Project) Permissions() string {
+	for _, path := range []string{p.Path(PermissionsFile), filepath.Join(p.Root, ConfigFile)} {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return ""
+}
+
+// Protected reports whether fullPath, with symlinks resolved, is one of the files that decide what tools
+// in workingDir may do: the .goocodeignore deny-list, the project's approval policies, or one of extra.
+// Tools may never write them.
+func Protected(workingDir, fullPath string, extra ...string) bool {
+	root := workingDir
+	if p, ok := Find(workingDir); ok {
+		root = p.Root
+	}
+	files := append([]string{
+		filepath.Join(workingDir, ignore.FileName),
+		filepath.Join(root, DirName, PermissionsFile),
+		filepath.Join(root, ConfigFile),
+	}, extra...)
+	real := ignore.Resolve(fullPath)
+	for _, file := range files {
+		if file != "" && ignore.Resolve(file) == real {
+			return true
+		}
+	}
+	return false
+}
+
+// Command is a custom slash command defined in .goocode/commands or ~/.goocode/commands
+type Command struct {
+	Name        string
+	Description string // First line of the file
+	Prompt      string // Sent to the model with $ARGUMENTS replaced
+}
+
+// Commands lists the project's custom slash commands, sorted by name
+func (p *Project) Commands() []Command {
+	return LoadCommands(p.Path(CommandsDir))
+}
+
+// LoadCommands reads the custom slash commands in dir, one Markdown file per command, sorted by name
+func LoadCommands(dir string) []Command {
+	files, _ := filepath.Glob(filepath.Join(dir, "*.md"))
+	sort.Strings(files)
+	var commands []Command
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil || strings.TrimSpace(string(data)) == "" {
+			continue
+		}
+		prompt := strings.TrimSpace(string(data))
+		description, _, _ := strings.Cut(prompt, "\n")
+		commands = append(commands, Command{
+			Name:        strings.TrimSuffix(filepath.Base(file), ".md"),
+			Description: strings.TrimSpace(strings.TrimLeft(description, "#")),
+			Prompt:      prompt,
+		})
+	}
+	return commands
+}
+
+// Expand fills the command's prompt with args; without a $ARGUMENTS placeholder they are appended
+func (c Command) Expand(args string) string {
+	if strings.Contains(c.Prompt, "$ARGUMENTS") {
+		return strings.ReplaceAll(c.Prompt, "$ARGUMENTS", args)
+	}
+	if args == "" {
+		return c.Prompt
+	}
+	return c.Prompt + "\n\n" + args
+}
+
+// EnsureGitignore makes sure .goocode/.gitignore excludes the transient parts
+func (p *Project) EnsureGitignore() error {
+	path := p.Path(".gitignore")
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	present := map[string]bool{}
+	for _, line := range strings.Split(string(existing), "\n") {
+		present[strings.TrimSpace(line)] = true
+	}
+	var missing []string
+	for _, entry := range transient {
+		if !present[entry] {
+			missing = append(missing, entry)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	content := string(existing)
+	if content == "" {
+		content = "# Managed by GooCode: regenerated data stays out of version control\n"
+	} else if !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	content += strings.Join(missing, "\n") + "\n"
+	if err := os.MkdirAll(p.Path(), 0o755); err != nil {
+		return fmt.Errorf("failed to create project directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// commentPattern matches the HTML comments the scaffold uses for guidance
+var commentPattern = regexp.MustCompile(`(?s)<!--.*?-->`)
+
+// readText returns a file's content without guidance comments, or "" if only headings are left
+func (p *Project) readText(name string) string {
+	data, err := os.ReadFile(p.Path(name))
+	if err != nil {
+		return ""
+	}
+	text := strings.TrimSpace(commentPattern.ReplaceAllString(string(data), ""))
+	for _, line := range strings.Split(text, "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			return text
+		}
+	}
+	return ""
+}
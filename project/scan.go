@@ -0,0 +1,143 @@
+package project
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// BriefFile is the project brief written by /init, kept at the project root so it can be committed
+const BriefFile = "GOOCODE.md"
+
+// Scan limits keep the onboarding request to a reasonable size
+const (
+	maxTreeEntries  = 400
+	maxTreeDepth    = 4
+	maxConfigBytes  = 4000
+	maxReadmeBytes  = 8000
+	maxScannedFiles = 25
+)
+
+// scanSkipDirs are never listed in the file tree
+var scanSkipDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, ".venv": true, "venv": true, "__pycache__": true,
+	"dist": true, "build": true, "target": true, DirName: true,
+}
+
+// keyFiles are manifests and configs worth showing the model, matched by base name
+var keyFiles = []string{
+	"go.mod", "package.json", "Cargo.toml", "pyproject.toml", "requirements.txt", "setup.py", "setup.cfg",
+	"pom.xml", "build.gradle", "build.gradle.kts", "Gemfile", "composer.json", "mix.exs", "CMakeLists.txt",
+	"Makefile", "Justfile", "Taskfile.yml", "Dockerfile", "docker-compose.yml", "compose.yaml",
+	"tsconfig.json", ".golangci.yml", ".eslintrc.json", ".pre-commit-config.yaml",
+}
+
+// Brief returns the project brief, or "" if /init hasn't written one
+func (p *Project) Brief() string {
+	data, err := os.ReadFile(filepath.Join(p.Root, BriefFile))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Scan summarizes the repository for onboarding: the file tree, the README, and key configs and manifests
+func (p *Project) Scan() (string, error) {
+	var tree []string
+	var configs []string
+	readme := ""
+	truncated := false
+
+	err := filepath.WalkDir(p.Root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, _ := filepath.Rel(p.Root, path)
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		depth := strings.Count(rel, "/")
+
+		if d.IsDir() {
+			if scanSkipDirs[d.Name()] || (strings.HasPrefix(d.Name(), ".") && d.Name() != ".github") {
+				return filepath.SkipDir
+			}
+		}
+		// Directories at the depth cap are listed without their contents, and count toward the entry cap too
+		capped := d.IsDir() && depth >= maxTreeDepth
+		if len(tree) < maxTreeEntries {
+			entry := rel
+			if d.IsDir() {
+				entry += "/"
+			}
+			if capped {
+				entry += " ..."
+			}
+			tree = append(tree, entry)
+		} else {
+			truncated = true
+		}
+
+		if capped {
+			return filepath.SkipDir
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch {
+		case readme == "" && depth == 0 && strings.HasPrefix(strings.ToUpper(d.Name()), "README"):
+			readme = rel
+		case len(configs) < maxScannedFiles && isKeyFile(rel, d.Name()):
+			configs = append(configs, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to scan %s: %w", p.Root, err)
+	}
+
+	var b strings.Builder
+	b.WriteString("## File tree\n")
+	for _, entry := range tree {
+		b.WriteString(entry + "\n")
+	}
+	if truncated {
+		fmt.Fprintf(&b, "... (tree truncated after %d entries)\n", maxTreeEntries)
+	}
+	if readme != "" {
+		fmt.Fprintf(&b, "\n## %s\n%s\n", readme, readHead(filepath.Join(p.Root, readme), maxReadmeBytes))
+	}
+	sort.Strings(configs)
+	for _, rel := range configs {
+		fmt.Fprintf(&b, "\n## %s\n%s\n", rel, readHead(filepath.Join(p.Root, rel), maxConfigBytes))
+	}
+	return b.String(), nil
+}
+
+// isKeyFile reports whether a file is a manifest, build config or CI workflow
+func isKeyFile(rel, name string) bool {
+	if strings.HasPrefix(rel, ".github/workflows/") {
+		return true
+	}
+	for _, key := range keyFiles {
+		if name == key {
+			return true
+		}
+	}
+	return false
+}
+
+func readHead(path string, max int) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Sprintf("(unreadable: %v)", err)
+	}
+	if len(data) > max {
+		return string(data[:max]) + "\n... (truncated)"
+	}
+	return string(data)
+}
@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"context"
+
+	"anthropic-chat/tokenizer"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// AnthropicProvider sends requests to the Anthropic Messages API, directly or through a cloud platform
+type AnthropicProvider struct {
+	client   *anthropic.Client
+	platform string
+}
+
+// NewAnthropicProvider creates a provider backed by the given SDK client
+func NewAnthropicProvider(client *anthropic.Client) *AnthropicProvider {
+	return &AnthropicProvider{client: client, platform: PlatformAnthropic}
+}
+
+// NewPlatformProvider creates a provider for a client set up with BedrockOptions or VertexOptions
+func NewPlatformProvider(client *anthropic.Client, platform string) *AnthropicProvider {
+	return &AnthropicProvider{client: client, platform: platform}
+}
+
+// Name returns the provider name
+func (p *AnthropicProvider) Name() string {
+	return p.platform
+}
+
+// StreamMessage starts a streaming Messages API request
+func (p *AnthropicProvider) StreamMessage(ctx context.Context, params anthropic.MessageNewParams) Stream {
+	return p.client.Messages.NewStreaming(ctx, params)
+}
+
+// NewMessage performs a non-streaming Messages API request
+func (p *AnthropicProvider) NewMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	return p.client.Messages.New(ctx, params)
+}
+
+// CountTokens returns the input token count reported by the API; Bedrock has no token counting endpoint,
+// so there it is estimated with the local tokenizer
+func (p *AnthropicProvider) CountTokens(ctx context.Context, params anthropic.MessageCountTokensParams) (int, error) {
+	if p.platform == PlatformBedrock {
+		return tokenizer.CountParams(params), nil
+	}
+	count, err := p.client.Messages.CountTokens(ctx, params)
+	if err != nil {
+		return 0, err
+	}
+	return int(count.InputTokens), nil
+}
@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go/bedrock"
+	"github.com/anthropics/anthropic-sdk-go/option"
+	"github.com/anthropics/anthropic-sdk-go/vertex"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"golang.org/x/oauth2/google"
+)
+
+// Platforms the Anthropic provider can reach Claude through
+const (
+	PlatformAnthropic = "anthropic" // The Anthropic API, with an API key
+	PlatformBedrock   = "bedrock"   // AWS Bedrock, with the standard AWS credential chain
+	PlatformVertex    = "vertex"    // Google Cloud Vertex AI, with Application Default Credentials
+)
+
+// vertexScope is the OAuth scope Vertex AI requests are authorized with
+const vertexScope = "https://www.googleapis.com/auth/cloud-platform"
+
+// BedrockOptions authenticates SDK requests against AWS Bedrock; region overrides the AWS configuration when set
+func BedrockOptions(ctx context.Context, region string) ([]option.RequestOption, error) {
+	var load []func(*awsconfig.LoadOptions) error
+	if region != "" {
+		load = append(load, awsconfig.WithRegion(region))
+	}
+	cfg, err := awsconfig.LoadDefaultConfig(ctx, load...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("no AWS region configured; set AWS_REGION or GOOCODE_REGION")
+	}
+	return []option.RequestOption{bedrock.WithConfig(cfg)}, nil
+}
+
+// VertexOptions authenticates SDK requests against Vertex AI in region; project defaults to the credentials' project
+func VertexOptions(ctx context.Context, region, project string) ([]option.RequestOption, error) {
+	if region == "" {
+		return nil, fmt.Errorf("no Vertex AI region configured; set GOOCODE_REGION")
+	}
+	creds, err := google.FindDefaultCredentials(ctx, vertexScope)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find Google Application Default Credentials: %w", err)
+	}
+	if project == "" {
+		project = creds.ProjectID
+	}
+	if project == "" {
+		return nil, fmt.Errorf("no Google Cloud project configured; set GOOCODE_VERTEX_PROJECT")
+	}
+	return []option.RequestOption{vertex.WithCredentials(ctx, region, project, creds)}, nil
+}
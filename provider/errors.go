@@ -0,0 +1,64 @@
+package provider
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// ErrContextLength is returned by providers when a request does not fit the model's context window
+var ErrContextLength = errors.New("prompt is too long for the model's context window")
+
+// statusOverloaded is the status the API answers with when it is temporarily overloaded
+const statusOverloaded = 529
+
+// ErrOverloaded is returned by providers when the model is overloaded or the rate limit is exhausted
+var ErrOverloaded = errors.New("the model is overloaded or rate limited")
+
+// contextLengthMessages are the phrases the API uses when it rejects an oversized request
+var contextLengthMessages = []string{
+	"prompt is too long",
+	"context length",
+	"context window",
+	"input length and `max_tokens` exceed",
+}
+
+// IsContextLengthError reports whether err means the request exceeded the context window
+func IsContextLengthError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrContextLength) {
+		return true
+	}
+	var apiErr *anthropic.Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusBadRequest {
+		return false
+	}
+	message := strings.ToLower(apiErr.RawJSON())
+	for _, phrase := range contextLengthMessages {
+		if strings.Contains(message, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOverloadedError reports whether err means the model is overloaded or rate limited, after the
+// client's own retries gave up
+func IsOverloadedError(err error) bool {
+	if errors.Is(err, ErrOverloaded) {
+		return true
+	}
+	var apiErr *anthropic.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, statusOverloaded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,328 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"anthropic-chat/tokenizer"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const defaultMockReply = "This is the mock provider. The scenario has no more scripted responses."
+
+// Scenario is a scripted conversation loaded from a JSON file
+type Scenario struct {
+	// Responses are replayed in order; entries with Match only fire when the latest user text matches
+	Responses []ScriptedResponse `json:"responses"`
+	// Default is returned once every scripted response has been used
+	Default string `json:"default,omitempty"`
+	// DelayMs slows down the simulated stream between chunks
+	DelayMs int `json:"delay_ms,omitempty"`
+}
+
+// ScriptedResponse is one canned assistant message
+type ScriptedResponse struct {
+	Match     string            `json:"match,omitempty"`
+	Text      string            `json:"text,omitempty"`
+	ToolCalls []ScriptedToolUse `json:"tool_calls,omitempty"`
+	// Error fails the request instead; "context_length" simulates an oversized prompt and "overloaded" an
+	// overloaded or rate limited model
+	Error string `json:"error,omitempty"`
+	// DropAfter cuts the stream after this many text chunks, simulating a dropped connection
+	DropAfter int `json:"drop_after,omitempty"`
+	// StopReason overrides why the response ended, e.g. "max_tokens" for one cut off at the output limit
+	StopReason string `json:"stop_reason,omitempty"`
+
+	matcher *regexp.Regexp
+}
+
+// ScriptedToolUse is a tool call the mock model will make
+type ScriptedToolUse struct {
+	Name  string          `json:"name"`
+	Input json.RawMessage `json:"input,omitempty"`
+}
+
+// LoadScenario reads and validates a scenario file
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario file: %w", err)
+	}
+
+	var scenario Scenario
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return nil, fmt.Errorf("failed to parse scenario file %s: %w", path, err)
+	}
+
+	for i := range scenario.Responses {
+		if scenario.Responses[i].Match == "" {
+			continue
+		}
+		matcher, err := regexp.Compile(scenario.Responses[i].Match)
+		if err != nil {
+			return nil, fmt.Errorf("invalid match pattern in response %d: %w", i+1, err)
+		}
+		scenario.Responses[i].matcher = matcher
+	}
+	return &scenario, nil
+}
+
+// MockProvider answers requests from a Scenario without touching the network
+type MockProvider struct {
+	scenario *Scenario
+	used     []bool
+	nextID   int
+	mu       sync.Mutex
+}
+
+// NewMockProvider creates a mock provider; a nil scenario produces only the default reply
+func NewMockProvider(scenario *Scenario) *MockProvider {
+	if scenario == nil {
+		scenario = &Scenario{}
+	}
+	return &MockProvider{
+		scenario: scenario,
+		used:     make([]bool, len(scenario.Responses)),
+	}
+}
+
+// Name returns the provider name
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+// StreamMessage replays the next scripted response as a sequence of stream events
+func (p *MockProvider) StreamMessage(ctx context.Context, params anthropic.MessageNewParams) Stream {
+	response := p.next(params)
+	if response.Error != "" {
+		return &mockStream{ctx: ctx, err: scriptedError(response.Error), index: -1}
+	}
+	stream := &mockStream{
+		ctx:    ctx,
+		events: p.buildEvents(params, response),
+		delay:  time.Duration(p.scenario.DelayMs) * time.Millisecond,
+		index:  -1,
+	}
+	if keep := 2 + response.DropAfter; response.DropAfter > 0 && keep < len(stream.events) {
+		// message_start and content_block_start come before the text chunks
+		stream.events, stream.dropErr = stream.events[:keep], io.ErrUnexpectedEOF
+	}
+	return stream
+}
+
+// NewMessage returns the next scripted response as a complete message
+func (p *MockProvider) NewMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	stream := p.StreamMessage(ctx, params)
+	message := anthropic.Message{}
+	for stream.Next() {
+		if err := message.Accumulate(stream.Current()); err != nil {
+			return nil, err
+		}
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// CountTokens estimates tokens with the local tokenizer
+func (p *MockProvider) CountTokens(ctx context.Context, params anthropic.MessageCountTokensParams) (int, error) {
+	return tokenizer.CountParams(params), nil
+}
+
+// next picks the first unused response that applies to the latest user text
+func (p *MockProvider) next(params anthropic.MessageNewParams) ScriptedResponse {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	userText := latestUserText(params.Messages)
+	for i, response := range p.scenario.Responses {
+		if p.used[i] {
+			continue
+		}
+		if response.matcher != nil && !response.matcher.MatchString(userText) {
+			continue
+		}
+		p.used[i] = true
+		return response
+	}
+
+	text := p.scenario.Default
+	if text == "" {
+		text = defaultMockReply
+	}
+	return ScriptedResponse{Text: text}
+}
+
+func (p *MockProvider) buildEvents(params anthropic.MessageNewParams, response ScriptedResponse) []anthropic.MessageStreamEventUnion {
+	p.mu.Lock()
+	p.nextID++
+	id := p.nextID
+	p.mu.Unlock()
+
+	raw := []map[string]interface{}{{
+		"type": "message_start",
+		"message": map[string]interface{}{
+			"id": fmt.Sprintf("msg_mock_%d", id), "type": "message", "role": "assistant",
+			"model": string(params.Model), "content": []interface{}{},
+			"usage": map[string]interface{}{"input_tokens": 0, "output_tokens": 0},
+		},
+	}}
+
+	index := 0
+	if response.Text != "" {
+		raw = append(raw, map[string]interface{}{
+			"type": "content_block_start", "index": index,
+			"content_block": map[string]interface{}{"type": "text", "text": ""},
+		})
+		for _, chunk := range chunkText(response.Text) {
+			raw = append(raw, map[string]interface{}{
+				"type": "content_block_delta", "index": index,
+				"delta": map[string]interface{}{"type": "text_delta", "text": chunk},
+			})
+		}
+		raw = append(raw, map[string]interface{}{"type": "content_block_stop", "index": index})
+		index++
+	}
+
+	for i, call := range response.ToolCalls {
+		input := call.Input
+		if len(input) == 0 {
+			input = json.RawMessage("{}")
+		}
+		raw = append(raw,
+			map[string]interface{}{
+				"type": "content_block_start", "index": index,
+				"content_block": map[string]interface{}{
+					"type": "tool_use", "id": fmt.Sprintf("toolu_mock_%d_%d", id, i),
+					"name": call.Name, "input": map[string]interface{}{},
+				},
+			},
+			map[string]interface{}{
+				"type": "content_block_delta", "index": index,
+				"delta": map[string]interface{}{"type": "input_json_delta", "partial_json": string(input)},
+			},
+			map[string]interface{}{"type": "content_block_stop", "index": index},
+		)
+		index++
+	}
+
+	stopReason := "end_turn"
+	if len(response.ToolCalls) > 0 {
+		stopReason = "tool_use"
+	}
+	if response.StopReason != "" {
+		stopReason = response.StopReason
+	}
+	raw = append(raw,
+		map[string]interface{}{
+			"type":  "message_delta",
+			"delta": map[string]interface{}{"stop_reason": stopReason},
+			"usage": map[string]interface{}{"output_tokens": tokenizer.Count(response.Text)},
+		},
+		map[string]interface{}{"type": "message_stop"},
+	)
+
+	events := make([]anthropic.MessageStreamEventUnion, 0, len(raw))
+	for _, item := range raw {
+		data, _ := json.Marshal(item)
+		var event anthropic.MessageStreamEventUnion
+		if err := json.Unmarshal(data, &event); err == nil {
+			events = append(events, event)
+		}
+	}
+	return events
+}
+
+// scriptedError turns a scenario error string into the error a real provider would return
+func scriptedError(message string) error {
+	switch message {
+	case "context_length":
+		return ErrContextLength
+	case "overloaded":
+		return ErrOverloaded
+	}
+	return errors.New(message)
+}
+
+// mockStream iterates over pre-built events
+type mockStream struct {
+	ctx     context.Context
+	events  []anthropic.MessageStreamEventUnion
+	delay   time.Duration
+	index   int
+	err     error
+	dropErr error // Reported once the events run out, for streams that are cut short
+}
+
+func (s *mockStream) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	if s.index+1 >= len(s.events) {
+		s.err = s.dropErr
+		return false
+	}
+	if s.delay > 0 && s.index >= 0 {
+		select {
+		case <-s.ctx.Done():
+			s.err = s.ctx.Err()
+			return false
+		case <-time.After(s.delay):
+		}
+	}
+	s.index++
+	return true
+}
+
+func (s *mockStream) Current() anthropic.MessageStreamEventUnion {
+	return s.events[s.index]
+}
+
+func (s *mockStream) Err() error {
+	return s.err
+}
+
+func (s *mockStream) Close() error {
+	return nil
+}
+
+// chunkText splits text at word boundaries to imitate streamed deltas
+func chunkText(text string) []string {
+	var chunks []string
+	for len(text) > 0 {
+		end := strings.IndexAny(text[1:], " \n")
+		if end < 0 {
+			chunks = append(chunks, text)
+			break
+		}
+		chunks = append(chunks, text[:end+1])
+		text = text[end+1:]
+	}
+	return chunks
+}
+
+func latestUserText(messages []anthropic.MessageParam) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != anthropic.MessageParamRoleUser {
+			continue
+		}
+		var text strings.Builder
+		for _, block := range messages[i].Content {
+			if block.OfText != nil {
+				text.WriteString(block.OfText.Text)
+			}
+		}
+		return text.String()
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Stream is the subset of the SDK's streaming iterator the agent relies on
+type Stream interface {
+	Next() bool
+	Current() anthropic.MessageStreamEventUnion
+	Err() error
+	Close() error
+}
+
+// Provider abstracts the model backend used for inference, summarization and token counting
+type Provider interface {
+	Name() string
+	StreamMessage(ctx context.Context, params anthropic.MessageNewParams) Stream
+	NewMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error)
+	CountTokens(ctx context.Context, params anthropic.MessageCountTokensParams) (int, error)
+}
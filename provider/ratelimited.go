@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"anthropic-chat/ratelimit"
+	"anthropic-chat/tokenizer"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// RateLimited wraps a provider so every call draws from a shared rate limit budget
+type RateLimited struct {
+	inner   Provider
+	limiter *ratelimit.Limiter
+}
+
+// NewRateLimited wraps inner with limiter
+func NewRateLimited(inner Provider, limiter *ratelimit.Limiter) *RateLimited {
+	return &RateLimited{inner: inner, limiter: limiter}
+}
+
+// RateLimitMiddleware feeds API rate limit headers back into limiter
+func RateLimitMiddleware(limiter *ratelimit.Limiter) option.Middleware {
+	return func(req *http.Request, next option.MiddlewareNext) (*http.Response, error) {
+		resp, err := next(req)
+		if resp != nil {
+			limiter.Observe(resp.StatusCode, resp.Header)
+		}
+		return resp, err
+	}
+}
+
+// Name returns the wrapped provider's name
+func (p *RateLimited) Name() string {
+	return p.inner.Name()
+}
+
+// StreamMessage waits for budget, then starts the stream; the slot is held until the stream ends
+func (p *RateLimited) StreamMessage(ctx context.Context, params anthropic.MessageNewParams) Stream {
+	release, err := p.limiter.Acquire(ctx, estimateTokens(params))
+	if err != nil {
+		return &errStream{err: err}
+	}
+	return &releasingStream{Stream: p.inner.StreamMessage(ctx, params), release: release}
+}
+
+// NewMessage waits for budget, then performs the request
+func (p *RateLimited) NewMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	release, err := p.limiter.Acquire(ctx, estimateTokens(params))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return p.inner.NewMessage(ctx, params)
+}
+
+// CountTokens waits for request budget, then counts tokens
+func (p *RateLimited) CountTokens(ctx context.Context, params anthropic.MessageCountTokensParams) (int, error) {
+	release, err := p.limiter.Acquire(ctx, 0)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	return p.inner.CountTokens(ctx, params)
+}
+
+// estimateTokens approximates input tokens from the request size (~4 bytes per token)
+func estimateTokens(params anthropic.MessageNewParams) int {
+	return tokenizer.Request(params)
+}
+
+// releasingStream returns its rate limit slot once fully consumed or closed
+type releasingStream struct {
+	Stream
+	release func()
+	once    sync.Once
+}
+
+func (s *releasingStream) Next() bool {
+	if s.Stream.Next() {
+		return true
+	}
+	s.once.Do(s.release)
+	return false
+}
+
+func (s *releasingStream) Close() error {
+	s.once.Do(s.release)
+	return s.Stream.Close()
+}
+
+// errStream is a stream that failed before it started
+type errStream struct {
+	err error
+}
+
+func (s *errStream) Next() bool { return false }
+func (s *errStream) Current() anthropic.MessageStreamEventUnion {
+	return anthropic.MessageStreamEventUnion{}
+}
+func (s *errStream) Err() error   { return s.err }
+func (s *errStream) Close() error { return nil }
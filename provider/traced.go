@@ -0,0 +1,97 @@
+package provider
+
+import (
+	"context"
+	"sync"
+
+	"anthropic-chat/telemetry"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Traced wraps a provider so every call is recorded as an OpenTelemetry span with latency and token metrics
+type Traced struct {
+	inner Provider
+}
+
+// NewTraced wraps inner with tracing
+func NewTraced(inner Provider) *Traced {
+	return &Traced{inner: inner}
+}
+
+// Name returns the wrapped provider's name
+func (p *Traced) Name() string {
+	return p.inner.Name()
+}
+
+// StreamMessage starts a traced stream; the span ends once the stream is drained or closed
+func (p *Traced) StreamMessage(ctx context.Context, params anthropic.MessageNewParams) Stream {
+	ctx, op := telemetry.StartAPICall(ctx, "chat", string(params.Model))
+	op.SetAttributes(attribute.Bool("gen_ai.request.stream", true), attribute.Int64("gen_ai.request.max_tokens", params.MaxTokens))
+	return &tracedStream{Stream: p.inner.StreamMessage(ctx, params), op: op}
+}
+
+// NewMessage performs a traced request
+func (p *Traced) NewMessage(ctx context.Context, params anthropic.MessageNewParams) (*anthropic.Message, error) {
+	ctx, op := telemetry.StartAPICall(ctx, "chat", string(params.Model))
+	op.SetAttributes(attribute.Int64("gen_ai.request.max_tokens", params.MaxTokens))
+	message, err := p.inner.NewMessage(ctx, params)
+	if err == nil {
+		usage := message.Usage
+		op.RecordUsage(usage.InputTokens, usage.OutputTokens, usage.CacheReadInputTokens, usage.CacheCreationInputTokens)
+		op.SetAttributes(attribute.StringSlice("gen_ai.response.finish_reasons", []string{string(message.StopReason)}))
+	}
+	op.End(err)
+	return message, err
+}
+
+// CountTokens performs a traced token count
+func (p *Traced) CountTokens(ctx context.Context, params anthropic.MessageCountTokensParams) (int, error) {
+	ctx, op := telemetry.StartAPICall(ctx, "count_tokens", string(params.Model))
+	tokens, err := p.inner.CountTokens(ctx, params)
+	if err == nil {
+		op.SetAttributes(attribute.Int("gen_ai.usage.input_tokens", tokens))
+	}
+	op.End(err)
+	return tokens, err
+}
+
+// tracedStream collects usage from stream events and ends its span when the stream finishes
+type tracedStream struct {
+	Stream
+	op         *telemetry.Operation
+	usage      anthropic.Usage
+	stopReason string
+	once       sync.Once
+}
+
+func (s *tracedStream) Next() bool {
+	if s.Stream.Next() {
+		switch event := s.Stream.Current().AsAny().(type) {
+		case anthropic.MessageStartEvent:
+			s.usage = event.Message.Usage
+		case anthropic.MessageDeltaEvent:
+			s.usage.OutputTokens = event.Usage.OutputTokens
+			s.stopReason = string(event.Delta.StopReason)
+		}
+		return true
+	}
+	s.finish()
+	return false
+}
+
+func (s *tracedStream) Close() error {
+	s.finish()
+	return s.Stream.Close()
+}
+
+func (s *tracedStream) finish() {
+	s.once.Do(func() {
+		s.op.RecordUsage(s.usage.InputTokens, s.usage.OutputTokens, s.usage.CacheReadInputTokens, s.usage.CacheCreationInputTokens)
+		if s.stopReason != "" {
+			s.op.SetAttributes(attribute.StringSlice("gen_ai.response.finish_reasons", []string{s.stopReason}))
+		}
+		s.op.End(s.Stream.Err())
+	})
+}
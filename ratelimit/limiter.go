@@ -0,0 +1,169 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Limiter enforces requests-per-minute, tokens-per-minute and concurrency budgets on the client side.
+// Limits of 0 start disabled and are learned from API rate limit headers when available.
+type Limiter struct {
+	mu           sync.Mutex
+	requests     bucket
+	tokens       bucket
+	blockedUntil time.Time
+	slots        chan struct{}
+	now          func() time.Time
+}
+
+// New creates a limiter; any limit of 0 means unlimited until headers say otherwise
+func New(requestsPerMinute, tokensPerMinute, maxConcurrent int) *Limiter {
+	l := &Limiter{now: time.Now}
+	l.requests.reset(float64(requestsPerMinute), l.now())
+	l.tokens.reset(float64(tokensPerMinute), l.now())
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+// Acquire blocks until a request estimated at tokens input tokens fits the budget.
+// The returned release function must be called when the request finishes.
+func (l *Limiter) Acquire(ctx context.Context, tokens int) (func(), error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+
+	for {
+		wait := l.reserve(float64(tokens))
+		if wait <= 0 {
+			return release, nil
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			release()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// reserve takes capacity if available, otherwise returns how long to wait before retrying
+func (l *Limiter) reserve(tokens float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Before(l.blockedUntil) {
+		return l.blockedUntil.Sub(now)
+	}
+
+	l.requests.refill(now)
+	l.tokens.refill(now)
+	wait := l.requests.waitFor(1)
+	if tokenWait := l.tokens.waitFor(tokens); tokenWait > wait {
+		wait = tokenWait
+	}
+	if wait > 0 {
+		return wait
+	}
+
+	l.requests.take(1)
+	l.tokens.take(tokens)
+	return 0
+}
+
+// Observe updates the budget from Anthropic rate limit response headers
+func (l *Limiter) Observe(statusCode int, header http.Header) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.requests.observe(header, "anthropic-ratelimit-requests", now)
+	l.tokens.observe(header, "anthropic-ratelimit-input-tokens", now)
+	l.tokens.observe(header, "anthropic-ratelimit-tokens", now)
+
+	if statusCode == http.StatusTooManyRequests {
+		if seconds, err := strconv.Atoi(header.Get("retry-after")); err == nil && seconds > 0 {
+			until := now.Add(time.Duration(seconds) * time.Second)
+			if until.After(l.blockedUntil) {
+				l.blockedUntil = until
+			}
+		}
+	}
+}
+
+// bucket is a token bucket refilled continuously at capacity per minute
+type bucket struct {
+	capacity float64
+	level    float64
+	updated  time.Time
+}
+
+func (b *bucket) reset(capacity float64, now time.Time) {
+	b.capacity = capacity
+	b.level = capacity
+	b.updated = now
+}
+
+func (b *bucket) refill(now time.Time) {
+	if b.capacity <= 0 {
+		return
+	}
+	elapsed := now.Sub(b.updated).Minutes()
+	b.level += elapsed * b.capacity
+	if b.level > b.capacity {
+		b.level = b.capacity
+	}
+	b.updated = now
+}
+
+func (b *bucket) waitFor(amount float64) time.Duration {
+	if b.capacity <= 0 {
+		return 0
+	}
+	if amount > b.capacity {
+		// A single oversized request can never fit; let it through once the bucket is full
+		amount = b.capacity
+	}
+	if b.level >= amount {
+		return 0
+	}
+	missing := amount - b.level
+	return time.Duration(missing / b.capacity * float64(time.Minute))
+}
+
+func (b *bucket) take(amount float64) {
+	if b.capacity <= 0 {
+		return
+	}
+	b.level -= amount
+}
+
+// observe applies <prefix>-limit and <prefix>-remaining headers
+func (b *bucket) observe(header http.Header, prefix string, now time.Time) {
+	if limit, err := strconv.ParseFloat(header.Get(prefix+"-limit"), 64); err == nil && limit > 0 && b.capacity <= 0 {
+		b.reset(limit, now)
+	}
+	if remaining, err := strconv.ParseFloat(header.Get(prefix+"-remaining"), 64); err == nil && b.capacity > 0 {
+		b.refill(now)
+		if remaining < b.level {
+			b.level = remaining
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package redact
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// AuditEntry records the redactions applied to one tool result; the secrets themselves are never logged
+type AuditEntry struct {
+	Time       time.Time   `json:"time"`
+	Tool       string      `json:"tool"`
+	WorkingDir string      `json:"working_dir"`
+	Redactions []Redaction `json:"redactions"`
+}
+
+// AuditLog appends redaction records to a JSON lines file
+type AuditLog struct {
+	path string
+}
+
+// NewAuditLog creates an audit log writing to path
+func NewAuditLog(path string) *AuditLog {
+	return &AuditLog{path: path}
+}
+
+// Record appends an entry to the log
+func (l *AuditLog) Record(entry AuditEntry) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open redaction audit log: %w", err)
+	}
+	defer file.Close()
+
+	if entry.Time.IsZero() {
+		entry.Time = time.Now()
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	_, err = file.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,120 @@
+package redact
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// minEnvSecretLength keeps short, guessable environment values from being redacted everywhere
+const minEnvSecretLength = 8
+
+// Rule is a named pattern for one kind of secret
+type Rule struct {
+	Name    string
+	Pattern *regexp.Regexp
+	Group   int // Submatch holding the secret; 0 redacts the whole match
+}
+
+// DefaultRules cover common API keys, tokens and credentials
+var DefaultRules = []Rule{
+	{Name: "private-key", Pattern: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`)},
+	{Name: "anthropic-key", Pattern: regexp.MustCompile(`sk-ant-[A-Za-z0-9_\-]{20,}`)},
+	{Name: "openai-key", Pattern: regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_\-]{20,}`)},
+	{Name: "aws-access-key", Pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{Name: "aws-secret-key", Pattern: regexp.MustCompile(`(?i)aws_secret_access_key["']?\s*[=:]\s*["']?([A-Za-z0-9/+=]{40})`), Group: 1},
+	{Name: "github-token", Pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{Name: "slack-token", Pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9\-]{10,}`)},
+	{Name: "google-api-key", Pattern: regexp.MustCompile(`\bAIza[0-9A-Za-z_\-]{35}`)},
+	{Name: "stripe-key", Pattern: regexp.MustCompile(`\b(?:sk|rk)_(?:live|test)_[0-9A-Za-z]{16,}`)},
+	{Name: "jwt", Pattern: regexp.MustCompile(`\beyJ[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}\.[A-Za-z0-9_\-]{10,}`)},
+	{Name: "bearer-token", Pattern: regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9\-._~+/]{20,}=*)`), Group: 1},
+	{Name: "url-credentials", Pattern: regexp.MustCompile(`[a-z][a-z0-9+.\-]*://[^\s:/@]+:([^\s@/]{3,})@`), Group: 1},
+	// .env style assignments such as DATABASE_PASSWORD=...; names must be upper case so code identifiers are left alone
+	{Name: "secret-assignment", Pattern: regexp.MustCompile(`\b[A-Z0-9_]*(?:API_?KEY|SECRET|TOKEN|PASSWORD|PASSWD|CREDENTIALS?)[A-Z0-9_]*["']?\s*[=:]\s*["']?([A-Za-z0-9_\-+/=.~]{8,})`), Group: 1},
+}
+
+// Redaction counts the secrets replaced by one rule
+type Redaction struct {
+	Rule  string `json:"rule"`
+	Count int    `json:"count"`
+}
+
+// Redactor replaces secrets in text with placeholders
+type Redactor struct {
+	rules     []Rule
+	envValues map[string]string // secret value -> environment variable name
+}
+
+// New creates a Redactor with the default rules plus the values of sensitive environment variables
+func New() *Redactor {
+	r := &Redactor{rules: DefaultRules, envValues: make(map[string]string)}
+	for _, entry := range os.Environ() {
+		name, value, ok := strings.Cut(entry, "=")
+		if ok && len(value) >= minEnvSecretLength && IsSensitiveName(name) {
+			r.envValues[value] = name
+		}
+	}
+	return r
+}
+
+// IsSensitiveName reports whether an environment variable name suggests a secret
+func IsSensitiveName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, marker := range []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL"} {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns text with secrets replaced by [REDACTED:<rule>] and a summary of what was replaced
+func (r *Redactor) Redact(text string) (string, []Redaction) {
+	counts := make(map[string]int)
+
+	// Known environment values first, so they are labelled with their variable name
+	for value, name := range r.envValues {
+		if n := strings.Count(text, value); n > 0 {
+			text = strings.ReplaceAll(text, value, placeholder("env:"+name))
+			counts["env:"+name] += n
+		}
+	}
+
+	for _, rule := range r.rules {
+		text = rule.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+			if rule.Group == 0 {
+				counts[rule.Name]++
+				return placeholder(rule.Name)
+			}
+			sub := rule.Pattern.FindStringSubmatchIndex(match)
+			start, end := sub[2*rule.Group], sub[2*rule.Group+1]
+			if start < 0 || strings.HasPrefix(match[start:end], "[REDACTED") {
+				return match
+			}
+			counts[rule.Name]++
+			return match[:start] + placeholder(rule.Name) + match[end:]
+		})
+	}
+
+	redactions := make([]Redaction, 0, len(counts))
+	for rule, count := range counts {
+		redactions = append(redactions, Redaction{Rule: rule, Count: count})
+	}
+	sort.Slice(redactions, func(i, j int) bool { return redactions[i].Rule < redactions[j].Rule })
+	return text, redactions
+}
+
+// Total returns the number of secrets replaced across redactions
+func Total(redactions []Redaction) int {
+	total := 0
+	for _, redaction := range redactions {
+		total += redaction.Count
+	}
+	return total
+}
+
+func placeholder(rule string) string {
+	return "[REDACTED:" + rule + "]"
+}
@@ -0,0 +1,66 @@
+package session
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter selects sessions for bulk operations
+type Filter struct {
+	OlderThan time.Duration // only sessions idle for at least this long (0 = any age)
+	Project   string        // only sessions whose working dir is this directory or inside it
+}
+
+// Matches reports whether sess satisfies every criterion of the filter
+func (f Filter) Matches(sess *Session) bool {
+	if f.OlderThan > 0 && time.Since(sess.UpdatedAt) < f.OlderThan {
+		return false
+	}
+	if f.Project != "" {
+		project, err := filepath.Abs(f.Project)
+		if err != nil {
+			return false
+		}
+		dir, err := filepath.Abs(sess.WorkingDir)
+		if err != nil {
+			return false
+		}
+		if dir != project && !strings.HasPrefix(dir, project+string(filepath.Separator)) {
+			return false
+		}
+	}
+	return true
+}
+
+// IsEmpty reports whether the filter has no criteria
+func (f Filter) IsEmpty() bool {
+	return f.OlderThan == 0 && f.Project == ""
+}
+
+// ParseAge parses durations like "90m", "12h", "30d" or "2w"
+func ParseAge(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+
+	units := map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour}
+	for suffix, unit := range units {
+		if strings.HasSuffix(value, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(value, suffix))
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid age %q", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 12h, 30d, 2w)", value)
+	}
+	return d, nil
+}
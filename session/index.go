@@ -0,0 +1,217 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const indexFile = "index.json"
+
+// IndexEntry is the lightweight record kept for every session so listing and search
+// don't need to load full conversations
+type IndexEntry struct {
+	ID         string    `json:"id"`
+	Title      string    `json:"title,omitempty"`
+	WorkingDir string    `json:"working_dir"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	TokenCount int       `json:"token_count,omitempty"`
+	Messages   int       `json:"messages"`
+	State      State     `json:"state"`
+}
+
+// String formats the entry as one line for session listings
+func (e IndexEntry) String() string {
+	title := e.Title
+	if title == "" {
+		title = "(untitled)"
+	}
+	return fmt.Sprintf("%s  %-8s  %s  %6d tok  %-40s  %s", e.ID, e.State, e.UpdatedAt.Format("2006-01-02 15:04"), e.TokenCount, title, e.WorkingDir)
+}
+
+func entryFor(sess *Session) IndexEntry {
+	return IndexEntry{
+		ID:         sess.ID,
+		Title:      sess.Title,
+		WorkingDir: sess.WorkingDir,
+		CreatedAt:  sess.CreatedAt,
+		UpdatedAt:  sess.UpdatedAt,
+		TokenCount: sess.TokenCount,
+		Messages:   len(sess.Messages),
+		State:      sess.State(),
+	}
+}
+
+// Index returns every indexed session, newest first, rebuilding the index if it's missing
+func (s *Store) Index() ([]IndexEntry, error) {
+	entries, err := s.readIndex()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]IndexEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UpdatedAt.After(list[j].UpdatedAt)
+	})
+	return list, nil
+}
+
+// Search fuzzy-matches query against session titles and working directories.
+// Results are ordered by match quality, then recency.
+func (s *Store) Search(query string, states ...State) ([]IndexEntry, error) {
+	entries, err := s.Index()
+	if err != nil {
+		return nil, err
+	}
+
+	allowed := make(map[State]bool, len(states))
+	for _, state := range states {
+		allowed[state] = true
+	}
+
+	type scored struct {
+		entry IndexEntry
+		score int
+	}
+	var matches []scored
+	for _, entry := range entries {
+		if len(allowed) > 0 && !allowed[entry.State] {
+			continue
+		}
+		if query == "" {
+			matches = append(matches, scored{entry, 0})
+			continue
+		}
+		score := max(fuzzyScore(query, entry.Title), fuzzyScore(query, entry.WorkingDir))
+		if score > 0 {
+			matches = append(matches, scored{entry, score})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	results := make([]IndexEntry, len(matches))
+	for i, match := range matches {
+		results[i] = match.entry
+	}
+	return results, nil
+}
+
+// fuzzyScore returns 0 when query's characters don't appear in order in text, and a higher
+// score for substring matches, matches at word starts, and consecutive runs
+func fuzzyScore(query, text string) int {
+	query = strings.ToLower(strings.TrimSpace(query))
+	lower := strings.ToLower(text)
+	if query == "" || lower == "" {
+		return 0
+	}
+	if idx := strings.Index(lower, query); idx >= 0 {
+		score := 1000 + len(query)*10
+		if idx == 0 || !unicode.IsLetter(rune(lower[idx-1])) {
+			score += 100
+		}
+		return score
+	}
+
+	score, pos, run := 0, 0, 0
+	runes := []rune(lower)
+	for _, qc := range query {
+		if unicode.IsSpace(qc) {
+			continue
+		}
+		found := false
+		for pos < len(runes) {
+			c := runes[pos]
+			pos++
+			if c == qc {
+				run++
+				score += 1 + run*2
+				if pos == 1 || !unicode.IsLetter(runes[pos-2]) {
+					score += 5
+				}
+				found = true
+				break
+			}
+			run = 0
+		}
+		if !found {
+			return 0
+		}
+	}
+	return score
+}
+
+func (s *Store) updateIndex(change func(map[string]IndexEntry)) error {
+	entries, err := s.readIndex()
+	if err != nil {
+		return err
+	}
+	change(entries)
+	return s.writeIndex(entries)
+}
+
+func (s *Store) readIndex() (map[string]IndexEntry, error) {
+	data, err := os.ReadFile(filepath.Join(s.root, indexFile))
+	if os.IsNotExist(err) {
+		return s.rebuildIndex()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session index: %w", err)
+	}
+
+	var list []IndexEntry
+	if err := json.Unmarshal(data, &list); err != nil {
+		// A corrupt index is only a cache; rebuild it from the session files
+		return s.rebuildIndex()
+	}
+	entries := make(map[string]IndexEntry, len(list))
+	for _, entry := range list {
+		entries[entry.ID] = entry
+	}
+	return entries, nil
+}
+
+func (s *Store) rebuildIndex() (map[string]IndexEntry, error) {
+	sessions, err := s.List(StateActive, StateArchived, StateDeleted)
+	if err != nil {
+		return nil, err
+	}
+	entries := make(map[string]IndexEntry, len(sessions))
+	for _, sess := range sessions {
+		entries[sess.ID] = entryFor(sess)
+	}
+	return entries, nil
+}
+
+func (s *Store) writeIndex(entries map[string]IndexEntry) error {
+	list := make([]IndexEntry, 0, len(entries))
+	for _, entry := range entries {
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UpdatedAt.After(list[j].UpdatedAt)
+	})
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session index: %w", err)
+	}
+	if err := os.MkdirAll(s.root, 0o755); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+	path := filepath.Join(s.root, indexFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write session index: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
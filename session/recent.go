@@ -0,0 +1,42 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// RecentDirsKeep is how many recently used working directories are remembered
+const RecentDirsKeep = 10
+
+// RecentDirs returns the working directories recorded in path, most recent first; a missing file means none
+func RecentDirs(path string) []string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	if json.Unmarshal(data, &dirs) != nil {
+		return nil
+	}
+	return dirs
+}
+
+// AddRecentDir moves dir to the front of the list in path, dropping the oldest entries past RecentDirsKeep
+func AddRecentDir(path, dir string) error {
+	dirs := slices.DeleteFunc(RecentDirs(path), func(d string) bool { return d == dir })
+	dirs = append([]string{dir}, dirs...)
+	if len(dirs) > RecentDirsKeep {
+		dirs = dirs[:RecentDirsKeep]
+	}
+	data, err := json.MarshalIndent(dirs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode recent directories: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create directory for recent directories: %w", err)
+	}
+	return os.WriteFile(path, data, 0600)
+}
@@ -0,0 +1,88 @@
+package session
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// State describes where a session currently lives in the store
+type State string
+
+const (
+	StateActive   State = "active"
+	StateArchived State = "archived"
+	StateDeleted  State = "deleted"
+)
+
+// Session is a persisted conversation
+type Session struct {
+	ID         string                   `json:"id"`
+	Title      string                   `json:"title,omitempty"`
+	WorkingDir string                   `json:"working_dir"`
+	TokenCount int                      `json:"token_count,omitempty"`
+	CreatedAt  time.Time                `json:"created_at"`
+	UpdatedAt  time.Time                `json:"updated_at"`
+	ArchivedAt *time.Time               `json:"archived_at,omitempty"`
+	DeletedAt  *time.Time               `json:"deleted_at,omitempty"`
+	Messages   []anthropic.MessageParam `json:"messages"`
+	Artifacts  []Artifact               `json:"artifacts,omitempty"`
+	Prompt     *PromptOverride          `json:"prompt,omitempty"`
+	Todos      []Todo                   `json:"todos,omitempty"`
+}
+
+// PromptOverride changes the system prompt for one session, set with /system
+type PromptOverride struct {
+	Replace string   `json:"replace,omitempty"` // Used instead of the configured system prompt when set
+	Append  []string `json:"append,omitempty"`  // Added after the system prompt, in order
+}
+
+// Todo is an item of the model's task list, kept with manage_todos
+type Todo struct {
+	Content string `json:"content"`
+	Status  string `json:"status"`
+}
+
+// Artifact is a generated non-code output (report, diagram, docs) written during the session
+type Artifact struct {
+	Path        string    `json:"path"`
+	Kind        string    `json:"kind,omitempty"`
+	Description string    `json:"description,omitempty"`
+	Bytes       int       `json:"bytes"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// New creates an empty session for workingDir
+func New(workingDir string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:         newID(now),
+		WorkingDir: workingDir,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		Messages:   []anthropic.MessageParam{},
+	}
+}
+
+// State reports whether the session is active, archived or in the trash
+func (s *Session) State() State {
+	switch {
+	case s.DeletedAt != nil:
+		return StateDeleted
+	case s.ArchivedAt != nil:
+		return StateArchived
+	default:
+		return StateActive
+	}
+}
+
+func newID(now time.Time) string {
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return now.Format("20060102-150405")
+	}
+	return fmt.Sprintf("%s-%s", now.Format("20060102-150405"), hex.EncodeToString(suffix))
+}
@@ -0,0 +1,206 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	activeDir  = "."
+	archiveDir = "archive"
+	trashDir   = "trash"
+)
+
+// Store persists sessions as JSON files, with archive and trash subdirectories
+type Store struct {
+	root string
+}
+
+// NewStore creates a store rooted at dir
+func NewStore(dir string) *Store {
+	return &Store{root: dir}
+}
+
+// Save writes the session into the directory matching its state
+func (s *Store) Save(sess *Session) error {
+	sess.UpdatedAt = time.Now()
+	return s.write(sess)
+}
+
+// write persists the session without touching its activity timestamp
+func (s *Store) write(sess *Session) error {
+	dir := s.dirFor(sess.State())
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create session directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(sess, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode session: %w", err)
+	}
+
+	// Write through a temp file so a crash never leaves a truncated session
+	path := filepath.Join(dir, sess.ID+".json")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write session: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return s.updateIndex(func(entries map[string]IndexEntry) {
+		entries[sess.ID] = entryFor(sess)
+	})
+}
+
+// Load reads a session by ID from any state directory
+func (s *Store) Load(id string) (*Session, error) {
+	path, err := s.find(id)
+	if err != nil {
+		return nil, err
+	}
+	return readSession(path)
+}
+
+// List returns sessions in the given states, newest first
+func (s *Store) List(states ...State) ([]*Session, error) {
+	var sessions []*Session
+	for _, state := range states {
+		entries, err := os.ReadDir(s.dirFor(state))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read session directory: %w", err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || entry.Name() == indexFile || !strings.HasSuffix(entry.Name(), ".json") {
+				continue
+			}
+			sess, err := readSession(filepath.Join(s.dirFor(state), entry.Name()))
+			if err != nil {
+				continue
+			}
+			sessions = append(sessions, sess)
+		}
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].UpdatedAt.After(sessions[j].UpdatedAt)
+	})
+	return sessions, nil
+}
+
+// Archive moves an active session into the archive
+func (s *Store) Archive(id string) error {
+	return s.transition(id, func(sess *Session) {
+		now := time.Now()
+		sess.ArchivedAt = &now
+		sess.DeletedAt = nil
+	})
+}
+
+// Delete moves a session into the trash, where it stays recoverable until purged
+func (s *Store) Delete(id string) error {
+	return s.transition(id, func(sess *Session) {
+		now := time.Now()
+		sess.DeletedAt = &now
+	})
+}
+
+// Restore brings an archived or deleted session back to the active list
+func (s *Store) Restore(id string) error {
+	return s.transition(id, func(sess *Session) {
+		sess.ArchivedAt = nil
+		sess.DeletedAt = nil
+	})
+}
+
+// Purge permanently removes trashed sessions deleted more than retention ago
+func (s *Store) Purge(retention time.Duration) ([]string, error) {
+	trashed, err := s.List(StateDeleted)
+	if err != nil {
+		return nil, err
+	}
+
+	cutoff := time.Now().Add(-retention)
+	var purged []string
+	for _, sess := range trashed {
+		if sess.DeletedAt == nil || sess.DeletedAt.After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(s.dirFor(StateDeleted), sess.ID+".json")); err != nil {
+			return purged, fmt.Errorf("failed to purge session %s: %w", sess.ID, err)
+		}
+		purged = append(purged, sess.ID)
+	}
+
+	err = s.updateIndex(func(entries map[string]IndexEntry) {
+		for _, id := range purged {
+			delete(entries, id)
+		}
+	})
+	return purged, err
+}
+
+// transition applies change to a session and moves its file to the matching directory
+func (s *Store) transition(id string, change func(*Session)) error {
+	oldPath, err := s.find(id)
+	if err != nil {
+		return err
+	}
+	sess, err := readSession(oldPath)
+	if err != nil {
+		return err
+	}
+
+	// State changes don't count as activity, so age-based filters keep working
+	change(sess)
+	if err := s.write(sess); err != nil {
+		return err
+	}
+
+	newPath := filepath.Join(s.dirFor(sess.State()), sess.ID+".json")
+	if newPath != oldPath {
+		return os.Remove(oldPath)
+	}
+	return nil
+}
+
+func (s *Store) find(id string) (string, error) {
+	for _, state := range []State{StateActive, StateArchived, StateDeleted} {
+		path := filepath.Join(s.dirFor(state), id+".json")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("session %s not found", id)
+}
+
+func (s *Store) dirFor(state State) string {
+	switch state {
+	case StateArchived:
+		return filepath.Join(s.root, archiveDir)
+	case StateDeleted:
+		return filepath.Join(s.root, trashDir)
+	default:
+		return filepath.Join(s.root, activeDir)
+	}
+}
+
+func readSession(path string) (*Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read session: %w", err)
+	}
+	var sess Session
+	if err := json.Unmarshal(data, &sess); err != nil {
+		return nil, fmt.Errorf("failed to parse session %s: %w", filepath.Base(path), err)
+	}
+	return &sess, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"anthropic-chat/config"
+	"anthropic-chat/session"
+)
+
+// runSessionsCommand implements `goocode sessions <list|archive|delete|restore|purge>`
+func runSessionsCommand(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: goocode sessions <list|search|archive|delete|restore|purge> [flags] [session-id...]")
+	}
+
+	cfg := config.NewConfig()
+	store := session.NewStore(cfg.Session.Dir)
+
+	action := args[0]
+	flags := flag.NewFlagSet("sessions "+action, flag.ContinueOnError)
+	olderThan := flags.String("older-than", "", "Only sessions idle for at least this long (e.g. 12h, 30d, 2w)")
+	project := flags.String("project", "", "Only sessions whose working directory is inside this path")
+	all := flags.Bool("all", false, "For list: include archived and deleted sessions; for purge: ignore the trash period")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	age, err := session.ParseAge(*olderThan)
+	if err != nil {
+		return err
+	}
+	filter := session.Filter{OlderThan: age, Project: *project}
+
+	switch action {
+	case "list":
+		states := []session.State{session.StateActive}
+		if *all {
+			states = append(states, session.StateArchived, session.StateDeleted)
+		}
+		return listSessions(store, filter, states)
+	case "search":
+		states := []session.State{session.StateActive, session.StateArchived}
+		if *all {
+			states = append(states, session.StateDeleted)
+		}
+		matches, err := store.Search(strings.Join(flags.Args(), " "), states...)
+		if err != nil {
+			return err
+		}
+		for _, entry := range matches {
+			if filter.Matches(&session.Session{WorkingDir: entry.WorkingDir, UpdatedAt: entry.UpdatedAt}) {
+				fmt.Println(entry)
+			}
+		}
+		return nil
+	case "archive":
+		return bulkSessions(store, "Archived", flags.Args(), filter, []session.State{session.StateActive}, store.Archive)
+	case "delete":
+		return bulkSessions(store, "Moved to trash", flags.Args(), filter, []session.State{session.StateActive, session.StateArchived}, store.Delete)
+	case "restore":
+		return bulkSessions(store, "Restored", flags.Args(), filter, []session.State{session.StateArchived, session.StateDeleted}, store.Restore)
+	case "purge":
+		retention := time.Duration(cfg.Session.TrashDays) * 24 * time.Hour
+		if *all {
+			retention = 0
+		}
+		purged, err := store.Purge(retention)
+		for _, id := range purged {
+			fmt.Printf("Purged %s\n", id)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d session(s) permanently removed\n", len(purged))
+		return nil
+	default:
+		return fmt.Errorf("unknown sessions action %q", action)
+	}
+}
+
+func listSessions(store *session.Store, filter session.Filter, states []session.State) error {
+	matches, err := store.Search("", states...)
+	if err != nil {
+		return err
+	}
+	for _, entry := range matches {
+		if filter.Matches(&session.Session{WorkingDir: entry.WorkingDir, UpdatedAt: entry.UpdatedAt}) {
+			fmt.Println(entry)
+		}
+	}
+	return nil
+}
+
+// bulkSessions applies op to the given IDs, or to every session in states matching filter when no IDs are given
+func bulkSessions(store *session.Store, verb string, ids []string, filter session.Filter, states []session.State, op func(string) error) error {
+	if len(ids) == 0 {
+		if filter.IsEmpty() {
+			return fmt.Errorf("specify session IDs or a filter (--older-than, --project)")
+		}
+		sessions, err := store.List(states...)
+		if err != nil {
+			return err
+		}
+		for _, sess := range sessions {
+			if filter.Matches(sess) {
+				ids = append(ids, sess.ID)
+			}
+		}
+	}
+
+	failed := 0
+	for _, id := range ids {
+		if err := op(id); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Printf("%s %s\n", verb, id)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d session(s) failed", failed, len(ids))
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/config"
+	"anthropic-chat/provider"
+)
+
+// runSetupCommand implements `goocode setup`, writing the user settings file
+func runSetupCommand(args []string) error {
+	flags := flag.NewFlagSet("setup", flag.ContinueOnError)
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	return runSetupWizard(bufio.NewScanner(os.Stdin))
+}
+
+// needsSetup reports whether this is a first run: no API key from any source, no settings file yet,
+// and someone at the terminal to ask
+func needsSetup(providerName string) bool {
+	if providerName != "anthropic" || os.Getenv("ANTHROPIC_API_KEY") != "" {
+		return false
+	}
+	// Bedrock and Vertex authenticate with cloud credentials, not an API key
+	if platform := os.Getenv("GOOCODE_PLATFORM"); platform != "" && platform != provider.PlatformAnthropic {
+		return false
+	}
+	if _, err := os.Stat(config.SettingsFile()); err == nil {
+		return false
+	}
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// runSetupWizard asks for the API key, default model and default working directory, saves them
+// to the settings file and applies them to this process
+func runSetupWizard(scanner *bufio.Scanner) error {
+	path := config.SettingsFile()
+	settings, err := config.ReadSettings(path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Setting up GooCode; answers are saved to %s\n", path)
+
+	ask := func(prompt, current string) (string, error) {
+		if current != "" {
+			prompt += fmt.Sprintf(" [%s]", current)
+		}
+		fmt.Print(prompt + ": ")
+		if !scanner.Scan() {
+			return "", fmt.Errorf("failed to read input")
+		}
+		if answer := strings.TrimSpace(scanner.Text()); answer != "" {
+			return answer, nil
+		}
+		return current, nil
+	}
+
+	currentKey := ""
+	if settings.APIKey != "" {
+		currentKey = "keep current"
+	}
+	for {
+		key, err := ask("Anthropic API key (from console.anthropic.com)", currentKey)
+		if err != nil {
+			return err
+		}
+		if key != "keep current" {
+			settings.APIKey = key
+		}
+		if settings.APIKey != "" {
+			break
+		}
+		fmt.Println("An API key is required.")
+	}
+
+	model, err := ask("Default model", settings.Model)
+	if err != nil {
+		return err
+	}
+	if model == "" && settings.Model == "" {
+		model = config.DefaultModel
+	}
+	if _, known := config.LookupModel(model); !known {
+		fmt.Printf("Warning: %s is not in the model catalog\n", model)
+	}
+	settings.Model = model
+
+	for {
+		dir, err := ask("Default working directory (Enter for the directory you launch from)", settings.WorkingDir)
+		if err != nil {
+			return err
+		}
+		if dir == "" {
+			break
+		}
+		resolved, err := resolveDirectory(dir)
+		if err == nil {
+			resolved, err = filepath.Abs(resolved)
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			continue
+		}
+		settings.WorkingDir = resolved
+		break
+	}
+
+	if err := config.WriteSettings(path, settings); err != nil {
+		return err
+	}
+	config.ApplySettings(path, settings)
+	fmt.Printf("Saved %s\n\n", path)
+	return nil
+}
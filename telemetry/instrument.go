@@ -0,0 +1,132 @@
+package telemetry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentSet is the duration histogram and outcome counter recorded for one kind of operation
+type instrumentSet struct {
+	duration metric.Float64Histogram
+	count    metric.Int64Counter
+}
+
+type instruments struct {
+	turns  instrumentSet
+	api    instrumentSet
+	tools  instrumentSet
+	tokens metric.Int64Counter
+}
+
+var (
+	instrumentsOnce sync.Once
+	global          instruments
+)
+
+// meters creates the instruments on first use; the global meter provider forwards them to the exporter once Setup has run
+func meters() *instruments {
+	instrumentsOnce.Do(func() {
+		meter := otel.Meter(instrumentationName)
+		global.turns = newInstrumentSet(meter, "goocode.turn", "user turns")
+		global.api = newInstrumentSet(meter, "goocode.api", "model API requests")
+		global.tools = newInstrumentSet(meter, "goocode.tool", "tool executions")
+		global.tokens, _ = meter.Int64Counter("goocode.api.tokens",
+			metric.WithDescription("Tokens used by model API requests, by token type"),
+			metric.WithUnit("{token}"))
+	})
+	return &global
+}
+
+func newInstrumentSet(meter metric.Meter, prefix, what string) instrumentSet {
+	duration, _ := meter.Float64Histogram(prefix+".duration",
+		metric.WithDescription("Duration of "+what),
+		metric.WithUnit("s"))
+	count, _ := meter.Int64Counter(prefix+".count",
+		metric.WithDescription("Number of "+what+", by outcome"),
+		metric.WithUnit("{call}"))
+	return instrumentSet{duration: duration, count: count}
+}
+
+// Operation is a traced unit of work whose duration and outcome are recorded when it ends
+type Operation struct {
+	ctx     context.Context
+	span    trace.Span
+	set     instrumentSet
+	attrs   []attribute.KeyValue
+	started time.Time
+	once    sync.Once
+}
+
+func start(ctx context.Context, name string, set instrumentSet, attrs ...attribute.KeyValue) (context.Context, *Operation) {
+	ctx, span := otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, &Operation{ctx: ctx, span: span, set: set, attrs: attrs, started: time.Now()}
+}
+
+// StartTurn traces one user turn, including every model request and tool call it makes
+func StartTurn(ctx context.Context, turn int) (context.Context, *Operation) {
+	ctx, op := start(ctx, "goocode.turn", meters().turns)
+	op.span.SetAttributes(attribute.Int("goocode.turn", turn))
+	return ctx, op
+}
+
+// StartAPICall traces a model API request such as chat or count_tokens
+func StartAPICall(ctx context.Context, operation, model string) (context.Context, *Operation) {
+	return start(ctx, operation+" "+model, meters().api,
+		attribute.String("gen_ai.system", "anthropic"),
+		attribute.String("gen_ai.operation.name", operation),
+		attribute.String("gen_ai.request.model", model),
+	)
+}
+
+// StartTool traces a tool execution
+func StartTool(ctx context.Context, name string) (context.Context, *Operation) {
+	return start(ctx, "execute_tool "+name, meters().tools,
+		attribute.String("gen_ai.operation.name", "execute_tool"),
+		attribute.String("gen_ai.tool.name", name),
+	)
+}
+
+// SetAttributes adds attributes to the operation's span
+func (o *Operation) SetAttributes(attrs ...attribute.KeyValue) {
+	o.span.SetAttributes(attrs...)
+}
+
+// RecordUsage attaches token counts to the span and adds them to the token counter
+func (o *Operation) RecordUsage(input, output, cacheRead, cacheCreation int64) {
+	o.span.SetAttributes(
+		attribute.Int64("gen_ai.usage.input_tokens", input),
+		attribute.Int64("gen_ai.usage.output_tokens", output),
+		attribute.Int64("gen_ai.usage.cache_read_input_tokens", cacheRead),
+		attribute.Int64("gen_ai.usage.cache_creation_input_tokens", cacheCreation),
+	)
+	tokens := meters().tokens
+	for tokenType, count := range map[string]int64{"input": input, "output": output, "cache_read": cacheRead, "cache_creation": cacheCreation} {
+		if count > 0 {
+			attrs := append(o.attrs[:len(o.attrs):len(o.attrs)], attribute.String("gen_ai.token.type", tokenType))
+			tokens.Add(o.ctx, count, metric.WithAttributes(attrs...))
+		}
+	}
+}
+
+// End finishes the span and records the duration and outcome; only the first call has any effect
+func (o *Operation) End(err error) {
+	o.once.Do(func() {
+		outcome := "ok"
+		if err != nil {
+			outcome = "error"
+			o.span.RecordError(err)
+			o.span.SetStatus(codes.Error, err.Error())
+		}
+		attrs := metric.WithAttributes(append(o.attrs[:len(o.attrs):len(o.attrs)], attribute.String("outcome", outcome))...)
+		o.set.duration.Record(o.ctx, time.Since(o.started).Seconds(), attrs)
+		o.set.count.Add(o.ctx, 1, attrs)
+		o.span.End()
+	})
+}
@@ -0,0 +1,122 @@
+package tokenizer
+
+import (
+	"encoding/json"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Fixed costs the API adds around content, measured against its token counting endpoint
+const (
+	messageOverhead  = 4    // Role and turn markers of each message
+	blockOverhead    = 3    // Each content block beyond the first
+	toolUseOverhead  = 10   // Tool call framing around its name and input
+	toolDefOverhead  = 12   // Framing of each tool definition
+	toolUsePrompt    = 346  // System prompt the API adds when tools are offered
+	imageTokens      = 1600 // An image of typical size, for want of its dimensions
+	documentOverhead = 20   // Framing of a document block around its source
+)
+
+// CountJSON approximates the tokens of v serialized as JSON, as tool inputs and schemas are sent
+func CountJSON(v any) int {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0
+	}
+	return Count(string(data))
+}
+
+// Messages approximates the tokens of a conversation
+func Messages(messages []anthropic.MessageParam) int {
+	tokens := 0
+	for _, msg := range messages {
+		tokens += messageOverhead
+		for i, block := range msg.Content {
+			if i > 0 {
+				tokens += blockOverhead
+			}
+			tokens += contentBlock(block)
+		}
+	}
+	return tokens
+}
+
+// Tool approximates the tokens of one tool definition
+func Tool(name, description string, schema any) int {
+	return toolDefOverhead + Count(name) + Count(description) + CountJSON(schema)
+}
+
+// Tools approximates the tokens of the tool definitions in a request, including the tool use system
+// prompt the API adds when there are any
+func Tools(tools []anthropic.ToolParam) int {
+	if len(tools) == 0 {
+		return 0
+	}
+	tokens := toolUsePrompt
+	for _, tool := range tools {
+		tokens += Tool(tool.Name, tool.Description.Value, tool.InputSchema)
+	}
+	return tokens
+}
+
+// Request approximates the input tokens of a Messages API request
+func Request(params anthropic.MessageNewParams) int {
+	tokens := Messages(params.Messages)
+	for _, block := range params.System {
+		tokens += Count(block.Text)
+	}
+	var tools []anthropic.ToolParam
+	for _, tool := range params.Tools {
+		if tool.OfTool != nil {
+			tools = append(tools, *tool.OfTool)
+		} else {
+			tokens += CountJSON(tool)
+		}
+	}
+	return tokens + Tools(tools)
+}
+
+// CountParams approximates what the token counting endpoint would report for params
+func CountParams(params anthropic.MessageCountTokensParams) int {
+	tokens := Messages(params.Messages)
+	if params.System.OfString.Valid() {
+		tokens += Count(params.System.OfString.Value)
+	}
+	for _, block := range params.System.OfTextBlockArray {
+		tokens += Count(block.Text)
+	}
+	var tools []anthropic.ToolParam
+	for _, tool := range params.Tools {
+		if tool.OfTool != nil {
+			tools = append(tools, *tool.OfTool)
+		} else {
+			tokens += CountJSON(tool)
+		}
+	}
+	return tokens + Tools(tools)
+}
+
+func contentBlock(block anthropic.ContentBlockParamUnion) int {
+	switch {
+	case block.OfText != nil:
+		return Count(block.OfText.Text)
+	case block.OfToolUse != nil:
+		return toolUseOverhead + Count(block.OfToolUse.Name) + CountJSON(block.OfToolUse.Input)
+	case block.OfToolResult != nil:
+		tokens := toolUseOverhead
+		for _, content := range block.OfToolResult.Content {
+			switch {
+			case content.OfText != nil:
+				tokens += Count(content.OfText.Text)
+			case content.OfImage != nil:
+				tokens += imageTokens
+			}
+		}
+		return tokens
+	case block.OfImage != nil:
+		return imageTokens
+	case block.OfDocument != nil:
+		return documentOverhead + CountJSON(block.OfDocument)
+	}
+	return CountJSON(block)
+}
@@ -0,0 +1,127 @@
+package tokenizer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Count approximates how many tokens Claude's tokenizer splits text into, without a network call. It
+// walks the text the way a byte-pair tokenizer tends to cut it: a word with its leading space is one
+// token unless it is long, identifiers break at case changes, numbers go in groups of three digits,
+// punctuation pairs up, runs of spaces and newlines merge, and ideographs cost a token each.
+func Count(text string) int {
+	tokens := 0
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+		switch {
+		case r == ' ' && i+1 < len(text) && isWordStart(text[i+1:]):
+			i += size // A single space before a word travels with it
+		case isIdeograph(r):
+			tokens++
+			i += size
+		case unicode.IsLetter(r):
+			n, end := word(text[i:])
+			tokens += n
+			i += end
+		case unicode.IsDigit(r):
+			end := runWhile(text[i:], unicode.IsDigit)
+			tokens += (utf8.RuneCountInString(text[i:i+end]) + 2) / 3
+			i += end
+		case unicode.IsSpace(r):
+			end := runWhile(text[i:], unicode.IsSpace)
+			tokens += whitespace(text[i : i+end])
+			i += end
+		case r < utf8.RuneSelf:
+			end := runWhile(text[i:], isPunct)
+			tokens += (end + 1) / 2
+			i += end
+		default:
+			// Emoji and other symbols outside the common vocabulary come out as a few byte tokens
+			tokens += (size + 1) / 2
+			i += size
+		}
+	}
+	return tokens
+}
+
+// word counts the tokens of the identifier or word at the start of s and returns where it ends
+func word(s string) (int, int) {
+	tokens, end, part, upper, lower := 0, 0, 0, 0, 0
+	flush := func() {
+		switch {
+		case part == 0:
+		case upper == part && part > 1:
+			tokens += (part + 2) / 3 // UPPERCASE runs are rare in the vocabulary
+		case part <= 8:
+			tokens++
+		default:
+			tokens += 1 + (part-5)/4
+		}
+		if extended := part - upper - lower; extended > 0 {
+			tokens += extended / 2 // Accented and other non-ASCII letters cost extra
+		}
+		part, upper, lower = 0, 0, 0
+	}
+	prevLower := false
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if !unicode.IsLetter(r) || isIdeograph(r) {
+			break
+		}
+		if unicode.IsUpper(r) && prevLower {
+			flush() // camelCase boundary
+		}
+		part++
+		switch {
+		case r < utf8.RuneSelf && unicode.IsUpper(r):
+			upper++
+		case r < utf8.RuneSelf:
+			lower++
+		}
+		prevLower = unicode.IsLower(r)
+		end += size
+	}
+	flush()
+	return tokens, end
+}
+
+// whitespace counts a run of spaces, tabs and newlines: newlines merge in pairs, indentation in fours
+func whitespace(s string) int {
+	newlines, spaces, tabs := 0, 0, 0
+	for _, r := range s {
+		switch r {
+		case '\n':
+			newlines++
+		case '\t':
+			tabs++
+		default:
+			spaces++
+		}
+	}
+	return (newlines+1)/2 + (spaces+3)/4 + (tabs+1)/2
+}
+
+func runWhile(s string, f func(rune) bool) int {
+	end := 0
+	for end < len(s) {
+		r, size := utf8.DecodeRuneInString(s[end:])
+		if !f(r) {
+			break
+		}
+		end += size
+	}
+	return end
+}
+
+func isWordStart(s string) bool {
+	r, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsLetter(r) && !isIdeograph(r) || unicode.IsDigit(r)
+}
+
+func isPunct(r rune) bool {
+	return r < utf8.RuneSelf && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r)
+}
+
+func isIdeograph(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
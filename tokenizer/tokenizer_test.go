@@ -0,0 +1,125 @@
+package tokenizer
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// recordedCountsFile holds the token counting endpoint's answers for the samples below. Record them again
+// after changing a sample with GOOCODE_RECORD_TOKEN_COUNTS=true and ANTHROPIC_API_KEY set.
+var recordedCountsFile = filepath.Join("testdata", "api_counts.json")
+
+// countTolerance is the accuracy the tokenizer promises against the API
+const countTolerance = 0.02
+
+// samples cover the kinds of content a session sends: prose, code, JSON, command output, numbers and other scripts
+var samples = map[string]string{
+	"prose": strings.Repeat("The agent reads the files it needs, proposes a change, and waits for approval before writing anything. "+
+		"When the conversation grows past the context window, older turns are summarized so the session can continue.\n\n", 8),
+	"go": strings.Repeat(`// Resolve returns fullPath with symlinks resolved
+func Resolve(fullPath string) string {
+	rest := ""
+	for dir := fullPath; ; dir = filepath.Dir(dir) {
+		if real, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(real, rest)
+		}
+		if dir == filepath.Dir(dir) {
+			return fullPath
+		}
+		rest = filepath.Join(filepath.Base(dir), rest)
+	}
+}
+
+`, 6),
+	"json": strings.Repeat(`{"path":"internal/server/handler.go","edits":[{"old_text":"return nil, err","new_text":"return nil, fmt.Errorf(\"failed to load config: %w\", err)"}],"dry_run":false}`+"\n", 10),
+	"shell": strings.Repeat(`--- FAIL: TestHandler (0.02s)
+    handler_test.go:48: expected status 200, got 500
+FAIL
+FAIL	example.com/service/internal/server	0.214s
+ok  	example.com/service/internal/store	(cached)
+-rw-r--r--  1 dev  staff   4213 Oct 14 09:12 handler.go
+`, 8),
+	"numbers": strings.Repeat("2026-10-14T13:09:06Z 200 1843ms 10.0.3.17 4096 0.9873 -12.5e3 65535\n", 12),
+	"markdown": strings.Repeat("## Configuration\n\n- `GOOCODE_READ_ONLY`: Set to `true` to leave out every tool that changes files (default: false)\n"+
+		"- `GOOCODE_MAX_TOKENS`: Output tokens per response, **capped** by the model's limit\n\n| Flag | Meaning |\n|------|---------|\n| `-p` | One-shot prompt |\n\n", 6),
+	"cjk":   strings.Repeat("設定ファイルを読み込んでから、変更を提案します。承認されるまで何も書き込みません。\n", 10),
+	"camel": strings.Repeat("newRemoveDirectoryTool HTTPServerConfig parseJSONResponse XMLHttpRequest getUserMessage snake_case_name CONSTANT_VALUE\n", 10),
+}
+
+// sampleParams is the request each sample is counted in: one user message with the sample as its text
+func sampleParams(text string) anthropic.MessageCountTokensParams {
+	return anthropic.MessageCountTokensParams{
+		Model:    anthropic.ModelClaudeSonnet4_0,
+		Messages: []anthropic.MessageParam{anthropic.NewUserMessage(anthropic.NewTextBlock(text))},
+	}
+}
+
+func TestCountMatchesRecordedAPICounts(t *testing.T) {
+	if os.Getenv("GOOCODE_RECORD_TOKEN_COUNTS") == "true" {
+		recordCounts(t)
+	}
+	data, err := os.ReadFile(recordedCountsFile)
+	if os.IsNotExist(err) {
+		t.Skipf("%s has not been recorded; run with GOOCODE_RECORD_TOKEN_COUNTS=true and ANTHROPIC_API_KEY set", recordedCountsFile)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	var recorded map[string]int
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		t.Fatalf("failed to parse %s: %v", recordedCountsFile, err)
+	}
+
+	names := make([]string, 0, len(samples))
+	for name := range samples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			want, ok := recorded[name]
+			if !ok {
+				t.Fatalf("no recorded count; record the samples again")
+			}
+			got := CountParams(sampleParams(samples[name]))
+			if off := math.Abs(float64(got-want)) / float64(want); off > countTolerance {
+				t.Errorf("estimated %d tokens, the API counted %d (%.1f%% off, more than %.0f%%)", got, want, off*100, countTolerance*100)
+			}
+		})
+	}
+}
+
+// recordCounts asks the token counting endpoint for every sample and saves the answers
+func recordCounts(t *testing.T) {
+	t.Helper()
+	if os.Getenv("ANTHROPIC_API_KEY") == "" {
+		t.Fatal("GOOCODE_RECORD_TOKEN_COUNTS needs ANTHROPIC_API_KEY")
+	}
+	client := anthropic.NewClient()
+	recorded := map[string]int{}
+	for name, text := range samples {
+		count, err := client.Messages.CountTokens(context.Background(), sampleParams(text))
+		if err != nil {
+			t.Fatalf("failed to count %s: %v", name, err)
+		}
+		recorded[name] = int(count.InputTokens)
+	}
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(recordedCountsFile), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(recordedCountsFile, append(data, '\n'), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
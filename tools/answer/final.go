@@ -0,0 +1,88 @@
+package answer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"anthropic-chat/tools"
+	"anthropic-chat/utils"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// wrapField holds the answer when the user's schema isn't an object, since tool inputs must be
+const wrapField = "result"
+
+// FinalAnswerTool implements the final_answer tool, whose input is the run's structured answer
+type FinalAnswerTool struct {
+	schema  anthropic.ToolInputSchemaParam
+	wrapped bool // The user's schema describes the result field rather than the whole input
+}
+
+// NewFinalAnswerTool creates a final_answer tool taking input that matches the JSON Schema document
+func NewFinalAnswerTool(schemaJSON []byte) (*FinalAnswerTool, error) {
+	var document map[string]any
+	if err := json.Unmarshal(schemaJSON, &document); err != nil {
+		return nil, fmt.Errorf("invalid JSON schema: %w", err)
+	}
+	if unsupported := tools.UnsupportedKeywords(document); len(unsupported) > 0 {
+		// An answer that broke these rules would still be printed as valid
+		return nil, fmt.Errorf("the schema uses %s, which the answer check doesn't support; inline any $ref and describe the answer with type, properties, required, items, enum, const and length, bound and pattern limits", strings.Join(unsupported, ", "))
+	}
+	if schemaType, ok := document["type"]; ok && schemaType != "object" {
+		delete(document, "$schema")
+		wrapped, err := json.Marshal(map[string]any{
+			"type":       "object",
+			"properties": map[string]any{wrapField: document},
+			"required":   []string{wrapField},
+		})
+		if err != nil {
+			return nil, err
+		}
+		schema, err := utils.SchemaFromJSON(wrapped)
+		return &FinalAnswerTool{schema: schema, wrapped: true}, err
+	}
+	schema, err := utils.SchemaFromJSON(schemaJSON)
+	if err != nil {
+		return nil, err
+	}
+	return &FinalAnswerTool{schema: schema}, nil
+}
+
+// Name returns the tool name
+func (t *FinalAnswerTool) Name() string {
+	return "final_answer"
+}
+
+// Description returns the tool description
+func (t *FinalAnswerTool) Description() string {
+	if t.wrapped {
+		return "Submit the final answer, in the result field, once you have everything it needs. It is checked against the schema; if it doesn't match you get the problems back and must call this again with a corrected answer."
+	}
+	return "Submit the final answer once you have everything it needs; the input is the answer itself. It is checked against the schema; if it doesn't match you get the problems back and must call this again with a corrected answer."
+}
+
+// InputSchema returns the user's schema
+func (t *FinalAnswerTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return t.schema
+}
+
+// Execute records the answer; it has already been validated against the schema by the registry
+func (t *FinalAnswerTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	sink, ok := agent.(tools.AnswerSink)
+	if !ok {
+		return "", fmt.Errorf("structured answers are not supported here")
+	}
+	answer := input
+	if t.wrapped {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(input, &fields); err != nil {
+			return "", fmt.Errorf("failed to parse input: %w", err)
+		}
+		answer = fields[wrapField]
+	}
+	sink.SetAnswer(answer)
+	return "Answer recorded. You are done: don't call any more tools or repeat the answer.", nil
+}
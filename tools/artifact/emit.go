@@ -0,0 +1,58 @@
+package artifact
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// EmitArtifactTool implements the emit_artifact tool
+type EmitArtifactTool struct{}
+
+// NewEmitArtifactTool creates a new EmitArtifact tool instance
+func NewEmitArtifactTool() *EmitArtifactTool {
+	return &EmitArtifactTool{}
+}
+
+// Name returns the tool name
+func (t *EmitArtifactTool) Name() string {
+	return "emit_artifact"
+}
+
+// Description returns the tool description
+func (t *EmitArtifactTool) Description() string {
+	return "Save a generated non-code output (report, diagram source, generated documentation, analysis results) to the session's artifact directory instead of the source tree. The artifact is listed in the session manifest. Use regular file tools for source code."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *EmitArtifactTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.EmitArtifactInputSchema
+}
+
+// Execute writes the artifact
+func (t *EmitArtifactTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var emitInput schemas.EmitArtifactInput
+	if err := json.Unmarshal(input, &emitInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	if strings.TrimSpace(emitInput.Name) == "" {
+		return "", fmt.Errorf("name is required")
+	}
+
+	writer, ok := agent.(tools.ArtifactWriter)
+	if !ok {
+		return "", fmt.Errorf("artifacts are not supported here")
+	}
+	path, err := writer.WriteArtifact(emitInput.Name, emitInput.Kind, emitInput.Description, []byte(emitInput.Content))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Saved artifact %s (%s)", path, ui.FormatBytesPlain(int64(len(emitInput.Content)))), nil
+}
@@ -0,0 +1,101 @@
+package codeindex
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/codeindex"
+	"anthropic-chat/embeddings"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const defaultLimit = 5
+
+// SemanticSearchTool implements the semantic_search tool
+type SemanticSearchTool struct {
+	baseDir  string
+	embedder embeddings.Embedder
+}
+
+// NewSemanticSearchTool creates a new semantic_search tool keeping code indexes in baseDir
+func NewSemanticSearchTool(baseDir string, embedder embeddings.Embedder) *SemanticSearchTool {
+	return &SemanticSearchTool{baseDir: baseDir, embedder: embedder}
+}
+
+// Name returns the tool name
+func (t *SemanticSearchTool) Name() string {
+	return "semantic_search"
+}
+
+// Description returns the tool description
+func (t *SemanticSearchTool) Description() string {
+	return "Find code by meaning rather than exact text: describe what the code does and get the most relevant chunks with their file and line range. Use it to locate where something is implemented in an unfamiliar or large repository before reading files. The index is updated for changed files before each search."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *SemanticSearchTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.SemanticSearchInputSchema
+}
+
+// Execute refreshes the code index for the working directory and searches it
+func (t *SemanticSearchTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var searchInput schemas.SemanticSearchInput
+	if err := json.Unmarshal(input, &searchInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	if strings.TrimSpace(searchInput.Query) == "" {
+		return "", fmt.Errorf("query is required")
+	}
+	if filepath.IsAbs(searchInput.Path) {
+		// The index stores paths relative to the working directory
+		rel, err := filepath.Rel(agent.WorkingDir(), searchInput.Path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return "", fmt.Errorf("%s is outside the working directory: %w", searchInput.Path, tools.ErrPathNotAllowed)
+		}
+		searchInput.Path = rel
+	}
+	if searchInput.Path != "" {
+		if _, err := agent.ResolveFilePath(searchInput.Path); err != nil {
+			return "", err
+		}
+	}
+	limit := searchInput.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	index, err := codeindex.Open(t.baseDir, agent.WorkingDir(), t.embedder)
+	if err != nil {
+		return "", err
+	}
+	index.Skip = func(fullPath string, isDir bool) bool { return tools.Ignored(agent, fullPath, isDir) }
+	index.Redact = func(text string) string { return tools.RedactSecrets(agent, text) }
+	update, err := index.Update(ctx, func(source string, chunks int) {
+		tools.ReportProgress(agent, t.Name(), "indexed %s (%d chunks)", source, chunks)
+	})
+	if err != nil {
+		return "", err
+	}
+	results, err := index.Search(ctx, searchInput.Query, searchInput.Path, limit)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "No indexed code matched. The project may have no source files the index recognizes, or none under the given path.", nil
+	}
+
+	var out strings.Builder
+	for i, result := range results {
+		fmt.Fprintf(&out, "[%d] %s, %s (score %.2f)\n%s\n\n", i+1, result.Source, result.Title, result.Score, result.Text)
+	}
+	if update.Skipped > 0 {
+		fmt.Fprintf(&out, "[%d files were not indexed because the project is very large; narrow the search with read or list tools if nothing fits]\n", update.Skipped)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
@@ -0,0 +1,91 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/tools/schemas"
+)
+
+// ErrPathNotAllowed is wrapped by path resolution errors for paths outside the working directory
+var ErrPathNotAllowed = errors.New("only paths inside the working directory are allowed")
+
+// ErrIgnored is wrapped by path resolution errors for paths the project's .goocodeignore denies
+var ErrIgnored = errors.New("access denied by .goocodeignore")
+
+// ErrTimeout is wrapped by errors for commands that ran past their time limit
+var ErrTimeout = errors.New("command timed out")
+
+// ErrorCode classifies a failed tool call for the model
+type ErrorCode string
+
+// Error codes reported in tool results
+const (
+	CodeInvalidInput     ErrorCode = "invalid_input"
+	CodeUnknownTool      ErrorCode = "unknown_tool"
+	CodeNotFound         ErrorCode = "not_found"
+	CodePermissionDenied ErrorCode = "permission_denied"
+	CodePathNotAllowed   ErrorCode = "path_not_allowed"
+	CodeDeclined         ErrorCode = "declined"
+	CodeReadOnly         ErrorCode = "read_only"
+	CodeTimeout          ErrorCode = "timeout"
+	CodeCanceled         ErrorCode = "canceled"
+	CodeFailed           ErrorCode = "failed"
+)
+
+// Classification says what kind of failure an error is and what to do about it
+type Classification struct {
+	Code      ErrorCode
+	Retryable bool   // Calling again with different input can succeed
+	Hint      string // Suggested remediation
+}
+
+// ClassifyError maps a tool error to a code, whether a retry can help, and a hint
+func ClassifyError(err error) Classification {
+	var validation *ValidationError
+	var notFound *ToolNotFoundError
+	switch {
+	case errors.As(err, &validation):
+		return Classification{CodeInvalidInput, true, ""} // The message already lists what to fix
+	case errors.As(err, &notFound):
+		return Classification{CodeUnknownTool, true, "Call one of the tools you were given instead."}
+	case errors.Is(err, approval.ErrReadOnly):
+		return Classification{CodeReadOnly, false, "This session is read-only; explain the change to the user instead of making it."}
+	case errors.Is(err, approval.ErrDenied):
+		return Classification{CodeDeclined, false, "The user or the approval policy refused this action. Don't retry it; take another approach or ask the user."}
+	case errors.Is(err, schemas.ErrInvalidPath):
+		return Classification{CodeInvalidInput, true, "Use a path relative to the working directory, without '..'."}
+	case errors.Is(err, ErrIgnored):
+		return Classification{CodePathNotAllowed, false, "The project's .goocodeignore keeps this path away from tools. Work without it, or ask the user for what you need."}
+	case errors.Is(err, ErrPathNotAllowed):
+		return Classification{CodePathNotAllowed, false, "Use a path relative to the working directory, without '..'."}
+	case errors.Is(err, os.ErrNotExist):
+		return Classification{CodeNotFound, true, "Check the path, for example with list_files; paths are relative to the working directory."}
+	case errors.Is(err, os.ErrPermission):
+		return Classification{CodePermissionDenied, false, "The file system refused access. Don't retry the same path."}
+	case errors.Is(err, context.Canceled):
+		return Classification{CodeCanceled, false, "The call was interrupted; wait for the user's direction."}
+	case errors.Is(err, ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return Classification{CodeTimeout, true, "Retry with a longer timeout_seconds or a smaller piece of work."}
+	default:
+		return Classification{CodeFailed, true, "Read the message; retry only if a change of input can fix it."}
+	}
+}
+
+// FormatError renders a tool error for the model with its classification, like
+// "Error [not_found, retryable]: ...\nHint: ..."
+func FormatError(err error) string {
+	class := ClassifyError(err)
+	retry := "retryable"
+	if !class.Retryable {
+		retry = "fatal"
+	}
+	message := fmt.Sprintf("Error [%s, %s]: %s", class.Code, retry, err.Error())
+	if class.Hint != "" {
+		message += "\nHint: " + class.Hint
+	}
+	return message
+}
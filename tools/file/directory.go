@@ -0,0 +1,316 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/config"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// CreateDirectoryTool implements the create_directory tool
+type CreateDirectoryTool struct{}
+
+// NewCreateDirectoryTool creates a new CreateDirectory tool instance
+func NewCreateDirectoryTool() *CreateDirectoryTool {
+	return &CreateDirectoryTool{}
+}
+
+// Name returns the tool name
+func (t *CreateDirectoryTool) Name() string {
+	return "create_directory"
+}
+
+// Description returns the tool description
+func (t *CreateDirectoryTool) Description() string {
+	return "Create one or more directories, e.g. to scaffold a project layout. With parents=true missing parent directories are created too, like mkdir -p. Directories that already exist are reported, not treated as errors."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *CreateDirectoryTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.CreateDirectoryInputSchema
+}
+
+// Execute creates the directories
+func (t *CreateDirectoryTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var createInput schemas.CreateDirectoryInput
+	if err := json.Unmarshal(input, &createInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	if err := createInput.Paths.Validate(); err != nil {
+		return "", err
+	}
+
+	var created, existing []string
+	seen := make(map[string]bool)
+	for _, path := range createInput.Paths.Strings() {
+		target, err := agent.ResolveFilePath(path)
+		if err != nil {
+			return "", err
+		}
+		missing, err := missingDirs(agent, target)
+		if err != nil {
+			return "", fmt.Errorf("cannot create %s: %w", path, err)
+		}
+		if len(missing) == 0 {
+			existing = append(existing, dirName(agent, target))
+			continue
+		}
+		if len(missing) > 1 && !createInput.Parents {
+			return "", fmt.Errorf("cannot create %s: parent %s does not exist; set parents=true to create it too", path, dirName(agent, missing[0]))
+		}
+		for _, dir := range missing {
+			if !seen[dir] {
+				seen[dir] = true
+				created = append(created, dir)
+			}
+		}
+	}
+	if len(created) == 0 {
+		return fmt.Sprintf("Nothing to create; already exists: %s", strings.Join(existing, ", ")), nil
+	}
+
+	names := make([]string, len(created))
+	for i, dir := range created {
+		names[i] = dirName(agent, dir)
+	}
+	err := tools.RequestApproval(agent, approval.Request{
+		Tool:    t.Name(),
+		Summary: fmt.Sprintf("create %s", strings.Join(names, ", ")),
+		Paths:   names,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for i, dir := range created {
+		if err := os.Mkdir(dir, 0o755); err != nil && !os.IsExist(err) {
+			return "", fmt.Errorf("created %d of %d directories, then failed: %w", i, len(created), err)
+		}
+	}
+
+	var result strings.Builder
+	fmt.Fprintf(&result, "Created %d director%s:", len(created), plural(len(created), "y", "ies"))
+	for _, name := range names {
+		result.WriteString("\n  " + name)
+	}
+	if len(existing) > 0 {
+		fmt.Fprintf(&result, "\nAlready existed: %s", strings.Join(existing, ", "))
+	}
+	return result.String(), nil
+}
+
+// missingDirs returns target and those of its ancestors that don't exist yet, outermost first. The
+// nearest existing ancestor must be a directory that, following symlinks, is inside the working directory.
+func missingDirs(agent tools.ToolContext, target string) ([]string, error) {
+	var missing []string
+	dir := target
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return nil, fmt.Errorf("%s is a file", displayPath(agent, dir))
+			}
+			break
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		missing = append([]string{dir}, missing...)
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+
+	root, err := filepath.EvalSymlinks(agent.WorkingDir())
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !isWithin(resolved, root) {
+		return nil, fmt.Errorf("%s links outside the working directory: %w", displayPath(agent, dir), tools.ErrPathNotAllowed)
+	}
+	return missing, nil
+}
+
+// RemoveDirectoryTool implements the remove_directory tool
+type RemoveDirectoryTool struct{}
+
+// NewRemoveDirectoryTool creates a new RemoveDirectory tool instance
+func NewRemoveDirectoryTool() *RemoveDirectoryTool {
+	return &RemoveDirectoryTool{}
+}
+
+// Name returns the tool name
+func (t *RemoveDirectoryTool) Name() string {
+	return "remove_directory"
+}
+
+// Description returns the tool description
+func (t *RemoveDirectoryTool) Description() string {
+	return "Remove a directory. Only empty directories are removed unless recursive=true, which deletes everything inside it as well. Removal always needs the user's approval; the result lists what was deleted."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *RemoveDirectoryTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.RemoveDirectoryInputSchema
+}
+
+// Execute removes the directory
+func (t *RemoveDirectoryTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var removeInput schemas.RemoveDirectoryInput
+	if err := json.Unmarshal(input, &removeInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+
+	if err := removeInput.Path.Validate(); err != nil {
+		return "", err
+	}
+	target, err := agent.ResolveFilePath(string(removeInput.Path))
+	if err != nil {
+		return "", err
+	}
+	if filepath.Clean(target) == filepath.Clean(agent.WorkingDir()) {
+		return "", fmt.Errorf("cannot remove the working directory: %w", tools.ErrPathNotAllowed)
+	}
+
+	info, err := os.Lstat(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to access %s: %w", removeInput.Path, err)
+	}
+	switch {
+	case info.Mode()&os.ModeSymlink != 0:
+		return "", fmt.Errorf("%s is a symlink, not a directory", removeInput.Path)
+	case !info.IsDir():
+		return "", fmt.Errorf("%s is a file, not a directory", removeInput.Path)
+	}
+	// A symlinked parent could lead outside the working directory, so check where the path really is
+	root, err := filepath.EvalSymlinks(agent.WorkingDir())
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to access %s: %w", removeInput.Path, err)
+	}
+	if !isWithin(resolved, root) || resolved == root {
+		return "", fmt.Errorf("%s links outside the working directory: %w", removeInput.Path, tools.ErrPathNotAllowed)
+	}
+
+	contents, err := directoryContents(ctx, agent, target)
+	if err != nil {
+		return "", err
+	}
+	if len(contents.entries) > 0 && !removeInput.Recursive {
+		return "", fmt.Errorf("%s is not empty (%s); set recursive=true to remove it with its contents", removeInput.Path, contents.describe())
+	}
+
+	name := dirName(agent, target)
+	summary := fmt.Sprintf("remove empty directory %s", name)
+	if len(contents.entries) > 0 {
+		summary = fmt.Sprintf("remove directory %s and everything in it: %s", name, contents.describe())
+	}
+	err = tools.RequestApproval(agent, approval.Request{
+		Tool:      t.Name(),
+		Summary:   summary,
+		Paths:     append([]string{name}, contents.entries...),
+		Dangerous: true,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if removeInput.Recursive {
+		err = os.RemoveAll(target)
+	} else {
+		err = os.Remove(target)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to remove %s: %w", removeInput.Path, err)
+	}
+	tools.NoteFileChanged(agent, target)
+
+	if len(contents.entries) == 0 {
+		return fmt.Sprintf("Removed empty directory %s", name), nil
+	}
+	var result strings.Builder
+	fmt.Fprintf(&result, "Removed directory %s with %s:", name, contents.describe())
+	for i, entry := range contents.entries {
+		if i == config.DirectoryEntriesShown {
+			fmt.Fprintf(&result, "\n  ... and %d more", len(contents.entries)-i)
+			break
+		}
+		result.WriteString("\n  " + entry)
+	}
+	return result.String(), nil
+}
+
+// treeContents is what a directory holds, for confirming and reporting a removal
+type treeContents struct {
+	entries []string // Paths relative to the working directory, directories ending in a slash
+	files   int      // Files, symlinks and other non-directories
+	dirs    int
+	bytes   int64
+}
+
+// directoryContents lists everything under dir without following symlinks
+func directoryContents(ctx context.Context, agent tools.ToolContext, dir string) (treeContents, error) {
+	contents := treeContents{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if path == dir {
+			return nil
+		}
+		if tools.Ignored(agent, path, info.IsDir()) {
+			return fmt.Errorf("contains %s: %w", displayPath(agent, path), tools.ErrIgnored)
+		}
+		if info.IsDir() {
+			contents.dirs++
+			contents.entries = append(contents.entries, dirName(agent, path))
+			return nil
+		}
+		contents.files++
+		contents.bytes += info.Size()
+		contents.entries = append(contents.entries, filepath.ToSlash(displayPath(agent, path)))
+		return nil
+	})
+	if err != nil {
+		return contents, fmt.Errorf("failed to list %s: %w", displayPath(agent, dir), err)
+	}
+	return contents, nil
+}
+
+func (c treeContents) describe() string {
+	return fmt.Sprintf("%d file%s and %d director%s, %s", c.files, plural(c.files, "", "s"), c.dirs, plural(c.dirs, "y", "ies"), ui.FormatBytesPlain(c.bytes))
+}
+
+// dirName shows a directory relative to the working directory, ending in a slash
+func dirName(agent tools.ToolContext, dir string) string {
+	return filepath.ToSlash(displayPath(agent, dir)) + "/"
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
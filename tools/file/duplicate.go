@@ -0,0 +1,276 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// progressInterval controls how often directory copies report progress
+const progressInterval = 100
+
+// DuplicateFileTool implements the duplicate_file tool
+type DuplicateFileTool struct{}
+
+// NewDuplicateFileTool creates a new DuplicateFile tool instance
+func NewDuplicateFileTool() *DuplicateFileTool {
+	return &DuplicateFileTool{}
+}
+
+// Name returns the tool name
+func (t *DuplicateFileTool) Name() string {
+	return "duplicate_file"
+}
+
+// Description returns the tool description
+func (t *DuplicateFileTool) Description() string {
+	return "Copy a file, or a directory tree with recursive=true. Without a destination the copy is named like name(1).ext. Existing files are never replaced unless overwrite=true."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *DuplicateFileTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.DuplicateFileInputSchema
+}
+
+// Execute performs the copy
+func (t *DuplicateFileTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var dupInput schemas.DuplicateFileInput
+	if err := json.Unmarshal(input, &dupInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+
+	srcPath, err := agent.ResolveFilePath(dupInput.Source)
+	if err != nil {
+		return "", err
+	}
+
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to access %s: %w", dupInput.Source, err)
+	}
+	if info.IsDir() && !dupInput.Recursive {
+		return "", fmt.Errorf("%s is a directory; set recursive=true to copy it", dupInput.Source)
+	}
+
+	var dstPath string
+	if dupInput.Destination != "" {
+		dstPath, err = agent.ResolveFilePath(dupInput.Destination)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		dstPath = nextAvailableName(srcPath, info.IsDir())
+	}
+
+	if _, err := os.Stat(dstPath); err == nil && !dupInput.Overwrite {
+		return "", fmt.Errorf("destination %s already exists; set overwrite=true to replace it", displayPath(agent, dstPath))
+	}
+	if info.IsDir() && isWithin(dstPath, srcPath) {
+		return "", fmt.Errorf("cannot copy %s into itself", dupInput.Source)
+	}
+
+	// Every file the copy writes is approved, so protected paths and deny rules apply inside a tree too
+	plan := copyPlan{entries: []copyEntry{{src: srcPath, dst: dstPath, info: info}}}
+	if info.IsDir() {
+		if plan, err = planTree(ctx, agent, srcPath, dstPath); err != nil {
+			return "", err
+		}
+	}
+	paths := make([]string, 0, len(plan.entries))
+	for _, entry := range plan.entries {
+		if !tools.InWorkingDir(agent, entry.dst) {
+			return "", fmt.Errorf("%s links outside the working directory: %w", displayPath(agent, entry.dst), tools.ErrPathNotAllowed)
+		}
+		if tools.Ignored(agent, entry.dst, entry.info.IsDir()) {
+			return "", fmt.Errorf("%s: %w", displayPath(agent, entry.dst), tools.ErrIgnored)
+		}
+		paths = append(paths, filepath.ToSlash(displayPath(agent, entry.dst)))
+	}
+
+	err = tools.RequestApproval(agent, approval.Request{
+		Tool:    t.Name(),
+		Summary: fmt.Sprintf("copy %s to %s", displayPath(agent, srcPath), displayPath(agent, dstPath)),
+		Paths:   paths,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if !info.IsDir() {
+		if err := copyFile(srcPath, dstPath, info.Mode()); err != nil {
+			return "", err
+		}
+		tools.NoteFileChanged(agent, dstPath)
+		return fmt.Sprintf("Copied %s to %s (%s)", dupInput.Source, displayPath(agent, dstPath), ui.FormatBytesPlain(info.Size())), nil
+	}
+
+	stats, err := t.copyTree(ctx, agent, plan, dupInput.Overwrite)
+	if err != nil {
+		return "", fmt.Errorf("copy stopped after %d files: %w", stats.files, err)
+	}
+
+	result := fmt.Sprintf("Copied directory %s to %s: %d files, %d directories, %s", dupInput.Source, displayPath(agent, dstPath), stats.files, stats.dirs, ui.FormatBytesPlain(stats.bytes))
+	if plan.skipped > 0 {
+		result += fmt.Sprintf(" (%d symlinks or special files skipped)", plan.skipped)
+	}
+	if plan.ignored > 0 {
+		result += fmt.Sprintf(" (%d path(s) denied by .goocodeignore left out)", plan.ignored)
+	}
+	return result, nil
+}
+
+// copyEntry is a directory or regular file a copy creates
+type copyEntry struct {
+	src  string
+	dst  string
+	info os.FileInfo
+}
+
+// copyPlan is what a directory copy will create, walked before it is approved
+type copyPlan struct {
+	entries []copyEntry
+	skipped int // Symlinks and special files, which aren't copied
+	ignored int // Paths .goocodeignore denies, left out of the copy
+}
+
+type copyStats struct {
+	files int
+	dirs  int
+	bytes int64
+}
+
+// planTree walks src for the directories and files a copy to dst creates, parents before their contents
+func planTree(ctx context.Context, agent tools.ToolContext, src, dst string) (copyPlan, error) {
+	plan := copyPlan{}
+	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if rel != "." && tools.Ignored(agent, path, info.IsDir()) {
+			plan.ignored++
+			if info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// Symlinks could point outside the working directory, so they're not followed
+		if !info.IsDir() && !info.Mode().IsRegular() {
+			plan.skipped++
+			return nil
+		}
+		plan.entries = append(plan.entries, copyEntry{src: path, dst: filepath.Join(dst, rel), info: info})
+		return nil
+	})
+	return plan, err
+}
+
+// copyTree carries out a directory copy, reporting progress every progressInterval files
+func (t *DuplicateFileTool) copyTree(ctx context.Context, agent tools.ToolContext, plan copyPlan, overwrite bool) (copyStats, error) {
+	stats := copyStats{}
+	for _, entry := range plan.entries {
+		if ctx.Err() != nil {
+			return stats, ctx.Err()
+		}
+		if entry.info.IsDir() {
+			stats.dirs++
+			if err := os.MkdirAll(entry.dst, entry.info.Mode().Perm()); err != nil {
+				return stats, err
+			}
+			continue
+		}
+
+		if _, err := os.Stat(entry.dst); err == nil && !overwrite {
+			return stats, fmt.Errorf("%s already exists; set overwrite=true to replace it", displayPath(agent, entry.dst))
+		}
+		// A link could have been put in place since the copy was approved
+		if !tools.InWorkingDir(agent, entry.dst) {
+			return stats, fmt.Errorf("%s links outside the working directory: %w", displayPath(agent, entry.dst), tools.ErrPathNotAllowed)
+		}
+		if err := copyFile(entry.src, entry.dst, entry.info.Mode()); err != nil {
+			return stats, err
+		}
+		tools.NoteFileChanged(agent, entry.dst)
+
+		stats.files++
+		stats.bytes += entry.info.Size()
+		if stats.files%progressInterval == 0 {
+			tools.ReportProgress(agent, t.Name(), "copied %d files (%s)", stats.files, ui.FormatBytes(stats.bytes))
+		}
+	}
+	return stats, nil
+}
+
+func copyFile(src, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", src, err)
+	}
+	defer in.Close()
+
+	// Truncating the destination would empty the source first if they are the same file
+	if srcInfo, err := in.Stat(); err == nil {
+		if dstInfo, err := os.Stat(dst); err == nil && os.SameFile(srcInfo, dstInfo) {
+			return fmt.Errorf("%s and %s are the same file", src, dst)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("failed to create destination directory: %w", err)
+	}
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode.Perm())
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", dst, err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy to %s: %w", dst, err)
+	}
+	return out.Close()
+}
+
+// nextAvailableName returns path with a (n) suffix before the extension that doesn't exist yet
+func nextAvailableName(path string, isDir bool) string {
+	ext := ""
+	if !isDir {
+		ext = filepath.Ext(path)
+	}
+	base := strings.TrimSuffix(path, ext)
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("%s(%d)%s", base, n, ext)
+		if _, err := os.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func displayPath(agent tools.ToolContext, path string) string {
+	if rel, err := filepath.Rel(agent.WorkingDir(), path); err == nil {
+		return rel
+	}
+	return path
+}
@@ -6,13 +6,22 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"anthropic-chat/config"
 	"anthropic-chat/tools"
 	"anthropic-chat/tools/schemas"
+	"anthropic-chat/ui"
 
 	"github.com/anthropics/anthropic-sdk-go"
 )
 
+// listTimeFormat is how modification times are shown in tree and flat output
+const listTimeFormat = "2006-01-02 15:04"
+
 // ListFilesTool implements the list_files tool
 type ListFilesTool struct{}
 
@@ -28,7 +37,7 @@ func (t *ListFilesTool) Name() string {
 
 // Description returns the tool description
 func (t *ListFilesTool) Description() string {
-	return "List files and directories at specified path (defaults to current directory)."
+	return "List files and directories at specified path (defaults to current directory) with each entry's type, size and modification time. Directory sizes are the total of the files beneath them. Listings of more than " + strconv.Itoa(config.ListFilesMaxEntries) + " entries are summarized by extension and directory instead; list a subdirectory to see its files."
 }
 
 // InputSchema returns the input schema for this tool
@@ -36,6 +45,16 @@ func (t *ListFilesTool) InputSchema() anthropic.ToolInputSchemaParam {
 	return schemas.ListFilesInputSchema
 }
 
+// fileEntry is one listed file or directory
+type fileEntry struct {
+	Path     string    `json:"path"` // Slash-separated, relative to the listed directory
+	Type     string    `json:"type"` // file, dir or symlink
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+
+	children []*fileEntry
+}
+
 // Execute performs the list files operation
 func (t *ListFilesTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
 	var listInput schemas.ListFilesInput
@@ -54,7 +73,9 @@ func (t *ListFilesTool) Execute(ctx context.Context, agent tools.ToolContext, in
 	}
 
 	// Collect files and directories
-	files := []string{}
+	root := &fileEntry{Type: "dir"}
+	dirs := map[string]*fileEntry{".": root}
+	entries := []*fileEntry{}
 	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
@@ -65,26 +86,113 @@ func (t *ListFilesTool) Execute(ctx context.Context, agent tools.ToolContext, in
 			return err
 		}
 
-		// Skip the current directory entry
-		if relPath != "." {
+		// Skip the current directory entry, and paths .goocodeignore hides
+		if relPath == "." {
+			return nil
+		}
+		if tools.Ignored(agent, path, info.IsDir()) {
 			if info.IsDir() {
-				files = append(files, relPath+"/")
-			} else {
-				files = append(files, relPath)
+				return filepath.SkipDir
 			}
+			return nil
+		}
+		entry := &fileEntry{Path: filepath.ToSlash(relPath), Type: "file", Size: info.Size(), Modified: info.ModTime()}
+		switch {
+		case info.IsDir():
+			entry.Type, entry.Size = "dir", 0
+			dirs[relPath] = entry
+		case info.Mode()&os.ModeSymlink != 0:
+			entry.Type = "symlink"
 		}
+		parent := dirs[filepath.Dir(relPath)]
+		parent.children = append(parent.children, entry)
+		entries = append(entries, entry)
 		return nil
 	})
 
 	if err != nil {
 		return "", fmt.Errorf("failed to list files in %s: %w", listInput.Path, err)
 	}
+	totalSize(root)
 
-	// Convert to JSON for output
-	result, err := json.Marshal(files)
+	less, err := entryOrder(listInput.SortBy)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal file list: %w", err)
+		return "", err
 	}
 
-	return string(result), nil
+	if len(entries) == 0 && listInput.OutputFormat != "json" {
+		return "(no files)", nil
+	}
+	if len(entries) > config.ListFilesMaxEntries {
+		return summarizeListing(root, entries, filepath.ToSlash(filepath.Clean(listInput.Path))), nil
+	}
+	switch listInput.OutputFormat {
+	case "", "tree":
+		var b strings.Builder
+		writeTree(&b, root, 0, less)
+		return strings.TrimSuffix(b.String(), "\n"), nil
+	case "flat":
+		sort.SliceStable(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+		lines := make([]string, len(entries))
+		for i, entry := range entries {
+			lines[i] = fmt.Sprintf("%-7s %9s  %s  %s", entry.Type, ui.FormatBytesPlain(entry.Size), entry.Modified.Format(listTimeFormat), displayName(entry, entry.Path))
+		}
+		return strings.Join(lines, "\n"), nil
+	case "json":
+		sort.SliceStable(entries, func(i, j int) bool { return less(entries[i], entries[j]) })
+		result, err := json.Marshal(entries)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal file list: %w", err)
+		}
+		return string(result), nil
+	default:
+		return "", fmt.Errorf("unknown output_format %q (expected tree, flat or json)", listInput.OutputFormat)
+	}
+}
+
+// totalSize sets each directory's size to the total of the files beneath it
+func totalSize(entry *fileEntry) int64 {
+	if entry.Type != "dir" {
+		return entry.Size
+	}
+	entry.Size = 0
+	for _, child := range entry.children {
+		entry.Size += totalSize(child)
+	}
+	return entry.Size
+}
+
+// entryOrder returns the comparison for sort_by
+func entryOrder(sortBy string) (func(a, b *fileEntry) bool, error) {
+	switch sortBy {
+	case "", "name":
+		return func(a, b *fileEntry) bool { return a.Path < b.Path }, nil
+	case "size":
+		return func(a, b *fileEntry) bool { return a.Size > b.Size }, nil
+	case "mtime":
+		return func(a, b *fileEntry) bool { return a.Modified.After(b.Modified) }, nil
+	default:
+		return nil, fmt.Errorf("unknown sort_by %q (expected name, size or mtime)", sortBy)
+	}
+}
+
+// writeTree renders entry's children indented by depth, with their own children beneath them
+func writeTree(b *strings.Builder, entry *fileEntry, depth int, less func(a, b *fileEntry) bool) {
+	sort.SliceStable(entry.children, func(i, j int) bool { return less(entry.children[i], entry.children[j]) })
+	for _, child := range entry.children {
+		name := displayName(child, filepath.Base(child.Path))
+		fmt.Fprintf(b, "%s%-*s %9s  %s\n", strings.Repeat("  ", depth), max(40-2*depth, len(name)), name, ui.FormatBytesPlain(child.Size), child.Modified.Format(listTimeFormat))
+		writeTree(b, child, depth+1, less)
+	}
+}
+
+// displayName marks directories with a trailing slash and symlinks with an @
+func displayName(entry *fileEntry, name string) string {
+	switch entry.Type {
+	case "dir":
+		return name + "/"
+	case "symlink":
+		return name + "@"
+	}
+	return name
 }
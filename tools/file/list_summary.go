@@ -0,0 +1,128 @@
+package file
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"anthropic-chat/config"
+	"anthropic-chat/ui"
+)
+
+// summarizeListing describes a listing too large to return in full: counts per extension, the largest
+// directories and the top level, with a hint on how to narrow it down
+func summarizeListing(root *fileEntry, entries []*fileEntry, listed string) string {
+	type extStats struct {
+		ext   string
+		files int
+		size  int64
+	}
+	byExt := map[string]*extStats{}
+	files, dirs := 0, 0
+	for _, entry := range entries {
+		if entry.Type == "dir" {
+			dirs++
+			continue
+		}
+		files++
+		ext := strings.ToLower(path.Ext(entry.Path))
+		if ext == "" || ext == path.Base(entry.Path) {
+			ext = "(none)"
+		}
+		if byExt[ext] == nil {
+			byExt[ext] = &extStats{ext: ext}
+		}
+		byExt[ext].files++
+		byExt[ext].size += entry.Size
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d entries (%d files, %d directories, %s) is more than the %d that are listed in full, so here is a summary.\n",
+		len(entries), files, dirs, ui.FormatBytesPlain(root.Size), config.ListFilesMaxEntries)
+
+	exts := make([]*extStats, 0, len(byExt))
+	for _, stats := range byExt {
+		exts = append(exts, stats)
+	}
+	sort.Slice(exts, func(i, j int) bool {
+		if exts[i].files != exts[j].files {
+			return exts[i].files > exts[j].files
+		}
+		return exts[i].ext < exts[j].ext
+	})
+	b.WriteString("\nFiles by extension:\n")
+	for i, stats := range exts {
+		if i == config.ListSummaryTopGroups {
+			fmt.Fprintf(&b, "  ... %d more extensions\n", len(exts)-i)
+			break
+		}
+		fmt.Fprintf(&b, "  %-10s %6d files %10s\n", stats.ext, stats.files, ui.FormatBytesPlain(stats.size))
+	}
+
+	fileCounts := map[*fileEntry]int{}
+	countFiles(root, fileCounts)
+	ranked := make([]*fileEntry, 0, len(fileCounts))
+	for dir := range fileCounts {
+		if dir != root {
+			ranked = append(ranked, dir)
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		if ranked[i].Size != ranked[j].Size {
+			return ranked[i].Size > ranked[j].Size
+		}
+		return ranked[i].Path < ranked[j].Path
+	})
+	if len(ranked) > 0 {
+		b.WriteString("\nLargest directories:\n")
+		for i, dir := range ranked {
+			if i == config.ListSummaryTopGroups {
+				break
+			}
+			fmt.Fprintf(&b, "  %-40s %6d files %10s\n", dir.Path+"/", fileCounts[dir], ui.FormatBytesPlain(dir.Size))
+		}
+	}
+
+	b.WriteString("\nTop level:\n")
+	sort.SliceStable(root.children, func(i, j int) bool { return root.children[i].Path < root.children[j].Path })
+	for i, child := range root.children {
+		if i == config.ListSummaryTopLevel {
+			fmt.Fprintf(&b, "  ... %d more\n", len(root.children)-i)
+			break
+		}
+		name := displayName(child, path.Base(child.Path))
+		if child.Type == "dir" {
+			fmt.Fprintf(&b, "  %-40s %6d files %10s\n", name, fileCounts[child], ui.FormatBytesPlain(child.Size))
+		} else {
+			fmt.Fprintf(&b, "  %-40s %23s\n", name, ui.FormatBytesPlain(child.Size))
+		}
+	}
+
+	example := "src"
+	for _, child := range root.children {
+		if child.Type == "dir" && !strings.HasPrefix(child.Path, ".") {
+			example = child.Path
+			break
+		}
+	}
+	if listed != "" {
+		example = path.Join(listed, path.Base(example))
+	}
+	fmt.Fprintf(&b, "\nList a subdirectory to see its files, e.g. {\"path\": %q}, or use read_many_files with a glob to read specific ones.", example)
+	return b.String()
+}
+
+// countFiles records how many files are beneath each directory from entry down, returning entry's count
+func countFiles(entry *fileEntry, counts map[*fileEntry]int) int {
+	files := 0
+	for _, child := range entry.children {
+		if child.Type == "dir" {
+			files += countFiles(child, counts)
+		} else {
+			files++
+		}
+	}
+	counts[entry] = files
+	return files
+}
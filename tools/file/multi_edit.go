@@ -0,0 +1,258 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/config"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// MultiEditTool implements the multi_edit tool
+type MultiEditTool struct{}
+
+// NewMultiEditTool creates a new MultiEdit tool instance
+func NewMultiEditTool() *MultiEditTool {
+	return &MultiEditTool{}
+}
+
+// Name returns the tool name
+func (t *MultiEditTool) Name() string {
+	return "multi_edit"
+}
+
+// Description returns the tool description
+func (t *MultiEditTool) Description() string {
+	return "Make several exact text replacements in one existing file with a single call. Edits apply in order, each to the result of the ones before it, and are all checked before the file is written: if any old text is missing or ambiguous, nothing changes and every failing edit is reported. Prefer one call with all the changes a file needs over one call per change."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *MultiEditTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.MultiEditInputSchema
+}
+
+// Execute checks every edit against the file and writes the result once
+func (t *MultiEditTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var editInput schemas.MultiEditInput
+	if err := json.Unmarshal(input, &editInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	if err := editInput.Path.Validate(); err != nil {
+		return "", err
+	}
+	if len(editInput.Edits) > config.MultiEditMaxEdits {
+		return "", fmt.Errorf("%d edits given; at most %d are accepted per call", len(editInput.Edits), config.MultiEditMaxEdits)
+	}
+
+	target, err := agent.ResolveFilePath(string(editInput.Path))
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to access %s: %w", editInput.Path, err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("%s is a directory", editInput.Path)
+	}
+	if info.Size() > config.MultiEditMaxBytes {
+		return "", fmt.Errorf("%s is %s; files over %s are not edited in memory", editInput.Path, ui.FormatBytesPlain(info.Size()), ui.FormatBytesPlain(config.MultiEditMaxBytes))
+	}
+	data, err := os.ReadFile(target)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", editInput.Path, err)
+	}
+
+	name := filepath.ToSlash(displayPath(agent, target))
+	content := string(data)
+	var failures []string
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- a/%s\n+++ b/%s\n", name, name)
+	added, removed := 0, 0
+	for i, edit := range editInput.Edits {
+		matches, err := locate(content, edit)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("edit %d: %v", i+1, err))
+			continue
+		}
+		shift := 0
+		for _, at := range matches {
+			a, r := writeHunk(&diff, content, at, edit.Old, edit.New, shift)
+			added, removed, shift = added+a, removed+r, shift+a-r
+		}
+		content = replaceAt(content, matches, len(edit.Old), edit.New)
+	}
+	if len(failures) > 0 {
+		return "", fmt.Errorf("no edits were applied to %s:\n%s", editInput.Path, strings.Join(failures, "\n"))
+	}
+	if content == string(data) {
+		return fmt.Sprintf("%s already has these edits; nothing changed", name), nil
+	}
+
+	proposed := content
+	err = tools.RequestApproval(agent, approval.Request{
+		Tool:     t.Name(),
+		Summary:  fmt.Sprintf("apply %d edit(s) to %s (+%d -%d lines)", len(editInput.Edits), name, added, removed),
+		Paths:    []string{name},
+		Lines:    max(added, removed),
+		Diff:     diff.String(),
+		Proposed: &content,
+	})
+	if err != nil {
+		return "", err
+	}
+	// The file may have been changed while the approval prompt or an editor session was open
+	if now, err := os.Stat(target); err != nil || !now.ModTime().Equal(info.ModTime()) || now.Size() != info.Size() {
+		return "", fmt.Errorf("%s changed while the edits waited for approval; nothing was written, read it again before editing", name)
+	}
+
+	if err := writeFileAtomic(target, []byte(content), info.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", editInput.Path, err)
+	}
+	tools.NoteFileChanged(agent, target)
+	if content != proposed {
+		return fmt.Sprintf("The user edited the result of your %d edit(s) to %s before it was written; read the file before changing it further", len(editInput.Edits), name), nil
+	}
+	return fmt.Sprintf("Applied %d edit(s) to %s (+%d -%d lines)", len(editInput.Edits), name, added, removed), nil
+}
+
+// locate returns the offsets of the matches of edit.Old that the edit replaces
+func locate(content string, edit schemas.FileEdit) ([]int, error) {
+	if edit.Old == "" {
+		return nil, fmt.Errorf("old text is empty")
+	}
+	if edit.Old == edit.New {
+		return nil, fmt.Errorf("old and new text are the same")
+	}
+	var matches []int
+	for from := 0; ; {
+		i := strings.Index(content[from:], edit.Old)
+		if i < 0 {
+			break
+		}
+		matches = append(matches, from+i)
+		from += i + len(edit.Old)
+	}
+
+	switch {
+	case len(matches) == 0 && strings.Contains(content, strings.TrimSpace(edit.Old)):
+		return nil, fmt.Errorf("old text not found; it matches only with different leading or trailing whitespace, so copy it exactly")
+	case len(matches) == 0:
+		return nil, fmt.Errorf("old text not found")
+	case edit.Occurrence == -1:
+		return matches, nil
+	case edit.Occurrence < -1:
+		return nil, fmt.Errorf("occurrence %d is invalid; use 1 or more, or -1 for every match", edit.Occurrence)
+	case edit.Occurrence == 0 && len(matches) > 1:
+		return nil, fmt.Errorf("old text appears %d times (lines %s); include more surrounding text or set occurrence", len(matches), matchLines(content, matches))
+	case edit.Occurrence == 0:
+		return matches, nil
+	case edit.Occurrence > len(matches):
+		return nil, fmt.Errorf("occurrence %d requested but old text appears %d time(s)", edit.Occurrence, len(matches))
+	}
+	return matches[edit.Occurrence-1 : edit.Occurrence], nil
+}
+
+// replaceAt replaces the oldLen bytes at each of the ascending offsets with replacement
+func replaceAt(content string, offsets []int, oldLen int, replacement string) string {
+	var b strings.Builder
+	last := 0
+	for _, at := range offsets {
+		b.WriteString(content[last:at])
+		b.WriteString(replacement)
+		last = at + oldLen
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
+// writeHunk adds the lines a replacement of old by replacement at offset at touches to diff, with a
+// little unchanged context, and returns the lines added and removed. Hunks are numbered against the
+// file as it was before that edit, with shift lines added by earlier matches of the same edit.
+func writeHunk(diff *strings.Builder, before string, at int, old, replacement string, shift int) (int, int) {
+	start := strings.LastIndex(before[:at], "\n") + 1
+	prefix, end := before[start:at], at+len(old)
+	suffix := ""
+	if !strings.HasSuffix(prefix+old, "\n") || !(strings.HasSuffix(prefix+replacement, "\n") || prefix+replacement == "") {
+		// The edit ends inside a line, so the rest of that line changes with it
+		suffix = before[end:]
+		if nl := strings.IndexByte(suffix, '\n'); nl >= 0 {
+			suffix = suffix[:nl+1]
+		}
+		end += len(suffix)
+	}
+	oldLines, newLines := splitLines(prefix+old+suffix), splitLines(prefix+replacement+suffix)
+
+	var leading []string
+	if start > 0 {
+		leading = splitLines(before[:start])
+		leading = leading[max(0, len(leading)-config.MultiEditDiffContext):]
+	}
+	trailing := splitLines(before[end:])
+	trailing = trailing[:min(len(trailing), config.MultiEditDiffContext)]
+
+	line := strings.Count(before[:start], "\n") + 1 - len(leading)
+	unchanged := len(leading) + len(trailing)
+	fmt.Fprintf(diff, "@@ -%d,%d +%d,%d @@\n", line, len(oldLines)+unchanged, line+shift, len(newLines)+unchanged)
+	writeLines(diff, " ", leading)
+	writeLines(diff, "-", oldLines)
+	writeLines(diff, "+", newLines)
+	writeLines(diff, " ", trailing)
+	return len(newLines), len(oldLines)
+}
+
+// splitLines splits s into lines without their newlines
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func writeLines(diff *strings.Builder, prefix string, lines []string) {
+	for _, line := range lines {
+		diff.WriteString(prefix + line + "\n")
+	}
+}
+
+// matchLines lists the line numbers of the matches, for telling ambiguous ones apart
+func matchLines(content string, offsets []int) string {
+	numbers := make([]string, 0, len(offsets))
+	for _, at := range offsets {
+		numbers = append(numbers, fmt.Sprint(strings.Count(content[:at], "\n")+1))
+	}
+	return strings.Join(numbers, ", ")
+}
+
+// writeFileAtomic replaces path with data through a temporary file in the same directory, so a failed
+// write never leaves it half edited. A symlink is kept and its target replaced.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 
+	"anthropic-chat/config"
 	"anthropic-chat/tools"
 	"anthropic-chat/tools/schemas"
 
@@ -27,7 +28,7 @@ func (t *ReadFileTool) Name() string {
 
 // Description returns the tool description
 func (t *ReadFileTool) Description() string {
-	return "Read file contents from relative path within working directory."
+	return "Read file contents from relative path within working directory. Files too large to read at once return an outline and one chunk, chosen by hint; pass the cursor at the end of a chunk to read the next."
 }
 
 // InputSchema returns the input schema for this tool
@@ -48,11 +49,62 @@ func (t *ReadFileTool) Execute(ctx context.Context, agent tools.ToolContext, inp
 		return "", err
 	}
 
-	// Read the file content
-	content, err := os.ReadFile(fullPath)
+	info, err := os.Stat(fullPath)
 	if err != nil {
 		return "", fmt.Errorf("failed to read file %s: %w", readInput.Path, err)
 	}
 
-	return string(content), nil
+	// Huge files are paged through rather than returned whole
+	if readInput.Cursor != "" || info.Size() > config.ReadFileMaxBytes {
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read file %s: %w", readInput.Path, err)
+		}
+		if readInput.Cursor != "" || isLarge(content) {
+			result, err := readChunked(ctx, readInput.Path, content, info, readInput.Hint, readInput.Cursor)
+			if err != nil {
+				return "", err
+			}
+			tools.NoteFileRead(agent, fullPath, info)
+			return result + pinNote(agent, readInput), nil
+		}
+	}
+
+	// A version the model has already seen isn't worth its context a second time
+	cache, caching := agent.(tools.ReadCache)
+	var content []byte
+	if caching {
+		if cached, ok := cache.CachedRead(fullPath, info); ok {
+			if !readInput.Force {
+				return "[File unchanged since you last read it; its contents are not repeated. Set force to true if you need them again.]" + pinNote(agent, readInput), nil
+			}
+			content = cached
+		}
+	}
+
+	// Read the file content
+	if content == nil {
+		if content, err = os.ReadFile(fullPath); err != nil {
+			return "", fmt.Errorf("failed to read file %s: %w", readInput.Path, err)
+		}
+		if caching {
+			cache.RememberRead(fullPath, info, content)
+		}
+	}
+	return string(content) + pinNote(agent, readInput), nil
+}
+
+// pinNote pins the file when asked to and reports the outcome
+func pinNote(agent tools.ToolContext, readInput schemas.ReadFileInput) string {
+	if !readInput.Pinned {
+		return ""
+	}
+	pinner, ok := agent.(tools.Pinner)
+	if !ok {
+		return "\n\n[Not pinned: pinning is not available]"
+	}
+	if err := pinner.PinFile(readInput.Path); err != nil {
+		return fmt.Sprintf("\n\n[Not pinned: %v]", err)
+	}
+	return fmt.Sprintf("\n\n[Pinned %s: its current contents are now included with every request]", readInput.Path)
 }
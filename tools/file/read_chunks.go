@@ -0,0 +1,188 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"anthropic-chat/config"
+	"anthropic-chat/tools/outline"
+	"anthropic-chat/ui"
+)
+
+// chunk is a run of whole lines of a large file, counting from 1
+type chunk struct {
+	start, end int
+}
+
+// readChunked answers a read of a file too large to return whole: the first read gets the file's outline
+// and the chunk most relevant to hint, and each result ends with a cursor for the chunk after it
+func readChunked(ctx context.Context, path string, content []byte, info os.FileInfo, hint, cursor string) (string, error) {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	declarations, _ := outline.Declarations(ctx, path, content)
+	version := fileVersion(info)
+
+	var out strings.Builder
+	var current chunk
+	switch {
+	case cursor != "":
+		start, cursorVersion, err := parseCursor(cursor)
+		if err != nil {
+			return "", err
+		}
+		if start > len(lines) {
+			return "", fmt.Errorf("cursor %s is past the end of %s (%d lines)", cursor, path, len(lines))
+		}
+		if cursorVersion != version {
+			out.WriteString("[The file has changed since this cursor was issued, so the chunk may not follow on exactly from the last one.]\n")
+		}
+		current = nextChunk(lines, start, breakLines(lines, declarations))
+	default:
+		fmt.Fprintf(&out, "[%s is %s and %d lines, too large to read at once. ", path, ui.FormatBytesPlain(int64(len(content))), len(lines))
+		breaks := breakLines(lines, declarations)
+		current = nextChunk(lines, 1, breaks)
+		if hint != "" {
+			target := hintLine(lines, declarations, hint)
+			if target == 0 {
+				fmt.Fprintf(&out, "%q was not found, so the first chunk follows", hint)
+			} else {
+				for target > current.end {
+					current = nextChunk(lines, current.end+1, breaks)
+				}
+				fmt.Fprintf(&out, "The chunk containing %q (line %d) follows", hint, target)
+			}
+		} else {
+			out.WriteString("The first chunk follows; pass a hint with a symbol name or text to jump to the relevant part")
+		}
+		out.WriteString(".]\n\n")
+		if summary, err := outline.Outline(ctx, path, content); err == nil {
+			out.WriteString("Outline:\n" + capOutline(summary) + "\n")
+		}
+	}
+
+	fmt.Fprintf(&out, "Lines %d-%d of %d:\n", current.start, current.end, len(lines))
+	for _, line := range lines[current.start-1 : current.end] {
+		out.WriteString(line)
+	}
+	if !strings.HasSuffix(lines[current.end-1], "\n") {
+		out.WriteString("\n")
+	}
+	if current.end < len(lines) {
+		fmt.Fprintf(&out, "\n[%d more lines. Call read_file with cursor %q for the next chunk, or with a hint to jump elsewhere.]", len(lines)-current.end, formatCursor(current.end+1, version))
+	} else {
+		out.WriteString("\n[End of file.]")
+	}
+	return out.String(), nil
+}
+
+// breakLines returns the lines where top-level declarations start, counting the comments and
+// annotations just above them, as the preferred places to end a chunk
+func breakLines(lines []string, declarations []outline.Declaration) map[int]bool {
+	breaks := make(map[int]bool)
+	for _, d := range declarations {
+		if d.Depth != 0 || d.Line > len(lines) {
+			continue
+		}
+		start := d.Line
+		for start > 1 && isPreamble(lines[start-2]) {
+			start--
+		}
+		breaks[start] = true
+	}
+	return breaks
+}
+
+// isPreamble reports whether line is a comment or annotation that belongs to the declaration below it
+func isPreamble(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	for _, prefix := range []string{"//", "#", "/*", "*", "--", "@"} {
+		if strings.HasPrefix(trimmed, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// capOutline keeps the start of an outline too long to be worth its context
+func capOutline(summary string) string {
+	if len(summary) <= config.ReadOutlineBytes {
+		return summary
+	}
+	cut := strings.LastIndex(summary[:config.ReadOutlineBytes], "\n") + 1
+	rest := strings.Count(summary[cut:], "\n")
+	return fmt.Sprintf("%s[%d more outline lines; use a hint to jump to a symbol further on]\n", summary[:cut], rest)
+}
+
+// nextChunk takes up to ReadChunkBytes of whole lines from start, ending before the last top-level
+// declaration that starts in its second half, so declarations are not split where avoidable
+func nextChunk(lines []string, start int, breaks map[int]bool) chunk {
+	size, end, lastBreak := 0, start-1, 0
+	for end < len(lines) {
+		next := len(lines[end])
+		if size+next > config.ReadChunkBytes && end >= start {
+			break
+		}
+		size += next
+		end++
+		if breaks[end+1] && size >= config.ReadChunkBytes/2 {
+			lastBreak = end
+		}
+	}
+	if end < len(lines) && lastBreak > 0 {
+		end = lastBreak
+	}
+	return chunk{start: start, end: end}
+}
+
+// hintLine finds the line hint refers to: a declaration whose signature names it, or else the first line
+// containing it. It returns 0 when hint is nowhere in the file.
+func hintLine(lines []string, declarations []outline.Declaration, hint string) int {
+	word := regexp.MustCompile(`\b` + regexp.QuoteMeta(hint) + `\b`)
+	for _, d := range declarations {
+		if word.MatchString(d.Signature) {
+			return d.Line
+		}
+	}
+	for _, exact := range []bool{true, false} {
+		for i, line := range lines {
+			if (exact && word.MatchString(line)) || (!exact && strings.Contains(strings.ToLower(line), strings.ToLower(hint))) {
+				return i + 1
+			}
+		}
+	}
+	return 0
+}
+
+// fileVersion identifies the file's current contents well enough to notice a stale cursor
+func fileVersion(info os.FileInfo) string {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%d/%d", info.Size(), info.ModTime().UnixNano())
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+func formatCursor(line int, version string) string {
+	return fmt.Sprintf("line-%d-%s", line, version)
+}
+
+func parseCursor(cursor string) (int, string, error) {
+	rest, ok := strings.CutPrefix(cursor, "line-")
+	number, version, found := strings.Cut(rest, "-")
+	line, err := strconv.Atoi(number)
+	if !ok || !found || err != nil || line < 1 {
+		return 0, "", fmt.Errorf("invalid cursor %q; use the cursor from the end of the previous chunk", cursor)
+	}
+	return line, version, nil
+}
+
+// isLarge reports whether content is text over the whole-file read budget
+func isLarge(content []byte) bool {
+	return len(content) > config.ReadFileMaxBytes && bytes.IndexByte(content[:min(len(content), binarySniffBytes)], 0) < 0
+}
@@ -0,0 +1,219 @@
+package file
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/config"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// binarySniffBytes is how much of a file is checked for NUL bytes to detect binary content
+const binarySniffBytes = 8000
+
+// ReadManyFilesTool implements the read_many_files tool
+type ReadManyFilesTool struct{}
+
+// NewReadManyFilesTool creates a new ReadManyFiles tool instance
+func NewReadManyFilesTool() *ReadManyFilesTool {
+	return &ReadManyFilesTool{}
+}
+
+// Name returns the tool name
+func (t *ReadManyFilesTool) Name() string {
+	return "read_many_files"
+}
+
+// Description returns the tool description
+func (t *ReadManyFilesTool) Description() string {
+	return "Read several files in one call. Accepts relative paths, directories and glob patterns (** matches any depth). Returns each file under a '==> path <==' header; content past the combined size cap is cut off and listed at the end. Prefer this over repeated read_file calls when you need several related files."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *ReadManyFilesTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.ReadManyFilesInputSchema
+}
+
+// Execute reads the matching files into one delimited bundle
+func (t *ReadManyFilesTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var readInput schemas.ReadManyFilesInput
+	if err := json.Unmarshal(input, &readInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	if err := schemas.ValidateGlobs(readInput.Paths); err != nil {
+		return "", err
+	}
+
+	budget := config.ReadManyMaxBytes
+	if readInput.MaxBytes > 0 && readInput.MaxBytes < budget {
+		budget = readInput.MaxBytes
+	}
+
+	files, skipped, err := expandPaths(agent, readInput.Paths)
+	if err != nil {
+		return "", err
+	}
+
+	var bundle strings.Builder
+	read, total := 0, 0
+	for i, rel := range files {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		if i == config.ReadManyMaxFiles || budget == 0 {
+			for _, rest := range files[i:] {
+				skipped = append(skipped, rest+" (not read: limit reached)")
+			}
+			break
+		}
+
+		fullPath := filepath.Join(agent.WorkingDir(), filepath.FromSlash(rel))
+		info, err := os.Stat(fullPath)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s (%v)", rel, err))
+			continue
+		}
+		if cache, ok := agent.(tools.ReadCache); ok {
+			if _, unchanged := cache.CachedRead(fullPath, info); unchanged {
+				skipped = append(skipped, rel+" (unchanged since last read)")
+				continue
+			}
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s (%v)", rel, err))
+			continue
+		}
+		if bytes.IndexByte(content[:min(len(content), binarySniffBytes)], 0) >= 0 {
+			skipped = append(skipped, rel+" (binary)")
+			continue
+		}
+
+		header := fmt.Sprintf("==> %s (%s) <==", rel, ui.FormatBytesPlain(int64(len(content))))
+		if cache, ok := agent.(tools.ReadCache); ok && len(content) <= budget {
+			cache.RememberRead(fullPath, info, content)
+		}
+		if len(content) > budget {
+			header = fmt.Sprintf("==> %s (%s, first %s shown) <==", rel, ui.FormatBytesPlain(int64(len(content))), ui.FormatBytesPlain(int64(budget)))
+			content = content[:budget]
+		}
+		if bundle.Len() > 0 {
+			bundle.WriteString("\n")
+		}
+		bundle.WriteString(header + "\n")
+		bundle.Write(content)
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			bundle.WriteString("\n")
+		}
+
+		budget -= len(content)
+		total += len(content)
+		read++
+	}
+
+	fmt.Fprintf(&bundle, "\n[read %d of %d files, %s]", read, len(files), ui.FormatBytesPlain(int64(total)))
+	if len(skipped) > 0 {
+		bundle.WriteString("\n[skipped: " + strings.Join(skipped, ", ") + "]")
+	}
+	return bundle.String(), nil
+}
+
+// expandPaths resolves paths, directories and glob patterns to slash-separated relative file paths, first match first
+func expandPaths(agent tools.ToolContext, patterns []schemas.GlobPattern) ([]string, []string, error) {
+	var files, skipped []string
+	seen := make(map[string]bool)
+	add := func(rel string) {
+		if !seen[rel] {
+			seen[rel] = true
+			files = append(files, rel)
+		}
+	}
+
+	var tree []string // Every file in the working directory, walked on first use
+	for _, glob := range patterns {
+		pattern := string(glob)
+		if !strings.ContainsAny(pattern, "*?[") && !strings.HasSuffix(pattern, "/") {
+			fullPath, err := agent.ResolveFilePath(pattern)
+			if err != nil {
+				return nil, nil, err
+			}
+			info, err := os.Stat(fullPath)
+			if err != nil {
+				skipped = append(skipped, pattern+" (not found)")
+				continue
+			}
+			if !info.IsDir() {
+				add(filepath.ToSlash(displayPath(agent, fullPath)))
+				continue
+			}
+			pattern = "**"
+			if rel := filepath.ToSlash(displayPath(agent, fullPath)); rel != "." {
+				pattern = rel + "/"
+			}
+		}
+
+		if tree == nil {
+			var err error
+			if tree, err = walkFiles(agent, agent.WorkingDir()); err != nil {
+				return nil, nil, err
+			}
+		}
+		matched := false
+		for _, rel := range tree {
+			if approval.MatchPath(pattern, rel) {
+				add(rel)
+				matched = true
+			}
+		}
+		if !matched {
+			skipped = append(skipped, pattern+" (no matches)")
+		}
+	}
+	return files, skipped, nil
+}
+
+// walkFiles lists regular files under dir as sorted slash-separated relative paths, skipping .git and
+// whatever .goocodeignore denies
+func walkFiles(agent tools.ToolContext, dir string) ([]string, error) {
+	files := []string{}
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" || (path != dir && tools.Ignored(agent, path, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if tools.Ignored(agent, path, false) {
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list files: %w", err)
+	}
+	sort.Strings(files)
+	return files, nil
+}
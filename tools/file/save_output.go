@@ -0,0 +1,174 @@
+package file
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+var codeFencePattern = regexp.MustCompile("(?ms)^[ \t]*(```|~~~)[^\n]*\n(.*?)^[ \t]*(```|~~~)[ \t]*$")
+
+// SaveOutputTool implements the save_output tool
+type SaveOutputTool struct{}
+
+// NewSaveOutputTool creates a new SaveOutput tool instance
+func NewSaveOutputTool() *SaveOutputTool {
+	return &SaveOutputTool{}
+}
+
+// Name returns the tool name
+func (t *SaveOutputTool) Name() string {
+	return "save_output"
+}
+
+// Description returns the tool description
+func (t *SaveOutputTool) Description() string {
+	return "Write your last response, or one fenced code block from it, to a file. Write the code in your reply first, then call this instead of repeating it in the tool input. Existing files are never replaced unless overwrite=true."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *SaveOutputTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.SaveOutputInputSchema
+}
+
+// Execute writes the selected part of the last response
+func (t *SaveOutputTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var saveInput schemas.SaveOutputInput
+	if err := json.Unmarshal(input, &saveInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+
+	source, ok := agent.(tools.ResponseSource)
+	if !ok {
+		return "", fmt.Errorf("no response is available to save")
+	}
+	return SaveOutput(agent, t.Name(), source.LastResponse(), saveInput.Path, saveInput.Block, saveInput.Overwrite)
+}
+
+// SaveOutput writes response, or its nth code block (0 = all of it, -1 = the last block), to path
+func SaveOutput(agent tools.ToolContext, toolName, response, path string, block int, overwrite bool) (string, error) {
+	if strings.TrimSpace(response) == "" {
+		return "", fmt.Errorf("there is no assistant response to save yet")
+	}
+	content, err := SelectOutput(response, block)
+	if err != nil {
+		return "", err
+	}
+
+	target, err := agent.ResolveFilePath(path)
+	if err != nil {
+		return "", err
+	}
+	name := filepath.ToSlash(displayPath(agent, target))
+	action, diff, perm := "write", additionDiff(name, content), os.FileMode(0o644)
+	if info, err := os.Stat(target); err == nil {
+		if info.IsDir() {
+			return "", fmt.Errorf("%s is a directory", path)
+		}
+		if !overwrite {
+			return "", fmt.Errorf("%s already exists; set overwrite=true to replace it", path)
+		}
+		current, err := os.ReadFile(target)
+		if err != nil {
+			return "", fmt.Errorf("failed to read %s: %w", path, err)
+		}
+		action, diff, perm = "overwrite", replacementDiff(name, string(current), content), info.Mode().Perm()
+	}
+
+	lines := strings.Count(content, "\n")
+	proposed := content
+	err = tools.RequestApproval(agent, approval.Request{
+		Tool:     toolName,
+		Summary:  fmt.Sprintf("%s %s with %d lines", action, name, lines),
+		Paths:    []string{name},
+		Lines:    lines,
+		Diff:     diff,
+		Proposed: &content,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := writeFileAtomic(target, []byte(content), perm); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	tools.NoteFileChanged(agent, target)
+	if content != proposed {
+		return fmt.Sprintf("Saved the user's edited version (%d lines) to %s", strings.Count(content, "\n"), displayPath(agent, target)), nil
+	}
+	return fmt.Sprintf("Saved %d lines (%s) to %s", lines, ui.FormatBytesPlain(int64(len(content))), displayPath(agent, target)), nil
+}
+
+// SelectOutput returns the whole response for block 0, otherwise the body of the nth fenced code block
+func SelectOutput(response string, block int) (string, error) {
+	if block == 0 {
+		return strings.TrimSpace(response) + "\n", nil
+	}
+
+	matches := codeFencePattern.FindAllStringSubmatch(response, -1)
+	if len(matches) == 0 {
+		return "", fmt.Errorf("the response has no code blocks")
+	}
+	if block == -1 {
+		block = len(matches)
+	}
+	if block < 1 || block > len(matches) {
+		return "", fmt.Errorf("code block %d does not exist; the response has %d", block, len(matches))
+	}
+	return matches[block-1][2], nil
+}
+
+// replacementDiff renders replacing before with after as a diff with one hunk around the changed lines
+func replacementDiff(path, before, after string) string {
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- a/%s\n+++ b/%s\n", path, path)
+	if before == after {
+		return diff.String()
+	}
+	start := 0
+	for start < len(before) && start < len(after) && before[start] == after[start] {
+		start++
+	}
+	start = strings.LastIndex(before[:start], "\n") + 1
+	// The unchanged tail is kept to whole lines in both versions
+	end := 0
+	for end < len(before)-start && end < len(after)-start && before[len(before)-1-end] == after[len(after)-1-end] {
+		end++
+	}
+	for end > 0 && (!atLineStart(before, len(before)-end) || !atLineStart(after, len(after)-end)) {
+		end--
+	}
+	writeHunk(&diff, before, start, before[start:len(before)-end], after[start:len(after)-end], 0)
+	return diff.String()
+}
+
+// atLineStart reports whether offset i of s begins a line
+func atLineStart(s string, i int) bool {
+	return i == 0 || s[i-1] == '\n'
+}
+
+// additionDiff renders content as the diff of a new file
+func additionDiff(path, content string) string {
+	var diff strings.Builder
+	fmt.Fprintf(&diff, "--- /dev/null\n+++ b/%s\n", path)
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if line != "" {
+			diff.WriteString("+" + line)
+		}
+	}
+	return diff.String()
+}
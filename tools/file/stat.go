@@ -0,0 +1,245 @@
+package file
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"anthropic-chat/config"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// languages maps file extensions to the language they are written in
+var languages = map[string]string{
+	".go": "Go", ".py": "Python", ".pyi": "Python", ".js": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	".jsx": "JavaScript (JSX)", ".ts": "TypeScript", ".tsx": "TypeScript (TSX)", ".rs": "Rust", ".java": "Java",
+	".kt": "Kotlin", ".rb": "Ruby", ".php": "PHP", ".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".hpp": "C++",
+	".cs": "C#", ".swift": "Swift", ".scala": "Scala", ".sh": "Shell", ".bash": "Shell", ".zsh": "Shell",
+	".sql": "SQL", ".html": "HTML", ".css": "CSS", ".scss": "SCSS", ".md": "Markdown", ".json": "JSON",
+	".yaml": "YAML", ".yml": "YAML", ".toml": "TOML", ".xml": "XML", ".proto": "Protocol Buffers", ".lua": "Lua",
+	".ex": "Elixir", ".exs": "Elixir", ".hs": "Haskell", ".tf": "Terraform", ".txt": "Text",
+}
+
+// languageNames maps well-known file names without a telling extension to their language
+var languageNames = map[string]string{
+	"Makefile": "Makefile", "Dockerfile": "Dockerfile", "go.mod": "Go module", "go.sum": "Go checksums",
+	"Gemfile": "Ruby", "Rakefile": "Ruby", "CMakeLists.txt": "CMake",
+}
+
+// StatFileTool implements the stat_file tool
+type StatFileTool struct{}
+
+// NewStatFileTool creates a new StatFile tool instance
+func NewStatFileTool() *StatFileTool {
+	return &StatFileTool{}
+}
+
+// Name returns the tool name
+func (t *StatFileTool) Name() string {
+	return "stat_file"
+}
+
+// Description returns the tool description
+func (t *StatFileTool) Description() string {
+	return "Check files or directories without reading them: existence, size, mode, modification time, line count, language, and whether git tracks the file and it has uncommitted changes. Much cheaper than read_file for checking that a file exists or is fresh."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *StatFileTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.StatFileInputSchema
+}
+
+// Execute reports on each path; a missing path is part of the result, not an error
+func (t *StatFileTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var statInput schemas.StatFileInput
+	if err := json.Unmarshal(input, &statInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	if err := statInput.Paths.Validate(); err != nil {
+		return "", err
+	}
+
+	var reports []string
+	for _, path := range statInput.Paths.Strings() {
+		fullPath, err := agent.ResolveFilePath(path)
+		if err != nil {
+			return "", err
+		}
+		report, err := statPath(ctx, path, fullPath)
+		if err != nil {
+			return "", err
+		}
+		reports = append(reports, report)
+	}
+	return strings.Join(reports, "\n\n"), nil
+}
+
+// statPath describes one path as "key: value" lines
+func statPath(ctx context.Context, path, fullPath string) (string, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "path: %s\n", path)
+	info, err := os.Lstat(fullPath)
+	if errors.Is(err, os.ErrNotExist) {
+		b.WriteString("exists: no")
+		return b.String(), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, _ := os.Readlink(fullPath)
+		fmt.Fprintf(&b, "symlink: %s\n", target)
+		if info, err = os.Stat(fullPath); err != nil {
+			b.WriteString("exists: no (broken symlink)")
+			return b.String(), nil
+		}
+	}
+	kind := "file"
+	if info.IsDir() {
+		kind = "directory"
+	}
+	fmt.Fprintf(&b, "type: %s\n", kind)
+	if info.IsDir() {
+		if entries, err := os.ReadDir(fullPath); err == nil {
+			fmt.Fprintf(&b, "entries: %d\n", len(entries))
+		}
+	} else {
+		fmt.Fprintf(&b, "size: %s (%d bytes)\n", ui.FormatBytesPlain(info.Size()), info.Size())
+	}
+	fmt.Fprintf(&b, "mode: %s\n", info.Mode().Perm())
+	fmt.Fprintf(&b, "modified: %s (%s ago)\n", info.ModTime().Format(time.RFC3339), ui.FormatDurationPlain(time.Since(info.ModTime())))
+	if !info.IsDir() {
+		if lines, binary, ok := countLines(fullPath, info.Size()); ok {
+			if binary {
+				b.WriteString("lines: binary file\n")
+			} else {
+				fmt.Fprintf(&b, "lines: %d\n", lines)
+			}
+		}
+		if language := detectLanguage(fullPath); language != "" {
+			fmt.Fprintf(&b, "language: %s\n", language)
+		}
+	}
+	fmt.Fprintf(&b, "git: %s", gitState(ctx, fullPath, info.IsDir()))
+	return b.String(), nil
+}
+
+// countLines counts the lines of a text file, as wc -l does plus a final line without a newline. It
+// reports binary files, and gives up on files over StatLineCountMaxBytes.
+func countLines(fullPath string, size int64) (lines int, binary bool, ok bool) {
+	if size > config.StatLineCountMaxBytes {
+		return 0, false, false
+	}
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return 0, false, false
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	if head, _ := reader.Peek(binarySniffBytes); bytes.IndexByte(head, 0) >= 0 {
+		return 0, true, true
+	}
+	buf := make([]byte, 32*1024)
+	last := byte('\n')
+	for {
+		n, err := reader.Read(buf)
+		if n > 0 {
+			lines += bytes.Count(buf[:n], []byte{'\n'})
+			last = buf[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, false, false
+		}
+	}
+	if last != '\n' {
+		lines++
+	}
+	return lines, false, true
+}
+
+// detectLanguage names a file's language from its name or extension, or from a #! line
+func detectLanguage(fullPath string) string {
+	name := filepath.Base(fullPath)
+	if language, ok := languageNames[name]; ok {
+		return language
+	}
+	if language, ok := languages[strings.ToLower(filepath.Ext(name))]; ok {
+		return language
+	}
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	first, _ := bufio.NewReader(f).ReadString('\n')
+	interpreter, ok := strings.CutPrefix(strings.TrimSpace(first), "#!")
+	if !ok {
+		return ""
+	}
+	switch fields := strings.Fields(interpreter); {
+	case len(fields) == 0:
+		return ""
+	case filepath.Base(fields[0]) == "env" && len(fields) > 1:
+		interpreter = fields[len(fields)-1]
+	default:
+		interpreter = filepath.Base(fields[0])
+	}
+	switch {
+	case strings.HasPrefix(interpreter, "python"):
+		return "Python"
+	case interpreter == "node":
+		return "JavaScript"
+	case interpreter == "ruby":
+		return "Ruby"
+	case interpreter == "sh", interpreter == "bash", interpreter == "zsh":
+		return "Shell"
+	}
+	return ""
+}
+
+// gitState says whether git tracks the path and whether it has uncommitted changes
+func gitState(ctx context.Context, fullPath string, isDir bool) string {
+	ctx, cancel := context.WithTimeout(ctx, config.StatGitTimeoutSeconds*time.Second)
+	defer cancel()
+	dir, name := filepath.Split(fullPath)
+	out, err := exec.CommandContext(ctx, "git", "-C", dir, "status", "--porcelain=v1", "--ignored", "--", name).Output()
+	if errors.Is(err, exec.ErrNotFound) {
+		return "unknown (git is not installed)"
+	}
+	if err != nil {
+		return "not in a git repository"
+	}
+	status := strings.TrimRight(string(out), "\n")
+	switch {
+	case isDir && status == "":
+		return "no uncommitted changes"
+	case isDir:
+		return fmt.Sprintf("%d path(s) inside with uncommitted changes or untracked", strings.Count(status, "\n")+1)
+	case status == "":
+		return "tracked, clean"
+	case strings.HasPrefix(status, "??"):
+		return "untracked"
+	case strings.HasPrefix(status, "!!"):
+		return "ignored"
+	default:
+		return fmt.Sprintf("tracked, uncommitted changes (%s)", strings.TrimSpace(status[:2]))
+	}
+}
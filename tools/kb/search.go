@@ -0,0 +1,80 @@
+package kb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"anthropic-chat/embeddings"
+	"anthropic-chat/kb"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const defaultLimit = 5
+
+// SearchTool implements the kb_search tool
+type SearchTool struct {
+	baseDir  string
+	embedder embeddings.Embedder
+}
+
+// NewSearchTool creates a new kb_search tool reading knowledge bases from baseDir
+func NewSearchTool(baseDir string, embedder embeddings.Embedder) *SearchTool {
+	return &SearchTool{baseDir: baseDir, embedder: embedder}
+}
+
+// Name returns the tool name
+func (t *SearchTool) Name() string {
+	return "kb_search"
+}
+
+// Description returns the tool description
+func (t *SearchTool) Description() string {
+	return "Search the project's own documentation (Markdown docs, ADRs, runbooks added with `goocode kb add`) and return the most relevant passages with their source files. Prefer this over general knowledge for questions about this project's architecture, decisions and procedures."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *SearchTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.KBSearchInputSchema
+}
+
+// Execute searches the knowledge base for the working directory
+func (t *SearchTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var searchInput schemas.KBSearchInput
+	if err := json.Unmarshal(input, &searchInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	if strings.TrimSpace(searchInput.Query) == "" {
+		return "", fmt.Errorf("query is required")
+	}
+	limit := searchInput.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	base, err := kb.Open(t.baseDir, agent.WorkingDir(), t.embedder)
+	if err != nil {
+		return "", err
+	}
+	results, err := base.Search(ctx, searchInput.Query, limit)
+	if err != nil {
+		return "", err
+	}
+	if len(results) == 0 {
+		return "The knowledge base for this project is empty. The user can add documentation with `goocode kb add docs/`.", nil
+	}
+
+	var out strings.Builder
+	for i, result := range results {
+		location := result.Source
+		if result.Title != "" {
+			location += " § " + result.Title
+		}
+		fmt.Fprintf(&out, "[%d] %s (score %.2f)\n%s\n\n", i+1, location, result.Score, result.Text)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
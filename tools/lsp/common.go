@@ -0,0 +1,74 @@
+package lsp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/lsp"
+	"anthropic-chat/tools"
+)
+
+// locationResult is the model-facing representation of an LSP location
+type locationResult struct {
+	Path   string `json:"path"`
+	Line   int    `json:"line"`
+	Column int    `json:"column"`
+	Text   string `json:"text,omitempty"`
+}
+
+// toPosition converts 1-based tool coordinates to a zero-based LSP position
+func toPosition(line, column int) lsp.Position {
+	if line < 1 {
+		line = 1
+	}
+	if column < 1 {
+		column = 1
+	}
+	return lsp.Position{Line: line - 1, Character: column - 1}
+}
+
+// formatLocations maps LSP locations to paths relative to the working directory with the source line attached
+// for files the context may read
+func formatLocations(agent tools.ToolContext, locations []lsp.Location) []locationResult {
+	fileLines := make(map[string][]string)
+	results := make([]locationResult, 0, len(locations))
+
+	for _, location := range locations {
+		path := lsp.URIToPath(location.URI)
+		result := locationResult{
+			Path:   relativeTo(agent.WorkingDir(), path),
+			Line:   location.Range.Start.Line + 1,
+			Column: location.Range.Start.Character + 1,
+		}
+
+		lines, ok := fileLines[path]
+		if !ok {
+			// Lines of files outside the working directory, or denied by .goocodeignore, aren't shown
+			if tools.InWorkingDir(agent, path) && !tools.Ignored(agent, path, false) {
+				if content, err := os.ReadFile(path); err == nil {
+					lines = strings.Split(string(content), "\n")
+				}
+			}
+			fileLines[path] = lines
+		}
+		if location.Range.Start.Line < len(lines) {
+			result.Text = strings.TrimSpace(lines[location.Range.Start.Line])
+		}
+
+		results = append(results, result)
+	}
+	return results
+}
+
+func relativeTo(base, path string) string {
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return path
+	}
+	rel, err := filepath.Rel(absBase, path)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return path
+	}
+	return filepath.ToSlash(rel)
+}
@@ -0,0 +1,70 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"anthropic-chat/lsp"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// GoToDefinitionTool implements the go_to_definition tool
+type GoToDefinitionTool struct {
+	manager *lsp.Manager
+}
+
+// NewGoToDefinitionTool creates a new GoToDefinition tool instance
+func NewGoToDefinitionTool(manager *lsp.Manager) *GoToDefinitionTool {
+	return &GoToDefinitionTool{manager: manager}
+}
+
+// Name returns the tool name
+func (t *GoToDefinitionTool) Name() string {
+	return "go_to_definition"
+}
+
+// Description returns the tool description
+func (t *GoToDefinitionTool) Description() string {
+	return "Find where the symbol at a file position is defined, using the project's language server (Go, Python, TypeScript/JavaScript)."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *GoToDefinitionTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.GoToDefinitionInputSchema
+}
+
+// Execute performs the definition lookup
+func (t *GoToDefinitionTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var defInput schemas.GoToDefinitionInput
+	if err := json.Unmarshal(input, &defInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+
+	fullPath, err := agent.ResolveFilePath(defInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	client, err := t.manager.ClientFor(ctx, agent.WorkingDir(), fullPath)
+	if err != nil {
+		return "", err
+	}
+
+	locations, err := client.Definition(ctx, fullPath, toPosition(defInput.Line, defInput.Column))
+	if err != nil {
+		return "", fmt.Errorf("definition lookup failed for %s: %w", defInput.Path, err)
+	}
+	if len(locations) == 0 {
+		return "No definition found at that position.", nil
+	}
+
+	result, err := json.Marshal(formatLocations(agent, locations))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal definitions: %w", err)
+	}
+	return string(result), nil
+}
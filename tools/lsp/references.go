@@ -0,0 +1,70 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"anthropic-chat/lsp"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// FindReferencesTool implements the find_references tool
+type FindReferencesTool struct {
+	manager *lsp.Manager
+}
+
+// NewFindReferencesTool creates a new FindReferences tool instance
+func NewFindReferencesTool(manager *lsp.Manager) *FindReferencesTool {
+	return &FindReferencesTool{manager: manager}
+}
+
+// Name returns the tool name
+func (t *FindReferencesTool) Name() string {
+	return "find_references"
+}
+
+// Description returns the tool description
+func (t *FindReferencesTool) Description() string {
+	return "List every reference to the symbol at a file position, using the project's language server."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *FindReferencesTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.FindReferencesInputSchema
+}
+
+// Execute performs the references lookup
+func (t *FindReferencesTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var refInput schemas.FindReferencesInput
+	if err := json.Unmarshal(input, &refInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+
+	fullPath, err := agent.ResolveFilePath(refInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	client, err := t.manager.ClientFor(ctx, agent.WorkingDir(), fullPath)
+	if err != nil {
+		return "", err
+	}
+
+	locations, err := client.References(ctx, fullPath, toPosition(refInput.Line, refInput.Column), refInput.IncludeDeclaration)
+	if err != nil {
+		return "", fmt.Errorf("reference lookup failed for %s: %w", refInput.Path, err)
+	}
+	if len(locations) == 0 {
+		return "No references found at that position.", nil
+	}
+
+	result, err := json.Marshal(formatLocations(agent, locations))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal references: %w", err)
+	}
+	return string(result), nil
+}
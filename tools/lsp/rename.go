@@ -0,0 +1,405 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/config"
+	"anthropic-chat/lsp"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// identifierPattern matches a valid new name
+var identifierPattern = regexp.MustCompile(`^[\p{L}_][\p{L}\p{N}_]*$`)
+
+// renameSkipDirs are never searched by the text fallback
+var renameSkipDirs = map[string]bool{".git": true, "node_modules": true, "vendor": true}
+
+// RenameSymbolTool implements the rename_symbol tool
+type RenameSymbolTool struct {
+	manager *lsp.Manager
+}
+
+// NewRenameSymbolTool creates a new RenameSymbol tool instance
+func NewRenameSymbolTool(manager *lsp.Manager) *RenameSymbolTool {
+	return &RenameSymbolTool{manager: manager}
+}
+
+// Name returns the tool name
+func (t *RenameSymbolTool) Name() string {
+	return "rename_symbol"
+}
+
+// Description returns the tool description
+func (t *RenameSymbolTool) Description() string {
+	return "Rename the identifier at a file position everywhere in the project with one call, returning the files changed and a combined diff. Uses the project's language server (Go, Python, TypeScript/JavaScript), which renames only that symbol; for other files, or when no server is installed, it renames every whole-word match in files with the same extension, including comments and strings, so check the diff. Prefer this over editing files one by one."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *RenameSymbolTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.RenameSymbolInputSchema
+}
+
+// renamedFile is one file a rename changes
+type renamedFile struct {
+	path        string // Absolute
+	name        string // Relative to the working directory
+	before      string
+	after       string
+	occurrences int
+	mode        os.FileMode
+}
+
+// Execute finds every occurrence of the symbol, asks for approval with the combined diff and writes the files
+func (t *RenameSymbolTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var renameInput schemas.RenameSymbolInput
+	if err := json.Unmarshal(input, &renameInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	if !identifierPattern.MatchString(renameInput.NewName) {
+		return "", fmt.Errorf("%q is not a valid identifier", renameInput.NewName)
+	}
+
+	fullPath, err := agent.ResolveFilePath(renameInput.Path)
+	if err != nil {
+		return "", err
+	}
+	content, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", renameInput.Path, err)
+	}
+	oldName, err := identifierAt(string(content), renameInput.Line, renameInput.Column)
+	if err != nil {
+		return "", fmt.Errorf("%s:%d:%d: %w", renameInput.Path, renameInput.Line, renameInput.Column, err)
+	}
+	if oldName == renameInput.NewName {
+		return "", fmt.Errorf("the symbol is already named %s", oldName)
+	}
+
+	var files []renamedFile
+	method := "via the language server"
+	client, err := t.manager.ClientFor(ctx, agent.WorkingDir(), fullPath)
+	if err == nil {
+		edits, err := client.Rename(ctx, fullPath, toPosition(renameInput.Line, renameInput.Column), renameInput.NewName)
+		if err != nil {
+			return "", fmt.Errorf("rename failed for %s: %w", renameInput.Path, err)
+		}
+		if len(edits) == 0 {
+			return "", fmt.Errorf("the language server found nothing to rename at %s:%d:%d", renameInput.Path, renameInput.Line, renameInput.Column)
+		}
+		if files, err = applyServerEdits(agent, edits, oldName); err != nil {
+			return "", err
+		}
+	} else {
+		method = fmt.Sprintf("by whole-word text match in %s files, because %v; comments and strings were renamed too", extensionLabel(fullPath), err)
+		if files, err = textRename(ctx, agent, fullPath, oldName, renameInput.NewName); err != nil {
+			return "", err
+		}
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("no occurrences of %s were found to rename", oldName)
+	}
+	slices.SortFunc(files, func(a, b renamedFile) int { return strings.Compare(a.name, b.name) })
+
+	var diff strings.Builder
+	names := make([]string, 0, len(files))
+	occurrences, lines := 0, 0
+	for _, file := range files {
+		names = append(names, file.name)
+		occurrences += file.occurrences
+		lines += writeRenameDiff(&diff, file)
+	}
+	err = tools.RequestApproval(agent, approval.Request{
+		Tool:    t.Name(),
+		Summary: fmt.Sprintf("rename %s to %s in %d file(s) (%d occurrence(s))", oldName, renameInput.NewName, len(files), occurrences),
+		Paths:   names,
+		Lines:   lines,
+		Diff:    diff.String(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for i, file := range files {
+		if err := os.WriteFile(file.path, []byte(file.after), file.mode); err != nil {
+			return "", fmt.Errorf("failed to write %s after renaming %d of %d file(s): %w", file.name, i, len(files), err)
+		}
+		tools.NoteFileChanged(agent, file.path)
+	}
+	var result strings.Builder
+	fmt.Fprintf(&result, "Renamed %s to %s: %d occurrence(s) in %d file(s), %s.\n\n", oldName, renameInput.NewName, occurrences, len(files), method)
+	for _, file := range files {
+		fmt.Fprintf(&result, "%s (%d)\n", file.name, file.occurrences)
+	}
+	combined := diff.String()
+	if len(combined) > config.RenameResultDiffBytes {
+		combined = combined[:strings.LastIndex(combined[:config.RenameResultDiffBytes], "\n")+1] + "[diff truncated; read the files to see the rest]\n"
+	}
+	result.WriteString("\n" + combined)
+	return result.String(), nil
+}
+
+// applyServerEdits applies the language server's edits in memory, refusing any file outside the
+// working directory or denied by it, and any edit that doesn't replace oldName
+func applyServerEdits(agent tools.ToolContext, edits map[string][]lsp.TextEdit, oldName string) ([]renamedFile, error) {
+	var files []renamedFile
+	for path, fileEdits := range edits {
+		name := relativeTo(agent.WorkingDir(), path)
+		if filepath.IsAbs(name) {
+			return nil, fmt.Errorf("the rename would change %s, outside the working directory; nothing was changed", path)
+		}
+		fullPath, err := agent.ResolveFilePath(name)
+		if err != nil {
+			return nil, fmt.Errorf("the rename would change %s: %w", name, err)
+		}
+		info, err := os.Stat(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to access %s: %w", name, err)
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+		after, err := applyTextEdits(string(content), fileEdits, oldName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to apply the edits to %s: %w; nothing was changed", name, err)
+		}
+		if after == string(content) {
+			continue
+		}
+		files = append(files, renamedFile{path: fullPath, name: name, before: string(content), after: after, occurrences: len(fileEdits), mode: info.Mode().Perm()})
+	}
+	return files, nil
+}
+
+// applyTextEdits applies non-overlapping LSP edits to content, each of which must replace oldName.
+// A range that covers anything else means the server saw different contents than the file has now.
+func applyTextEdits(content string, edits []lsp.TextEdit, oldName string) (string, error) {
+	type span struct {
+		start, end int
+		text       string
+	}
+	spans := make([]span, 0, len(edits))
+	for _, edit := range edits {
+		start, err := byteOffset(content, edit.Range.Start)
+		if err != nil {
+			return "", err
+		}
+		end, err := byteOffset(content, edit.Range.End)
+		if err != nil {
+			return "", err
+		}
+		if end < start {
+			return "", fmt.Errorf("edit range ends before it starts")
+		}
+		if content[start:end] != oldName {
+			return "", fmt.Errorf("the edit at line %d doesn't cover %s, so the file changed since the language server read it", edit.Range.Start.Line+1, oldName)
+		}
+		spans = append(spans, span{start, end, edit.NewText})
+	}
+	// Apply from the end so earlier offsets stay valid
+	slices.SortFunc(spans, func(a, b span) int { return b.start - a.start })
+	for i, s := range spans {
+		if i > 0 && s.end > spans[i-1].start {
+			return "", fmt.Errorf("edits overlap")
+		}
+		content = content[:s.start] + s.text + content[s.end:]
+	}
+	return content, nil
+}
+
+// byteOffset converts an LSP position, whose character counts UTF-16 code units, to a byte offset in content
+func byteOffset(content string, pos lsp.Position) (int, error) {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		nl := strings.IndexByte(content[offset:], '\n')
+		if nl < 0 {
+			return 0, fmt.Errorf("line %d is past the end of the file", pos.Line+1)
+		}
+		offset += nl + 1
+	}
+	for units := 0; units < pos.Character && offset < len(content) && content[offset] != '\n'; {
+		r, size := utf8.DecodeRuneInString(content[offset:])
+		units += utf16.RuneLen(r)
+		offset += size
+	}
+	return offset, nil
+}
+
+// textRename renames every whole-word match of oldName in the files under the working directory with
+// the same extension as path
+func textRename(ctx context.Context, agent tools.ToolContext, path, oldName, newName string) ([]renamedFile, error) {
+	ext := filepath.Ext(path)
+	var files []renamedFile
+	err := filepath.WalkDir(agent.WorkingDir(), func(current string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Skip what can't be read
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.IsDir() {
+			if current != agent.WorkingDir() && (renameSkipDirs[entry.Name()] || tools.Ignored(agent, current, true)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(current) != ext || !entry.Type().IsRegular() || tools.Ignored(agent, current, false) {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil || info.Size() > config.RenameMaxFileBytes {
+			return nil
+		}
+		content, err := os.ReadFile(current)
+		if err != nil || strings.IndexByte(string(content), 0) >= 0 {
+			return nil
+		}
+		after, count := replaceWord(string(content), oldName, newName)
+		if count > 0 {
+			files = append(files, renamedFile{path: current, name: relativeTo(agent.WorkingDir(), current), before: string(content), after: after, occurrences: count, mode: info.Mode().Perm()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search for %s: %w", oldName, err)
+	}
+	return files, nil
+}
+
+// replaceWord replaces each match of word not joined to other identifier characters, returning the result and the count
+func replaceWord(content, word, replacement string) (string, int) {
+	var b strings.Builder
+	count, last := 0, 0
+	for from := 0; ; {
+		i := strings.Index(content[from:], word)
+		if i < 0 {
+			break
+		}
+		start, end := from+i, from+i+len(word)
+		from = end
+		if before, _ := utf8.DecodeLastRuneInString(content[:start]); start > 0 && isIdentifierRune(before) {
+			continue
+		}
+		if after, _ := utf8.DecodeRuneInString(content[end:]); end < len(content) && isIdentifierRune(after) {
+			continue
+		}
+		b.WriteString(content[last:start])
+		b.WriteString(replacement)
+		last = end
+		count++
+	}
+	if count == 0 {
+		return content, 0
+	}
+	b.WriteString(content[last:])
+	return b.String(), count
+}
+
+// identifierAt returns the identifier covering the 1-based line and column of content
+func identifierAt(content string, line, column int) (string, error) {
+	lines := strings.Split(content, "\n")
+	if line < 1 || line > len(lines) {
+		return "", fmt.Errorf("line is outside the file, which has %d lines", len(lines))
+	}
+	runes := []rune(lines[line-1])
+	at := max(column, 1) - 1
+	if at >= len(runes) || !isIdentifierRune(runes[at]) {
+		// Allow pointing just past the end of the identifier
+		if at == 0 || at > len(runes) || !isIdentifierRune(runes[at-1]) {
+			return "", fmt.Errorf("no identifier at this position")
+		}
+		at--
+	}
+	start, end := at, at+1
+	for start > 0 && isIdentifierRune(runes[start-1]) {
+		start--
+	}
+	for end < len(runes) && isIdentifierRune(runes[end]) {
+		end++
+	}
+	if unicode.IsDigit(runes[start]) {
+		return "", fmt.Errorf("%s is a number, not an identifier", string(runes[start:end]))
+	}
+	return string(runes[start:end]), nil
+}
+
+func isIdentifierRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// extensionLabel names the kind of files the text fallback searches
+func extensionLabel(path string) string {
+	if ext := filepath.Ext(path); ext != "" {
+		return ext
+	}
+	return "extensionless"
+}
+
+// writeRenameDiff adds a unified diff of file to diff, with a little unchanged context around each hunk,
+// and returns the lines changed
+func writeRenameDiff(diff *strings.Builder, file renamedFile) int {
+	before, after := splitLines(file.before), splitLines(file.after)
+	fmt.Fprintf(diff, "--- a/%s\n+++ b/%s\n", file.name, file.name)
+	if len(before) != len(after) {
+		// An edit added or removed lines, so show the whole file
+		fmt.Fprintf(diff, "@@ -1,%d +1,%d @@\n", len(before), len(after))
+		writeLines(diff, "-", before)
+		writeLines(diff, "+", after)
+		return max(len(before), len(after))
+	}
+
+	var changed []int
+	for i := range before {
+		if before[i] != after[i] {
+			changed = append(changed, i)
+		}
+	}
+	for i := 0; i < len(changed); {
+		// Lines close enough to share their context go in one hunk
+		j := i
+		for j+1 < len(changed) && changed[j+1]-changed[j] <= 2*config.RenameDiffContext+1 {
+			j++
+		}
+		start := max(0, changed[i]-config.RenameDiffContext)
+		end := min(len(before), changed[j]+config.RenameDiffContext+1)
+		fmt.Fprintf(diff, "@@ -%d,%d +%d,%d @@\n", start+1, end-start, start+1, end-start)
+		for line := start; line < end; line++ {
+			if before[line] == after[line] {
+				diff.WriteString(" " + before[line] + "\n")
+			} else {
+				diff.WriteString("-" + before[line] + "\n+" + after[line] + "\n")
+			}
+		}
+		i = j + 1
+	}
+	return len(changed)
+}
+
+// splitLines splits s into lines without their newlines
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
+
+func writeLines(diff *strings.Builder, prefix string, lines []string) {
+	for _, line := range lines {
+		diff.WriteString(prefix + line + "\n")
+	}
+}
@@ -0,0 +1,95 @@
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"anthropic-chat/lsp"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// DocumentSymbolsTool implements the document_symbols tool
+type DocumentSymbolsTool struct {
+	manager *lsp.Manager
+}
+
+// NewDocumentSymbolsTool creates a new DocumentSymbols tool instance
+func NewDocumentSymbolsTool(manager *lsp.Manager) *DocumentSymbolsTool {
+	return &DocumentSymbolsTool{manager: manager}
+}
+
+// symbolResult is the model-facing representation of a document symbol
+type symbolResult struct {
+	Name     string         `json:"name"`
+	Kind     string         `json:"kind"`
+	Detail   string         `json:"detail,omitempty"`
+	Line     int            `json:"line"`
+	EndLine  int            `json:"end_line"`
+	Children []symbolResult `json:"children,omitempty"`
+}
+
+// Name returns the tool name
+func (t *DocumentSymbolsTool) Name() string {
+	return "document_symbols"
+}
+
+// Description returns the tool description
+func (t *DocumentSymbolsTool) Description() string {
+	return "List the symbols (types, functions, methods, fields) declared in a source file with their line ranges, using the project's language server."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *DocumentSymbolsTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.DocumentSymbolsInputSchema
+}
+
+// Execute performs the document symbols lookup
+func (t *DocumentSymbolsTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var symInput schemas.DocumentSymbolsInput
+	if err := json.Unmarshal(input, &symInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+
+	fullPath, err := agent.ResolveFilePath(symInput.Path)
+	if err != nil {
+		return "", err
+	}
+
+	client, err := t.manager.ClientFor(ctx, agent.WorkingDir(), fullPath)
+	if err != nil {
+		return "", err
+	}
+
+	symbols, err := client.DocumentSymbols(ctx, fullPath)
+	if err != nil {
+		return "", fmt.Errorf("symbol lookup failed for %s: %w", symInput.Path, err)
+	}
+	if len(symbols) == 0 {
+		return "No symbols found in file.", nil
+	}
+
+	result, err := json.Marshal(convertSymbols(symbols))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal symbols: %w", err)
+	}
+	return string(result), nil
+}
+
+func convertSymbols(symbols []lsp.DocumentSymbol) []symbolResult {
+	results := make([]symbolResult, 0, len(symbols))
+	for _, symbol := range symbols {
+		results = append(results, symbolResult{
+			Name:     symbol.Name,
+			Kind:     lsp.SymbolKindName(symbol.Kind),
+			Detail:   symbol.Detail,
+			Line:     symbol.Range.Start.Line + 1,
+			EndLine:  symbol.Range.End.Line + 1,
+			Children: convertSymbols(symbol.Children),
+		})
+	}
+	return results
+}
@@ -0,0 +1,159 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/config"
+	"anthropic-chat/memory"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// MemoryTool implements the memory tool
+type MemoryTool struct{}
+
+// NewMemoryTool creates a new Memory tool instance
+func NewMemoryTool() *MemoryTool {
+	return &MemoryTool{}
+}
+
+// Name returns the tool name
+func (t *MemoryTool) Name() string {
+	return "memory"
+}
+
+// Description returns the tool description
+func (t *MemoryTool) Description() string {
+	return "Save and look up durable facts about this project that are expensive to rediscover, such as 'the API runs on port 8123' or 'tests require docker'. Facts persist across sessions in the project directory, and relevant ones are brought back automatically after the conversation is compacted. Search before re-investigating something you may already have learned. Don't store secrets or things the code already says plainly."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *MemoryTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.MemoryInputSchema
+}
+
+// Execute runs one memory action against the project's store
+func (t *MemoryTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var memoryInput schemas.MemoryInput
+	if err := json.Unmarshal(input, &memoryInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	keeper, ok := agent.(tools.MemoryKeeper)
+	if !ok {
+		return "", fmt.Errorf("memory is not supported here")
+	}
+	store, err := memory.Open(keeper.MemoryFile())
+	if err != nil {
+		return "", err
+	}
+
+	key := strings.TrimSpace(memoryInput.Key)
+	if key == "" && memoryInput.Action != "search" && memoryInput.Action != "list" {
+		return "", fmt.Errorf("key is required for %s", memoryInput.Action)
+	}
+	switch memoryInput.Action {
+	case "save":
+		value := strings.TrimSpace(memoryInput.Value)
+		if value == "" {
+			return "", fmt.Errorf("value is required for save")
+		}
+		if len(value) > config.MemoryValueMaxChars {
+			return "", fmt.Errorf("value is %d characters; keep facts under %d and point to a file for details", len(value), config.MemoryValueMaxChars)
+		}
+		if _, exists := store.Get(key); !exists && len(store.Facts) >= config.MemoryMaxFacts {
+			return "", fmt.Errorf("memory holds %d facts, the most kept per project; delete stale ones first", len(store.Facts))
+		}
+		if err := approveChange(agent, keeper, fmt.Sprintf("save %q to project memory: %s", key, value)); err != nil {
+			return "", err
+		}
+		replaced := store.Set(key, value)
+		if err := store.Save(); err != nil {
+			return "", err
+		}
+		if replaced {
+			return fmt.Sprintf("Updated %q", key), nil
+		}
+		return fmt.Sprintf("Saved %q", key), nil
+	case "get":
+		fact, ok := store.Get(key)
+		if !ok {
+			return fmt.Sprintf("Nothing is saved under %q%s", key, related(store, key)), nil
+		}
+		return fmt.Sprintf("%s: %s", fact.Key, fact.Value), nil
+	case "delete":
+		if _, exists := store.Get(key); !exists {
+			return fmt.Sprintf("Nothing is saved under %q", key), nil
+		}
+		if err := approveChange(agent, keeper, fmt.Sprintf("delete %q from project memory", key)); err != nil {
+			return "", err
+		}
+		store.Delete(key)
+		if err := store.Save(); err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Deleted %q", key), nil
+	case "search":
+		if strings.TrimSpace(memoryInput.Query) == "" {
+			return "", fmt.Errorf("query is required for search")
+		}
+		facts := store.Search(memoryInput.Query, config.MemoryRecallLimit)
+		if len(facts) == 0 {
+			return "No saved facts match", nil
+		}
+		return Format(facts), nil
+	case "list":
+		if len(store.Facts) == 0 {
+			return "No facts are saved for this project", nil
+		}
+		keys := make([]string, len(store.Facts))
+		for i, fact := range store.Facts {
+			keys[i] = fact.Key
+		}
+		return fmt.Sprintf("%d saved fact(s): %s", len(keys), strings.Join(keys, ", ")), nil
+	default:
+		return "", fmt.Errorf("unknown action %q (expected save, get, search, delete or list)", memoryInput.Action)
+	}
+}
+
+// approveChange asks before the memory file is written, so read-only mode, dry runs and deny rules apply
+func approveChange(agent tools.ToolContext, keeper tools.MemoryKeeper, summary string) error {
+	path := keeper.MemoryFile()
+	if rel, err := filepath.Rel(agent.WorkingDir(), path); err == nil {
+		path = rel
+	}
+	return tools.RequestApproval(agent, approval.Request{
+		Tool:    "memory",
+		Summary: summary,
+		Paths:   []string{filepath.ToSlash(path)},
+		Lines:   1,
+	})
+}
+
+// Format lists facts as "- key: value" lines
+func Format(facts []memory.Fact) string {
+	lines := make([]string, len(facts))
+	for i, fact := range facts {
+		lines[i] = fmt.Sprintf("- %s: %s", fact.Key, fact.Value)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// related suggests saved keys close to one that wasn't found
+func related(store *memory.Store, key string) string {
+	facts := store.Search(key, 3)
+	if len(facts) == 0 {
+		return ""
+	}
+	keys := make([]string, len(facts))
+	for i, fact := range facts {
+		keys[i] = fmt.Sprintf("%q", fact.Key)
+	}
+	return "; related keys: " + strings.Join(keys, ", ")
+}
@@ -0,0 +1,107 @@
+package outline
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// printerConfig aligns with spaces, since tabwriter cells don't survive being cut out of the file
+var printerConfig = printer.Config{Mode: printer.UseSpaces | printer.TabIndent, Tabwidth: 8}
+
+// outlineGo lists a Go file's declarations with go/parser; long var values are elided
+func outlineGo(path string, src []byte) ([]string, []entry, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+	if file == nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	header := []string{"package " + file.Name.Name}
+	if len(file.Imports) > 0 {
+		paths := make([]string, 0, len(file.Imports))
+		for _, spec := range file.Imports {
+			importPath, _ := strconv.Unquote(spec.Path.Value)
+			paths = append(paths, importPath)
+		}
+		header = append(header, "imports: "+strings.Join(paths, ", "))
+	}
+
+	var entries []entry
+	for _, decl := range file.Decls {
+		var doc *ast.CommentGroup
+		var node ast.Node
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			fn := *d
+			doc, fn.Doc, fn.Body = d.Doc, nil, nil
+			node = &fn
+		case *ast.GenDecl:
+			if d.Tok == token.IMPORT {
+				continue
+			}
+			gen := *d
+			doc, gen.Doc = d.Doc, nil
+			if d.Tok == token.VAR {
+				gen.Specs = elideValues(d.Specs)
+			}
+			node = &gen
+		default:
+			continue
+		}
+
+		var signature bytes.Buffer
+		if err := printerConfig.Fprint(&signature, fset, node); err != nil {
+			return nil, nil, fmt.Errorf("failed to print declaration: %w", err)
+		}
+		entries = append(entries, entry{
+			Line:      fset.Position(decl.Pos()).Line,
+			EndLine:   fset.Position(decl.End()).Line,
+			Doc:       commentText(doc),
+			Signature: signature.String(),
+		})
+	}
+	return header, entries, nil
+}
+
+// elideValues replaces function and composite literal values, which can run for pages, with "..."
+func elideValues(specs []ast.Spec) []ast.Spec {
+	elided := make([]ast.Spec, len(specs))
+	for i, spec := range specs {
+		value, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			elided[i] = spec
+			continue
+		}
+		copied := *value
+		copied.Doc, copied.Comment = nil, nil
+		copied.Values = make([]ast.Expr, len(value.Values))
+		for j, expr := range value.Values {
+			switch expr.(type) {
+			case *ast.FuncLit, *ast.CompositeLit:
+				copied.Values[j] = &ast.Ident{Name: "...", NamePos: expr.Pos()}
+			default:
+				copied.Values[j] = expr
+			}
+		}
+		elided[i] = &copied
+	}
+	return elided
+}
+
+// commentText renders a doc comment group back as // lines
+func commentText(doc *ast.CommentGroup) string {
+	if doc == nil {
+		return ""
+	}
+	lines := make([]string, 0, len(doc.List))
+	for _, comment := range doc.List {
+		lines = append(lines, comment.Text)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,100 @@
+package outline
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	maxSignatureLines = 20 // Longer declarations, such as big structs, are cut with a marker
+	maxDocLines       = 10 // Doc comments are cut to this many lines
+)
+
+// entry is one declaration in an outline
+type entry struct {
+	Line, EndLine int
+	Depth         int // Nesting inside classes, impls, namespaces and the like
+	Doc           string
+	Signature     string
+}
+
+// Declaration is where a declaration in a source file starts and ends, for splitting files at them
+type Declaration struct {
+	Line, EndLine int
+	Depth         int    // 0 for top-level declarations
+	Signature     string // The declaration without its body, e.g. "func Outline(ctx context.Context, ...) (string, error)"
+}
+
+// Declarations lists the declarations of the source file at path in order
+func Declarations(ctx context.Context, path string, src []byte) ([]Declaration, error) {
+	_, _, entries, err := parse(ctx, path, src)
+	if err != nil {
+		return nil, err
+	}
+	declarations := make([]Declaration, len(entries))
+	for i, e := range entries {
+		declarations[i] = Declaration{Line: e.Line, EndLine: e.EndLine, Depth: e.Depth, Signature: e.Signature}
+	}
+	return declarations, nil
+}
+
+// parse picks the parser for path's language
+func parse(ctx context.Context, path string, src []byte) (language string, header []string, entries []entry, err error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext == ".go" {
+		header, entries, err = outlineGo(path, src)
+		return "go", header, entries, err
+	}
+	language, entries, err = outlineTreeSitter(ctx, ext, src)
+	return language, nil, entries, err
+}
+
+// Outline returns the declarations, signatures and doc comments of the source file at path, without bodies
+func Outline(ctx context.Context, path string, src []byte) (string, error) {
+	language, header, entries, err := parse(ctx, path, src)
+	if err != nil {
+		return "", err
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "%s (%s, %d lines)\n", filepath.ToSlash(path), language, bytes.Count(src, []byte("\n"))+1)
+	for _, line := range header {
+		out.WriteString(line + "\n")
+	}
+	if len(entries) == 0 {
+		out.WriteString("(no declarations)\n")
+	}
+	for _, e := range entries {
+		indent := strings.Repeat("  ", e.Depth)
+		if e.Depth == 0 || e.Doc != "" {
+			out.WriteString("\n")
+		}
+		for _, line := range clip(e.Doc, maxDocLines) {
+			out.WriteString(indent + line + "\n")
+		}
+		for i, line := range clip(e.Signature, maxSignatureLines) {
+			if i == 0 {
+				fmt.Fprintf(&out, "%s%d-%d: %s\n", indent, e.Line, e.EndLine, line)
+			} else {
+				out.WriteString(indent + line + "\n")
+			}
+		}
+	}
+	return out.String(), nil
+}
+
+// clip splits text into lines, keeping at most limit of them
+func clip(text string, limit int) []string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	if len(lines) > limit {
+		lines = append(lines[:limit], fmt.Sprintf("… (%d more lines)", len(lines)-limit))
+	}
+	return lines
+}
@@ -0,0 +1,56 @@
+package outline
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// OutlineFileTool implements the outline_file tool
+type OutlineFileTool struct{}
+
+// NewOutlineFileTool creates a new OutlineFile tool instance
+func NewOutlineFileTool() *OutlineFileTool {
+	return &OutlineFileTool{}
+}
+
+// Name returns the tool name
+func (t *OutlineFileTool) Name() string {
+	return "outline_file"
+}
+
+// Description returns the tool description
+func (t *OutlineFileTool) Description() string {
+	return "Outline a source file: its declarations, signatures and doc comments with line ranges, but no function bodies. " +
+		"Costs a fraction of read_file, so use it to survey large files before deciding which parts to read. " +
+		"Supports Go, Python, JavaScript, TypeScript, Rust, Java, C and C++."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *OutlineFileTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.OutlineFileInputSchema
+}
+
+// Execute parses the file and returns its outline
+func (t *OutlineFileTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var outlineInput schemas.OutlineFileInput
+	if err := json.Unmarshal(input, &outlineInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+
+	fullPath, err := agent.ResolveFilePath(outlineInput.Path)
+	if err != nil {
+		return "", err
+	}
+	src, err := os.ReadFile(fullPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", outlineInput.Path, err)
+	}
+	return Outline(ctx, outlineInput.Path, src)
+}
@@ -0,0 +1,272 @@
+//go:build cgo
+
+package outline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	sitter "github.com/smacker/go-tree-sitter"
+	"github.com/smacker/go-tree-sitter/c"
+	"github.com/smacker/go-tree-sitter/cpp"
+	"github.com/smacker/go-tree-sitter/java"
+	"github.com/smacker/go-tree-sitter/javascript"
+	"github.com/smacker/go-tree-sitter/python"
+	"github.com/smacker/go-tree-sitter/rust"
+	"github.com/smacker/go-tree-sitter/typescript/tsx"
+	"github.com/smacker/go-tree-sitter/typescript/typescript"
+)
+
+// grammar says which tree-sitter nodes of a language make up its outline
+type grammar struct {
+	name        string
+	language    func() *sitter.Language
+	decls       set               // Nodes listed in the outline
+	containers  set               // Declarations whose body is outlined too, one level deeper
+	whole       set               // Declarations shown in full rather than up to their body, like struct definitions
+	transparent set               // Nodes whose children are outlined as if they were siblings, like preprocessor blocks
+	wrappers    map[string]string // Nodes wrapping a declaration in the named field, like decorators and exports
+	docs        set               // Preceding siblings shown with a declaration, like comments and attributes
+}
+
+type set map[string]bool
+
+func newSet(types ...string) set {
+	s := make(set, len(types))
+	for _, t := range types {
+		s[t] = true
+	}
+	return s
+}
+
+var (
+	cDecls  = []string{"function_definition", "declaration", "type_definition", "struct_specifier", "union_specifier", "enum_specifier", "preproc_def", "preproc_function_def"}
+	cBlocks = []string{"preproc_if", "preproc_ifdef", "preproc_else", "preproc_elif"}
+	jsDecls = []string{"function_declaration", "generator_function_declaration", "class_declaration", "method_definition", "lexical_declaration", "variable_declaration"}
+	tsDecls = append([]string{"abstract_class_declaration", "interface_declaration", "type_alias_declaration", "enum_declaration", "function_signature", "method_signature", "abstract_method_signature", "public_field_definition", "module", "internal_module"}, jsDecls...)
+	tsWhole = newSet("interface_declaration", "type_alias_declaration", "enum_declaration")
+)
+
+var pythonGrammar = &grammar{
+	name:       "python",
+	language:   python.GetLanguage,
+	decls:      newSet("function_definition", "class_definition", "decorated_definition"),
+	containers: newSet("class_definition"),
+	wrappers:   map[string]string{"decorated_definition": "definition"},
+	docs:       newSet("comment"),
+}
+
+var javascriptGrammar = &grammar{
+	name:       "javascript",
+	language:   javascript.GetLanguage,
+	decls:      newSet(append(jsDecls, "export_statement")...),
+	containers: newSet("class_declaration"),
+	wrappers:   map[string]string{"export_statement": "declaration"},
+	docs:       newSet("comment"),
+}
+
+var typescriptGrammar = &grammar{
+	name:       "typescript",
+	language:   typescript.GetLanguage,
+	decls:      newSet(append(tsDecls, "export_statement")...),
+	containers: newSet("class_declaration", "abstract_class_declaration", "module", "internal_module"),
+	whole:      tsWhole,
+	wrappers:   map[string]string{"export_statement": "declaration"},
+	docs:       newSet("comment"),
+}
+
+var tsxGrammar = &grammar{
+	name:       "tsx",
+	language:   tsx.GetLanguage,
+	decls:      typescriptGrammar.decls,
+	containers: typescriptGrammar.containers,
+	whole:      tsWhole,
+	wrappers:   typescriptGrammar.wrappers,
+	docs:       typescriptGrammar.docs,
+}
+
+var grammars = map[string]*grammar{
+	".py":  pythonGrammar,
+	".pyi": pythonGrammar,
+	".js":  javascriptGrammar,
+	".jsx": javascriptGrammar,
+	".mjs": javascriptGrammar,
+	".cjs": javascriptGrammar,
+	".ts":  typescriptGrammar,
+	".mts": typescriptGrammar,
+	".tsx": tsxGrammar,
+	".rs": {
+		name:     "rust",
+		language: rust.GetLanguage,
+		decls: newSet("function_item", "function_signature_item", "struct_item", "enum_item", "union_item", "trait_item",
+			"impl_item", "type_item", "const_item", "static_item", "mod_item", "macro_definition"),
+		containers: newSet("impl_item", "trait_item", "mod_item"),
+		whole:      newSet("struct_item", "enum_item", "union_item"),
+		docs:       newSet("line_comment", "block_comment", "attribute_item"),
+	},
+	".java": {
+		name:     "java",
+		language: java.GetLanguage,
+		decls: newSet("class_declaration", "interface_declaration", "enum_declaration", "record_declaration",
+			"annotation_type_declaration", "method_declaration", "constructor_declaration", "field_declaration", "enum_constant"),
+		containers:  newSet("class_declaration", "interface_declaration", "enum_declaration", "record_declaration"),
+		transparent: newSet("enum_body_declarations"),
+		docs:        newSet("line_comment", "block_comment"),
+	},
+	".c": {
+		name:        "c",
+		language:    c.GetLanguage,
+		decls:       newSet(cDecls...),
+		whole:       newSet("struct_specifier", "union_specifier", "enum_specifier", "type_definition"),
+		transparent: newSet(cBlocks...),
+		docs:        newSet("comment"),
+	},
+	".cc": cppGrammar, ".cpp": cppGrammar, ".cxx": cppGrammar, ".hpp": cppGrammar, ".hh": cppGrammar, ".h": cppGrammar,
+}
+
+// cppGrammar also covers .h, since C headers parse fine as C++
+var cppGrammar = &grammar{
+	name:        "c++",
+	language:    cpp.GetLanguage,
+	decls:       newSet(append(cDecls, "class_specifier", "namespace_definition", "field_declaration", "template_declaration")...),
+	containers:  newSet("class_specifier", "struct_specifier", "namespace_definition"),
+	whole:       newSet("union_specifier", "enum_specifier", "type_definition"),
+	transparent: newSet(append(cBlocks, "linkage_specification", "declaration_list")...),
+	wrappers:    map[string]string{"template_declaration": ""},
+	docs:        newSet("comment"),
+}
+
+// outlineTreeSitter lists the declarations of a file in one of the languages with a tree-sitter grammar
+func outlineTreeSitter(ctx context.Context, ext string, src []byte) (string, []entry, error) {
+	g, ok := grammars[ext]
+	if !ok {
+		return "", nil, fmt.Errorf("outlines are not supported for %q files; use read_file", ext)
+	}
+	parser := sitter.NewParser()
+	defer parser.Close()
+	parser.SetLanguage(g.language())
+	tree, err := parser.ParseCtx(ctx, nil, src)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse %s source: %w", g.name, err)
+	}
+	defer tree.Close()
+
+	o := &outliner{grammar: g, src: src}
+	o.walk(tree.RootNode(), 0)
+	return g.name, o.entries, nil
+}
+
+type outliner struct {
+	*grammar
+	src     []byte
+	entries []entry
+}
+
+// walk adds the declarations among node's children
+func (o *outliner) walk(node *sitter.Node, depth int) {
+	for i := 0; i < int(node.NamedChildCount()); i++ {
+		child := node.NamedChild(i)
+		switch {
+		case o.decls[child.Type()]:
+			o.add(child, depth)
+		case o.transparent[child.Type()]:
+			o.walk(child, depth)
+		}
+	}
+}
+
+// add records one declaration, and the declarations in its body when it is a container
+func (o *outliner) add(outer *sitter.Node, depth int) {
+	decl := o.unwrap(outer)
+	if decl == nil {
+		return
+	}
+	body := decl.ChildByFieldName("body")
+
+	var signature string
+	if body == nil || o.whole[decl.Type()] {
+		signature = outer.Content(o.src)
+		if body == nil && !o.whole[decl.Type()] {
+			if first, _, multiline := strings.Cut(signature, "\n"); multiline {
+				signature = first + " …"
+			}
+		}
+	} else {
+		// Decorators and templates keep their own lines; the declaration up to its body is joined into one
+		raw := string(o.src[outer.StartByte():decl.StartByte()])
+		prefix := strings.TrimSpace(raw)
+		if strings.Contains(raw, "\n") {
+			prefix = dedent(prefix) + "\n"
+		} else if prefix != "" {
+			prefix += " "
+		}
+		signature = prefix + strings.Join(strings.Fields(string(o.src[decl.StartByte():body.StartByte()])), " ")
+	}
+
+	o.entries = append(o.entries, entry{
+		Line:      int(outer.StartPoint().Row) + 1,
+		EndLine:   int(outer.EndPoint().Row) + 1,
+		Depth:     depth,
+		Doc:       o.doc(outer, body),
+		Signature: signature,
+	})
+	if body != nil && o.containers[decl.Type()] {
+		o.walk(body, depth+1)
+	}
+}
+
+// unwrap returns the declaration inside a wrapper such as a decorator, or nil for wrappers around nothing worth listing
+func (o *outliner) unwrap(node *sitter.Node) *sitter.Node {
+	for {
+		field, ok := o.wrappers[node.Type()]
+		if !ok {
+			return node
+		}
+		var inner *sitter.Node
+		if field != "" {
+			inner = node.ChildByFieldName(field)
+		} else if n := node.NamedChildCount(); n > 0 {
+			inner = node.NamedChild(int(n) - 1)
+		}
+		if inner == nil {
+			return nil
+		}
+		node = inner
+	}
+}
+
+// doc collects the comments and attributes directly above node, and a Python docstring's first line
+func (o *outliner) doc(node, body *sitter.Node) string {
+	var lines []string
+	next := node
+	for prev := node.PrevNamedSibling(); prev != nil && o.docs[prev.Type()]; prev = prev.PrevNamedSibling() {
+		if prev.EndPoint().Row+1 < next.StartPoint().Row {
+			break
+		}
+		lines = append([]string{dedent(prev.Content(o.src))}, lines...)
+		next = prev
+	}
+
+	if o.name == "python" && body != nil && body.NamedChildCount() > 0 {
+		if statement := body.NamedChild(0); statement.Type() == "expression_statement" && statement.NamedChildCount() > 0 {
+			if docstring := statement.NamedChild(0); docstring.Type() == "string" {
+				first, _, _ := strings.Cut(strings.Trim(docstring.Content(o.src), "\"'rRbBuU \n"), "\n")
+				lines = append(lines, `"""`+first+`"""`)
+			}
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// dedent trims the leading whitespace of every line, so block comments line up with the outline
+func dedent(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(line)
+		if i > 0 && strings.HasPrefix(lines[i], "*") {
+			lines[i] = " " + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,13 @@
+//go:build !cgo
+
+package outline
+
+import (
+	"context"
+	"fmt"
+)
+
+// outlineTreeSitter needs cgo for the tree-sitter grammars; without it only Go files can be outlined
+func outlineTreeSitter(ctx context.Context, ext string, src []byte) (string, []entry, error) {
+	return "", nil, fmt.Errorf("outlines of %q files need a build with cgo; only Go files are supported here, use read_file", ext)
+}
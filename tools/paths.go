@@ -0,0 +1,20 @@
+package tools
+
+import (
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/ignore"
+)
+
+// Within reports whether fullPath, with symlinks resolved, is inside dir. A path that doesn't exist yet
+// is judged by its nearest existing parent, so nothing is created through a link out.
+func Within(fullPath, dir string) bool {
+	rel, err := filepath.Rel(ignore.Resolve(dir), ignore.Resolve(fullPath))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// InWorkingDir reports whether fullPath, with symlinks resolved, is inside the context's working directory
+func InWorkingDir(agent ToolContext, fullPath string) bool {
+	return Within(fullPath, agent.WorkingDir())
+}
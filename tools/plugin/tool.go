@@ -0,0 +1,66 @@
+package plugin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/plugin"
+	"anthropic-chat/tools"
+	"anthropic-chat/utils"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// summaryInputChars bounds how much of the input an approval prompt shows
+const summaryInputChars = 200
+
+// PluginTool exposes one tool declared by an external plugin
+type PluginTool struct {
+	plugin *plugin.Plugin
+	spec   plugin.ToolSpec
+	schema anthropic.ToolInputSchemaParam
+}
+
+// NewPluginTool creates a tool that forwards calls for spec to p
+func NewPluginTool(p *plugin.Plugin, spec plugin.ToolSpec) (*PluginTool, error) {
+	schema, err := utils.SchemaFromJSON(spec.InputSchema)
+	if err != nil {
+		return nil, fmt.Errorf("tool %s: %w", spec.Name, err)
+	}
+	return &PluginTool{plugin: p, spec: spec, schema: schema}, nil
+}
+
+// Name returns the tool name
+func (t *PluginTool) Name() string {
+	return t.spec.Name
+}
+
+// Description returns the tool description
+func (t *PluginTool) Description() string {
+	return t.spec.Description
+}
+
+// InputSchema returns the input schema for this tool
+func (t *PluginTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return t.schema
+}
+
+// Execute forwards the call to the plugin process
+func (t *PluginTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	if t.spec.RequiresApproval {
+		err := tools.RequestApproval(agent, approval.Request{
+			Tool:    t.Name(),
+			Summary: fmt.Sprintf("run plugin tool %s with %s", t.Name(), approval.Shorten(string(input), summaryInputChars)),
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	progress := func(message string) {
+		tools.ReportProgress(agent, t.Name(), "%s", message)
+	}
+	return t.plugin.Execute(ctx, t.Name(), input, agent.WorkingDir(), progress)
+}
@@ -0,0 +1,14 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// CreateDirectoryInput represents the input schema for the create_directory tool
+type CreateDirectoryInput struct {
+	Paths   PathList `json:"paths" jsonschema_description:"Relative paths of the directories to create, e.g. [\"cmd/server\", \"internal/store\"]."`
+	Parents bool     `json:"parents,omitempty" jsonschema_description:"Also create missing parent directories, like mkdir -p. Without it the parent of each path must exist."`
+}
+
+// CreateDirectoryInputSchema is the cached schema for CreateDirectoryInput
+var CreateDirectoryInputSchema = utils.GenerateSchema[CreateDirectoryInput]()
@@ -0,0 +1,13 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// DocumentSymbolsInput represents the input schema for the document_symbols tool
+type DocumentSymbolsInput struct {
+	Path string `json:"path" jsonschema_description:"Relative path of the source file to outline."`
+}
+
+// DocumentSymbolsInputSchema is the cached schema for DocumentSymbolsInput
+var DocumentSymbolsInputSchema = utils.GenerateSchema[DocumentSymbolsInput]()
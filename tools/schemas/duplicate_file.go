@@ -0,0 +1,16 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// DuplicateFileInput represents the input schema for the duplicate_file tool
+type DuplicateFileInput struct {
+	Source      string `json:"source" jsonschema_description:"Relative path of the file or directory to copy."`
+	Destination string `json:"destination,omitempty" jsonschema_description:"Optional relative destination path. Defaults to the source name with a (1) suffix, e.g. main(1).go."`
+	Recursive   bool   `json:"recursive,omitempty" jsonschema_description:"Required to copy a directory and everything inside it."`
+	Overwrite   bool   `json:"overwrite,omitempty" jsonschema_description:"Allow replacing files that already exist at the destination."`
+}
+
+// DuplicateFileInputSchema is the cached schema for DuplicateFileInput
+var DuplicateFileInputSchema = utils.GenerateSchema[DuplicateFileInput]()
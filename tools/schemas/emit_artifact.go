@@ -0,0 +1,16 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// EmitArtifactInput represents the input schema for the emit_artifact tool
+type EmitArtifactInput struct {
+	Name        string `json:"name" jsonschema_description:"File name for the artifact, e.g. coverage-report.md or diagrams/architecture.mmd."`
+	Content     string `json:"content" jsonschema_description:"Full content of the artifact."`
+	Kind        string `json:"kind,omitempty" jsonschema:"enum=report,enum=diagram,enum=doc,enum=analysis,enum=data,enum=other" jsonschema_description:"What the artifact is."`
+	Description string `json:"description,omitempty" jsonschema_description:"One line describing the artifact, recorded in the session manifest."`
+}
+
+// EmitArtifactInputSchema is the cached schema for EmitArtifactInput
+var EmitArtifactInputSchema = utils.GenerateSchema[EmitArtifactInput]()
@@ -0,0 +1,16 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// FindReferencesInput represents the input schema for the find_references tool
+type FindReferencesInput struct {
+	Path               string `json:"path" jsonschema_description:"Relative path of the file containing the symbol."`
+	Line               int    `json:"line" jsonschema_description:"1-based line number of the symbol."`
+	Column             int    `json:"column" jsonschema_description:"1-based column of the symbol on that line."`
+	IncludeDeclaration bool   `json:"include_declaration,omitempty" jsonschema_description:"Also return the declaration itself."`
+}
+
+// FindReferencesInputSchema is the cached schema for FindReferencesInput
+var FindReferencesInputSchema = utils.GenerateSchema[FindReferencesInput]()
@@ -0,0 +1,15 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// GoToDefinitionInput represents the input schema for the go_to_definition tool
+type GoToDefinitionInput struct {
+	Path   string `json:"path" jsonschema_description:"Relative path of the file containing the symbol."`
+	Line   int    `json:"line" jsonschema_description:"1-based line number of the symbol."`
+	Column int    `json:"column" jsonschema_description:"1-based column of the symbol on that line."`
+}
+
+// GoToDefinitionInputSchema is the cached schema for GoToDefinitionInput
+var GoToDefinitionInputSchema = utils.GenerateSchema[GoToDefinitionInput]()
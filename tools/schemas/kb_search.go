@@ -0,0 +1,14 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// KBSearchInput represents the input schema for the kb_search tool
+type KBSearchInput struct {
+	Query string `json:"query" jsonschema_description:"What to look up in the project's documentation, e.g. 'how are database migrations run' or 'why was the event bus chosen'."`
+	Limit int    `json:"limit,omitempty" jsonschema_description:"Maximum number of passages to return (default 5)."`
+}
+
+// KBSearchInputSchema is the cached schema for KBSearchInput
+var KBSearchInputSchema = utils.GenerateSchema[KBSearchInput]()
@@ -6,7 +6,9 @@ import (
 
 // ListFilesInput represents the input schema for the list_files tool
 type ListFilesInput struct {
-	Path string `json:"path,omitempty" jsonschema_description:"Optional relative path (defaults to current directory)."`
+	Path         string `json:"path,omitempty" jsonschema_description:"Optional relative path (defaults to current directory)."`
+	OutputFormat string `json:"output_format,omitempty" jsonschema:"enum=tree,enum=flat,enum=json" jsonschema_description:"tree (default) indents entries under their directories, flat lists one relative path per line, json returns an array of objects."`
+	SortBy       string `json:"sort_by,omitempty" jsonschema:"enum=name,enum=size,enum=mtime" jsonschema_description:"Order entries by name (default), size (largest first) or mtime (newest first); tree output sorts within each directory."`
 }
 
 // ListFilesInputSchema is the cached schema for ListFilesInput
@@ -0,0 +1,19 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// TodoItem is one task in the manage_todos list
+type TodoItem struct {
+	Content string `json:"content" jsonschema:"minLength=1" jsonschema_description:"What the task is, as a short imperative sentence."`
+	Status  string `json:"status" jsonschema:"enum=pending,enum=in_progress,enum=done" jsonschema_description:"Where the task stands. Keep at most one task in_progress."`
+}
+
+// ManageTodosInput represents the input schema for the manage_todos tool
+type ManageTodosInput struct {
+	Todos []TodoItem `json:"todos" jsonschema_description:"The whole task list in order, replacing the previous one. Send an empty list to clear it."`
+}
+
+// ManageTodosInputSchema is the cached schema for ManageTodosInput
+var ManageTodosInputSchema = utils.GenerateSchema[ManageTodosInput]()
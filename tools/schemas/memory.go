@@ -0,0 +1,16 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// MemoryInput represents the input schema for the memory tool
+type MemoryInput struct {
+	Action string `json:"action" jsonschema:"enum=save,enum=get,enum=search,enum=delete,enum=list" jsonschema_description:"save stores value under key (replacing any earlier value), get returns one fact, search finds facts related to query, delete removes one, list shows every key."`
+	Key    string `json:"key,omitempty" jsonschema_description:"Short name for the fact, e.g. 'api port' or 'test prerequisites'. Required for save, get and delete."`
+	Value  string `json:"value,omitempty" jsonschema_description:"The fact itself, e.g. 'The API runs on port 8123'. Required for save."`
+	Query  string `json:"query,omitempty" jsonschema_description:"Words to look for in keys and values. Required for search."`
+}
+
+// MemoryInputSchema is the cached schema for MemoryInput
+var MemoryInputSchema = utils.GenerateSchema[MemoryInput]()
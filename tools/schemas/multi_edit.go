@@ -0,0 +1,21 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// FileEdit is one replacement made by the multi_edit tool
+type FileEdit struct {
+	Old        string `json:"old" jsonschema:"minLength=1" jsonschema_description:"Exact text to replace, including whitespace and indentation. Include enough surrounding lines to make it unique."`
+	New        string `json:"new" jsonschema_description:"Replacement text. Empty deletes the old text."`
+	Occurrence int    `json:"occurrence,omitempty" jsonschema_description:"Which match of old to replace, counting from 1, when it appears more than once. Omit it when old is unique; -1 replaces every match."`
+}
+
+// MultiEditInput represents the input schema for the multi_edit tool
+type MultiEditInput struct {
+	Path  RelPath    `json:"path" jsonschema_description:"Relative path of the file to edit."`
+	Edits []FileEdit `json:"edits" jsonschema:"minItems=1" jsonschema_description:"Replacements applied in order, each to the result of the ones before it. Either all of them apply or none do."`
+}
+
+// MultiEditInputSchema is the cached schema for MultiEditInput
+var MultiEditInputSchema = utils.GenerateSchema[MultiEditInput]()
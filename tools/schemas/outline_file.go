@@ -0,0 +1,13 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// OutlineFileInput represents the input schema for the outline_file tool
+type OutlineFileInput struct {
+	Path string `json:"path" jsonschema_description:"Relative path of the source file to outline."`
+}
+
+// OutlineFileInputSchema is the cached schema for OutlineFileInput
+var OutlineFileInputSchema = utils.GenerateSchema[OutlineFileInput]()
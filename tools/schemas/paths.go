@@ -0,0 +1,126 @@
+package schemas
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"anthropic-chat/config"
+
+	"github.com/invopop/jsonschema"
+)
+
+// ErrInvalidPath is wrapped by errors for path and glob inputs that fail validation
+var ErrInvalidPath = errors.New("invalid path")
+
+// relativePattern rejects absolute paths; it is checked by tools.ValidateInput as well as sent to the model
+const relativePattern = `^[^/\\]`
+
+// RelPath is a path relative to the working directory
+type RelPath string
+
+// GlobPattern is a relative path that may contain glob syntax; ** matches any number of directories
+type GlobPattern string
+
+// PathList is a non-empty list of relative paths, at most config.PathListMaxItems long
+type PathList []RelPath
+
+// JSONSchemaExtend constrains RelPath fields to non-empty relative paths
+func (RelPath) JSONSchemaExtend(schema *jsonschema.Schema) {
+	schema.MinLength = uint64Ptr(1)
+	schema.Pattern = relativePattern
+}
+
+// JSONSchemaExtend constrains GlobPattern fields to non-empty relative patterns
+func (GlobPattern) JSONSchemaExtend(schema *jsonschema.Schema) {
+	schema.MinLength = uint64Ptr(1)
+	schema.Pattern = relativePattern
+}
+
+// JSONSchemaExtend bounds the length of PathList fields
+func (PathList) JSONSchemaExtend(schema *jsonschema.Schema) {
+	schema.MinItems = uint64Ptr(1)
+	schema.MaxItems = uint64Ptr(config.PathListMaxItems)
+}
+
+// Validate checks that the path stays inside the working directory: relative, without '..' and NUL bytes
+func (p RelPath) Validate() error {
+	return checkRelative(string(p))
+}
+
+// Validate checks the pattern as RelPath does, and that each segment is valid glob syntax
+func (g GlobPattern) Validate() error {
+	if err := checkRelative(string(g)); err != nil {
+		return err
+	}
+	for _, segment := range strings.Split(filepath.ToSlash(string(g)), "/") {
+		if segment == "**" {
+			continue
+		}
+		if _, err := path.Match(segment, ""); err != nil {
+			return fmt.Errorf("%q: bad glob syntax in %q: %w", string(g), segment, ErrInvalidPath)
+		}
+	}
+	return nil
+}
+
+// Validate checks the list's length and every path in it
+func (l PathList) Validate() error {
+	if len(l) == 0 {
+		return fmt.Errorf("no paths given: %w", ErrInvalidPath)
+	}
+	if len(l) > config.PathListMaxItems {
+		return fmt.Errorf("%d paths given, at most %d are allowed at a time: %w", len(l), config.PathListMaxItems, ErrInvalidPath)
+	}
+	for _, p := range l {
+		if err := p.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Strings returns the paths as plain strings
+func (l PathList) Strings() []string {
+	paths := make([]string, len(l))
+	for i, p := range l {
+		paths[i] = string(p)
+	}
+	return paths
+}
+
+// ValidateGlobs checks a list of glob patterns, which must not be empty
+func ValidateGlobs(patterns []GlobPattern) error {
+	if len(patterns) == 0 {
+		return fmt.Errorf("no paths or patterns given: %w", ErrInvalidPath)
+	}
+	for _, pattern := range patterns {
+		if err := pattern.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func checkRelative(p string) error {
+	switch {
+	case p == "":
+		return fmt.Errorf("path is empty: %w", ErrInvalidPath)
+	case strings.ContainsRune(p, 0):
+		return fmt.Errorf("%q contains a NUL byte: %w", p, ErrInvalidPath)
+	case filepath.IsAbs(p) || strings.HasPrefix(p, "/") || strings.HasPrefix(p, `\`):
+		return fmt.Errorf("%q is absolute; use a path relative to the working directory: %w", p, ErrInvalidPath)
+	}
+	for _, segment := range strings.Split(filepath.ToSlash(p), "/") {
+		if segment == ".." {
+			return fmt.Errorf("%q leaves the working directory ('..'): %w", p, ErrInvalidPath)
+		}
+	}
+	return nil
+}
+
+func uint64Ptr(n uint64) *uint64 {
+	return &n
+}
@@ -6,7 +6,11 @@ import (
 
 // ReadFileInput represents the input schema for the read_file tool
 type ReadFileInput struct {
-	Path string `json:"path" jsonschema_description:"Relative file path in working directory."`
+	Path   string `json:"path" jsonschema_description:"Relative file path in working directory."`
+	Force  bool   `json:"force,omitempty" jsonschema_description:"Return the contents even if the file is unchanged since you last read it. Only needed when the earlier read is no longer available to you."`
+	Pinned bool   `json:"pinned,omitempty" jsonschema_description:"Also pin the file so its current contents are resent with every request and survive conversation summarization. Use for specs or other files the whole task depends on."`
+	Hint   string `json:"hint,omitempty" jsonschema_description:"For files too large to read at once: a symbol name or text to find, so the chunk containing it is returned instead of the first one."`
+	Cursor string `json:"cursor,omitempty" jsonschema_description:"Continuation cursor from an earlier chunked read of this file, to get the next chunk."`
 }
 
 // ReadFileInputSchema is the cached schema for ReadFileInput
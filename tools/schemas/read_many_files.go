@@ -0,0 +1,14 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// ReadManyFilesInput represents the input schema for the read_many_files tool
+type ReadManyFilesInput struct {
+	Paths    []GlobPattern `json:"paths" jsonschema:"minItems=1" jsonschema_description:"Relative file paths or glob patterns (e.g. agent/*.go, **/*_test.go, docs/) to read together."`
+	MaxBytes int           `json:"max_bytes,omitempty" jsonschema_description:"Optional combined size cap, lower than the default of 100000 bytes. Content past the cap is cut off."`
+}
+
+// ReadManyFilesInputSchema is the cached schema for ReadManyFilesInput
+var ReadManyFilesInputSchema = utils.GenerateSchema[ReadManyFilesInput]()
@@ -0,0 +1,14 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// RemoveDirectoryInput represents the input schema for the remove_directory tool
+type RemoveDirectoryInput struct {
+	Path      RelPath `json:"path" jsonschema_description:"Relative path of the directory to remove."`
+	Recursive bool    `json:"recursive,omitempty" jsonschema_description:"Remove the directory together with everything inside it, like rm -r. Without it only an empty directory is removed."`
+}
+
+// RemoveDirectoryInputSchema is the cached schema for RemoveDirectoryInput
+var RemoveDirectoryInputSchema = utils.GenerateSchema[RemoveDirectoryInput]()
@@ -0,0 +1,16 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// RenameSymbolInput represents the input schema for the rename_symbol tool
+type RenameSymbolInput struct {
+	Path    string `json:"path" jsonschema_description:"Relative path of a file containing the symbol."`
+	Line    int    `json:"line" jsonschema_description:"1-based line number of an occurrence of the symbol."`
+	Column  int    `json:"column" jsonschema_description:"1-based column of any character of the symbol on that line."`
+	NewName string `json:"new_name" jsonschema:"pattern=^[\\p{L}_][\\p{L}\\p{N}_]*$" jsonschema_description:"The new identifier."`
+}
+
+// RenameSymbolInputSchema is the cached schema for RenameSymbolInput
+var RenameSymbolInputSchema = utils.GenerateSchema[RenameSymbolInput]()
@@ -0,0 +1,15 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// RunSnippetInput represents the input schema for the run_snippet tool
+type RunSnippetInput struct {
+	Language       string `json:"language" jsonschema:"enum=go,enum=python,enum=javascript" jsonschema_description:"Language of the snippet."`
+	Code           string `json:"code" jsonschema_description:"Complete program to run, saved as main.go, main.py or main.js in an empty directory."`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema_description:"Maximum run time in seconds (default 30)."`
+}
+
+// RunSnippetInputSchema is the cached schema for RunSnippetInput
+var RunSnippetInputSchema = utils.GenerateSchema[RunSnippetInput]()
@@ -0,0 +1,16 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// RunTestsInput represents the input schema for the run_tests tool
+type RunTestsInput struct {
+	Framework      string `json:"framework,omitempty" jsonschema:"enum=go,enum=pytest,enum=npm" jsonschema_description:"Test framework to use; detected from project files when omitted."`
+	Path           string `json:"path,omitempty" jsonschema_description:"Optional relative path of the package or directory to test (defaults to the whole project)."`
+	Filter         string `json:"filter,omitempty" jsonschema_description:"Optional test name pattern to run a subset (go -run, pytest -k, jest -t)."`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema_description:"Maximum run time in seconds (default 300)."`
+}
+
+// RunTestsInputSchema is the cached schema for RunTestsInput
+var RunTestsInputSchema = utils.GenerateSchema[RunTestsInput]()
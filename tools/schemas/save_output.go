@@ -0,0 +1,15 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// SaveOutputInput represents the input schema for the save_output tool
+type SaveOutputInput struct {
+	Path      string `json:"path" jsonschema_description:"Relative path of the file to write."`
+	Block     int    `json:"block,omitempty" jsonschema_description:"1-based number of the fenced code block in your last response to save; -1 for the last block. Omit or use 0 to save the whole response."`
+	Overwrite bool   `json:"overwrite,omitempty" jsonschema_description:"Allow replacing a file that already exists."`
+}
+
+// SaveOutputInputSchema is the cached schema for SaveOutputInput
+var SaveOutputInputSchema = utils.GenerateSchema[SaveOutputInput]()
@@ -0,0 +1,122 @@
+package schemas_test
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// TestInputSchemas checks that every tool input schema is generated with the fields the tool reads, the
+// types the model must send and the required fields it can't leave out
+func TestInputSchemas(t *testing.T) {
+	cases := []struct {
+		name     string
+		schema   anthropic.ToolInputSchemaParam
+		required []string
+		types    map[string]string
+	}{
+		{"create_directory", schemas.CreateDirectoryInputSchema, []string{"paths"},
+			map[string]string{"paths": "array", "parents": "boolean"}},
+		{"document_symbols", schemas.DocumentSymbolsInputSchema, []string{"path"},
+			map[string]string{"path": "string"}},
+		{"duplicate_file", schemas.DuplicateFileInputSchema, []string{"source"},
+			map[string]string{"source": "string", "destination": "string", "recursive": "boolean", "overwrite": "boolean"}},
+		{"emit_artifact", schemas.EmitArtifactInputSchema, []string{"name", "content"},
+			map[string]string{"name": "string", "content": "string", "kind": "string", "description": "string"}},
+		{"find_references", schemas.FindReferencesInputSchema, []string{"path", "line", "column"},
+			map[string]string{"path": "string", "line": "integer", "column": "integer", "include_declaration": "boolean"}},
+		{"go_to_definition", schemas.GoToDefinitionInputSchema, []string{"path", "line", "column"},
+			map[string]string{"path": "string", "line": "integer", "column": "integer"}},
+		{"kb_search", schemas.KBSearchInputSchema, []string{"query"},
+			map[string]string{"query": "string", "limit": "integer"}},
+		{"list_files", schemas.ListFilesInputSchema, nil,
+			map[string]string{"path": "string", "output_format": "string", "sort_by": "string"}},
+		{"manage_todos", schemas.ManageTodosInputSchema, []string{"todos"},
+			map[string]string{"todos": "array"}},
+		{"memory", schemas.MemoryInputSchema, []string{"action"},
+			map[string]string{"action": "string", "key": "string", "value": "string", "query": "string"}},
+		{"multi_edit", schemas.MultiEditInputSchema, []string{"path", "edits"},
+			map[string]string{"path": "string", "edits": "array"}},
+		{"outline_file", schemas.OutlineFileInputSchema, []string{"path"},
+			map[string]string{"path": "string"}},
+		{"read_file", schemas.ReadFileInputSchema, []string{"path"},
+			map[string]string{"path": "string", "pinned": "boolean", "force": "boolean", "hint": "string", "cursor": "string"}},
+		{"read_many_files", schemas.ReadManyFilesInputSchema, []string{"paths"},
+			map[string]string{"paths": "array", "max_bytes": "integer"}},
+		{"remove_directory", schemas.RemoveDirectoryInputSchema, []string{"path"},
+			map[string]string{"path": "string", "recursive": "boolean"}},
+		{"rename_symbol", schemas.RenameSymbolInputSchema, []string{"path", "line", "column", "new_name"},
+			map[string]string{"path": "string", "line": "integer", "column": "integer", "new_name": "string"}},
+		{"run_snippet", schemas.RunSnippetInputSchema, []string{"language", "code"},
+			map[string]string{"language": "string", "code": "string", "timeout_seconds": "integer"}},
+		{"run_tests", schemas.RunTestsInputSchema, nil,
+			map[string]string{"path": "string", "framework": "string", "filter": "string", "timeout_seconds": "integer"}},
+		{"save_output", schemas.SaveOutputInputSchema, []string{"path"},
+			map[string]string{"path": "string", "block": "integer", "overwrite": "boolean"}},
+		{"semantic_search", schemas.SemanticSearchInputSchema, []string{"query"},
+			map[string]string{"query": "string", "path": "string", "limit": "integer"}},
+		{"shell", schemas.ShellInputSchema, []string{"command"},
+			map[string]string{"command": "string", "timeout_seconds": "integer"}},
+		{"stat_file", schemas.StatFileInputSchema, []string{"paths"},
+			map[string]string{"paths": "array"}},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := json.Marshal(tc.schema)
+			if err != nil {
+				t.Fatalf("failed to encode schema: %v", err)
+			}
+			var schema struct {
+				Type                 string                    `json:"type"`
+				Required             []string                  `json:"required"`
+				Properties           map[string]map[string]any `json:"properties"`
+				AdditionalProperties *bool                     `json:"additionalProperties"`
+			}
+			if err := json.Unmarshal(data, &schema); err != nil {
+				t.Fatalf("failed to decode schema: %v", err)
+			}
+
+			if schema.Type != "object" {
+				t.Errorf("type is %q, want object", schema.Type)
+			}
+			if !slices.Equal(schema.Required, tc.required) {
+				t.Errorf("required is %v, want %v", schema.Required, tc.required)
+			}
+			if schema.AdditionalProperties == nil || *schema.AdditionalProperties {
+				t.Errorf("additionalProperties isn't false, so misspelled fields would be accepted")
+			}
+			for field := range schema.Properties {
+				if _, ok := tc.types[field]; !ok {
+					t.Errorf("unexpected property %q", field)
+				}
+			}
+			for field, want := range tc.types {
+				property, ok := schema.Properties[field]
+				switch {
+				case !ok:
+					t.Errorf("property %q is missing", field)
+				case property["type"] != want:
+					t.Errorf("property %q has type %v, want %s", field, property["type"], want)
+				case want == "array" && property["items"] == nil:
+					t.Errorf("array property %q has no items schema", field)
+				case property["description"] == nil:
+					t.Errorf("property %q has no description", field)
+				}
+			}
+
+			var rules map[string]any
+			if err := json.Unmarshal(data, &rules); err != nil {
+				t.Fatalf("failed to decode schema: %v", err)
+			}
+			if unsupported := tools.UnsupportedKeywords(rules); len(unsupported) > 0 {
+				t.Errorf("uses keywords input validation doesn't check: %v", unsupported)
+			}
+		})
+	}
+}
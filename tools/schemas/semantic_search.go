@@ -0,0 +1,15 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// SemanticSearchInput represents the input schema for the semantic_search tool
+type SemanticSearchInput struct {
+	Query string `json:"query" jsonschema_description:"What the code does, in words, e.g. 'where are retries with backoff handled' or 'parse the config file'."`
+	Path  string `json:"path,omitempty" jsonschema_description:"Only search files under this relative directory or file."`
+	Limit int    `json:"limit,omitempty" jsonschema_description:"Maximum number of code chunks to return (default 5)."`
+}
+
+// SemanticSearchInputSchema is the cached schema for SemanticSearchInput
+var SemanticSearchInputSchema = utils.GenerateSchema[SemanticSearchInput]()
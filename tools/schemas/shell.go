@@ -0,0 +1,14 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// ShellInput represents the input schema for the shell tool
+type ShellInput struct {
+	Command        string `json:"command" jsonschema_description:"Shell command to run. The shell persists between calls, so cd, export and source (e.g. activating a virtualenv) carry over."`
+	TimeoutSeconds int    `json:"timeout_seconds,omitempty" jsonschema_description:"Maximum run time in seconds; the shell is restarted (losing its state) if the command takes longer."`
+}
+
+// ShellInputSchema is the cached schema for ShellInput
+var ShellInputSchema = utils.GenerateSchema[ShellInput]()
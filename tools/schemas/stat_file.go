@@ -0,0 +1,13 @@
+package schemas
+
+import (
+	"anthropic-chat/utils"
+)
+
+// StatFileInput represents the input schema for the stat_file tool
+type StatFileInput struct {
+	Paths PathList `json:"paths" jsonschema_description:"Relative paths of the files or directories to check."`
+}
+
+// StatFileInputSchema is the cached schema for StatFileInput
+var StatFileInputSchema = utils.GenerateSchema[StatFileInput]()
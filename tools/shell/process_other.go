@@ -0,0 +1,23 @@
+//go:build !windows
+
+package shell
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// isolate puts the shell in its own process group so commands it started die with it
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// kill stops the shell and everything in its process group
+func kill(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL); err != nil {
+		cmd.Process.Kill()
+	}
+}
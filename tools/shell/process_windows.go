@@ -0,0 +1,15 @@
+//go:build windows
+
+package shell
+
+import "os/exec"
+
+// isolate is a no-op on Windows, where the shell is sh from Git for Windows or similar
+func isolate(cmd *exec.Cmd) {}
+
+// kill stops the shell; processes it started may outlive it
+func kill(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -0,0 +1,236 @@
+package shell
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"anthropic-chat/tools"
+)
+
+// ErrTimeout is returned when a command runs past its timeout; the shell is restarted and its state lost
+var ErrTimeout = tools.ErrTimeout
+
+// Result is the outcome of one command
+type Result struct {
+	Output    string
+	ExitCode  int
+	Dir       string // The shell's working directory after the command
+	Truncated bool
+}
+
+// Session is a long-lived shell process, so cd, exported variables and activated virtualenvs
+// carry over from one command to the next
+type Session struct {
+	dir         string // Directory new shells start in
+	outputLimit int
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	lines  chan string
+	done   chan struct{} // Closed when the shell is stopped, releasing the output reader
+	marker string
+	cwd    string
+}
+
+// NewSession creates a session whose shell starts in dir; output beyond outputLimit bytes is trimmed
+func NewSession(dir string, outputLimit int) *Session {
+	return &Session{dir: dir, outputLimit: outputLimit, cwd: dir}
+}
+
+// Run executes command in the persistent shell and waits up to timeout for it to finish, or until ctx
+// is done. Either way the shell is stopped. onLine, if set, receives each line of output as it arrives.
+func (s *Session) Run(ctx context.Context, command string, timeout time.Duration, onLine func(string)) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cmd == nil {
+		if err := s.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Group the command so cd and export apply to the shell itself, with stdin detached and stderr merged
+	script := fmt.Sprintf("{\n%s\n} </dev/null 2>&1\nprintf '\\n%s %%d %%s\\n' \"$?\" \"$PWD\"\n", command, s.marker)
+	if _, err := io.WriteString(s.stdin, script); err != nil {
+		s.stop()
+		return nil, fmt.Errorf("shell is not running: %w", err)
+	}
+
+	capture := newCapture(s.outputLimit)
+	blankLines := 0 // Held back so the newline printed before the marker isn't streamed
+	deadline := time.After(timeout)
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				s.stop()
+				return &Result{Output: capture.String(), ExitCode: -1, Dir: s.dir, Truncated: capture.truncated}, fmt.Errorf("the shell exited; a new one starts in %s with the next command", s.dir)
+			}
+			if rest, found := strings.CutPrefix(line, s.marker+" "); found {
+				code, dir, _ := strings.Cut(rest, " ")
+				exitCode, _ := strconv.Atoi(code)
+				s.cwd = dir
+				return &Result{Output: strings.TrimSuffix(capture.String(), "\n"), ExitCode: exitCode, Dir: dir, Truncated: capture.truncated}, nil
+			}
+			capture.Write(line + "\n")
+			if onLine == nil {
+				continue
+			}
+			if line == "" {
+				blankLines++
+				continue
+			}
+			for ; blankLines > 0; blankLines-- {
+				onLine("")
+			}
+			onLine(line)
+		case <-deadline:
+			s.stop()
+			return &Result{Output: capture.String(), ExitCode: -1, Dir: s.dir, Truncated: capture.truncated}, fmt.Errorf("%w after %s; the shell was restarted, so its directory and environment were reset", ErrTimeout, timeout)
+		case <-ctx.Done():
+			s.stop()
+			return &Result{Output: capture.String(), ExitCode: -1, Dir: s.dir, Truncated: capture.truncated}, fmt.Errorf("command stopped: %w; the shell was restarted, so its directory and environment were reset", ctx.Err())
+		}
+	}
+}
+
+// Dir returns the shell's current working directory
+func (s *Session) Dir() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cwd
+}
+
+// Reset kills the shell; the next command starts a fresh one in dir
+func (s *Session) Reset(dir string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop()
+	s.dir = dir
+	s.cwd = dir
+}
+
+// Close kills the shell
+func (s *Session) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stop()
+}
+
+func (s *Session) start() error {
+	program := "bash"
+	args := []string{"--noprofile", "--norc"}
+	if _, err := exec.LookPath(program); err != nil {
+		program, args = "sh", nil
+	}
+
+	cmd := exec.Command(program, args...)
+	cmd.Dir = s.dir
+	cmd.Env = append(os.Environ(), "PS1=", "PS2=", "TERM=dumb")
+	isolate(cmd)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open shell input: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open shell output: %w", err)
+	}
+	cmd.Stderr = cmd.Stdout // Errors from the shell itself, such as syntax errors
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start %s: %w", program, err)
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Errorf("failed to generate shell marker: %w", err)
+	}
+
+	lines := make(chan string, 256)
+	done := make(chan struct{})
+	go func() {
+		defer close(lines)
+		reader := bufio.NewReaderSize(stdout, 64*1024)
+		for {
+			line, err := reader.ReadString('\n')
+			if line != "" {
+				select {
+				case lines <- strings.TrimSuffix(line, "\n"):
+				case <-done:
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	s.cmd, s.stdin, s.lines, s.done = cmd, stdin, lines, done
+	s.marker = "__GOOCODE_DONE_" + hex.EncodeToString(id) + "__"
+	s.cwd = s.dir
+	return nil
+}
+
+func (s *Session) stop() {
+	if s.cmd == nil {
+		return
+	}
+	close(s.done)
+	s.stdin.Close()
+	kill(s.cmd)
+	s.cmd.Wait()
+	s.cmd, s.stdin, s.lines, s.done = nil, nil, nil, nil
+	s.cwd = s.dir
+}
+
+// capture keeps the start and end of long output within limit bytes
+type capture struct {
+	limit     int
+	head      strings.Builder
+	tail      []string
+	tailBytes int
+	dropped   int
+	truncated bool
+}
+
+func newCapture(limit int) *capture {
+	return &capture{limit: limit}
+}
+
+func (c *capture) Write(line string) {
+	if c.limit <= 0 || c.head.Len()+len(line) <= c.limit/2 {
+		c.head.WriteString(line)
+		return
+	}
+	c.truncated = true
+	c.tail = append(c.tail, line)
+	c.tailBytes += len(line)
+	for c.tailBytes > c.limit/2 && len(c.tail) > 1 {
+		c.tailBytes -= len(c.tail[0])
+		c.dropped++
+		c.tail = c.tail[1:]
+	}
+}
+
+func (c *capture) String() string {
+	if !c.truncated {
+		return c.head.String()
+	}
+	note := ""
+	if c.dropped > 0 {
+		note = fmt.Sprintf("... [%d lines omitted] ...\n", c.dropped)
+	}
+	return c.head.String() + note + strings.Join(c.tail, "")
+}
@@ -0,0 +1,97 @@
+package shell
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/config"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// ShellTool implements the shell tool on top of a persistent Session
+type ShellTool struct {
+	session    *Session
+	timeout    time.Duration
+	maxTimeout time.Duration // Cap on the limit the model may ask for (0 = none)
+}
+
+// NewShellTool creates a shell tool running commands in session, with timeout as the default limit and
+// maxTimeout as the most the model may ask for
+func NewShellTool(session *Session, timeout, maxTimeout time.Duration) *ShellTool {
+	return &ShellTool{session: session, timeout: timeout, maxTimeout: maxTimeout}
+}
+
+// Name returns the tool name
+func (t *ShellTool) Name() string {
+	return "shell"
+}
+
+// Description returns the tool description
+func (t *ShellTool) Description() string {
+	return "Run a command in a persistent shell. The working directory, environment variables and activated virtualenvs persist across calls for the whole session. Stdout and stderr are combined; long output keeps its beginning and end. Interactive programs are not supported."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *ShellTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.ShellInputSchema
+}
+
+// Execute runs the command
+func (t *ShellTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var shellInput schemas.ShellInput
+	if err := json.Unmarshal(input, &shellInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	command := strings.TrimSpace(shellInput.Command)
+	if command == "" {
+		return "", fmt.Errorf("command is required")
+	}
+
+	err := tools.RequestApproval(agent, approval.Request{
+		Tool:      t.Name(),
+		Summary:   fmt.Sprintf("run `%s` in %s", command, t.session.Dir()),
+		Command:   command,
+		Dangerous: config.IsDangerousCommand(command),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	timeout := t.timeout
+	if shellInput.TimeoutSeconds > 0 {
+		timeout = time.Duration(shellInput.TimeoutSeconds) * time.Second
+		if t.maxTimeout > 0 {
+			timeout = min(timeout, t.maxTimeout)
+		}
+	}
+	var onLine func(string)
+	if monitor, ok := agent.(tools.CommandMonitor); ok {
+		monitor.CommandStarted(t.Name(), command)
+		defer monitor.CommandFinished(t.Name())
+		onLine = func(line string) { monitor.CommandOutput(t.Name(), line) }
+	}
+	result, err := t.session.Run(ctx, command, timeout, onLine)
+	if result == nil {
+		return "", err
+	}
+
+	output := strings.TrimRight(result.Output, "\n")
+	if output == "" {
+		output = "(no output)"
+	}
+	status := fmt.Sprintf("[exit code %d, cwd %s]", result.ExitCode, result.Dir)
+	if result.Truncated {
+		status = "[output trimmed to its beginning and end] " + status
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w\n%s\n%s", err, output, status)
+	}
+	return output + "\n" + status, nil
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package snippet
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+)
+
+const (
+	cpuSeconds    = 60     // CPU time a snippet process may use
+	fileSizeLimit = 204800 // Largest file a snippet may write, in 512-byte blocks (100 MB; go run writes a binary)
+)
+
+// limited wraps command so the shell's ulimit caps its CPU time and file size
+func limited(command []string) []string {
+	script := fmt.Sprintf(`ulimit -t %d -f %d 2>/dev/null; exec "$@"`, cpuSeconds, fileSizeLimit)
+	return append([]string{"sh", "-c", script, "sh"}, command...)
+}
+
+// isolate runs the snippet in its own process group, so a timeout kills everything it started
+func isolate(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+}
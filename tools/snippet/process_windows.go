@@ -0,0 +1,13 @@
+//go:build windows
+
+package snippet
+
+import "os/exec"
+
+// limited returns command unchanged; Windows has no ulimit, so only the timeout applies
+func limited(command []string) []string {
+	return command
+}
+
+// isolate is a no-op on Windows; processes a snippet started may outlive a timeout
+func isolate(cmd *exec.Cmd) {}
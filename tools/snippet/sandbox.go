@@ -0,0 +1,174 @@
+package snippet
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Backends a snippet can run in
+const (
+	BackendProcess = "process" // Resource-limited subprocess in a temporary directory
+	BackendDocker  = "docker"  // Throwaway container without network access
+)
+
+// language describes how to run snippets of one language
+type language struct {
+	file    string   // Name the code is saved under
+	command []string // Run inside the sandbox directory
+	image   string   // Docker image for the docker backend
+}
+
+var languages = map[string]language{
+	"go":         {file: "main.go", command: []string{"go", "run", "main.go"}, image: "golang:1.24-alpine"},
+	"python":     {file: "main.py", command: []string{"python3", "main.py"}, image: "python:3.12-alpine"},
+	"javascript": {file: "main.js", command: []string{"node", "main.js"}, image: "node:22-alpine"},
+}
+
+// Options configure one snippet run
+type Options struct {
+	Language    string
+	Code        string
+	Backend     string
+	Timeout     time.Duration
+	OutputLimit int // Bytes of output kept, beginning and end
+}
+
+// Result is the outcome of a snippet run
+type Result struct {
+	Output    string
+	ExitCode  int
+	Duration  time.Duration
+	TimedOut  bool
+	Truncated bool
+}
+
+// Command returns what will run for language, for approval prompts
+func Command(lang string) (string, error) {
+	l, ok := languages[lang]
+	if !ok {
+		return "", fmt.Errorf("unsupported language %q (expected go, python or javascript)", lang)
+	}
+	return strings.Join(l.command, " "), nil
+}
+
+// Run executes the snippet in a fresh temporary directory that is removed afterwards
+func Run(ctx context.Context, opts Options) (*Result, error) {
+	lang, ok := languages[opts.Language]
+	if !ok {
+		return nil, fmt.Errorf("unsupported language %q (expected go, python or javascript)", opts.Language)
+	}
+
+	dir, err := os.MkdirTemp("", "goocode-snippet-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.WriteFile(filepath.Join(dir, lang.file), []byte(opts.Code), 0644); err != nil {
+		return nil, fmt.Errorf("failed to write snippet: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, opts.Timeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	var container string
+	switch opts.Backend {
+	case "", BackendProcess:
+		if _, err := exec.LookPath(lang.command[0]); err != nil {
+			return nil, fmt.Errorf("%s snippets need %s installed: %w", opts.Language, lang.command[0], err)
+		}
+		cmd = processCommand(ctx, dir, lang.command)
+	case BackendDocker:
+		container = filepath.Base(dir)
+		cmd = dockerCommand(ctx, dir, container, lang)
+	default:
+		return nil, fmt.Errorf("unknown snippet backend %q (expected process or docker)", opts.Backend)
+	}
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	err = cmd.Run()
+	result := &Result{Duration: time.Since(start)}
+	if ctx.Err() == context.DeadlineExceeded {
+		result.TimedOut = true
+		if container != "" {
+			// Killing the docker client leaves the container running
+			exec.Command("docker", "kill", container).Run()
+		}
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case result.TimedOut:
+		result.ExitCode = -1
+	default:
+		return nil, fmt.Errorf("failed to run snippet: %w", err)
+	}
+	result.Output, result.Truncated = keepEnds(output.String(), opts.OutputLimit)
+	return result, nil
+}
+
+// processCommand runs command in dir with a minimal environment, so the snippet sees no API keys or project settings
+func processCommand(ctx context.Context, dir string, command []string) *exec.Cmd {
+	command = limited(command)
+	cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+	cmd.Dir = dir
+	cmd.Env = []string{
+		"PATH=" + os.Getenv("PATH"),
+		"HOME=" + dir,
+		"TMPDIR=" + dir,
+		"LANG=C.UTF-8",
+		"NO_COLOR=1",
+		// Go snippets reuse the build cache and toolchain but may not download anything
+		"GOCACHE=" + goCache(),
+		"GOTOOLCHAIN=local",
+		"GOPROXY=off",
+		"GOFLAGS=-mod=mod",
+	}
+	if runtime.GOOS == "windows" {
+		cmd.Env = append(cmd.Env, "SystemRoot="+os.Getenv("SystemRoot"))
+	}
+	isolate(cmd)
+	return cmd
+}
+
+// dockerCommand runs the snippet in a container with no network and capped memory, CPU and processes
+func dockerCommand(ctx context.Context, dir, name string, lang language) *exec.Cmd {
+	args := []string{"run", "--rm", "--name", name, "--network", "none",
+		"--memory", "256m", "--cpus", "1", "--pids-limit", "128",
+		"-v", dir + ":/work", "-w", "/work", lang.image}
+	return exec.CommandContext(ctx, "docker", append(args, lang.command...)...)
+}
+
+// goCache returns the user's Go build cache, so Go snippets don't rebuild the standard library each time
+func goCache() string {
+	if cache := os.Getenv("GOCACHE"); cache != "" {
+		return cache
+	}
+	if dir, err := os.UserCacheDir(); err == nil {
+		return filepath.Join(dir, "go-build")
+	}
+	return "off"
+}
+
+// keepEnds trims output longer than limit to its beginning and end
+func keepEnds(output string, limit int) (string, bool) {
+	if limit <= 0 || len(output) <= limit {
+		return output, false
+	}
+	half := limit / 2
+	return output[:half] + "\n...\n" + output[len(output)-half:], true
+}
@@ -0,0 +1,108 @@
+package snippet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+	"anthropic-chat/ui"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// previewLines bounds how much of the snippet an approval prompt shows
+const previewLines = 20
+
+// RunSnippetTool implements the run_snippet tool
+type RunSnippetTool struct {
+	backend     string
+	timeout     time.Duration
+	outputLimit int
+}
+
+// NewRunSnippetTool creates a run_snippet tool using backend, with timeout as the default limit
+func NewRunSnippetTool(backend string, timeout time.Duration, outputLimit int) *RunSnippetTool {
+	return &RunSnippetTool{backend: backend, timeout: timeout, outputLimit: outputLimit}
+}
+
+// Name returns the tool name
+func (t *RunSnippetTool) Name() string {
+	return "run_snippet"
+}
+
+// Description returns the tool description
+func (t *RunSnippetTool) Description() string {
+	return "Run a short, self-contained Go, Python or JavaScript program in a throwaway sandbox directory outside the project and return its combined output and exit code. Use it to check how a library call or algorithm behaves, not to run project code. Go snippets must be package main with only standard library imports; nothing can be downloaded."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *RunSnippetTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.RunSnippetInputSchema
+}
+
+// Execute runs the snippet
+func (t *RunSnippetTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var snippetInput schemas.RunSnippetInput
+	if err := json.Unmarshal(input, &snippetInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	if strings.TrimSpace(snippetInput.Code) == "" {
+		return "", fmt.Errorf("code is required")
+	}
+	command, err := Command(snippetInput.Language)
+	if err != nil {
+		return "", err
+	}
+
+	err = tools.RequestApproval(agent, approval.Request{
+		Tool:    t.Name(),
+		Summary: fmt.Sprintf("run this %s snippet in a %s sandbox:\n%s", snippetInput.Language, t.backend, preview(snippetInput.Code)),
+		Command: command,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	timeout := t.timeout
+	if snippetInput.TimeoutSeconds > 0 {
+		timeout = time.Duration(snippetInput.TimeoutSeconds) * time.Second
+	}
+	tools.ReportProgress(agent, t.Name(), "running %s snippet (%s backend)", snippetInput.Language, t.backend)
+	result, err := Run(ctx, Options{
+		Language:    snippetInput.Language,
+		Code:        snippetInput.Code,
+		Backend:     t.backend,
+		Timeout:     timeout,
+		OutputLimit: t.outputLimit,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	output := strings.TrimRight(result.Output, "\n")
+	if output == "" {
+		output = "(no output)"
+	}
+	status := fmt.Sprintf("[exit code %d, %s]", result.ExitCode, ui.FormatDurationPlain(result.Duration))
+	if result.Truncated {
+		status = "[output trimmed to its beginning and end] " + status
+	}
+	if result.TimedOut {
+		status = fmt.Sprintf("[timed out after %s] ", timeout) + status
+	}
+	return output + "\n" + status, nil
+}
+
+// preview returns the first lines of code for an approval prompt
+func preview(code string) string {
+	lines := strings.Split(strings.TrimRight(code, "\n"), "\n")
+	if len(lines) <= previewLines {
+		return strings.Join(lines, "\n")
+	}
+	return strings.Join(lines[:previewLines], "\n") + fmt.Sprintf("\n... (%d more lines)", len(lines)-previewLines)
+}
@@ -0,0 +1,151 @@
+package tools
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"anthropic-chat/config"
+)
+
+// suggestSkipDirs are not searched for near-matches of a missing path
+var suggestSkipDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, ".venv": true, "venv": true, "__pycache__": true,
+}
+
+// MissingPath returns the path of a file system error that means a file doesn't exist, if err is one
+func MissingPath(err error) (string, bool) {
+	var pathErr *fs.PathError
+	if !errors.Is(err, os.ErrNotExist) || !errors.As(err, &pathErr) {
+		return "", false
+	}
+	return pathErr.Path, true
+}
+
+// SuggestPaths finds the paths under root most likely meant by missing (relative to root or absolute
+// inside it): different case, a missing or wrong extension, the same name in another directory, or a
+// small typo. The best matches come first, relative to root and slash-separated. Paths skip reports
+// true for, such as those .goocodeignore denies, are left out.
+func SuggestPaths(root, missing string, skip func(fullPath string, isDir bool) bool) []string {
+	if filepath.IsAbs(missing) {
+		rel, err := filepath.Rel(root, missing)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			return nil
+		}
+		missing = rel
+	}
+	target := strings.ToLower(filepath.ToSlash(filepath.Clean(missing)))
+	targetBase := baseName(target)
+	targetStem := stem(target)
+
+	type candidate struct {
+		path  string
+		score int
+	}
+	var candidates []candidate
+	seen := 0
+	filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || path == root {
+			return nil
+		}
+		if entry.IsDir() && suggestSkipDirs[entry.Name()] {
+			return filepath.SkipDir
+		}
+		if skip != nil && skip(path, entry.IsDir()) {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if seen++; seen > config.PathSuggestScanLimit {
+			return filepath.SkipAll
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if entry.IsDir() && stem(target) != target {
+			return nil // A path with an extension names a file
+		}
+		if score, ok := pathScore(strings.ToLower(rel), target, targetBase, targetStem); ok {
+			candidates = append(candidates, candidate{rel, score})
+		}
+		return nil
+	})
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score < candidates[j].score
+		}
+		if len(candidates[i].path) != len(candidates[j].path) {
+			return len(candidates[i].path) < len(candidates[j].path)
+		}
+		return candidates[i].path < candidates[j].path
+	})
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < config.PathSuggestions; i++ {
+		suggestions = append(suggestions, candidates[i].path)
+	}
+	return suggestions
+}
+
+// pathScore rates how likely candidate (lowercased) is the intended target; lower is closer
+func pathScore(candidate, target, targetBase, targetStem string) (int, bool) {
+	switch {
+	case candidate == target:
+		return 0, true // Differs only in case
+	case stem(candidate) == targetStem:
+		return 1, true // Extension missing, added or different
+	case baseName(candidate) == targetBase:
+		return 2, true // Right name, wrong directory
+	}
+	if distance := editDistance(candidate, target); distance <= maxTypos(target) {
+		return 3 + distance, true
+	}
+	if distance := editDistance(baseName(candidate), targetBase); distance <= maxTypos(targetBase) {
+		return 6 + distance, true // A similar name elsewhere
+	}
+	return 0, false
+}
+
+// maxTypos is how many edits still count as a typo of s
+func maxTypos(s string) int {
+	return min(max(len(s)/5, 1), 3)
+}
+
+func baseName(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+// stem drops the extension of the last path element
+func stem(path string) string {
+	if dot := strings.LastIndex(path, "."); dot > strings.LastIndex(path, "/")+1 {
+		return path[:dot]
+	}
+	return path
+}
+
+// editDistance is the Levenshtein distance between a and b, by byte
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
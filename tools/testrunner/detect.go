@@ -0,0 +1,51 @@
+package testrunner
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Framework names understood by run_tests
+const (
+	FrameworkGo     = "go"
+	FrameworkPytest = "pytest"
+	FrameworkNpm    = "npm"
+)
+
+// markers maps each framework to files whose presence identifies it, in priority order
+var markers = []struct {
+	framework string
+	files     []string
+}{
+	{FrameworkGo, []string{"go.mod"}},
+	{FrameworkNpm, []string{"package.json"}},
+	{FrameworkPytest, []string{"pytest.ini", "pyproject.toml", "setup.cfg", "tox.ini", "conftest.py", "setup.py"}},
+}
+
+// DetectFramework walks up from dir to root looking for project marker files
+func DetectFramework(dir, root string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+
+	current, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for {
+		for _, marker := range markers {
+			for _, name := range marker.files {
+				if _, err := os.Stat(filepath.Join(current, name)); err == nil {
+					return marker.framework, nil
+				}
+			}
+		}
+		if current == absRoot || current == filepath.Dir(current) {
+			break
+		}
+		current = filepath.Dir(current)
+	}
+	return "", fmt.Errorf("could not detect test framework (no go.mod, package.json or pytest config found); pass framework explicitly")
+}
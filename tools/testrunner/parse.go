@@ -0,0 +1,206 @@
+package testrunner
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"anthropic-chat/ui"
+)
+
+const (
+	maxOutputBytes      = 8000
+	maxFailureLines     = 40
+	headLines           = 20
+	tailLines           = 80
+	maxFailingTestNames = 50
+)
+
+// Summary is the structured result returned to the model
+type Summary struct {
+	Framework    string   `json:"framework"`
+	Command      string   `json:"command"`
+	Success      bool     `json:"success"`
+	Passed       int      `json:"passed"`
+	Failed       int      `json:"failed"`
+	Skipped      int      `json:"skipped"`
+	FailingTests []string `json:"failing_tests,omitempty"`
+	ExitCode     int      `json:"exit_code"`
+	DurationMs   int64    `json:"duration_ms"`
+	TimedOut     bool     `json:"timed_out,omitempty"`
+	Output       string   `json:"output,omitempty"`
+}
+
+// goTestEvent is one line of `go test -json` output
+type goTestEvent struct {
+	Action  string `json:"Action"`
+	Package string `json:"Package"`
+	Test    string `json:"Test"`
+	Output  string `json:"Output"`
+}
+
+// parseGoTest fills summary from `go test -json` output, keeping only output of failing tests
+func parseGoTest(summary *Summary, output string) {
+	testOutput := make(map[string][]string)
+	var failures []string
+	var plain []string
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		var event goTestEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			// Build errors and other non-JSON lines are always relevant
+			plain = append(plain, line)
+			continue
+		}
+
+		key := event.Package + " " + event.Test
+		switch event.Action {
+		case "output":
+			if event.Test != "" {
+				testOutput[key] = append(testOutput[key], strings.TrimRight(event.Output, "\n"))
+			} else if strings.Contains(event.Output, "FAIL") || strings.Contains(event.Output, "build failed") {
+				plain = append(plain, strings.TrimRight(event.Output, "\n"))
+			}
+		case "pass":
+			if event.Test != "" {
+				summary.Passed++
+			}
+		case "skip":
+			if event.Test != "" {
+				summary.Skipped++
+			}
+		case "fail":
+			if event.Test != "" {
+				summary.Failed++
+				failures = append(failures, key)
+			}
+		}
+	}
+
+	var details strings.Builder
+	for _, key := range failures {
+		name := strings.TrimSpace(key)
+		if len(summary.FailingTests) < maxFailingTestNames {
+			summary.FailingTests = append(summary.FailingTests, name)
+		}
+		lines := testOutput[key]
+		if len(lines) > maxFailureLines {
+			lines = append(lines[:maxFailureLines], fmt.Sprintf("... (%d more lines)", len(testOutput[key])-maxFailureLines))
+		}
+		details.WriteString("--- " + name + "\n" + strings.Join(lines, "\n") + "\n")
+	}
+	if len(plain) > 0 {
+		details.WriteString(strings.Join(plain, "\n"))
+	}
+	summary.Output = truncate(details.String())
+}
+
+var (
+	pytestCount  = regexp.MustCompile(`(\d+) (passed|failed|skipped|errors?|xfailed|xpassed)`)
+	pytestFailed = regexp.MustCompile(`^(?:FAILED|ERROR) (\S+)`)
+)
+
+// parsePytest extracts counts from the final summary line and names from `-rfE` short summary lines
+func parsePytest(summary *Summary, output string) {
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		if match := pytestFailed.FindStringSubmatch(line); match != nil && len(summary.FailingTests) < maxFailingTestNames {
+			summary.FailingTests = append(summary.FailingTests, match[1])
+		}
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		matches := pytestCount.FindAllStringSubmatch(lines[i], -1)
+		if len(matches) == 0 {
+			continue
+		}
+		for _, match := range matches {
+			n, _ := strconv.Atoi(match[1])
+			switch match[2] {
+			case "passed", "xpassed":
+				summary.Passed += n
+			case "failed", "error", "errors":
+				summary.Failed += n
+			case "skipped", "xfailed":
+				summary.Skipped += n
+			}
+		}
+		break
+	}
+	summary.Output = truncate(headTail(lines))
+}
+
+var (
+	jestTotals   = regexp.MustCompile(`^Tests:\s+(.*)$`)
+	jestCount    = regexp.MustCompile(`(\d+) (passed|failed|skipped|todo)`)
+	jestFailed   = regexp.MustCompile(`^\s*●\s+(.+?)\s*$`)
+	mochaPassing = regexp.MustCompile(`^\s*(\d+) passing`)
+	mochaFailing = regexp.MustCompile(`^\s*(\d+) failing`)
+	mochaPending = regexp.MustCompile(`^\s*(\d+) pending`)
+)
+
+// parseNpm understands jest and mocha reporters, the two most common behind `npm test`
+func parseNpm(summary *Summary, output string) {
+	lines := strings.Split(output, "\n")
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		if match := jestTotals.FindStringSubmatch(strings.TrimSpace(line)); match != nil {
+			for _, count := range jestCount.FindAllStringSubmatch(match[1], -1) {
+				n, _ := strconv.Atoi(count[1])
+				switch count[2] {
+				case "passed":
+					summary.Passed = n
+				case "failed":
+					summary.Failed = n
+				default:
+					summary.Skipped += n
+				}
+			}
+		}
+		if match := jestFailed.FindStringSubmatch(line); match != nil && !seen[match[1]] {
+			seen[match[1]] = true
+			if len(summary.FailingTests) < maxFailingTestNames {
+				summary.FailingTests = append(summary.FailingTests, match[1])
+			}
+		}
+		if match := mochaPassing.FindStringSubmatch(line); match != nil {
+			summary.Passed, _ = strconv.Atoi(match[1])
+		}
+		if match := mochaFailing.FindStringSubmatch(line); match != nil {
+			summary.Failed, _ = strconv.Atoi(match[1])
+		}
+		if match := mochaPending.FindStringSubmatch(line); match != nil {
+			summary.Skipped, _ = strconv.Atoi(match[1])
+		}
+	}
+	sort.Strings(summary.FailingTests)
+	summary.Output = truncate(headTail(lines))
+}
+
+// headTail keeps the beginning (setup errors) and end (failures, summary) of long output
+func headTail(lines []string) string {
+	if len(lines) <= headLines+tailLines {
+		return strings.Join(lines, "\n")
+	}
+	omitted := len(lines) - headLines - tailLines
+	parts := append([]string{}, lines[:headLines]...)
+	parts = append(parts, fmt.Sprintf("... (%d lines omitted) ...", omitted))
+	parts = append(parts, lines[len(lines)-tailLines:]...)
+	return strings.Join(parts, "\n")
+}
+
+// truncate caps output size, preferring to keep the tail where summaries live
+func truncate(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) <= maxOutputBytes {
+		return output
+	}
+	keep := maxOutputBytes - 100
+	return fmt.Sprintf("... (%s truncated) ...\n%s", ui.FormatBytesPlain(int64(len(output)-keep)), output[len(output)-keep:])
+}
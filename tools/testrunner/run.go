@@ -0,0 +1,197 @@
+package testrunner
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"anthropic-chat/approval"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const defaultTimeout = 5 * time.Minute
+
+// RunTestsTool implements the run_tests tool
+type RunTestsTool struct{}
+
+// NewRunTestsTool creates a new RunTests tool instance
+func NewRunTestsTool() *RunTestsTool {
+	return &RunTestsTool{}
+}
+
+// Name returns the tool name
+func (t *RunTestsTool) Name() string {
+	return "run_tests"
+}
+
+// Description returns the tool description
+func (t *RunTestsTool) Description() string {
+	return "Run the project's test suite (go test, pytest or npm test, detected automatically) or a filtered subset, returning JSON with pass/fail counts, failing test names and trimmed failure output."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *RunTestsTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.RunTestsInputSchema
+}
+
+// Execute runs the tests and summarizes the results
+func (t *RunTestsTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var testInput schemas.RunTestsInput
+	if err := json.Unmarshal(input, &testInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+
+	dir := agent.WorkingDir()
+	if testInput.Path != "" {
+		var err error
+		dir, err = agent.ResolveFilePath(testInput.Path)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	framework, args, workDir, err := prepare(agent.WorkingDir(), dir, testInput.Framework, testInput.Filter)
+	if err != nil {
+		return "", err
+	}
+	// Tests run the project's code, so they are gated like any other command
+	command := strings.Join(args, " ")
+	err = tools.RequestApproval(agent, approval.Request{
+		Tool:    t.Name(),
+		Summary: fmt.Sprintf("run `%s` in %s", command, workDir),
+		Command: command,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	timeout := time.Duration(testInput.TimeoutSeconds) * time.Second
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	summary := runCommand(ctx, framework, args, workDir, timeout)
+
+	result, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal test results: %w", err)
+	}
+	return string(result), nil
+}
+
+// Run executes the tests under dir (inside project root root) and summarizes them.
+// An empty framework is detected from project files; a zero timeout uses the default.
+func Run(ctx context.Context, root, dir, framework, filter string, timeout time.Duration) (*Summary, error) {
+	framework, args, workDir, err := prepare(root, dir, framework, filter)
+	if err != nil {
+		return nil, err
+	}
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	return runCommand(ctx, framework, args, workDir, timeout), nil
+}
+
+// prepare detects the framework when none is given and returns it with the command to run and where
+func prepare(root, dir, framework, filter string) (string, []string, string, error) {
+	if framework == "" {
+		var err error
+		framework, err = DetectFramework(dir, root)
+		if err != nil {
+			return "", nil, "", err
+		}
+	}
+	args, workDir, err := buildCommand(framework, root, dir, filter)
+	if err != nil {
+		return "", nil, "", err
+	}
+	return framework, args, workDir, nil
+}
+
+// buildCommand returns the argv and working directory for the framework
+func buildCommand(framework, root, dir, filter string) ([]string, string, error) {
+	switch framework {
+	case FrameworkGo:
+		target := "./..."
+		if rel, err := filepath.Rel(root, dir); err == nil && rel != "." {
+			target = "./" + filepath.ToSlash(rel) + "/..."
+		}
+		args := []string{"go", "test", "-json", target}
+		if filter != "" {
+			args = append(args, "-run", filter)
+		}
+		return args, root, nil
+	case FrameworkPytest:
+		args := []string{"python", "-m", "pytest", "-q", "-rfE"}
+		if dir != root {
+			args = append(args, dir)
+		}
+		if filter != "" {
+			args = append(args, "-k", filter)
+		}
+		return args, root, nil
+	case FrameworkNpm:
+		args := []string{"npm", "test", "--silent"}
+		if filter != "" {
+			args = append(args, "--", "-t", filter)
+		}
+		return args, dir, nil
+	default:
+		return nil, "", fmt.Errorf("unsupported framework %q (expected go, pytest or npm)", framework)
+	}
+}
+
+func runCommand(ctx context.Context, framework string, args []string, dir string, timeout time.Duration) *Summary {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Dir = dir
+	cmd.Env = append(cmd.Environ(), "CI=true", "NO_COLOR=1", "FORCE_COLOR=0")
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	err := cmd.Run()
+
+	summary := &Summary{
+		Framework:  framework,
+		Command:    strings.Join(args, " "),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		summary.ExitCode = exitErr.ExitCode()
+	default:
+		summary.ExitCode = -1
+		summary.Output = fmt.Sprintf("failed to run tests: %v", err)
+		return summary
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		summary.TimedOut = true
+	}
+
+	switch framework {
+	case FrameworkGo:
+		parseGoTest(summary, output.String())
+	case FrameworkPytest:
+		parsePytest(summary, output.String())
+	case FrameworkNpm:
+		parseNpm(summary, output.String())
+	}
+
+	summary.Success = summary.ExitCode == 0 && !summary.TimedOut && summary.Failed == 0
+	return summary
+}
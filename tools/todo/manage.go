@@ -0,0 +1,76 @@
+package todo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"anthropic-chat/config"
+	"anthropic-chat/tools"
+	"anthropic-chat/tools/schemas"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// ManageTodosTool implements the manage_todos tool
+type ManageTodosTool struct{}
+
+// NewManageTodosTool creates a new ManageTodos tool instance
+func NewManageTodosTool() *ManageTodosTool {
+	return &ManageTodosTool{}
+}
+
+// Name returns the tool name
+func (t *ManageTodosTool) Name() string {
+	return "manage_todos"
+}
+
+// Description returns the tool description
+func (t *ManageTodosTool) Description() string {
+	return "Keep a task list for multi-step work. Each call replaces the whole list; give every task a status of pending, in_progress or done. The user sees the list between turns, and it is sent with every request, so it survives conversation compaction. Write the plan before starting, mark a task in_progress when you start it and done as soon as it is finished. Skip it for one-step requests."
+}
+
+// InputSchema returns the input schema for this tool
+func (t *ManageTodosTool) InputSchema() anthropic.ToolInputSchemaParam {
+	return schemas.ManageTodosInputSchema
+}
+
+// Execute replaces the task list
+func (t *ManageTodosTool) Execute(ctx context.Context, agent tools.ToolContext, input json.RawMessage) (string, error) {
+	var todoInput schemas.ManageTodosInput
+	if err := json.Unmarshal(input, &todoInput); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+	if len(todoInput.Todos) > config.TodoMaxItems {
+		return "", fmt.Errorf("%d tasks given; keep the list to %d by merging small steps", len(todoInput.Todos), config.TodoMaxItems)
+	}
+
+	keeper, ok := agent.(tools.TodoKeeper)
+	if !ok {
+		return "", fmt.Errorf("task lists are not supported here")
+	}
+	todos := make([]tools.Todo, 0, len(todoInput.Todos))
+	counts := make(map[string]int)
+	for _, item := range todoInput.Todos {
+		content := strings.TrimSpace(item.Content)
+		if content == "" {
+			return "", fmt.Errorf("every task needs content")
+		}
+		counts[item.Status]++
+		todos = append(todos, tools.Todo{Content: content, Status: item.Status})
+	}
+	if counts[tools.TodoInProgress] > 1 {
+		return "", fmt.Errorf("%d tasks are in_progress; keep one in progress and the rest pending", counts[tools.TodoInProgress])
+	}
+	keeper.SetTodos(todos)
+
+	if len(todos) == 0 {
+		return "Task list cleared", nil
+	}
+	result := fmt.Sprintf("Task list updated: %d done, %d in progress, %d pending", counts[tools.TodoDone], counts[tools.TodoInProgress], counts[tools.TodoPending])
+	if counts[tools.TodoDone] == len(todos) {
+		result += "; every task is done"
+	}
+	return result, nil
+}
@@ -3,6 +3,12 @@ package tools
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+
+	"anthropic-chat/approval"
 
 	"github.com/anthropics/anthropic-sdk-go"
 )
@@ -22,6 +28,120 @@ type ToolContext interface {
 	ResolveFilePath(relativePath string) (string, error)
 }
 
+// ProgressReporter is optionally implemented by a ToolContext so long-running tools can report progress
+type ProgressReporter interface {
+	ReportProgress(toolName string, message string)
+}
+
+// ReportProgress sends a progress message if the context supports it
+func ReportProgress(agent ToolContext, toolName string, format string, args ...interface{}) {
+	if reporter, ok := agent.(ProgressReporter); ok {
+		reporter.ReportProgress(toolName, fmt.Sprintf(format, args...))
+	}
+}
+
+// ResponseSource is optionally implemented by a ToolContext to expose the model's most recent reply
+type ResponseSource interface {
+	LastResponse() string
+}
+
+// ArtifactWriter is optionally implemented by a ToolContext to store generated outputs outside the source tree
+type ArtifactWriter interface {
+	WriteArtifact(name, kind, description string, content []byte) (string, error)
+}
+
+// Pinner is optionally implemented by a ToolContext so files can be kept in context across summarization
+type Pinner interface {
+	PinFile(path string) error
+}
+
+// MemoryKeeper is optionally implemented by a ToolContext to say where the project's remembered facts are stored
+type MemoryKeeper interface {
+	MemoryFile() string
+}
+
+// AnswerSink is optionally implemented by a ToolContext to receive the structured answer of a run
+type AnswerSink interface {
+	SetAnswer(answer json.RawMessage)
+}
+
+// ReadCache is optionally implemented by a ToolContext to remember which file versions the model has already seen
+type ReadCache interface {
+	// CachedRead returns the content last read from fullPath if the file still has info's size and mtime
+	CachedRead(fullPath string, info os.FileInfo) ([]byte, bool)
+	RememberRead(fullPath string, info os.FileInfo, content []byte)
+}
+
+// FileTracker is optionally implemented by a ToolContext to keep track of the files tools have read or written
+type FileTracker interface {
+	FileChanged(fullPath string)
+	FileRead(fullPath string, info os.FileInfo)
+}
+
+// NoteFileChanged tells the context that a tool wrote or removed fullPath, if it keeps track
+func NoteFileChanged(agent ToolContext, fullPath string) {
+	if tracker, ok := agent.(FileTracker); ok {
+		tracker.FileChanged(fullPath)
+	}
+}
+
+// NoteFileRead tells the context that the model was shown part of the version of fullPath described by
+// info, if it keeps track; whole reads are recorded by ReadCache.RememberRead
+func NoteFileRead(agent ToolContext, fullPath string, info os.FileInfo) {
+	if tracker, ok := agent.(FileTracker); ok {
+		tracker.FileRead(fullPath, info)
+	}
+}
+
+// IgnoreChecker is optionally implemented by a ToolContext whose working directory has a .goocodeignore deny-list
+type IgnoreChecker interface {
+	Ignored(fullPath string, isDir bool) bool
+}
+
+// Ignored reports whether the context's .goocodeignore denies fullPath; tools that walk directories skip such paths
+func Ignored(agent ToolContext, fullPath string, isDir bool) bool {
+	checker, ok := agent.(IgnoreChecker)
+	return ok && checker.Ignored(fullPath, isDir)
+}
+
+// SecretRedactor is optionally implemented by a ToolContext that hides secrets from what is sent off the machine
+type SecretRedactor interface {
+	RedactSecrets(text string) string
+}
+
+// RedactSecrets returns text with secrets replaced if the context redacts them
+func RedactSecrets(agent ToolContext, text string) string {
+	if redactor, ok := agent.(SecretRedactor); ok {
+		return redactor.RedactSecrets(text)
+	}
+	return text
+}
+
+// CommandMonitor is optionally implemented by a ToolContext to show a command's output while it runs
+type CommandMonitor interface {
+	CommandStarted(toolName, command string)
+	CommandOutput(toolName, line string)
+	CommandFinished(toolName string)
+}
+
+// Todo statuses
+const (
+	TodoPending    = "pending"
+	TodoInProgress = "in_progress"
+	TodoDone       = "done"
+)
+
+// Todo is one item of the model's task list
+type Todo struct {
+	Content string
+	Status  string // TodoPending, TodoInProgress or TodoDone
+}
+
+// TodoKeeper is optionally implemented by a ToolContext to keep the model's task list across turns and compactions
+type TodoKeeper interface {
+	SetTodos(todos []Todo)
+}
+
 // ToolDefinition represents a complete tool definition for registration
 type ToolDefinition struct {
 	Name        string
@@ -30,31 +150,76 @@ type ToolDefinition struct {
 	Function    func(json.RawMessage) (string, error)
 }
 
-// Registry manages all available tools
+// Registry manages all available tools. It is safe for concurrent use and keeps tools in
+// registration order, so the tool list sent to the API is stable and prompt caching keeps working.
 type Registry struct {
-	tools map[string]Tool
+	mu    sync.RWMutex
+	tools []Tool
+	index map[string]int // Tool name -> position in tools
 }
 
 // NewRegistry creates a new tool registry
 func NewRegistry() *Registry {
 	return &Registry{
-		tools: make(map[string]Tool),
+		index: make(map[string]int),
 	}
 }
 
-// Register adds a tool to the registry
+// Register adds a tool to the registry; a tool with the same name is replaced in place
 func (r *Registry) Register(tool Tool) {
-	r.tools[tool.Name()] = tool
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i, exists := r.index[tool.Name()]; exists {
+		r.tools[i] = tool
+		return
+	}
+	r.index[tool.Name()] = len(r.tools)
+	r.tools = append(r.tools, tool)
+}
+
+// Replace swaps in tool for the registered tool of the same name, keeping its position.
+// It reports false, registering nothing, when there is no such tool.
+func (r *Registry) Replace(tool Tool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, exists := r.index[tool.Name()]
+	if exists {
+		r.tools[i] = tool
+	}
+	return exists
+}
+
+// Unregister removes the named tool, reporting whether it was registered
+func (r *Registry) Unregister(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, exists := r.index[name]
+	if !exists {
+		return false
+	}
+	r.tools = slices.Delete(r.tools, i, i+1)
+	delete(r.index, name)
+	for j := i; j < len(r.tools); j++ {
+		r.index[r.tools[j].Name()] = j
+	}
+	return true
 }
 
 // Get retrieves a tool by name
 func (r *Registry) Get(name string) (Tool, bool) {
-	tool, exists := r.tools[name]
-	return tool, exists
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	i, exists := r.index[name]
+	if !exists {
+		return nil, false
+	}
+	return r.tools[i], true
 }
 
-// All returns all registered tools as ToolDefinitions for the Anthropic SDK
+// All returns all registered tools as ToolDefinitions for the Anthropic SDK, in registration order
 func (r *Registry) All() []ToolDefinition {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
 	var definitions []ToolDefinition
 	for _, tool := range r.tools {
 		definitions = append(definitions, ToolDefinition{
@@ -69,10 +234,18 @@ func (r *Registry) All() []ToolDefinition {
 
 // Execute runs a tool with the given input
 func (r *Registry) Execute(ctx context.Context, agent ToolContext, toolName string, input json.RawMessage) (string, error) {
-	tool, exists := r.tools[toolName]
+	tool, exists := r.Get(toolName)
 	if !exists {
 		return "", &ToolNotFoundError{Name: toolName}
 	}
+	if err := ValidateInput(toolName, tool.InputSchema(), input); err != nil {
+		return "", err
+	}
+	if gate, ok := agent.(Gate); ok {
+		if err := gate.CheckToolCall(toolName, input); err != nil {
+			return "", err
+		}
+	}
 
 	return tool.Execute(ctx, agent, input)
 }
@@ -85,3 +258,21 @@ type ToolNotFoundError struct {
 func (e *ToolNotFoundError) Error() string {
 	return "tool " + e.Name + " not found"
 }
+
+// Approver is optionally implemented by a ToolContext to gate actions that change files or run commands
+type Approver interface {
+	Approve(req approval.Request) error
+}
+
+// Gate is optionally implemented by a ToolContext to allow or refuse every tool call before it runs
+type Gate interface {
+	CheckToolCall(toolName string, input json.RawMessage) error
+}
+
+// RequestApproval asks the context to approve req, returning an error when it is refused
+func RequestApproval(agent ToolContext, req approval.Request) error {
+	if approver, ok := agent.(Approver); ok {
+		return approver.Approve(req)
+	}
+	return nil
+}
@@ -0,0 +1,313 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// FieldError is one problem found in a tool input
+type FieldError struct {
+	Field   string // Dotted path such as "path" or "edits[2].old_text"
+	Problem string
+}
+
+// ValidationError lists every problem with a tool input so the model can fix them in one retry
+type ValidationError struct {
+	Tool     string
+	Problems []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "invalid input for %s:", e.Tool)
+	for _, problem := range e.Problems {
+		fmt.Fprintf(&b, "\n- %s: %s", problem.Field, problem.Problem)
+	}
+	b.WriteString("\nFix these fields and call the tool again.")
+	return b.String()
+}
+
+// ValidateInput checks input against a tool's schema: required fields, types, enum and const values,
+// unknown fields, string lengths and patterns, number bounds, and array lengths
+func ValidateInput(toolName string, schema anthropic.ToolInputSchemaParam, input json.RawMessage) error {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil // An unreadable schema shouldn't block the tool; it still parses its own input
+	}
+	var rules map[string]any
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return nil
+	}
+
+	var value any = map[string]any{}
+	if trimmed := bytes.TrimSpace(input); len(trimmed) > 0 && !bytes.Equal(trimmed, []byte("null")) {
+		if err := json.Unmarshal(trimmed, &value); err != nil {
+			return &ValidationError{Tool: toolName, Problems: []FieldError{{Field: "(input)", Problem: "not valid JSON: " + err.Error()}}}
+		}
+	}
+
+	var problems []FieldError
+	validateValue("", value, rules, &problems)
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ValidationError{Tool: toolName, Problems: problems}
+}
+
+func validateValue(field string, value any, rules map[string]any, problems *[]FieldError) {
+	report := func(format string, args ...any) {
+		name := field
+		if name == "" {
+			name = "(input)"
+		}
+		*problems = append(*problems, FieldError{Field: name, Problem: fmt.Sprintf(format, args...)})
+	}
+
+	if expected := schemaTypes(rules["type"]); len(expected) > 0 && !slices.ContainsFunc(expected, func(t string) bool { return hasType(value, t) }) {
+		report("expected %s, got %s", strings.Join(expected, " or "), typeName(value))
+		return
+	}
+	if enum, ok := rules["enum"].([]any); ok && !inEnum(value, enum) {
+		options := make([]string, len(enum))
+		for i, option := range enum {
+			options[i] = fmt.Sprint(option)
+		}
+		report("must be one of %s, got %v", strings.Join(options, ", "), value)
+	}
+	if constant, ok := rules["const"]; ok && !sameValue(value, constant) {
+		report("must be %v, got %v", constant, value)
+	}
+
+	switch v := value.(type) {
+	case string:
+		minLength, _ := rules["minLength"].(float64)
+		maxLength, hasMax := rules["maxLength"].(float64)
+		pattern, _ := rules["pattern"].(string)
+		length := float64(utf8.RuneCountInString(v))
+		switch {
+		case v == "" && minLength > 0:
+			report("must not be empty")
+		case length < minLength:
+			report("must be at least %d characters", int(minLength))
+		case hasMax && length > maxLength:
+			report("must be at most %d characters, got %d", int(maxLength), int(length))
+		case pattern != "" && !matchesPattern(pattern, v):
+			report("%q does not match the pattern %s", v, pattern)
+		}
+	case float64:
+		if minimum, ok := rules["minimum"].(float64); ok && v < minimum {
+			report("must be at least %v, got %v", minimum, v)
+		}
+		if maximum, ok := rules["maximum"].(float64); ok && v > maximum {
+			report("must be at most %v, got %v", maximum, v)
+		}
+		if minimum, ok := rules["exclusiveMinimum"].(float64); ok && v <= minimum {
+			report("must be more than %v, got %v", minimum, v)
+		}
+		if maximum, ok := rules["exclusiveMaximum"].(float64); ok && v >= maximum {
+			report("must be less than %v, got %v", maximum, v)
+		}
+	case map[string]any:
+		properties, _ := rules["properties"].(map[string]any)
+		if required, ok := rules["required"].([]any); ok {
+			for _, name := range required {
+				if _, present := v[fmt.Sprint(name)]; !present {
+					*problems = append(*problems, FieldError{Field: join(field, fmt.Sprint(name)), Problem: "required field is missing"})
+				}
+			}
+		}
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			propertyRules, known := properties[name].(map[string]any)
+			if !known {
+				switch additional := rules["additionalProperties"].(type) {
+				case bool:
+					if !additional {
+						*problems = append(*problems, FieldError{Field: join(field, name), Problem: "unknown field (allowed: " + strings.Join(sortedKeys(properties), ", ") + ")"})
+					}
+				case map[string]any:
+					validateValue(join(field, name), v[name], additional, problems)
+				}
+				continue
+			}
+			validateValue(join(field, name), v[name], propertyRules, problems)
+		}
+	case []any:
+		if minItems, ok := rules["minItems"].(float64); ok && float64(len(v)) < minItems {
+			report("must have at least %d item(s), got %d", int(minItems), len(v))
+		}
+		if maxItems, ok := rules["maxItems"].(float64); ok && float64(len(v)) > maxItems {
+			report("must have at most %d items, got %d; split the call", int(maxItems), len(v))
+		}
+		if itemRules, ok := rules["items"].(map[string]any); ok {
+			for i, item := range v {
+				validateValue(fmt.Sprintf("%s[%d]", field, i), item, itemRules, problems)
+			}
+		}
+	}
+}
+
+// supportedKeywords are the JSON Schema keywords ValidateInput checks, and the annotations that need no check
+var supportedKeywords = map[string]bool{
+	"type": true, "enum": true, "const": true, "properties": true, "required": true, "additionalProperties": true,
+	"items": true, "minItems": true, "maxItems": true, "minLength": true, "maxLength": true, "pattern": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"$schema": true, "$id": true, "$comment": true, "title": true, "description": true, "default": true,
+	"examples": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+}
+
+// UnsupportedKeywords lists, sorted, the keywords in schema that ValidateInput would silently ignore,
+// such as $ref, anyOf or format, so a schema that relies on them can be refused instead
+func UnsupportedKeywords(schema map[string]any) []string {
+	found := map[string]bool{}
+	var walk func(rules map[string]any)
+	walk = func(rules map[string]any) {
+		for keyword, value := range rules {
+			if !supportedKeywords[keyword] {
+				found[keyword] = true
+				continue
+			}
+			switch keyword {
+			case "properties":
+				properties, _ := value.(map[string]any)
+				for _, property := range properties {
+					if propertyRules, ok := property.(map[string]any); ok {
+						walk(propertyRules)
+					}
+				}
+			case "items", "additionalProperties":
+				if nested, ok := value.(map[string]any); ok {
+					walk(nested)
+				} else if _, ok := value.([]any); ok {
+					found[keyword+" (array form)"] = true
+				}
+			}
+		}
+	}
+	walk(schema)
+	return sortedKeys(found)
+}
+
+// schemaTypes returns a type keyword's types, whether given as one name or an array of them
+func schemaTypes(value any) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []any:
+		types := make([]string, 0, len(v))
+		for _, t := range v {
+			types = append(types, fmt.Sprint(t))
+		}
+		return types
+	}
+	return nil
+}
+
+// sameValue compares two decoded JSON values, as const requires
+func sameValue(a, b any) bool {
+	left, errLeft := json.Marshal(a)
+	right, errRight := json.Marshal(b)
+	return errLeft == nil && errRight == nil && bytes.Equal(left, right)
+}
+
+// patterns caches compiled schema patterns by source
+var patterns sync.Map
+
+// matchesPattern reports whether a string matches a schema pattern; a pattern Go can't compile matches anything
+func matchesPattern(pattern, s string) bool {
+	re, ok := patterns.Load(pattern)
+	if !ok {
+		compiled, err := regexp.Compile(pattern)
+		if err != nil {
+			return true
+		}
+		re, _ = patterns.LoadOrStore(pattern, compiled)
+	}
+	return re.(*regexp.Regexp).MatchString(s)
+}
+
+func hasType(value any, expected string) bool {
+	switch expected {
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		n, ok := value.(float64)
+		return ok && n == math.Trunc(n)
+	case "null":
+		return value == nil
+	}
+	return true
+}
+
+func typeName(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func inEnum(value any, enum []any) bool {
+	for _, option := range enum {
+		if option == value {
+			return true
+		}
+	}
+	return false
+}
+
+func join(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	commandStatusInterval = 200 * time.Millisecond // How often the spinner and elapsed time are redrawn
+	commandLabelChars     = 60                     // Longer commands are shortened on the status line
+)
+
+var spinnerFrames = []string{"-", "\\", "|", "/"}
+
+// CommandStatus prints a running command's output as it arrives, kept above a status line
+// with a spinner and the elapsed time. Without cursor control only the output is printed.
+type CommandStatus struct {
+	manager *Manager
+	command string
+	started time.Time
+
+	mu      sync.Mutex
+	frame   int
+	stopped bool
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// StartCommandStatus begins showing the status of command
+func (m *Manager) StartCommandStatus(command string) *CommandStatus {
+	label, _, multiline := strings.Cut(strings.TrimSpace(command), "\n")
+	if runes := []rune(label); len(runes) > commandLabelChars {
+		label, multiline = string(runes[:commandLabelChars]), true
+	}
+	if multiline {
+		label += "..."
+	}
+	s := &CommandStatus{manager: m, command: label, started: time.Now(), stop: make(chan struct{})}
+	if !m.caps.Cursor {
+		return s
+	}
+
+	s.draw()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(commandStatusInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				s.frame = (s.frame + 1) % len(spinnerFrames)
+				s.draw()
+				s.mu.Unlock()
+			}
+		}
+	}()
+	return s
+}
+
+// Line prints one line of output above the status line
+func (s *CommandStatus) Line(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stopped {
+		return
+	}
+	fmt.Print(s.manager.ClearLine() + s.manager.Paint(StyleOutput, text) + "\n")
+	if s.manager.caps.Cursor {
+		s.draw()
+	}
+}
+
+// Stop removes the status line
+func (s *CommandStatus) Stop() {
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return
+	}
+	s.stopped = true
+	s.mu.Unlock()
+
+	if !s.manager.caps.Cursor {
+		return
+	}
+	close(s.stop)
+	s.wg.Wait()
+	fmt.Print(s.manager.ClearLine())
+}
+
+// draw redraws the status line; the caller holds mu
+func (s *CommandStatus) draw() {
+	status := fmt.Sprintf("%s %s (%s)", spinnerFrames[s.frame], s.command, FormatDuration(time.Since(s.started)))
+	fmt.Print(s.manager.ClearLine() + s.manager.Paint(StyleInfo, status))
+}
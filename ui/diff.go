@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DiffStats counts the lines a unified diff adds and removes
+func DiffStats(diff string) (added, removed int) {
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+		case strings.HasPrefix(line, "+"):
+			added++
+		case strings.HasPrefix(line, "-"):
+			removed++
+		}
+	}
+	return added, removed
+}
+
+// FormatDiff colors a unified diff for the terminal, showing at most maxLines of it (0 = all)
+func (m *Manager) FormatDiff(diff string, maxLines int) string {
+	lines := strings.Split(strings.TrimSuffix(diff, "\n"), "\n")
+	hidden := 0
+	if maxLines > 0 && len(lines) > maxLines {
+		lines, hidden = lines[:maxLines], len(lines)-maxLines
+	}
+	var b strings.Builder
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++ "), strings.HasPrefix(line, "--- "):
+			line = m.Paint(StyleHeader, line)
+		case strings.HasPrefix(line, "@@"):
+			line = m.Paint(StyleHunk, line)
+		case strings.HasPrefix(line, "+"):
+			line = m.Paint(StyleAdded, line)
+		case strings.HasPrefix(line, "-"):
+			line = m.Paint(StyleRemoved, line)
+		}
+		b.WriteString(line + "\n")
+	}
+	if hidden > 0 {
+		b.WriteString(m.Paint(StyleOutput, fmt.Sprintf("... %s more diff lines", FormatCount(hidden))) + "\n")
+	}
+	return b.String()
+}
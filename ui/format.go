@@ -0,0 +1,197 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// numberFormat holds the separators a locale writes numbers with
+type numberFormat struct {
+	decimal   string
+	thousands string
+}
+
+var (
+	formatMu sync.RWMutex
+	numbers  = localeFormat("")
+
+	// plainFormat is used for text the model reads, which shouldn't change with the user's locale
+	plainFormat = numberFormat{decimal: ".", thousands: ","}
+)
+
+// Languages that write 1.234,5 and 1 234,5; the rest get 1,234.5
+var (
+	dotGrouping   = []string{"da", "de", "el", "es", "hr", "id", "it", "nl", "pt", "ro", "sl", "sr", "tr"}
+	spaceGrouping = []string{"bg", "cs", "et", "fi", "fr", "hu", "lt", "lv", "nb", "no", "pl", "ru", "sk", "sv", "uk"}
+)
+
+// SetLocale picks the number separators used by the Format functions, from a locale name such as
+// "de_DE.UTF-8"; an empty name uses LC_ALL, LC_NUMERIC or LANG
+func SetLocale(name string) {
+	formatMu.Lock()
+	defer formatMu.Unlock()
+	numbers = localeFormat(name)
+}
+
+func localeFormat(name string) numberFormat {
+	for _, candidate := range []string{name, os.Getenv("LC_ALL"), os.Getenv("LC_NUMERIC"), os.Getenv("LANG")} {
+		if candidate != "" {
+			name = candidate
+			break
+		}
+	}
+	language := strings.ToLower(name)
+	if i := strings.IndexAny(language, "_-.@"); i >= 0 {
+		language = language[:i]
+	}
+	for _, l := range dotGrouping {
+		if language == l {
+			return numberFormat{decimal: ",", thousands: "."}
+		}
+	}
+	for _, l := range spaceGrouping {
+		if language == l {
+			return numberFormat{decimal: ",", thousands: " "}
+		}
+	}
+	return numberFormat{decimal: ".", thousands: ","}
+}
+
+func currentFormat() numberFormat {
+	formatMu.RLock()
+	defer formatMu.RUnlock()
+	return numbers
+}
+
+// FormatCount writes an integer with thousands separators: 1,234,567
+func FormatCount(n int) string {
+	digits := fmt.Sprintf("%d", n)
+	sign := ""
+	if n < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= 3 {
+		return sign + digits
+	}
+	separator := currentFormat().thousands
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString(separator)
+		}
+		b.WriteRune(digit)
+	}
+	return b.String()
+}
+
+// FormatDecimal writes f with the given number of decimal places and the locale's decimal separator
+func FormatDecimal(f float64, places int) string {
+	return currentFormat().decimalString(f, places)
+}
+
+// FormatPercent writes a percentage with one decimal place: 42.5%
+func FormatPercent(f float64) string {
+	return FormatDecimal(f, 1) + "%"
+}
+
+// FormatBytes writes a byte count in binary units: 512 B, 1.5 KB, 2.3 MB, 1.1 GB
+func FormatBytes(n int64) string {
+	return currentFormat().bytes(n)
+}
+
+// FormatBytesPlain is FormatBytes with a decimal point in every locale, for tool results and other text
+// the model reads
+func FormatBytesPlain(n int64) string {
+	return plainFormat.bytes(n)
+}
+
+// FormatTokens abbreviates a token count to three significant figures or so: 950, 42.3K, 200K, 1M
+func FormatTokens(n int) string {
+	trim := func(s string) string {
+		return strings.TrimSuffix(s, currentFormat().decimal+"0")
+	}
+	switch {
+	case n >= 1000000:
+		return trim(FormatDecimal(float64(n)/1e6, 1)) + "M"
+	case n >= 1000:
+		return trim(FormatDecimal(float64(n)/1e3, 1)) + "K"
+	default:
+		return fmt.Sprintf("%d", n)
+	}
+}
+
+// FormatDuration writes a duration at a precision that suits its size: 420µs, 85ms, 2.4s, 3m05s, 1h02m
+func FormatDuration(d time.Duration) string {
+	return currentFormat().duration(d)
+}
+
+// FormatDurationPlain is FormatDuration with a decimal point in every locale, for text the model reads
+func FormatDurationPlain(d time.Duration) string {
+	return plainFormat.duration(d)
+}
+
+func (f numberFormat) decimalString(x float64, places int) string {
+	s := fmt.Sprintf("%.*f", places, x)
+	if f.decimal != "." {
+		s = strings.Replace(s, ".", f.decimal, 1)
+	}
+	return s
+}
+
+func (f numberFormat) bytes(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return f.decimalString(float64(n)/(1<<30), 1) + " GB"
+	case n >= 1<<20:
+		return f.decimalString(float64(n)/(1<<20), 1) + " MB"
+	case n >= 1<<10:
+		return f.decimalString(float64(n)/(1<<10), 1) + " KB"
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
+
+func (f numberFormat) duration(d time.Duration) string {
+	switch {
+	case d < time.Millisecond:
+		return d.Round(time.Microsecond).String()
+	case d < time.Second:
+		return d.Round(time.Millisecond).String()
+	case d < time.Minute:
+		return f.decimalString(d.Seconds(), 1) + "s"
+	case d < time.Hour:
+		d = d.Round(time.Second)
+		return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+	default:
+		d = d.Round(time.Minute)
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
+
+// EscapeControl makes control characters other than newlines and tabs visible, as \r or \x1b, so text
+// from a file or the model can't move the cursor or restyle the terminal when printed
+func EscapeControl(s string) string {
+	if !strings.ContainsFunc(s, isEscapedControl) {
+		return s
+	}
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\r':
+			b.WriteString(`\r`)
+		case isEscapedControl(r):
+			fmt.Fprintf(&b, `\x%02x`, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func isEscapedControl(r rune) bool {
+	return r != '\n' && r != '\t' && (r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0))
+}
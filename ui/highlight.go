@@ -0,0 +1,216 @@
+package ui
+
+import (
+	"os"
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/formatters"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+)
+
+// maxFenceIndent is how far a Markdown code fence may be indented
+const maxFenceIndent = 3
+
+// Highlighter colors fenced code blocks in streamed Markdown, passing everything else through.
+// Prose is printed as soon as it arrives; code is released a line at a time so each line can be
+// colored with the lexer state of the whole block so far.
+type Highlighter struct {
+	formatter chroma.Formatter
+	style     *chroma.Style
+
+	line    string // Current line, held back while it might still turn out to be a fence
+	printed int    // Bytes of line already printed by Break
+	decided bool   // The current prose line can't be a fence, so it is streamed directly
+
+	inCode bool
+	fence  string          // Opening fence characters; the block ends at a line of at least as many
+	lexer  chroma.Lexer    // From the language named on the fence, or detected from the code
+	source strings.Builder // Code of the current block so far
+}
+
+// NewHighlighter creates a highlighter, or one that passes text through unchanged when the
+// terminal has no color or highlighting is turned off
+func (m *Manager) NewHighlighter() *Highlighter {
+	if !m.caps.Color || m.config.HighlightStyle == "" || m.config.HighlightStyle == "off" {
+		return &Highlighter{}
+	}
+	formatter := formatters.TTY256
+	if colorTerm := os.Getenv("COLORTERM"); colorTerm == "truecolor" || colorTerm == "24bit" {
+		formatter = formatters.TTY16m
+	}
+	return &Highlighter{formatter: formatter, style: styles.Get(m.config.HighlightStyle)}
+}
+
+// Write consumes streamed text and returns the part that is ready to print
+func (h *Highlighter) Write(text string) string {
+	if h.formatter == nil {
+		return text
+	}
+
+	var out strings.Builder
+	for text != "" {
+		segment := text
+		if newline := strings.IndexByte(text, '\n'); newline >= 0 {
+			segment = text[:newline+1]
+		}
+		text = text[len(segment):]
+		complete := strings.HasSuffix(segment, "\n")
+
+		if !h.inCode && h.decided {
+			out.WriteString(segment)
+		} else {
+			h.line += segment
+			switch {
+			case h.inCode && complete:
+				out.WriteString(h.codeLine(h.line))
+			case !h.inCode && complete:
+				out.WriteString(h.proseLine(h.line)[h.printed:])
+			case !h.inCode && !couldBeFence(h.line):
+				out.WriteString(h.line[h.printed:])
+				h.decided = true
+			}
+		}
+
+		if complete {
+			h.line, h.printed, h.decided = "", 0, false
+		} else if h.decided {
+			h.line, h.printed = "", 0
+		}
+	}
+	return out.String()
+}
+
+// Break returns the part of the current line held back so far, so a notice can interrupt the response.
+// The line is still completed by the text that follows, so a fence or the code's lexer state isn't lost.
+func (h *Highlighter) Break() string {
+	if h.formatter == nil || len(h.line) == h.printed {
+		return ""
+	}
+	part := h.line[h.printed:]
+	if h.inCode {
+		part = h.highlight(h.source.String()+h.line, h.line)
+	}
+	h.printed = len(h.line)
+	return part
+}
+
+// Flush returns anything still held back at the end of a response
+func (h *Highlighter) Flush() string {
+	if h.formatter == nil || h.line == "" {
+		return ""
+	}
+	line := h.line
+	out := line[h.printed:]
+	if h.inCode {
+		out = h.codeLine(line)
+	}
+	h.line, h.printed, h.decided = "", 0, false
+	return out
+}
+
+// proseLine handles a complete line outside code, opening a block when it is a fence
+func (h *Highlighter) proseLine(line string) string {
+	fence, info, ok := ParseFence(line)
+	if !ok {
+		return line
+	}
+	h.inCode, h.fence = true, fence
+	h.lexer = nil
+	h.source.Reset()
+	if fields := strings.Fields(info); len(fields) > 0 {
+		h.lexer = lexers.Get(strings.ToLower(fields[0]))
+	}
+	return line
+}
+
+// codeLine highlights a complete line inside a block, or closes the block at its fence
+func (h *Highlighter) codeLine(line string) string {
+	if ClosesFence(line, h.fence) {
+		h.inCode = false
+		return line[h.printed:]
+	}
+
+	h.source.WriteString(line)
+	if h.lexer == nil {
+		// Unlabelled blocks are identified from their content once there is enough to go on
+		if h.lexer = lexers.Analyse(h.source.String()); h.lexer == nil {
+			return line[h.printed:]
+		}
+	}
+	return h.highlight(h.source.String(), line)
+}
+
+// highlight colors line, the last line of source, leaving out the part of it Break already printed
+func (h *Highlighter) highlight(source, line string) string {
+	if h.lexer == nil {
+		return line[h.printed:]
+	}
+	iterator, err := chroma.Coalesce(h.lexer).Tokenise(nil, source)
+	if err != nil {
+		return line[h.printed:]
+	}
+	lines := chroma.SplitTokensIntoLines(iterator.Tokens())
+	if len(lines) == 0 {
+		return line[h.printed:]
+	}
+	tokens := lines[len(lines)-1]
+	for skip := h.printed; skip > 0 && len(tokens) > 0; {
+		if len(tokens[0].Value) > skip {
+			tokens[0].Value = tokens[0].Value[skip:]
+			break
+		}
+		skip -= len(tokens[0].Value)
+		tokens = tokens[1:]
+	}
+	var out strings.Builder
+	if err := h.formatter.Format(&out, h.style, chroma.Literator(tokens...)); err != nil {
+		return line[h.printed:]
+	}
+	return out.String()
+}
+
+// ParseFence reports whether line opens or closes a Markdown code block, returning the fence and its
+// info string
+func ParseFence(line string) (fence, info string, ok bool) {
+	trimmed := strings.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > maxFenceIndent || len(trimmed) < 3 || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return "", "", false
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == trimmed[0] {
+		n++
+	}
+	if n < 3 {
+		return "", "", false
+	}
+	info = strings.TrimSpace(trimmed[n:])
+	if trimmed[0] == '`' && strings.Contains(info, "`") {
+		return "", "", false
+	}
+	return trimmed[:n], info, true
+}
+
+// ClosesFence reports whether line closes a code block opened with fence: the same character at least as
+// many times, with no info string
+func ClosesFence(line, fence string) bool {
+	closing, info, ok := ParseFence(line)
+	return ok && info == "" && closing[0] == fence[0] && len(closing) >= len(fence)
+}
+
+// couldBeFence reports whether an incomplete line might still become a fence
+func couldBeFence(partial string) bool {
+	trimmed := strings.TrimLeft(partial, " ")
+	if len(partial)-len(trimmed) > maxFenceIndent {
+		return false
+	}
+	if trimmed == "" {
+		return true
+	}
+	n := 0
+	for n < len(trimmed) && trimmed[n] == trimmed[0] {
+		n++
+	}
+	return (trimmed[0] == '`' || trimmed[0] == '~') && (n == len(trimmed) || n >= 3)
+}
@@ -0,0 +1,135 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// ErrInterrupted is returned by ReadLine when the user presses ctrl-c
+var ErrInterrupted = errors.New("interrupted")
+
+// LineEditor reads a line from the terminal with tab completion and history
+type LineEditor struct {
+	Complete func(line string) []string // Candidates that replace the whole line
+	History  []string                   // Older entries first; the up arrow starts from the end
+}
+
+// CanEditLines reports whether stdin and stdout are a terminal the line editor can drive
+func (m *Manager) CanEditLines() bool {
+	return m.caps.Cursor && term.IsTerminal(int(os.Stdin.Fd()))
+}
+
+// ReadLine shows prompt and reads one line in raw mode. Tab completes, a second tab lists the candidates,
+// up and down walk the history, ctrl-u clears the line and ctrl-d on an empty line returns io.EOF.
+func (e *LineEditor) ReadLine(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("failed to switch the terminal to raw mode: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	line, history := "", len(e.History)
+	lastTab := false
+	redraw := func() { fmt.Printf("\r\x1b[K%s%s", prompt, line) }
+	redraw()
+
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return "", err
+		}
+		for input := buf[:n]; len(input) > 0; {
+			key := input[0]
+			input = input[1:]
+			tab := false
+			switch {
+			case key == '\r' || key == '\n':
+				fmt.Print("\r\n")
+				return line, nil
+			case key == 3:
+				fmt.Print("^C\r\n")
+				return "", ErrInterrupted
+			case key == 4:
+				if line == "" {
+					fmt.Print("\r\n")
+					return "", io.EOF
+				}
+			case key == 127 || key == 8:
+				if _, size := utf8.DecodeLastRuneInString(line); size > 0 {
+					line = line[:len(line)-size]
+				}
+			case key == 21:
+				line = ""
+			case key == '\t':
+				tab = true
+				line = e.complete(line, lastTab)
+			case key == 0x1b:
+				// Arrow keys arrive as ESC [ A; other escape sequences are skipped
+				sequence := input
+				end := 0
+				for end < len(sequence) && (end < 1 || sequence[end] < 0x40 || sequence[end] > 0x7e) {
+					end++
+				}
+				if end < len(sequence) {
+					end++
+				}
+				input = sequence[end:]
+				if code := string(sequence[:end]); code == "[A" || code == "OA" {
+					if history > 0 {
+						history--
+						line = e.History[history]
+					}
+				} else if code == "[B" || code == "OB" {
+					if history < len(e.History) {
+						history++
+						line = ""
+						if history < len(e.History) {
+							line = e.History[history]
+						}
+					}
+				}
+			case key >= 0x20:
+				line += string([]byte{key}) // Multi-byte characters arrive a byte at a time
+			}
+			lastTab = tab
+			redraw()
+		}
+	}
+}
+
+// complete extends line to the candidates' common prefix; when that adds nothing and tab was pressed
+// twice, the candidates are listed below the prompt
+func (e *LineEditor) complete(line string, again bool) string {
+	if e.Complete == nil {
+		return line
+	}
+	candidates := e.Complete(line)
+	if len(candidates) == 0 {
+		fmt.Print("\a")
+		return line
+	}
+	prefix := candidates[0]
+	for _, candidate := range candidates[1:] {
+		for !strings.HasPrefix(candidate, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+	if len(prefix) > len(line) {
+		return prefix
+	}
+	if again && len(candidates) > 1 {
+		fmt.Print("\r\n" + strings.Join(candidates, "\r\n") + "\r\n")
+	} else {
+		fmt.Print("\a")
+	}
+	return line
+}
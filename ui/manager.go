@@ -2,16 +2,38 @@ package ui
 
 import (
 	"fmt"
-	"sync"
-	"time"
+	"sync/atomic"
+
+	"anthropic-chat/config"
 )
 
 // Manager handles UI-related functionality
-type Manager struct{}
+type Manager struct {
+	config    config.UIConfig
+	caps      Capabilities
+	collapsed string // Held-back remainder of the last long response, shown by /expand
+
+	collapsedHighlight *Highlighter // Highlighter state where the collapsed remainder starts
+
+	spinner atomic.Pointer[Spinner] // The spinner line being shown, if any
+}
+
+// NewManager creates a new UI manager for the detected terminal
+func NewManager(cfg config.UIConfig) *Manager {
+	SetLocale(cfg.Locale)
+	return &Manager{
+		config: cfg,
+		caps:   DetectCapabilities(cfg.ColorOutput),
+	}
+}
 
-// NewManager creates a new UI manager
-func NewManager() *Manager {
-	return &Manager{}
+// Verbosity returns the output level: quiet, normal or verbose (unknown values count as normal)
+func (m *Manager) Verbosity() string {
+	switch m.config.Verbosity {
+	case config.UIQuiet, config.UIVerbose:
+		return m.config.Verbosity
+	}
+	return config.UINormal
 }
 
 // ShowWelcome displays the welcome message
@@ -35,76 +57,30 @@ It can perform basic agentic tasks in your directory. Use at your own risk.`)
 // ShowCommands displays available commands
 func (m *Manager) ShowCommands() {
 	fmt.Println("BASIC COMMANDS:")
-	fmt.Println("Chat with GooCode (use 'ctrl-c' to quit)")
+	fmt.Println("Chat with GooCode (ctrl-c pauses a running turn so you can steer it; at the prompt it quits)")
 	fmt.Printf("Type '/cd' to change working directory\n")
-	fmt.Printf("Type '/tokens' to see current token count\n\n")
-}
-
-// ThinkingAnimation handles the "thinking..." animation
-type ThinkingAnimation struct {
-	stopChan chan bool
-	wg       sync.WaitGroup
-	running  bool
-	mu       sync.Mutex
-}
-
-// NewThinkingAnimation creates a new thinking animation
-func (m *Manager) NewThinkingAnimation() *ThinkingAnimation {
-	return &ThinkingAnimation{
-		stopChan: make(chan bool),
-	}
-}
-
-// Start begins the thinking animation
-func (ta *ThinkingAnimation) Start() {
-	ta.mu.Lock()
-	defer ta.mu.Unlock()
-
-	if ta.running {
-		return
-	}
-
-	ta.running = true
-	ta.wg.Add(1)
-
-	go func() {
-		defer ta.wg.Done()
-
-		dots := 1
-		fmt.Print("\u001b[93mthinking\u001b[0m.")
-
-		ticker := time.NewTicker(500 * time.Millisecond)
-		defer ticker.Stop()
-
-		for {
-			select {
-			case <-ta.stopChan:
-				return
-			case <-ticker.C:
-				if dots < 3 {
-					dots++
-					fmt.Print(".")
-				} else {
-					fmt.Print("\r\u001b[93mthinking\u001b[0m.")
-					dots = 1
-				}
-			}
-		}
-	}()
-}
-
-// Stop ends the thinking animation
-func (ta *ThinkingAnimation) Stop() {
-	ta.mu.Lock()
-	defer ta.mu.Unlock()
-
-	if !ta.running {
-		return
-	}
-
-	ta.running = false
-	close(ta.stopChan)
-	ta.wg.Wait()
-
-	fmt.Print("\r\033[K")
+	fmt.Printf("Type '/tokens [exact]' to see current token count\n")
+	fmt.Printf("Type '/stats' to see tool usage statistics\n")
+	fmt.Printf("Type '/profile [name|off]' to list the agent profiles or switch to one\n")
+	fmt.Printf("Type '/system [append|replace <text>|reset]' to view or override the system prompt for this session\n")
+	fmt.Printf("Type '/todos [clear]' to see the model's task list for multi-step work, or drop it\n")
+	fmt.Printf("Type '/summary' to see the files changed and commands run this session\n")
+	fmt.Printf("Type '/env' to see which .env files were loaded and the effective settings\n")
+	fmt.Printf("Type '/brief' to toggle terse answers, or '/verbosity <terse|normal|detailed>'\n")
+	fmt.Printf("Type '/force-tool <tool|any|none|off>' to require (or forbid) tool use in the next turn\n")
+	fmt.Printf("Type '/dryrun' to toggle reporting file changes and commands instead of performing them\n")
+	fmt.Printf("Type '/pin <file>', '/pin last' or '/pin note <text>' to keep content across compaction ('/unpin', '/pins', '/keep')\n")
+	fmt.Printf("Type '/clear' to wipe the conversation, or '/new' to save it and start a fresh session\n")
+	fmt.Printf("Type '/sessions [query]' to search saved sessions\n")
+	fmt.Printf("Type '/expand' to show the rest of a collapsed long response\n")
+	fmt.Printf("Type '/copy [n|all]' to copy a code block of the last response, or '/paste [lang]' to add the clipboard to your next message\n")
+	fmt.Printf("Type '/save-output <path> [block|last]' to save the last response or one of its code blocks\n")
+	fmt.Printf("Type '/artifacts' to list reports and other outputs generated this session\n")
+	fmt.Printf("Type '/remember <note>' to add a note to the project memory\n")
+	fmt.Printf("Type '/memories [query]' to see the facts the model saved, or '/memories delete <key>'\n")
+	fmt.Printf("Type '/allowed' to see the commands that run without asking, or '/allowed remove <prefix>'\n")
+	fmt.Printf("Type '/init' to scan the project and write a GOOCODE.md brief for future sessions\n")
+	fmt.Printf("Type '/shell reset' to restart the persistent shell\n")
+	fmt.Printf("Type '/model [name]' to show or switch the model\n")
+	fmt.Printf("Type '/refactor <goal>' to run a planned, step-by-step verified refactor\n\n")
 }
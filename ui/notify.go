@@ -0,0 +1,71 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"anthropic-chat/config"
+)
+
+// Notify signals an event the user may be waiting for, in the configured style. Terminal styles
+// only work when stdout is a terminal; nothing happens when notifications are off.
+func (m *Manager) Notify(title, message string) {
+	message = strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f {
+			return ' '
+		}
+		return r
+	}, message)
+
+	switch m.config.Notify {
+	case config.NotifyBell:
+		m.bell()
+	case config.NotifyOSC9:
+		if m.caps.Terminal {
+			fmt.Printf("\x1b]9;%s: %s\x07", title, message)
+		}
+	case config.NotifyDesktop:
+		if !desktopNotify(title, message) {
+			m.bell()
+		}
+	}
+}
+
+// NotifyTurn notifies that a turn started at started has finished, if it ran long enough to have
+// been left alone
+func (m *Manager) NotifyTurn(started time.Time, message string) {
+	if time.Since(started) >= time.Duration(m.config.NotifyAfter)*time.Second {
+		m.Notify("GooCode", message)
+	}
+}
+
+func (m *Manager) bell() {
+	if m.caps.Terminal {
+		fmt.Print("\a")
+	}
+}
+
+// desktopNotify shows a notification through the OS, reporting false when there is no way to
+func desktopNotify(title, message string) bool {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("osascript", "-e", fmt.Sprintf("display notification %q with title %q", message, title))
+	case "linux", "freebsd", "openbsd", "netbsd":
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+			return false // Over SSH or on a console there is no desktop to notify
+		}
+		cmd = exec.Command("notify-send", title, message)
+	default:
+		return false
+	}
+	if err := cmd.Start(); err != nil {
+		return false
+	}
+	go cmd.Wait()
+	return true
+}
@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"anthropic-chat/config"
+)
+
+const defaultPageLines = 24
+
+// ResponseWriter streams assistant text, holding back everything past the configured
+// line limit so very long responses can be collapsed or paged instead of scrolling away
+type ResponseWriter struct {
+	manager   *Manager
+	highlight *Highlighter
+	limit     int
+	lines     int
+	holding   bool
+	held      strings.Builder
+}
+
+// NewResponseWriter creates a writer for one assistant response
+func (m *Manager) NewResponseWriter() *ResponseWriter {
+	limit := 0
+	if m.caps.Terminal && m.config.LongOutput != config.LongOutputOff && m.config.LongOutputLines > 0 {
+		limit = m.config.LongOutputLines
+	}
+	return &ResponseWriter{manager: m, highlight: m.NewHighlighter(), limit: limit}
+}
+
+// Write prints text until the line limit is reached and holds back the rest
+func (w *ResponseWriter) Write(text string) {
+	if w.limit == 0 {
+		fmt.Print(w.highlight.Write(text))
+		return
+	}
+	if w.holding {
+		w.held.WriteString(text)
+		return
+	}
+
+	for text != "" {
+		newline := strings.IndexByte(text, '\n')
+		if newline < 0 {
+			fmt.Print(w.highlight.Write(text))
+			return
+		}
+		fmt.Print(w.highlight.Write(text[:newline+1]))
+		text = text[newline+1:]
+		w.lines++
+		if w.lines >= w.limit {
+			w.holding = true
+			w.held.WriteString(text)
+			return
+		}
+	}
+}
+
+// Flush prints the part of a line the highlighter is still holding back, before a notice or the end of
+// the response; the highlighter carries on from there if more text follows
+func (w *ResponseWriter) Flush() {
+	if !w.holding {
+		fmt.Print(w.highlight.Break())
+	}
+}
+
+// Finish handles any held-back output: collapsed behind /expand or shown through the pager.
+// readLine supplies keystrokes for the internal pager.
+func (w *ResponseWriter) Finish(readLine func() (string, bool)) {
+	if !w.holding {
+		fmt.Print(w.highlight.Flush())
+		return
+	}
+	rest := w.held.String()
+	if strings.TrimSpace(rest) == "" {
+		fmt.Print(rest)
+		return
+	}
+
+	hidden := strings.Count(strings.TrimRight(rest, "\n"), "\n") + 1
+	if w.manager.config.LongOutput == config.LongOutputPager {
+		w.manager.page(rest, w.highlight, readLine)
+		return
+	}
+
+	w.manager.collapsed, w.manager.collapsedHighlight = rest, w.highlight
+	fmt.Printf("%s\n", w.manager.Paint(StyleNotice, fmt.Sprintf("[%d more lines collapsed, type /expand to show them]", hidden)))
+}
+
+// Expand prints the most recently collapsed response remainder, reporting false if there is none
+func (m *Manager) Expand(readLine func() (string, bool)) bool {
+	if m.collapsed == "" {
+		return false
+	}
+	rest, highlight := m.collapsed, m.collapsedHighlight
+	m.collapsed, m.collapsedHighlight = "", nil
+	if highlight == nil {
+		highlight = &Highlighter{}
+	}
+	if m.config.LongOutput == config.LongOutputPager {
+		m.page(rest, highlight, readLine)
+		return true
+	}
+	fmt.Println(strings.TrimRight(highlight.Write(rest)+highlight.Flush(), "\n"))
+	return true
+}
+
+// page shows text through $PAGER when set, otherwise a page at a time with the internal pager.
+// highlight continues coloring code blocks for the internal pager from where streaming stopped.
+func (m *Manager) page(text string, highlight *Highlighter, readLine func() (string, bool)) {
+	if pager := os.Getenv("PAGER"); pager != "" {
+		fields := strings.Fields(pager)
+		cmd := exec.Command(fields[0], fields[1:]...)
+		cmd.Stdin = strings.NewReader(text)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err == nil {
+			return
+		}
+	}
+
+	pageLines := defaultPageLines
+	if rows, err := strconv.Atoi(os.Getenv("LINES")); err == nil && rows > 2 {
+		pageLines = rows - 1
+	}
+
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	shown := strings.Split(strings.TrimRight(highlight.Write(text)+highlight.Flush(), "\n"), "\n")
+	if len(shown) != len(lines) {
+		shown = lines
+	}
+	for start := 0; start < len(lines); start += pageLines {
+		end := min(start+pageLines, len(lines))
+		fmt.Println(strings.Join(shown[start:end], "\n"))
+		if end == len(lines) {
+			return
+		}
+
+		fmt.Print(m.Paint(StyleNotice, fmt.Sprintf("-- %d more lines: Enter for next page, a for all, q to collapse --", len(lines)-end)) + " ")
+		answer, ok := readLine()
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "a":
+			fmt.Println(strings.Join(shown[end:], "\n"))
+			return
+		case "q":
+			m.collapsed = strings.Join(lines[end:], "\n") + "\n"
+			fmt.Println(m.Paint(StyleNotice, "[rest collapsed, type /expand to show it]"))
+			return
+		}
+		if !ok {
+			return
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package ui
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Phases shown on the spinner line
+const (
+	PhaseThinking = "thinking"
+	PhaseRetrying = "retrying"
+)
+
+// Spinner shows a wait, such as a model response or a tool run, as a status line redrawn in place
+// with the phase, the elapsed time and the output tokens streamed so far. It can be stopped and
+// started again during the wait, keeping its clock and count. Without cursor control it shows nothing.
+type Spinner struct {
+	manager *Manager
+	started time.Time
+
+	mu        sync.Mutex
+	phase     string
+	tokens    int
+	firstSeen time.Time // When the first output token arrived, for the rate
+	frame     int
+	running   bool
+	stop      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewSpinner creates a spinner for a wait starting now
+func (m *Manager) NewSpinner() *Spinner {
+	return &Spinner{manager: m, started: time.Now(), phase: PhaseThinking}
+}
+
+// HideSpinner clears the spinner line, if one is shown, so a prompt can take its place
+func (m *Manager) HideSpinner() {
+	if s := m.spinner.Load(); s != nil {
+		s.Stop()
+	}
+}
+
+// Start shows the spinner line in phase, or switches the line already shown to phase
+func (s *Spinner) Start(phase string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.phase = phase
+	if s.running {
+		s.draw()
+		return
+	}
+	if !s.manager.config.ShowThinking || !s.manager.caps.Cursor {
+		return
+	}
+	if previous := s.manager.spinner.Swap(s); previous != nil && previous != s {
+		previous.Stop()
+	}
+
+	s.running = true
+	s.stop = make(chan struct{})
+	s.draw()
+
+	interval := time.Duration(s.manager.config.AnimationSpeed) * time.Millisecond
+	if interval <= 0 {
+		interval = commandStatusInterval
+	}
+	s.wg.Add(1)
+	go func(stop chan struct{}) {
+		defer s.wg.Done()
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				s.mu.Lock()
+				if s.running {
+					s.frame = (s.frame + 1) % len(spinnerFrames)
+					s.draw()
+				}
+				s.mu.Unlock()
+			}
+		}
+	}(s.stop)
+}
+
+// AddTokens counts n more streamed output tokens
+func (s *Spinner) AddTokens(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.tokens == 0 && n > 0 {
+		s.firstSeen = time.Now()
+	}
+	s.tokens += n
+}
+
+// Running reports whether the spinner line is shown
+func (s *Spinner) Running() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running
+}
+
+// Stop clears the spinner line; Start shows it again
+func (s *Spinner) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	close(s.stop)
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	s.manager.spinner.CompareAndSwap(s, nil)
+	fmt.Print(s.manager.ClearLine())
+}
+
+// draw redraws the spinner line; the caller holds mu
+func (s *Spinner) draw() {
+	since := time.Since(s.started)
+	elapsed := FormatDuration(since)
+	if since < time.Second {
+		elapsed = FormatDecimal(since.Seconds(), 1) + "s" // Tenths rather than a jumpy millisecond count
+	}
+	status := fmt.Sprintf("%s %s (%s", spinnerFrames[s.frame], s.phase, elapsed)
+	if s.tokens > 0 {
+		status += fmt.Sprintf(", %s tokens", FormatCount(s.tokens))
+		if streaming := time.Since(s.firstSeen); streaming >= time.Second {
+			status += fmt.Sprintf(", %d/s", int(float64(s.tokens)/streaming.Seconds()))
+		}
+	}
+	fmt.Print(s.manager.ClearLine() + s.manager.Paint(StyleAssistant, status+")"))
+}
@@ -0,0 +1,41 @@
+package ui
+
+// Style is an ANSI SGR color code used for a category of output
+type Style string
+
+const (
+	StyleUser      Style = "94" // bright blue: "You" prompt
+	StyleAssistant Style = "93" // bright yellow: assistant label
+	StyleTool      Style = "92" // bright green: tool invocations
+	StyleInfo      Style = "96" // bright cyan: tool results and info
+	StyleSuccess   Style = "92" // bright green: confirmations
+	StyleWarning   Style = "93" // bright yellow: warnings
+	StyleError     Style = "91" // bright red: errors
+	StyleNotice    Style = "95" // bright magenta: background housekeeping
+	StyleOutput    Style = "2"  // dim: live output of running commands
+	StyleAdded     Style = "32" // green: lines a diff adds
+	StyleRemoved   Style = "31" // red: lines a diff removes
+	StyleHunk      Style = "36" // cyan: diff hunk headers
+	StyleHeader    Style = "1"  // bold: diff file headers
+)
+
+// Paint wraps text in the escape codes for style when the terminal supports color
+func (m *Manager) Paint(style Style, text string) string {
+	if !m.caps.Color {
+		return text
+	}
+	return "\u001b[" + string(style) + "m" + text + "\u001b[0m"
+}
+
+// ClearLine erases the current line when the terminal supports cursor control
+func (m *Manager) ClearLine() string {
+	if !m.caps.Cursor {
+		return ""
+	}
+	return "\r\033[K"
+}
+
+// Capabilities returns the detected terminal capabilities
+func (m *Manager) Capabilities() Capabilities {
+	return m.caps
+}
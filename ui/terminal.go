@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"os"
+	"strings"
+)
+
+// Capabilities describes what the attached terminal can render
+type Capabilities struct {
+	Terminal bool // stdout is an interactive terminal rather than a pipe or file
+	Color    bool // ANSI color escape sequences
+	Cursor   bool // carriage return redraws and line clearing for animations
+}
+
+// DetectCapabilities inspects the environment and stdout to decide how much terminal
+// control is safe. colorOutput is the user's UIConfig.ColorOutput preference.
+func DetectCapabilities(colorOutput bool) Capabilities {
+	if !isTerminal(os.Stdout) {
+		// Piped or redirected output gets plain text with no redraw tricks
+		return Capabilities{}
+	}
+
+	term := strings.ToLower(os.Getenv("TERM"))
+	if term == "dumb" {
+		return Capabilities{Terminal: true}
+	}
+
+	// Windows consoles only understand escape sequences once VT processing is enabled
+	if !enableVirtualTerminal() {
+		return Capabilities{Terminal: true}
+	}
+
+	caps := Capabilities{Terminal: true, Color: colorOutput, Cursor: true}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		caps.Color = false
+	}
+	return caps
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,8 @@
+//go:build !windows
+
+package ui
+
+// enableVirtualTerminal is a no-op outside Windows, where terminals speak ANSI natively
+func enableVirtualTerminal() bool {
+	return true
+}
@@ -0,0 +1,34 @@
+//go:build windows
+
+package ui
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+const enableVirtualTerminalProcessing = 0x0004
+
+var (
+	kernel32           = syscall.NewLazyDLL("kernel32.dll")
+	procGetConsoleMode = kernel32.NewProc("GetConsoleMode")
+	procSetConsoleMode = kernel32.NewProc("SetConsoleMode")
+)
+
+// enableVirtualTerminal turns on ANSI escape handling for the console attached to stdout.
+// It fails on legacy consoles (pre Windows 10), in which case output degrades to plain text.
+func enableVirtualTerminal() bool {
+	handle := syscall.Handle(os.Stdout.Fd())
+
+	var mode uint32
+	if ok, _, _ := procGetConsoleMode.Call(uintptr(handle), uintptr(unsafe.Pointer(&mode))); ok == 0 {
+		// Not a console (e.g. mintty/MSYS pipes); trust terminals that advertise themselves
+		return os.Getenv("TERM") != "" || os.Getenv("WT_SESSION") != ""
+	}
+	if mode&enableVirtualTerminalProcessing != 0 {
+		return true
+	}
+	ok, _, _ := procSetConsoleMode.Call(uintptr(handle), uintptr(mode|enableVirtualTerminalProcessing))
+	return ok != 0
+}
@@ -1,6 +1,8 @@
 package utils
 
 import (
+	"encoding/json"
+	"fmt"
 	"reflect"
 	"sync"
 
@@ -42,11 +44,7 @@ func (sg *SchemaGenerator) GenerateSchemaForType(v interface{}) anthropic.ToolIn
 
 	// Generate schema
 	schema := sg.reflector.Reflect(v)
-
-	// Convert to Anthropic format
-	toolSchema := anthropic.ToolInputSchemaParam{
-		Properties: schema.Properties,
-	}
+	toolSchema := toToolSchema(schema)
 
 	// Cache the result
 	sg.mu.Lock()
@@ -56,6 +54,61 @@ func (sg *SchemaGenerator) GenerateSchemaForType(v interface{}) anthropic.ToolIn
 	return toolSchema
 }
 
+// toToolSchema converts a reflected schema to Anthropic format, keeping required fields, enums,
+// nested objects and descriptions rather than only the top-level properties
+func toToolSchema(schema *jsonschema.Schema) anthropic.ToolInputSchemaParam {
+	toolSchema := anthropic.ToolInputSchemaParam{
+		Properties: schema.Properties,
+		Required:   schema.Required,
+	}
+
+	// Everything else at the root (additionalProperties, $defs, description, ...) goes in ExtraFields
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return toolSchema
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return toolSchema
+	}
+	for _, key := range []string{"$schema", "$id", "type", "properties", "required"} {
+		delete(fields, key)
+	}
+	if len(fields) > 0 {
+		toolSchema.ExtraFields = fields
+	}
+	return toolSchema
+}
+
+// SchemaFromJSON converts a JSON Schema document, such as one declared by a plugin, to Anthropic format
+func SchemaFromJSON(data []byte) (anthropic.ToolInputSchemaParam, error) {
+	var fields map[string]any
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return anthropic.ToolInputSchemaParam{}, fmt.Errorf("invalid input schema: %w", err)
+		}
+	}
+	if schemaType, ok := fields["type"]; ok && schemaType != "object" {
+		return anthropic.ToolInputSchemaParam{}, fmt.Errorf("input schema type must be object, not %v", schemaType)
+	}
+
+	toolSchema := anthropic.ToolInputSchemaParam{Properties: fields["properties"]}
+	if required, ok := fields["required"].([]any); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				toolSchema.Required = append(toolSchema.Required, name)
+			}
+		}
+	}
+	for _, key := range []string{"$schema", "$id", "type", "properties", "required"} {
+		delete(fields, key)
+	}
+	if len(fields) > 0 {
+		toolSchema.ExtraFields = fields
+	}
+	return toolSchema, nil
+}
+
 // Default global schema generator instance
 var defaultGenerator = NewSchemaGenerator()
 
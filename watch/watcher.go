@@ -0,0 +1,189 @@
+package watch
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// skipDirs are never watched
+var skipDirs = map[string]bool{
+	".git": true, "node_modules": true, "vendor": true, ".venv": true, "__pycache__": true,
+}
+
+// Kind describes what happened to a file
+type Kind string
+
+const (
+	Created  Kind = "created"
+	Modified Kind = "modified"
+	Deleted  Kind = "deleted"
+)
+
+// Change is a file that changed since the last drain
+type Change struct {
+	Path string // Relative to the watched root, with forward slashes
+	Kind Kind
+}
+
+// Watcher records file changes under a directory tree
+type Watcher struct {
+	root    string
+	fsw     *fsnotify.Watcher
+	mu      sync.Mutex
+	changes map[string]Kind
+	last    time.Time // When the most recent event arrived
+	done    chan struct{}
+}
+
+// New starts watching root and every directory below it
+func New(root string) (*Watcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	w := &Watcher{root: root, fsw: fsw, changes: make(map[string]Kind), done: make(chan struct{})}
+	if err := w.addTree(root); err != nil {
+		fsw.Close()
+		return nil, err
+	}
+	go w.loop()
+	return w, nil
+}
+
+// addTree watches dir and its subdirectories
+func (w *Watcher) addTree(dir string) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return nil
+		}
+		if path != dir && (skipDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")) {
+			return filepath.SkipDir
+		}
+		if err := w.fsw.Add(path); err != nil {
+			return fmt.Errorf("failed to watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+func (w *Watcher) loop() {
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			w.handle(event)
+		case _, ok := <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// handle folds one event into the pending changes
+func (w *Watcher) handle(event fsnotify.Event) {
+	rel, err := filepath.Rel(w.root, event.Name)
+	if err != nil || rel == "." {
+		return
+	}
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if skipDirs[part] {
+			return
+		}
+	}
+
+	var kind Kind
+	switch {
+	case event.Has(fsnotify.Create):
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			_ = w.addTree(event.Name)
+			return
+		}
+		kind = Created
+	case event.Has(fsnotify.Write):
+		kind = Modified
+	case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+		kind = Deleted
+	default:
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.last = time.Now()
+	rel = filepath.ToSlash(rel)
+	previous, seen := w.changes[rel]
+	switch {
+	case !seen:
+		w.changes[rel] = kind
+	case previous == Created && kind == Deleted:
+		delete(w.changes, rel) // Temporary file that came and went
+	case previous == Created:
+		// Still a new file however often it was written
+	case previous == Deleted && kind == Created:
+		w.changes[rel] = Modified // Editors often save by replacing the file
+	default:
+		w.changes[rel] = kind
+	}
+}
+
+// Drain returns the changes recorded since the last call, sorted by path, and clears them
+func (w *Watcher) Drain() []Change {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	changes := make([]Change, 0, len(w.changes))
+	for path, kind := range w.changes {
+		changes = append(changes, Change{Path: path, Kind: kind})
+	}
+	w.changes = make(map[string]Kind)
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// Discard drops the recorded changes own matches, keeping the rest for the next Drain
+func (w *Watcher) Discard(own func(Change) bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for path, kind := range w.changes {
+		if own(Change{Path: path, Kind: kind}) {
+			delete(w.changes, path)
+		}
+	}
+}
+
+// Settle waits until no event has arrived for quiet (or timeout passes) so in-flight events are recorded
+func (w *Watcher) Settle(quiet, timeout time.Duration) {
+	start := time.Now()
+	deadline := start.Add(timeout)
+	for time.Now().Before(deadline) {
+		w.mu.Lock()
+		// Events can lag the write that caused them, so count quiet time from the call at the earliest
+		idle := time.Since(w.last)
+		if w.last.Before(start) {
+			idle = time.Since(start)
+		}
+		w.mu.Unlock()
+		if idle >= quiet {
+			return
+		}
+		time.Sleep(quiet - idle)
+	}
+}
+
+// Close stops watching
+func (w *Watcher) Close() error {
+	close(w.done)
+	return w.fsw.Close()
+}